@@ -0,0 +1,343 @@
+/*
+Package anonymize replaces personal information in stored transcripts with stable pseudonyms,
+producing datasets that are safe to share for prompt analysis or to prepare for fine-tuning.
+
+Transcripts are replay.Runs, recorded with a replay.Recorder. Every string in the request and
+response bodies is scanned for personal information, such as email addresses and phone numbers,
+and each match is replaced with a pseudonym like "<EMAIL_1f2e3d4c>". The user field of requests,
+which identifies the end user, is always replaced.
+
+Pseudonyms are an HMAC-SHA256 of the value with a secret key, so the same value gets the same
+pseudonym in every transcript anonymized with the same key. This keeps conversations coherent
+("<EMAIL_1f2e3d4c> wrote to <EMAIL_1f2e3d4c> again") and allows counting users across a dataset,
+while the values can't be recovered without the key. Keep the key secret and use a new one
+for unrelated datasets.
+
+Detection is based on patterns, it will miss personal information that has no pattern, such as
+names. Add known terms, such as the names of your customers, with WithTerms(). In streamed
+responses, a value that is split across events is not detected.
+
+Anonymizing a directory of transcripts:
+
+	a, err := anonymize.New(key, anonymize.WithTerms("NAME", customerNames...))
+	if err != nil {
+		return err
+	}
+
+	paths, err := filepath.Glob("runs/*.json")
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := anonymizeFile(a, p, filepath.Join("anonymized", filepath.Base(p))); err != nil {
+			return err
+		}
+	}
+
+	func anonymizeFile(a *anonymize.Anonymizer, src, dst string) error {
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		return a.Copy(out, in)
+	}
+*/
+package anonymize
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/replay"
+)
+
+// Detector finds a kind of personal information in text.
+type Detector struct {
+	// Kind is the kind of information, such as "EMAIL". It is part of the pseudonym.
+	Kind string
+	// Pattern matches the information.
+	Pattern *regexp.Regexp
+	// Normalize, if set, normalizes a match before it is hashed, so different ways of writing
+	// a value get the same pseudonym.
+	Normalize func(string) string
+}
+
+// Detectors are the detectors used by default.
+var Detectors = []Detector{
+	{Kind: "EMAIL", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), Normalize: strings.ToLower},
+	{Kind: "CARD", Pattern: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), Normalize: digits},
+	{Kind: "PHONE", Pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .\-]\d{3,4}[ .\-]\d{3,4}\b`), Normalize: digits},
+	{Kind: "IP", Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)},
+}
+
+// skipKeys are JSON keys whose values are never personal information and must stay valid.
+var skipKeys = map[string]bool{
+	"id":                 true,
+	"object":             true,
+	"model":              true,
+	"role":               true,
+	"type":               true,
+	"finish_reason":      true,
+	"system_fingerprint": true,
+}
+
+// replaceKeys are JSON keys whose whole value is replaced with a pseudonym of the kind.
+var replaceKeys = map[string]string{
+	"user": "USER",
+}
+
+// Option is an option for New().
+type Option func(a *Anonymizer) error
+
+// WithTerms replaces each of terms with a pseudonym of kind, such as WithTerms("NAME", "Alice", "Bob").
+// Terms are matched as whole words, ignoring case. This can be passed multiple times.
+func WithTerms(kind string, terms ...string) Option {
+	return func(a *Anonymizer) error {
+		if kind == "" {
+			return fmt.Errorf("WithTerms: kind is required")
+		}
+		var quoted []string
+		for _, t := range terms {
+			if t = strings.TrimSpace(t); t != "" {
+				quoted = append(quoted, regexp.QuoteMeta(t))
+			}
+		}
+		if len(quoted) == 0 {
+			return nil
+		}
+		// Longest first, so "Ann Lee" is matched before "Ann".
+		sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+		re, err := regexp.Compile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+		if err != nil {
+			return fmt.Errorf("WithTerms: %w", err)
+		}
+		a.detectors = append(a.detectors, Detector{Kind: kind, Pattern: re, Normalize: strings.ToLower})
+		return nil
+	}
+}
+
+// WithDetectors replaces the default Detectors. Detectors are applied in order, so put the more
+// specific ones first. Terms added with WithTerms() are applied after these.
+func WithDetectors(detectors ...Detector) Option {
+	return func(a *Anonymizer) error {
+		for _, d := range detectors {
+			if d.Kind == "" || d.Pattern == nil {
+				return fmt.Errorf("WithDetectors: Kind and Pattern are required")
+			}
+		}
+		// Terms may already have been added, keep them after the detectors.
+		var terms []Detector
+		if len(a.detectors) > a.defaults {
+			terms = a.detectors[a.defaults:]
+		}
+		a.detectors = append(append([]Detector{}, detectors...), terms...)
+		a.defaults = len(detectors)
+		return nil
+	}
+}
+
+// Anonymizer replaces personal information with stable pseudonyms. It is safe for concurrent use.
+type Anonymizer struct {
+	key       []byte
+	detectors []Detector
+	// defaults is the number of detectors that are not terms.
+	defaults int
+}
+
+// New creates an Anonymizer that derives pseudonyms with key. The key must be at least 16 bytes.
+func New(key []byte, options ...Option) (*Anonymizer, error) {
+	if len(key) < 16 {
+		return nil, fmt.Errorf("key must be at least 16 bytes")
+	}
+	a := &Anonymizer{
+		key:       append([]byte(nil), key...),
+		detectors: append([]Detector{}, Detectors...),
+		defaults:  len(Detectors),
+	}
+	for _, o := range options {
+		if err := o(a); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Pseudonym returns the pseudonym for value of kind, such as "<EMAIL_1f2e3d4c>".
+func (a *Anonymizer) Pseudonym(kind, value string) string {
+	h := hmac.New(sha256.New, a.key)
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return "<" + kind + "_" + hex.EncodeToString(h.Sum(nil)[:4]) + ">"
+}
+
+// Text returns s with all personal information replaced.
+func (a *Anonymizer) Text(s string) string {
+	for _, d := range a.detectors {
+		s = d.Pattern.ReplaceAllStringFunc(s, func(m string) string {
+			v := m
+			if d.Normalize != nil {
+				v = d.Normalize(v)
+			}
+			return a.Pseudonym(d.Kind, v)
+		})
+	}
+	return s
+}
+
+// Run returns a copy of run with personal information in the request and response bodies
+// replaced. Only headers that can't identify the user, such as Content-Type and the request ID,
+// are kept.
+func (a *Anonymizer) Run(run *replay.Run) (*replay.Run, error) {
+	out := &replay.Run{Version: run.Version, Exchanges: make([]replay.Exchange, 0, len(run.Exchanges))}
+	for _, e := range run.Exchanges {
+		req, err := a.body(e.ReqBody)
+		if err != nil {
+			return nil, fmt.Errorf("exchange %d: problem anonymizing request body: %w", e.Seq, err)
+		}
+		resp, err := a.body(e.RespBody)
+		if err != nil {
+			return nil, fmt.Errorf("exchange %d: problem anonymizing response body: %w", e.Seq, err)
+		}
+		e.ReqHeader = keepHeaders(e.ReqHeader)
+		e.RespHeader = keepHeaders(e.RespHeader)
+		e.ReqBody = req
+		e.RespBody = resp
+		e.Err = a.Text(e.Err)
+		out.Exchanges = append(out.Exchanges, e)
+	}
+	return out, nil
+}
+
+// Copy reads a Run saved with replay.Run.Save() from r and writes the anonymized Run to w.
+func (a *Anonymizer) Copy(w io.Writer, r io.Reader) error {
+	run, err := replay.Load(r)
+	if err != nil {
+		return err
+	}
+	run, err = a.Run(run)
+	if err != nil {
+		return err
+	}
+	return run.Save(w)
+}
+
+// body anonymizes a JSON body, or a stream of server-sent events with JSON data. Anything else
+// is treated as text.
+func (a *Anonymizer) body(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return b, nil
+	}
+	if json.Valid(b) {
+		return a.json(b)
+	}
+
+	lines := bytes.Split(b, []byte("\n"))
+	for i, l := range lines {
+		data, ok := bytes.CutPrefix(l, []byte("data: "))
+		if !ok || !json.Valid(data) {
+			lines[i] = []byte(a.Text(string(l)))
+			continue
+		}
+		data, err := a.json(data)
+		if err != nil {
+			return nil, err
+		}
+		lines[i] = append([]byte("data: "), data...)
+	}
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+func (a *Anonymizer) json(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(a.value("", v)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// value anonymizes v, which was decoded from JSON under key.
+func (a *Anonymizer) value(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = a.value(k, e)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = a.value(key, e)
+		}
+		return v
+	case string:
+		if skipKeys[key] {
+			return v
+		}
+		if kind, ok := replaceKeys[key]; ok && v != "" {
+			return a.Pseudonym(kind, v)
+		}
+		return a.Text(v)
+	}
+	return v
+}
+
+// keptHeaders are the headers kept by Run(). Other headers, such as cookies or custom headers
+// added by a proxy, may identify the user.
+var keptHeaders = []string{
+	"Content-Type",
+	"Retry-After",
+	"Retry-After-Ms",
+	"X-Ratelimit-Remaining-Requests",
+	"X-Ratelimit-Remaining-Tokens",
+	"X-Ms-Request-Id",
+	"Apim-Request-Id",
+}
+
+// keepHeaders returns the keptHeaders in h.
+func keepHeaders(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	out := http.Header{}
+	for _, k := range keptHeaders {
+		if v := h.Values(k); len(v) > 0 {
+			out[k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package anonymize
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/replay"
+)
+
+var key = []byte("0123456789abcdef")
+
+func TestText(t *testing.T) {
+	a, err := New(key, WithTerms("NAME", "Ann", "Ann Lee"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc string
+		in   string
+		want string
+	}{
+		{
+			desc: "email, case insensitive",
+			in:   "Mail ann@example.com or ANN@EXAMPLE.COM",
+			want: "Mail " + a.Pseudonym("EMAIL", "ann@example.com") + " or " + a.Pseudonym("EMAIL", "ann@example.com"),
+		},
+		{
+			desc: "phone in different formats",
+			in:   "Call 555-123-4567 or (555) 123 4567",
+			want: "Call " + a.Pseudonym("PHONE", "5551234567") + " or " + a.Pseudonym("PHONE", "5551234567"),
+		},
+		{
+			desc: "card",
+			in:   "Card 4111 1111 1111 1111.",
+			want: "Card " + a.Pseudonym("CARD", "4111111111111111") + ".",
+		},
+		{
+			desc: "ip",
+			in:   "From 10.0.0.1",
+			want: "From " + a.Pseudonym("IP", "10.0.0.1"),
+		},
+		{
+			desc: "terms, longest first",
+			in:   "ann lee met Ann. Annie stayed home.",
+			want: a.Pseudonym("NAME", "ann lee") + " met " + a.Pseudonym("NAME", "ann") + ". Annie stayed home.",
+		},
+		{
+			desc: "nothing to replace",
+			in:   "The capital of California is Sacramento, population 525041.",
+			want: "The capital of California is Sacramento, population 525041.",
+		},
+	}
+
+	for _, test := range tests {
+		if got := a.Text(test.in); got != test.want {
+			t.Errorf("TestText(%s): got %q, want %q", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestPseudonym(t *testing.T) {
+	a, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New([]byte("fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := a.Pseudonym("EMAIL", "ann@example.com")
+	if !strings.HasPrefix(p, "<EMAIL_") || len(p) != len("<EMAIL_12345678>") {
+		t.Errorf("TestPseudonym: got %q, want <EMAIL_ followed by 8 hex digits>", p)
+	}
+	if got := a.Pseudonym("EMAIL", "ann@example.com"); got != p {
+		t.Errorf("TestPseudonym(same key): got %q, want %q", got, p)
+	}
+	if got := b.Pseudonym("EMAIL", "ann@example.com"); got == p {
+		t.Errorf("TestPseudonym(other key): got %q, want a different pseudonym", got)
+	}
+	if got := a.Pseudonym("NAME", "ann@example.com"); got[len("<NAME_"):] == p[len("<EMAIL_"):] {
+		t.Errorf("TestPseudonym(other kind): got %q, want a different hash", got)
+	}
+
+	if _, err := New([]byte("short")); err == nil {
+		t.Errorf("TestPseudonym(short key): got err == nil, want err != nil")
+	}
+}
+
+func TestRun(t *testing.T) {
+	a, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := &replay.Run{
+		Version: 1,
+		Exchanges: []replay.Exchange{
+			{
+				Seq:       0,
+				URL:       "https://test/openai/deployments/gpt/chat/completions",
+				ReqHeader: http.Header{"Content-Type": {"application/json"}, "Cookie": {"session=ann"}},
+				ReqBody:   []byte(`{"messages":[{"role":"user","content":"I am ann@example.com"}],"user":"user-42","temperature":0.5}`),
+				Status:    200,
+				RespHeader: http.Header{
+					"Apim-Request-Id": {"req"},
+					"X-Forwarded-For": {"10.0.0.1"},
+				},
+				RespBody: []byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"Hi ann@example.com"}}]}`),
+			},
+			{
+				Seq:      1,
+				ReqBody:  []byte(`{"stream":true}`),
+				RespBody: []byte("data: {\"choices\":[{\"delta\":{\"content\":\"call 555-123-4567\"}}]}\n\ndata: [DONE]\n\n"),
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := run.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := a.Copy(out, buf); err != nil {
+		t.Fatalf("TestRun: got err == %s, want err == nil", err)
+	}
+	got, err := replay.Load(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := a.Pseudonym("EMAIL", "ann@example.com")
+	tests := []struct {
+		desc string
+		got  string
+		want string
+	}{
+		{
+			desc: "request body",
+			got:  string(got.Exchanges[0].ReqBody),
+			want: `{"messages":[{"content":"I am ` + email + `","role":"user"}],"temperature":0.5,"user":"` + a.Pseudonym("USER", "user-42") + `"}`,
+		},
+		{
+			desc: "response body",
+			got:  string(got.Exchanges[0].RespBody),
+			want: `{"choices":[{"message":{"content":"Hi ` + email + `","role":"assistant"}}],"id":"chatcmpl-1"}`,
+		},
+		{
+			desc: "stream",
+			got:  string(got.Exchanges[1].RespBody),
+			want: "data: {\"choices\":[{\"delta\":{\"content\":\"call " + a.Pseudonym("PHONE", "5551234567") + "\"}}]}\n\ndata: [DONE]\n\n",
+		},
+		{
+			desc: "url",
+			got:  got.Exchanges[0].URL,
+			want: "https://test/openai/deployments/gpt/chat/completions",
+		},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("TestRun(%s): got %s, want %s", test.desc, test.got, test.want)
+		}
+	}
+
+	if h := got.Exchanges[0].ReqHeader; h.Get("Cookie") != "" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("TestRun(request header): got %v, want only Content-Type", h)
+	}
+	if h := got.Exchanges[0].RespHeader; h.Get("X-Forwarded-For") != "" || h.Get("Apim-Request-Id") != "req" {
+		t.Errorf("TestRun(response header): got %v, want only Apim-Request-Id", h)
+	}
+	if strings.Contains(string(run.Exchanges[0].ReqBody), "<EMAIL_") {
+		t.Errorf("TestRun: the original Run was changed")
+	}
+}
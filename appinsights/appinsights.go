@@ -0,0 +1,327 @@
+/*
+Package appinsights exports request telemetry to Azure Monitor Application Insights. Each chat,
+completions and embeddings request is sent as a dependency, with its duration, result and
+model, and the tokens it used are sent as the custom metrics gen_ai.usage.input_tokens and
+gen_ai.usage.output_tokens, so cost can be charted and alerted on next to latency.
+
+The Exporter is a genai.Tracer, so requests are captured with a genai.Transport, which is used
+with azopenai.WithClient(). Telemetry is batched and sent in the background:
+
+	exp, err := appinsights.New(appinsights.WithConnectionString(connString), appinsights.WithRoleName("chatbot"))
+	if err != nil {
+		return err
+	}
+	defer exp.Close(context.Background())
+
+	tr, err := genai.New(exp, http.DefaultTransport)
+	if err != nil {
+		return err
+	}
+	client, err := azopenai.New(resourceName, auth, azopenai.WithClient(&http.Client{Transport: tr}))
+
+The connection string is the one shown on the overview page of the Application Insights
+resource. If WithConnectionString() is not used, it is read from the
+APPLICATIONINSIGHTS_CONNECTION_STRING environment variable, as the Azure SDKs do.
+
+This package does not depend on the Application Insights SDK, as the Go SDK is no longer
+maintained and Azure Monitor has no OpenTelemetry exporter for Go. It sends the same telemetry
+envelopes to the ingestion endpoint of the connection string.
+*/
+package appinsights
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/genai"
+)
+
+// EnvConnectionString is the environment variable the connection string is read from if
+// WithConnectionString() is not used.
+const EnvConnectionString = "APPLICATIONINSIGHTS_CONNECTION_STRING"
+
+// defaultIngestion is the ingestion endpoint used if the connection string does not have one.
+const defaultIngestion = "https://dc.services.visualstudio.com"
+
+// Option is an optional argument for New().
+type Option func(e *Exporter) error
+
+// WithConnectionString sets the connection string of the Application Insights resource.
+func WithConnectionString(s string) Option {
+	return func(e *Exporter) error {
+		e.connString = s
+		return nil
+	}
+}
+
+// WithRoleName sets the cloud role name, which is the name of the application on the
+// Application Map. Defaults to the name of the executable.
+func WithRoleName(name string) Option {
+	return func(e *Exporter) error {
+		e.role = name
+		return nil
+	}
+}
+
+// WithClient sets the http.Client used to send telemetry. This must not be a client that
+// uses the genai.Transport of the Exporter. Defaults to a client with a 30 second timeout.
+func WithClient(client *http.Client) Option {
+	return func(e *Exporter) error {
+		if client == nil {
+			return fmt.Errorf("WithClient: client cannot be nil")
+		}
+		e.client = client
+		return nil
+	}
+}
+
+// WithInterval sets how often telemetry is sent. Defaults to 10 seconds.
+func WithInterval(d time.Duration) Option {
+	return func(e *Exporter) error {
+		if d <= 0 {
+			return fmt.Errorf("WithInterval: interval must be > 0")
+		}
+		e.interval = d
+		return nil
+	}
+}
+
+// WithBatchSize sets the number of telemetry items that are sent before the interval has
+// passed. Defaults to 500.
+func WithBatchSize(n int) Option {
+	return func(e *Exporter) error {
+		if n < 1 {
+			return fmt.Errorf("WithBatchSize: size must be > 0")
+		}
+		e.batchSize = n
+		return nil
+	}
+}
+
+// WithErrorHandler sets a function that is called when telemetry sent in the background
+// could not be sent. Telemetry that could not be sent is dropped. By default errors are ignored.
+func WithErrorHandler(fn func(error)) Option {
+	return func(e *Exporter) error {
+		e.onError = fn
+		return nil
+	}
+}
+
+// Exporter sends request telemetry to Application Insights. It implements genai.Tracer.
+// It is safe for concurrent use.
+type Exporter struct {
+	connString string
+	ikey       string
+	trackURL   string
+	role       string
+	client     *http.Client
+	interval   time.Duration
+	batchSize  int
+	onError    func(error)
+
+	mu     sync.Mutex
+	items  []envelope
+	closed bool
+
+	full    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// New creates an Exporter and starts sending telemetry in the background. Close() must be
+// called to send the remaining telemetry and stop.
+func New(options ...Option) (*Exporter, error) {
+	e := &Exporter{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		interval:  10 * time.Second,
+		batchSize: 500,
+		full:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	if exe, err := os.Executable(); err == nil {
+		e.role = exe[strings.LastIndexAny(exe, `/\`)+1:]
+	}
+	for _, o := range options {
+		if err := o(e); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.connString == "" {
+		e.connString = os.Getenv(EnvConnectionString)
+	}
+	if e.connString == "" {
+		return nil, fmt.Errorf("a connection string must be set with WithConnectionString() or %s", EnvConnectionString)
+	}
+	ikey, ingestion, err := parseConnString(e.connString)
+	if err != nil {
+		return nil, err
+	}
+	e.ikey = ikey
+	e.trackURL = ingestion + "/v2.1/track"
+
+	go e.loop()
+	return e, nil
+}
+
+// parseConnString returns the instrumentation key and ingestion endpoint of a connection
+// string, which is a list of key=value pairs separated by semicolons.
+func parseConnString(s string) (ikey, ingestion string, err error) {
+	ingestion = defaultIngestion
+	for _, kv := range strings.Split(s, ";") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return "", "", fmt.Errorf("connection string has malformed pair %q", kv)
+		}
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "instrumentationkey":
+			ikey = strings.TrimSpace(v)
+		case "ingestionendpoint":
+			ingestion = strings.TrimRight(strings.TrimSpace(v), "/")
+		}
+	}
+	if ikey == "" {
+		return "", "", fmt.Errorf("connection string has no InstrumentationKey")
+	}
+	return ikey, ingestion, nil
+}
+
+// Start implements genai.Tracer.
+func (e *Exporter) Start(ctx context.Context, name string, attrs []genai.Attr) (context.Context, genai.Span) {
+	s := &span{e: e, name: name, start: time.Now(), attrs: map[string]any{}}
+	s.SetAttributes(attrs...)
+	return ctx, s
+}
+
+// add queues items to be sent. Items added after Close() are dropped.
+func (e *Exporter) add(items ...envelope) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return
+	}
+	e.items = append(e.items, items...)
+	if len(e.items) >= e.batchSize {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *Exporter) loop() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+		case <-e.full:
+		}
+		if err := e.Flush(context.Background()); err != nil && e.onError != nil {
+			e.onError(err)
+		}
+	}
+}
+
+// Flush sends the queued telemetry now. Every batch is sent even if an earlier one fails.
+// Telemetry that could not be sent is dropped and the errors of all failed batches are returned.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	items := e.items
+	e.items = nil
+	e.mu.Unlock()
+
+	var errs []error
+	for len(items) > 0 {
+		n := min(len(items), e.batchSize)
+		if err := e.send(ctx, items[:n]); err != nil {
+			errs = append(errs, fmt.Errorf("problem sending %d telemetry items: %w", n, err))
+		}
+		items = items[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops sending in the background and sends the remaining telemetry.
+func (e *Exporter) Close(ctx context.Context) error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.mu.Unlock()
+
+	close(e.stop)
+	<-e.stopped
+	return e.Flush(ctx)
+}
+
+// trackResp is the response of the ingestion endpoint.
+type trackResp struct {
+	ItemsReceived int `json:"itemsReceived"`
+	ItemsAccepted int `json:"itemsAccepted"`
+	Errors        []struct {
+		Index   int    `json:"index"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (e *Exporter) send(ctx context.Context, items []envelope) error {
+	body, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.trackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusPartialContent:
+		var tr trackResp
+		if err := json.Unmarshal(b, &tr); err != nil || len(tr.Errors) == 0 {
+			return fmt.Errorf("ingestion accepted only some items")
+		}
+		return fmt.Errorf("ingestion accepted %d of %d items, first error: %s", tr.ItemsAccepted, tr.ItemsReceived, tr.Errors[0].Message)
+	}
+	return fmt.Errorf("ingestion returned status code %d: %s", resp.StatusCode, b)
+}
+
+// newID returns a random hex ID of n bytes.
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,235 @@
+package appinsights
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/genai"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestParseConnString(t *testing.T) {
+	tests := []struct {
+		desc          string
+		s             string
+		wantIKey      string
+		wantIngestion string
+		wantErr       bool
+	}{
+		{
+			desc:          "full",
+			s:             "InstrumentationKey=abc;IngestionEndpoint=https://westus2-2.in.applicationinsights.azure.com/;LiveEndpoint=https://westus2.livediagnostics.monitor.azure.com/",
+			wantIKey:      "abc",
+			wantIngestion: "https://westus2-2.in.applicationinsights.azure.com",
+		},
+		{
+			desc:          "key only",
+			s:             "instrumentationkey=abc;",
+			wantIKey:      "abc",
+			wantIngestion: defaultIngestion,
+		},
+		{
+			desc:    "no key",
+			s:       "IngestionEndpoint=https://example.com",
+			wantErr: true,
+		},
+		{
+			desc:    "malformed",
+			s:       "InstrumentationKey",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		ikey, ingestion, err := parseConnString(test.s)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestParseConnString(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestParseConnString(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if ikey != test.wantIKey || ingestion != test.wantIngestion {
+			t.Errorf("TestParseConnString(%s): got (%s, %s), want (%s, %s)", test.desc, ikey, ingestion, test.wantIKey, test.wantIngestion)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1500 * time.Millisecond, "0.00:00:01.5000000"},
+		{26*time.Hour + 3*time.Minute + 100*time.Nanosecond, "1.02:03:00.0000001"},
+	}
+	for _, test := range tests {
+		if got := duration(test.d); got != test.want {
+			t.Errorf("TestDuration(%s): got %s, want %s", test.d, got, test.want)
+		}
+	}
+}
+
+func TestExporter(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		items []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2.1/track" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var got []map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		items = append(items, got...)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	exp, err := New(WithConnectionString("InstrumentationKey=key;IngestionEndpoint="+srv.URL+"/"), WithRoleName("app"), WithInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("TestExporter: New(): %s", err)
+	}
+
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id":"c1","model":"gpt-4","choices":[{"index":0,"finish_reason":"stop","message":{"content":"Hi"}}],"usage":{"prompt_tokens":5,"completion_tokens":2}}`)),
+		}, nil
+	})
+	tr, err := genai.New(exp, next)
+	if err != nil {
+		t.Fatalf("TestExporter: genai.New(): %s", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "https://test/openai/deployments/gpt4/chat/completions", strings.NewReader(`{"messages":[{"role":"user","content":"Hi"}]}`))
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("TestExporter: RoundTrip(): %s", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err := exp.Close(context.Background()); err != nil {
+		t.Fatalf("TestExporter: Close(): %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(items) != 2 {
+		t.Fatalf("TestExporter: got %d items, want 2", len(items))
+	}
+
+	dep := items[0]
+	if dep["name"] != "Microsoft.ApplicationInsights.RemoteDependency" || dep["iKey"] != "key" {
+		t.Errorf("TestExporter: got dependency envelope %v", dep)
+	}
+	if role := dep["tags"].(map[string]any)["ai.cloud.role"]; role != "app" {
+		t.Errorf("TestExporter: got role %v, want app", role)
+	}
+	base := dep["data"].(map[string]any)["baseData"].(map[string]any)
+	for k, want := range map[string]any{"name": "chat gpt4", "resultCode": "200", "success": true, "target": "test", "type": dependencyType} {
+		if base[k] != want {
+			t.Errorf("TestExporter: got dependency %s == %v, want %v", k, base[k], want)
+		}
+	}
+
+	base = items[1]["data"].(map[string]any)["baseData"].(map[string]any)
+	want := []any{
+		map[string]any{"name": "gen_ai.usage.input_tokens", "value": 5.0, "count": 1.0},
+		map[string]any{"name": "gen_ai.usage.output_tokens", "value": 2.0, "count": 1.0},
+	}
+	if !reflect.DeepEqual(base["metrics"], want) {
+		t.Errorf("TestExporter: got metrics %v, want %v", base["metrics"], want)
+	}
+}
+
+func TestExporterErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(`{"itemsReceived":1,"itemsAccepted":0,"errors":[{"index":0,"message":"bad"}]}`))
+	}))
+	defer srv.Close()
+
+	exp, err := New(WithConnectionString("InstrumentationKey=key;IngestionEndpoint="+srv.URL), WithInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("TestExporterErrorStatus: New(): %s", err)
+	}
+	defer exp.Close(context.Background())
+
+	_, s := exp.Start(context.Background(), "chat gpt4", nil)
+	s.End()
+	if err := exp.Flush(context.Background()); err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("TestExporterErrorStatus: got err == %v, want the ingestion error", err)
+	}
+}
+
+func TestExporterFlushBatches(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+
+		// The first and third batches fail.
+		if call%2 == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"itemsReceived":1,"itemsAccepted":0,"errors":[{"index":0,"message":"bad batch"}]}`))
+			return
+		}
+		w.Write([]byte(`{"itemsReceived":1,"itemsAccepted":1}`))
+	}))
+	defer srv.Close()
+
+	exp, err := New(WithConnectionString("InstrumentationKey=key;IngestionEndpoint="+srv.URL), WithInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("TestExporterFlushBatches: New(): %s", err)
+	}
+	defer exp.Close(context.Background())
+
+	for i := 0; i < 3; i++ {
+		_, s := exp.Start(context.Background(), "chat gpt4", nil)
+		s.End()
+	}
+	// Send each item in its own batch. This is set after the items are queued so that they are
+	// not sent in the background.
+	exp.mu.Lock()
+	exp.batchSize = 1
+	exp.mu.Unlock()
+
+	err = exp.Flush(context.Background())
+	if err == nil || strings.Count(err.Error(), "bad batch") != 2 {
+		t.Errorf("TestExporterFlushBatches: got err == %v, want the errors of both failed batches", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 3 {
+		t.Errorf("TestExporterFlushBatches: got %d batches sent, want 3", calls)
+	}
+}
@@ -0,0 +1,181 @@
+package appinsights
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/genai"
+)
+
+// envelope is an Application Insights telemetry item.
+type envelope struct {
+	Name string            `json:"name"`
+	Time string            `json:"time"`
+	IKey string            `json:"iKey"`
+	Tags map[string]string `json:"tags,omitempty"`
+	Data envelopeData      `json:"data"`
+}
+
+type envelopeData struct {
+	BaseType string `json:"baseType"`
+	BaseData any    `json:"baseData"`
+}
+
+// dependency is the RemoteDependencyData of a request.
+type dependency struct {
+	Ver        int               `json:"ver"`
+	Name       string            `json:"name"`
+	ID         string            `json:"id"`
+	ResultCode string            `json:"resultCode"`
+	Duration   string            `json:"duration"`
+	Success    bool              `json:"success"`
+	Data       string            `json:"data,omitempty"`
+	Target     string            `json:"target,omitempty"`
+	Type       string            `json:"type"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// metricData is the MetricData of custom metrics.
+type metricData struct {
+	Ver        int               `json:"ver"`
+	Metrics    []metric          `json:"metrics"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type metric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Count int     `json:"count"`
+}
+
+// dependencyType is the type of the dependencies, which groups them on the Application Map.
+const dependencyType = "Azure OpenAI"
+
+// span is a genai.Span that is sent as a dependency when it ends.
+type span struct {
+	e     *Exporter
+	name  string
+	start time.Time
+
+	mu    sync.Mutex
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+// SetAttributes implements genai.Span.
+func (s *span) SetAttributes(attrs ...genai.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+// AddEvent implements genai.Span. Events hold prompts and generated text, which are not
+// exported.
+func (s *span) AddEvent(name string, attrs ...genai.Attr) {}
+
+// RecordError implements genai.Span.
+func (s *span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End implements genai.Span.
+func (s *span) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	s.e.add(s.envelopes(time.Since(s.start))...)
+}
+
+// envelopes returns the dependency and token metrics of the span.
+func (s *span) envelopes(d time.Duration) []envelope {
+	now := s.start.UTC().Format(time.RFC3339Nano)
+	tags := map[string]string{"ai.operation.id": newID(16)}
+	if s.e.role != "" {
+		tags["ai.cloud.role"] = s.e.role
+	}
+
+	props := map[string]string{}
+	for _, k := range []string{"gen_ai.system", "gen_ai.operation.name", "gen_ai.request.model", "gen_ai.response.model", "gen_ai.response.id"} {
+		if v, ok := s.attrs[k].(string); ok && v != "" {
+			props[k] = v
+		}
+	}
+	if s.err != nil {
+		props["error"] = s.err.Error()
+	}
+
+	code := "200"
+	if v, ok := s.attrs["error.type"].(string); ok {
+		code = v
+	}
+	target, _ := s.attrs["server.address"].(string)
+
+	dep := dependency{
+		Ver:        2,
+		Name:       s.name,
+		ID:         newID(8),
+		ResultCode: code,
+		Duration:   duration(d),
+		Success:    s.err == nil,
+		Data:       s.name,
+		Target:     target,
+		Type:       dependencyType,
+		Properties: props,
+	}
+	items := []envelope{
+		{
+			Name: "Microsoft.ApplicationInsights.RemoteDependency",
+			Time: now,
+			IKey: s.e.ikey,
+			Tags: tags,
+			Data: envelopeData{BaseType: "RemoteDependencyData", BaseData: dep},
+		},
+	}
+
+	var metrics []metric
+	for _, k := range []string{"gen_ai.usage.input_tokens", "gen_ai.usage.output_tokens"} {
+		if v, ok := s.attrs[k].(int); ok {
+			metrics = append(metrics, metric{Name: k, Value: float64(v), Count: 1})
+		}
+	}
+	if len(metrics) > 0 {
+		mprops := map[string]string{}
+		for _, k := range []string{"gen_ai.operation.name", "gen_ai.request.model"} {
+			if v, ok := props[k]; ok {
+				mprops[k] = v
+			}
+		}
+		items = append(items, envelope{
+			Name: "Microsoft.ApplicationInsights.Metric",
+			Time: now,
+			IKey: s.e.ikey,
+			Tags: tags,
+			Data: envelopeData{BaseType: "MetricData", BaseData: metricData{Ver: 2, Metrics: metrics, Properties: mprops}},
+		})
+	}
+	return items
+}
+
+// duration formats d as the d.hh:mm:ss.fffffff TimeSpan Application Insights expects.
+func duration(d time.Duration) string {
+	ticks := d.Nanoseconds() / 100
+	const tps = 10_000_000
+	days := ticks / (tps * 86400)
+	ticks -= days * tps * 86400
+	h := ticks / (tps * 3600)
+	ticks -= h * tps * 3600
+	m := ticks / (tps * 60)
+	ticks -= m * tps * 60
+	sec := ticks / tps
+	ticks -= sec * tps
+	return fmt.Sprintf("%d.%02d:%02d:%02d.%07d", days, h, m, sec, ticks)
+}
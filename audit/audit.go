@@ -0,0 +1,286 @@
+/*
+Package audit writes a tamper-evident audit trail of the requests a client makes, for regulated
+environments. Each Record holds the SHA-256 hash of the previous Record, so removing, reordering
+or editing a Record breaks the chain. Records can also be signed with a crypto.Signer, which
+prevents someone with access to the trail from rewriting the whole chain.
+
+Records hold hashes of the request and response bodies, not the bodies, so the trail does not
+hold prompts or generated text. Credentials are never recorded.
+
+The Log is an http.RoundTripper, so it is used with azopenai.WithClient(). Because it sits below
+the retry logic, each retry is its own Record.
+
+Writing an audit trail:
+
+	f, err := os.OpenFile("audit.jsonl", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	l, err := audit.New(f, http.DefaultTransport, audit.WithSigner(key))
+	if err != nil {
+		return err
+	}
+	client, err := azopenai.New(resourceName, auth, azopenai.WithClient(&http.Client{Transport: l}))
+
+Verifying an audit trail:
+
+	f, err := os.Open("audit.jsonl")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := audit.Verify(f, key.Public()); err != nil {
+		return err
+	}
+*/
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// ErrTampered is returned by Verify() when the audit trail has been changed.
+var ErrTampered = errors.New("audit trail has been tampered with")
+
+// Record is an entry in the audit trail for one HTTP exchange.
+type Record struct {
+	// Seq is the position of the Record in the trail, starting at 0.
+	Seq int `json:"seq"`
+	// Time is when the request was made.
+	Time time.Time `json:"time"`
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// URL is the URL of the request.
+	URL string `json:"url"`
+	// ReqHash is the hex SHA-256 hash of the request body.
+	ReqHash string `json:"reqHash"`
+	// Status is the HTTP status code of the response. 0 if the request failed.
+	Status int `json:"status,omitempty"`
+	// RequestID is the request ID the service returned.
+	RequestID string `json:"requestID,omitempty"`
+	// Err is the error if the request failed without a response.
+	Err string `json:"err,omitempty"`
+
+	// Prev is the Hash of the previous Record, empty for the first Record.
+	Prev string `json:"prev"`
+	// Hash is the hex SHA-256 hash of the Record with Hash and Sig empty.
+	Hash string `json:"hash"`
+	// Sig is the signature of the Hash, if the Log has a signer.
+	Sig []byte `json:"sig,omitempty"`
+}
+
+// sum returns the hash of r with Hash and Sig empty.
+func (r Record) sum() ([]byte, error) {
+	r.Hash = ""
+	r.Sig = nil
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	s := sha256.Sum256(b)
+	return s[:], nil
+}
+
+// Log writes Records for each request to an io.Writer as JSON lines. It is safe for concurrent use.
+type Log struct {
+	next   http.RoundTripper
+	signer crypto.Signer
+
+	mu   sync.Mutex
+	w    io.Writer
+	seq  int
+	prev string
+}
+
+// Option is an optional argument for New().
+type Option func(l *Log) error
+
+// WithSigner signs the Hash of each Record with signer. RSA, ECDSA and Ed25519 keys are supported.
+func WithSigner(signer crypto.Signer) Option {
+	return func(l *Log) error {
+		switch signer.Public().(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		default:
+			return fmt.Errorf("WithSigner: key type %T is not supported", signer.Public())
+		}
+		l.signer = signer
+		return nil
+	}
+}
+
+// WithContinue continues an existing audit trail that ends with last, so that appending to a
+// file keeps a single chain.
+func WithContinue(last Record) Option {
+	return func(l *Log) error {
+		if last.Hash == "" {
+			return errors.New("WithContinue: Record has no Hash")
+		}
+		l.seq = last.Seq + 1
+		l.prev = last.Hash
+		return nil
+	}
+}
+
+// New creates a Log that writes to w and sends requests to next. If next is nil,
+// http.DefaultTransport is used.
+func New(w io.Writer, next http.RoundTripper, options ...Option) (*Log, error) {
+	if w == nil {
+		return nil, errors.New("w cannot be nil")
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	l := &Log{w: w, next: next}
+	for _, o := range options {
+		if err := o(l); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// RoundTrip implements http.RoundTripper. The Record is written when the response headers are
+// received. If the Record cannot be written, the response is closed and the error returned, so
+// no request goes unaudited.
+func (l *Log) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := Record{
+		Time:   time.Now().UTC(),
+		Method: req.Method,
+		URL:    req.URL.String(),
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("problem reading request body: %w", err)
+		}
+		body = b
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	h := sha256.Sum256(body)
+	r.ReqHash = hex.EncodeToString(h[:])
+
+	resp, err := l.next.RoundTrip(req)
+	if err != nil {
+		r.Err = err.Error()
+	} else {
+		r.Status = resp.StatusCode
+		r.RequestID = custom.NewResponseMeta(resp).RequestID
+	}
+
+	if werr := l.write(r); werr != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("problem writing audit record: %w", werr)
+	}
+	return resp, err
+}
+
+// write chains, signs and writes r.
+func (l *Log) write(r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r.Seq = l.seq
+	r.Prev = l.prev
+	sum, err := r.sum()
+	if err != nil {
+		return err
+	}
+	r.Hash = hex.EncodeToString(sum)
+	if l.signer != nil {
+		r.Sig, err = sign(l.signer, sum)
+		if err != nil {
+			return fmt.Errorf("problem signing: %w", err)
+		}
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := l.w.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	l.seq++
+	l.prev = r.Hash
+	return nil
+}
+
+// Verify reads an audit trail from r and checks the chain is unbroken. If pub is not nil, the
+// signature of each Record is also checked. A trail started with WithContinue() can only be
+// verified together with the trail it continues. Errors from a broken chain wrap ErrTampered.
+func Verify(r io.Reader, pub crypto.PublicKey) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	prev := ""
+	for seq := 0; ; seq++ {
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("record %d: %w", seq, err)
+		}
+
+		if rec.Seq != seq {
+			return fmt.Errorf("%w: record %d has seq %d", ErrTampered, seq, rec.Seq)
+		}
+		if rec.Prev != prev {
+			return fmt.Errorf("%w: record %d does not chain to the previous record", ErrTampered, seq)
+		}
+		sum, err := rec.sum()
+		if err != nil {
+			return fmt.Errorf("record %d: %w", seq, err)
+		}
+		if hex.EncodeToString(sum) != rec.Hash {
+			return fmt.Errorf("%w: record %d does not match its hash", ErrTampered, seq)
+		}
+		if pub != nil && !verify(pub, sum, rec.Sig) {
+			return fmt.Errorf("%w: record %d has a bad signature", ErrTampered, seq)
+		}
+		prev = rec.Hash
+	}
+}
+
+func sign(signer crypto.Signer, sum []byte) ([]byte, error) {
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		// Ed25519 signs the message itself, not a digest of it.
+		return signer.Sign(rand.Reader, sum, crypto.Hash(0))
+	}
+	return signer.Sign(rand.Reader, sum, crypto.SHA256)
+}
+
+func verify(pub crypto.PublicKey, sum, sig []byte) bool {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, sum, sig) == nil
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, sum, sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, sum, sig)
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLog(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Apim-Request-Id": []string{"req"}},
+			Body:       io.NopCloser(strings.NewReader("ok")),
+			Request:    req,
+		}, nil
+	})
+
+	buf := &bytes.Buffer{}
+	l, err := New(buf, backend, WithSigner(priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: l}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Post("https://test/chat", "application/json", strings.NewReader(`{"q":1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	trail := buf.String()
+	if strings.Contains(trail, `{"q":1}`) {
+		t.Errorf("TestLog: the request body was recorded")
+	}
+	if err := Verify(strings.NewReader(trail), pub); err != nil {
+		t.Errorf("TestLog: got err == %s, want err == nil", err)
+	}
+
+	lines := strings.SplitAfter(trail, "\n")
+	tests := []struct {
+		desc  string
+		trail string
+	}{
+		{desc: "record removed", trail: lines[0] + lines[2]},
+		{desc: "records reordered", trail: lines[1] + lines[0] + lines[2]},
+		{desc: "record edited", trail: strings.Replace(trail, `"status":200`, `"status":500`, 1)},
+	}
+	for _, test := range tests {
+		if err := Verify(strings.NewReader(test.trail), pub); !errors.Is(err, ErrTampered) {
+			t.Errorf("TestLog(%s): got err == %v, want ErrTampered", test.desc, err)
+		}
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	if err := Verify(strings.NewReader(trail), other); !errors.Is(err, ErrTampered) {
+		t.Errorf("TestLog(wrong key): got err == %v, want ErrTampered", err)
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Provider authorizes requests to the service. Authorizer is the Provider for API keys and
+// AzIdentity. Implement Provider for other schemes, such as the subscription key of an API
+// Management gateway in front of the service:
+//
+//	type apim struct{ key string }
+//
+//	func (a apim) Authorize(ctx context.Context, req *http.Request) error {
+//		req.Header.Set("Ocp-Apim-Subscription-Key", a.key)
+//		return nil
+//	}
+//
+// Authorize is called for every attempt of every request and must be safe for concurrent use.
+type Provider interface {
+	// Authorize adds the credentials to req.
+	Authorize(ctx context.Context, req *http.Request) error
+}
+
+// ProviderFunc is an adapter to use a function as a Provider.
+type ProviderFunc func(ctx context.Context, req *http.Request) error
+
+// Authorize implements Provider.Authorize().
+func (f ProviderFunc) Authorize(ctx context.Context, req *http.Request) error {
+	return f(ctx, req)
+}
+
+// Validate validates p. An Authorizer is validated with Authorizer.Validate() and the validated
+// Authorizer is returned. Other Providers are returned as is.
+func Validate(p Provider) (Provider, error) {
+	switch a := p.(type) {
+	case nil:
+		return nil, fmt.Errorf("Provider cannot be nil")
+	case Authorizer:
+		return a.Validate()
+	case *Authorizer:
+		if a == nil {
+			return nil, fmt.Errorf("Provider cannot be nil")
+		}
+		return a.Validate()
+	}
+	return p, nil
+}
@@ -4,10 +4,10 @@ Package azopenai provides a client for the Azure OpenAI Service.
 This package allows access to Azure OpenAI Service using either an API key or
 using [AzIdentity] to authenticate with Azure Active Directory.
 
-The client is split into three sub-clients: completions, chat, and embeddings.
+The client is split into sub-clients: completions, chat, embeddings, images, ingestion, audio, assistants, files and batch.
 Each of these sub-clients provides access to the corresponding API endpoints. You
 can access each of these sub-clients by calling the corresponding method on the main client.
-They will all share the same authentication and http.Client.
+They will all share the same authentication and http.Client, unless WithResource() is used.
 
 Required Information to use this SDK:
 
@@ -50,6 +50,64 @@ Creating a Client with AzIdentity and a user [Managed Identity for Azure Resourc
 		return err
 	}
 
+Creating a Client behind an API gateway that uses its own credentials, with a custom auth.Provider:
+
+	client, err := New(
+		resourceName,
+		auth.ProviderFunc(func(ctx context.Context, req *http.Request) error {
+			req.Header.Set("Ocp-Apim-Subscription-Key", subscriptionKey)
+			return nil
+		}),
+		azopenai.WithEndpoint("https://mygateway.azure-api.net"),
+	)
+
+Using a different resource for an API, such as embeddings provisioned in another region:
+
+	client, err := azopenai.New(
+		resourceName,
+		auth.Authorizer{ApiKey: apiKey},
+		azopenai.WithResource(
+			azopenai.EmbeddingsAPI,
+			azopenai.Resource{Name: "embeddings-eastus", Auth: &auth.Authorizer{ApiKey: embeddingsKey}},
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+Spreading chat calls across deployments in two regions, failing over when one is throttled:
+
+	client, err := azopenai.New(
+		resourceName,
+		auth.Authorizer{ApiKey: apiKey},
+		azopenai.WithDeployments("gpt4", rest.LeastLoaded, []azopenai.DeploymentSpec{
+			{DeploymentID: "gpt4"},
+			{Resource: azopenai.Resource{Name: "openai-westus", Auth: &auth.Authorizer{ApiKey: westKey}}, DeploymentID: "gpt4"},
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	chatClient := client.Chat("gpt4")
+
+Defining a policy once and applying it to calls by name:
+
+	err := azopenai.RegisterBundle("production-safe", azopenai.Bundle{
+		Chat:       []chat.CallOption{chat.WithAPIVersion("2024-10-21"), chat.WithFallback("gpt4-backup")},
+		Embeddings: []embeddings.CallOption{embeddings.WithNewlineRemoval()},
+	})
+	if err != nil {
+		return err
+	}
+	chats, err := chatClient.Call(ctx, msgs, chat.WithBundle("production-safe"))
+
+Detecting features at runtime instead of parsing versions:
+
+	caps := client.Capabilities()
+	if !caps.Supports(azopenai.ChatTools) {
+		// Fall back to assistants, which support tools.
+	}
+
 It should be noted that the New() method will not return an error if your credentials
 are invalid. Only after calling a method on the sub-clients will you get an error if your
 credentials or resource/deployment names are invalid.
@@ -57,18 +115,51 @@ credentials or resource/deployment names are invalid.
 IT IS HIGHLY RECOMMENDED TO USE A SUB-CLIENT
 IMMEDIATELY AFTER CREATION TO VALIDATE YOUR CREDENTIALS AND CONNECTIVITY.
 
+Compatibility:
+
+This SDK follows semantic versioning. Parts of the SDK that are replaced are not removed right
+away. They are marked "Deprecated:" in their documentation and keep working, through aliases or
+adapters to their replacement, for at least two minor versions. When a deprecated part is used,
+a warning is logged once to the WithLogger() logger, or sent to the WithDeprecationHandler()
+handler, so programs get notice before the part is removed:
+
+	client, err := azopenai.New(
+		resourceName,
+		auth.Authorizer{ApiKey: apiKey},
+		azopenai.WithDeprecationHandler(func(ctx context.Context, d rest.Deprecation) {
+			log.Println(d)
+		}),
+	)
+
 [AzIdentity]: https://github.com/Azure/azure-sdk-for-go/blob/main/sdk/azidentity/README.md
 [Managed Identity for Azure Resources]: https://learn.microsoft.com/azure/active-directory/managed-identities-azure-resources/overview
 */
 package azopenai
 
 import (
+	"context"
+	"crypto/tls"
+	"expvar"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/cache"
+	"github.com/element-of-surprise/azopenai/clients/assistants"
+	"github.com/element-of-surprise/azopenai/clients/audio"
+	"github.com/element-of-surprise/azopenai/clients/batch"
 	"github.com/element-of-surprise/azopenai/clients/chat"
 	"github.com/element-of-surprise/azopenai/clients/completions"
 	"github.com/element-of-surprise/azopenai/clients/embeddings"
+	"github.com/element-of-surprise/azopenai/clients/files"
+	"github.com/element-of-surprise/azopenai/clients/images"
+	"github.com/element-of-surprise/azopenai/clients/ingestion"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/feedback"
 	"github.com/element-of-surprise/azopenai/rest"
 )
 
@@ -77,15 +168,85 @@ type Client struct {
 	resourceName string
 	deploymentID string
 
-	auth   auth.Authorizer
+	auth   auth.Provider
 	client *http.Client
 	rest   *rest.Client
+
+	// restOptions are passed to rest.New().
+	restOptions []rest.Option
+	// endpoint is set by WithEndpoint(). It only applies to the resource passed to New().
+	endpoint string
+	// expvar is set by WithExpvar().
+	expvar string
+
+	// resources are set by WithResource().
+	resources map[API]Resource
+	// apis holds the rest.Client for APIs that use a Resource.
+	apis map[API]*rest.Client
+
+	// deployments are set by WithDeployments().
+	deployments map[string]deploymentPool
+	// pools holds the pool rest.Client for each name passed to WithDeployments().
+	pools map[string]*rest.Client
+
+	// feedback is set by WithFeedback().
+	feedback feedback.Sink
+
+	// shared is set by WithSharedClients().
+	shared *sharedClients
+}
+
+// API identifies one of the APIs of the service for WithResource().
+type API string
+
+const (
+	// CompletionsAPI is the completions API.
+	CompletionsAPI API = "completions"
+	// ChatAPI is the chat API.
+	ChatAPI API = "chat"
+	// EmbeddingsAPI is the embeddings API.
+	EmbeddingsAPI API = "embeddings"
+	// ImagesAPI is the image generation API.
+	ImagesAPI API = "images"
+	// IngestionAPI is the ingestion jobs API.
+	IngestionAPI API = "ingestion"
+	// AudioAPI is the audio transcription and translation API.
+	AudioAPI API = "audio"
+	// AssistantsAPI is the Assistants API.
+	AssistantsAPI API = "assistants"
+	// FilesAPI is the Files API.
+	FilesAPI API = "files"
+	// BatchAPI is the Batch API. It uploads its input with the Files API, so a Resource for
+	// BatchAPI is also used for those uploads.
+	BatchAPI API = "batch"
+)
+
+func (a API) validate() error {
+	switch a {
+	case CompletionsAPI, ChatAPI, EmbeddingsAPI, ImagesAPI, IngestionAPI, AudioAPI, AssistantsAPI, FilesAPI, BatchAPI:
+		return nil
+	}
+	return fmt.Errorf("API %q is not supported", a)
+}
+
+// Resource is an Azure OpenAI resource to use for an API instead of the resource passed to New().
+type Resource struct {
+	// Name is the name of the resource. This is required unless Options has rest.WithEndpoint().
+	Name string
+	// Auth authorizes requests to the resource, usually an *auth.Authorizer. If nil, the
+	// auth.Provider passed to New() is used.
+	Auth auth.Provider
+	// Options are passed to rest.New() after the options of the Client, so they override them.
+	// This can be used to set rest.WithCloud(), rest.WithEndpoint() or rest.WithAPIVersion() for
+	// the resource. The Client's WithEndpoint() does not apply to the resource.
+	Options []rest.Option
 }
 
 // Option provides optional arguments to the New constructor.
 type Option func(*Client) error
 
-// WithClient sets the HTTP client to use for requests.
+// WithClient sets the HTTP client to use for requests. This cannot be used with WithProxy(),
+// WithTLSConfig(), WithDialer() or WithConnPool().
 func WithClient(c *http.Client) Option {
 	return func(client *Client) error {
 		client.client = c
@@ -93,11 +254,352 @@ func WithClient(c *http.Client) Option {
 	}
 }
 
-// New creates a new instance of the Client.
-func New(resourceName string, auth auth.Authorizer, options ...Option) (*Client, error) {
+// WithProxy sends requests through the proxy at proxyURL. See rest.WithProxy() for more information.
+func WithProxy(proxyURL string) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithProxy(proxyURL))
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration. See rest.WithTLSConfig() for more information.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithTLSConfig(cfg))
+		return nil
+	}
+}
+
+// WithDialer sets the function used to dial connections. See rest.WithDialer() for more information.
+func WithDialer(dial rest.DialFunc) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithDialer(dial))
+		return nil
+	}
+}
+
+// WithConnPool tunes the connection pool, which by default keeps up to 100 idle connections to
+// the service. See rest.WithConnPool() for more information.
+func WithConnPool(p rest.ConnPool) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithConnPool(p))
+		return nil
+	}
+}
+
+// WithHeader adds a header that is sent with every request. See rest.WithHeader() for more information.
+func WithHeader(key, value string) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithHeader(key, value))
+		return nil
+	}
+}
+
+// WithOrganization sets the OpenAI-Organization header for OpenAI compatible gateways.
+// See rest.WithOrganization() for more information.
+func WithOrganization(org string) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithOrganization(org))
+		return nil
+	}
+}
+
+// WithProject sets the OpenAI-Project header for OpenAI compatible gateways.
+// See rest.WithProject() for more information.
+func WithProject(project string) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithProject(project))
+		return nil
+	}
+}
+
+// WithCloud sets the Azure cloud the resource is in, such as rest.AzureGovernment.
+// See rest.WithCloud() for more information.
+func WithCloud(cloud rest.Cloud) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithCloud(cloud))
+		return nil
+	}
+}
+
+// WithEndpoint sets the base URL of the service for custom domains, private links or gateways.
+// This does not apply to resources set with WithResource(). See rest.WithEndpoint() for more information.
+func WithEndpoint(endpoint string) Option {
+	return func(client *Client) error {
+		client.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithResource uses resource for api instead of the resource passed to New(). Quota is often
+// provisioned in different resources or regions for each API, such as embeddings in one
+// resource and chat in another. This can be passed once per API.
+func WithResource(api API, resource Resource) Option {
+	return func(client *Client) error {
+		if err := api.validate(); err != nil {
+			return fmt.Errorf("WithResource: %w", err)
+		}
+		if _, ok := client.resources[api]; ok {
+			return fmt.Errorf("WithResource: API %q was already set", api)
+		}
+		if client.resources == nil {
+			client.resources = map[API]Resource{}
+		}
+		client.resources[api] = resource
+		return nil
+	}
+}
+
+// DeploymentSpec is a deployment in a pool set with WithDeployments().
+type DeploymentSpec struct {
+	// Resource is the resource the deployment is in. If Resource is the zero value, the resource
+	// passed to New() is used.
+	Resource Resource
+	// DeploymentID is the ID of the deployment.
+	DeploymentID string
+}
+
+type deploymentPool struct {
+	strategy rest.Strategy
+	specs    []DeploymentSpec
+}
+
+// WithDeployments creates a pool of deployments named name, such as deployments of the same model
+// in several regions. Chat(name) and Embeddings(name) return clients that spread calls across the
+// deployments using strategy, failing over to the next deployment on 429 and 5XX errors.
+// With rest.MostRemaining, calls go to the deployment with the most quota left, which pools the
+// quota of several resources or keys behind one client. PoolStatus() reports the state of each
+// deployment. See rest.NewPool() for more information. The expvar stats of a deployment in another resource
+// are published under name + "." + pool name + "." + index, such as "azopenai.gpt4.1".
+func WithDeployments(name string, strategy rest.Strategy, specs []DeploymentSpec) Option {
+	return func(client *Client) error {
+		if name == "" {
+			return fmt.Errorf("WithDeployments: name cannot be empty")
+		}
+		if len(specs) == 0 {
+			return fmt.Errorf("WithDeployments: must have at least one DeploymentSpec")
+		}
+		if _, ok := client.deployments[name]; ok {
+			return fmt.Errorf("WithDeployments: %q was already set", name)
+		}
+		if client.deployments == nil {
+			client.deployments = map[string]deploymentPool{}
+		}
+		client.deployments[name] = deploymentPool{strategy: strategy, specs: append([]DeploymentSpec(nil), specs...)}
+		return nil
+	}
+}
+
+// WithAPIVersion sets the API version of the service to use, such as "2024-02-01".
+// See rest.WithAPIVersion() for more information.
+func WithAPIVersion(version string) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithAPIVersion(version))
+		return nil
+	}
+}
+
+// WithRateLimit sets a client side rate limit for each deployment, which should match its quota.
+// See rest.WithRateLimit() for more information.
+func WithRateLimit(limit rest.RateLimit) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithRateLimit(limit))
+		return nil
+	}
+}
+
+// WithExpvar publishes per deployment request stats via the expvar package under name.
+// Stats for a resource set with WithResource() are published under name + "." + API, such as
+// "azopenai.chat". Nothing is published unless New() succeeds. See rest.WithExpvar() for more
+// information, and the rest package documentation about the /debug/vars handler.
+func WithExpvar(name string) Option {
+	return func(client *Client) error {
+		client.expvar = name
+		return nil
+	}
+}
+
+// WithSharedClients makes Chat(), Completions() and Embeddings() return the same sub-client
+// each time they are called with a deployment ID, instead of a new one. Settings such as
+// SetParams() then apply everywhere the sub-client is used. Sub-clients are safe for
+// concurrent use.
+func WithSharedClients() Option {
+	return func(client *Client) error {
+		client.shared = &sharedClients{}
+		return nil
+	}
+}
+
+// sharedClients holds the sub-clients by deployment ID when WithSharedClients() is used.
+type sharedClients struct {
+	chat        sync.Map
+	completions sync.Map
+	embeddings  sync.Map
+}
+
+// loadShared returns the sub-client in m for deploymentID, storing the one made by create if
+// there is none.
+func loadShared[T any](m *sync.Map, deploymentID string, create func() T) T {
+	if v, ok := m.Load(deploymentID); ok {
+		return v.(T)
+	}
+	v, _ := m.LoadOrStore(deploymentID, create())
+	return v.(T)
+}
+
+// WithRetryPolicy sets the policy for retrying requests that fail with retryable status codes,
+// such as 429 (Too Many Requests). By default requests are not retried. rest.DefaultRetryPolicy
+// is a good starting point. See rest.WithRetryPolicy() for more information.
+func WithRetryPolicy(policy rest.RetryPolicy) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithRetryPolicy(policy))
+		return nil
+	}
+}
+
+// WithLogger logs the URL, status code and duration of every request, and any retries, to logger.
+// See rest.WithLogger() for more information.
+func WithLogger(logger *slog.Logger) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithLogger(logger))
+		return nil
+	}
+}
+
+// WithLogBodies also logs request and response bodies and headers, with credentials redacted.
+// This requires WithLogger(). See rest.WithLogBodies() for more information.
+func WithLogBodies() Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithLogBodies())
+		return nil
+	}
+}
+
+// WithLogCurl also logs an equivalent curl command for every request, with credentials redacted,
+// to reproduce problems outside of Go. This requires WithLogger(). See rest.WithLogCurl() for more information.
+func WithLogCurl() Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithLogCurl())
+		return nil
+	}
+}
+
+// WithRedactHeaders redacts the values of headers in logs and curl commands, such as the headers
+// set by a custom auth.Provider. See rest.WithRedactHeaders() for more information.
+func WithRedactHeaders(headers ...string) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithRedactHeaders(headers...))
+		return nil
+	}
+}
+
+// WithDecoder accepts responses compressed with the encoding name, such as "zstd" from a gateway,
+// and decompresses them with d. gzip is always accepted. See rest.WithDecoder() for more information.
+func WithDecoder(name string, d rest.Decoder) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithDecoder(name, d))
+		return nil
+	}
+}
+
+// WithoutCompression asks for responses that are not compressed, which is useful when debugging.
+// See rest.WithoutCompression() for more information.
+func WithoutCompression() Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithoutCompression())
+		return nil
+	}
+}
+
+// WithTimeout sets a deadline of d on each request that is not a stream, on top of the deadline
+// of the context. The sub-clients' WithTimeout() call options override it. See rest.WithTimeout()
+// for more information.
+func WithTimeout(d time.Duration) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithTimeout(d))
+		return nil
+	}
+}
+
+// WithStreamIdleTimeout fails a stream with rest.ErrStreamIdle when no data arrives within d.
+// See rest.WithStreamIdleTimeout() for more information.
+func WithStreamIdleTimeout(d time.Duration) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithStreamIdleTimeout(d))
+		return nil
+	}
+}
+
+// WithDedup makes concurrent identical completions, chat and embeddings calls share a single call
+// to the service. See rest.WithDedup() for more information.
+func WithDedup() Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithDedup())
+		return nil
+	}
+}
+
+// WithDeprecationHandler calls h the first time each deprecated part of the SDK is used, instead of
+// logging a warning to the WithLogger() logger. See rest.WithDeprecationHandler() for more information.
+func WithDeprecationHandler(h func(ctx context.Context, d rest.Deprecation)) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithDeprecationHandler(h))
+		return nil
+	}
+}
+
+// WithCache caches the responses of completions, chat and embeddings calls in c under namespace,
+// so identical requests don't call the service. cache.NewLRU() provides an in-memory cache.
+// A cache shared by clients with different credentials must use a different namespace for each.
+// See rest.WithCache() for more information.
+func WithCache(namespace string, c cache.Cache) Option {
+	return func(client *Client) error {
+		client.restOptions = append(client.restOptions, rest.WithCache(namespace, c))
+		return nil
+	}
+}
+
+// WithFeedback sets the Sink that Feedback() writes to.
+func WithFeedback(sink feedback.Sink) Option {
+	return func(client *Client) error {
+		if sink == nil {
+			return fmt.Errorf("WithFeedback: sink cannot be nil")
+		}
+		client.feedback = sink
+		return nil
+	}
+}
+
+// Bundle is a named set of CallOptions for each sub-client, such as a "production-safe" policy,
+// so call sites apply the policy with WithBundle(name) instead of repeating the options. The
+// sub-clients without CallOptions in a Bundle get an empty bundle, so WithBundle(name) works
+// with every sub-client.
+type Bundle struct {
+	Chat        []chat.CallOption
+	Completions []completions.CallOption
+	Embeddings  []embeddings.CallOption
+	Images      []images.CallOption
+	Audio       []audio.CallOption
+}
+
+// RegisterBundle registers b under name for each sub-client, such as with
+// chat.RegisterBundle(). Registering a name again replaces its options.
+func RegisterBundle(name string, b Bundle) error {
+	return errors.Join(
+		chat.RegisterBundle(name, b.Chat...),
+		completions.RegisterBundle(name, b.Completions...),
+		embeddings.RegisterBundle(name, b.Embeddings...),
+		images.RegisterBundle(name, b.Images...),
+		audio.RegisterBundle(name, b.Audio...),
+	)
+}
+
+// New creates a new instance of the Client. provider is usually an auth.Authorizer, but can be
+// any auth.Provider, such as one for an API gateway that uses its own credentials.
+func New(resourceName string, provider auth.Provider, options ...Option) (*Client, error) {
 	c := &Client{
 		resourceName: resourceName,
-		auth:         auth,
+		auth:         provider,
 	}
 
 	for _, o := range options {
@@ -106,35 +608,237 @@ func New(resourceName string, auth auth.Authorizer, options ...Option) (*Client,
 		}
 	}
 
-	if c.client == nil {
-		c.client = &http.Client{}
+	var opts []rest.Option
+	if c.client != nil {
+		opts = append(opts, rest.WithClient(c.client))
 	}
+	opts = append(opts, c.restOptions...)
 
-	r, err := rest.New(resourceName, auth, rest.WithClient(c.client))
+	defOpts := opts
+	if c.endpoint != "" {
+		defOpts = append(defOpts, rest.WithEndpoint(c.endpoint))
+	}
+	r, err := rest.New(resourceName, provider, defOpts...)
 	if err != nil {
 		return nil, err
 	}
 	c.rest = r
+	// Stats are published once every rest.Client is made, as expvar cannot unpublish them.
+	pub := []published{{r, c.expvar}}
 
+	c.apis = make(map[API]*rest.Client, len(c.resources))
+	for api, res := range c.resources {
+		r, err := c.newRest(res, opts)
+		if err != nil {
+			return nil, fmt.Errorf("problem creating client for the %s API resource: %w", api, err)
+		}
+		c.apis[api] = r
+		pub = append(pub, published{r, c.expvar + "." + string(api)})
+	}
+
+	c.pools = make(map[string]*rest.Client, len(c.deployments))
+	for name, dp := range c.deployments {
+		members := make([]rest.Member, 0, len(dp.specs))
+		for i, spec := range dp.specs {
+			m := rest.Member{Client: c.rest, DeploymentID: spec.DeploymentID}
+			if spec.Resource.Name != "" || spec.Resource.Auth != nil || len(spec.Resource.Options) > 0 {
+				r, err := c.newRest(spec.Resource, opts)
+				if err != nil {
+					return nil, fmt.Errorf("problem creating client for deployment %d of %q: %w", i, name, err)
+				}
+				m.Client = r
+				pub = append(pub, published{r, c.expvar + "." + name + "." + strconv.Itoa(i)})
+			}
+			members = append(members, m)
+		}
+		p, err := rest.NewPool(dp.strategy, members...)
+		if err != nil {
+			return nil, fmt.Errorf("problem creating deployments %q: %w", name, err)
+		}
+		c.pools[name] = p
+	}
+
+	if c.expvar != "" {
+		if err := publish(pub); err != nil {
+			return nil, fmt.Errorf("WithExpvar: %w", err)
+		}
+	}
 	return c, nil
 }
 
+// newRest creates the rest.Client for res.
+func (c *Client) newRest(res Resource, opts []rest.Option) (*rest.Client, error) {
+	a := c.auth
+	if res.Auth != nil {
+		a = res.Auth
+	}
+	resOpts := append([]rest.Option{}, opts...)
+	resOpts = append(resOpts, res.Options...)
+
+	return rest.New(res.Name, a, resOpts...)
+}
+
+// published is a rest.Client whose stats are published under name.
+type published struct {
+	client *rest.Client
+	name   string
+}
+
+// publish publishes the stats of each rest.Client. It checks every name is free first, so
+// that an error does not leave some of them published.
+func publish(pub []published) error {
+	seen := make(map[string]bool, len(pub))
+	for _, p := range pub {
+		if seen[p.name] || expvar.Get(p.name) != nil {
+			return fmt.Errorf("expvar %q is already published", p.name)
+		}
+		seen[p.name] = true
+	}
+	for _, p := range pub {
+		if err := p.client.PublishExpvar(p.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restFor returns the rest.Client to use for api.
+func (c *Client) restFor(api API) *rest.Client {
+	if r, ok := c.apis[api]; ok {
+		return r
+	}
+	return c.rest
+}
+
 // Completions will return a client for the Completions API. Completions attempt to return
 // sentence completions give some input text. Each call returns a
-// new instance of the client, not a shared instance.
+// new instance of the client, not a shared instance, unless WithSharedClients() is used.
 func (c *Client) Completions(deploymentID string) *completions.Client {
-	return completions.New(deploymentID, c.rest)
+	create := func() *completions.Client {
+		return completions.New(deploymentID, c.restFor(CompletionsAPI))
+	}
+	if c.shared != nil {
+		return loadShared(&c.shared.completions, deploymentID, create)
+	}
+	return create()
 }
 
 // Embeddings will return a client for the Embeddings API. Embeddings converts text strings
 // to vector representation that can be consumed by machine learning models. Each call returns a
-// new instance of the client, not a shared instance.
+// new instance of the client, not a shared instance, unless WithSharedClients() is used. If
+// deploymentID is the name of a pool set with WithDeployments(), calls are spread across the pool.
 func (c *Client) Embeddings(deploymentID string) *embeddings.Client {
-	return embeddings.New(deploymentID, c.rest)
+	create := func() *embeddings.Client {
+		if p, ok := c.pools[deploymentID]; ok {
+			return embeddings.New(deploymentID, p)
+		}
+		return embeddings.New(deploymentID, c.restFor(EmbeddingsAPI))
+	}
+	if c.shared != nil {
+		return loadShared(&c.shared.embeddings, deploymentID, create)
+	}
+	return create()
 }
 
 // Chat will return a client for the Chat API. Chat provides a simple way to interact with
-// the chat API for responding as a chat bot.
+// the chat API for responding as a chat bot. Each call returns a new instance of the client,
+// not a shared instance, unless WithSharedClients() is used. If deploymentID is the name of a
+// pool set with WithDeployments(), calls are spread across the pool.
 func (c *Client) Chat(deploymentID string) *chat.Client {
-	return chat.New(deploymentID, c.rest)
+	create := func() *chat.Client {
+		if p, ok := c.pools[deploymentID]; ok {
+			return chat.New(deploymentID, p)
+		}
+		return chat.New(deploymentID, c.restFor(ChatAPI))
+	}
+	if c.shared != nil {
+		return loadShared(&c.shared.chat, deploymentID, create)
+	}
+	return create()
+}
+
+// PoolStatus returns the status of each deployment in the pool set with WithDeployments(name),
+// such as its requests in flight and remaining quota.
+func (c *Client) PoolStatus(name string) ([]rest.MemberStatus, error) {
+	p, ok := c.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("no pool named %q was set with WithDeployments()", name)
+	}
+	return p.PoolStatus(), nil
+}
+
+// ConnStats returns the connection statistics of every resource the Client uses, see
+// rest.ConnStats. A low ReuseRatio() often explains high latency.
+func (c *Client) ConnStats() rest.ConnStats {
+	// Pools and APIs can share the default rest.Client, so each is only counted once.
+	clients := map[*rest.Client]bool{c.rest: true}
+	for _, r := range c.apis {
+		clients[r] = true
+	}
+	for _, p := range c.pools {
+		for _, m := range p.PoolStatus() {
+			clients[m.Client] = true
+		}
+	}
+
+	var sum rest.ConnStats
+	for r := range clients {
+		sum = sum.Add(r.ConnStats())
+	}
+	return sum
+}
+
+// Images will return a client for the image generation API. Images generates images from a
+// text description. Image generation does not use a deployment. Each call returns a
+// new instance of the client, not a shared instance.
+func (c *Client) Images() *images.Client {
+	return images.New(c.restFor(ImagesAPI))
+}
+
+// Ingestion will return a client for the ingestion jobs API. Ingestion jobs create search
+// indexes from documents in blob storage for use as chat data sources. Each call returns a
+// new instance of the client, not a shared instance.
+func (c *Client) Ingestion() *ingestion.Client {
+	return ingestion.New(c.restFor(IngestionAPI))
+}
+
+// Audio will return a client for the Whisper audio API. Audio transcribes audio into text or
+// translates it into English text. deploymentID must be a Whisper deployment. Each call returns a
+// new instance of the client, not a shared instance.
+func (c *Client) Audio(deploymentID string) *audio.Client {
+	return audio.New(deploymentID, c.restFor(AudioAPI))
+}
+
+// Assistants will return a client for the Assistants API. Assistants run a deployment with
+// instructions and tools on threads of messages kept by the service. Each call returns a
+// new instance of the client, not a shared instance.
+func (c *Client) Assistants() *assistants.Client {
+	return assistants.New(c.restFor(AssistantsAPI))
+}
+
+// Files will return a client for the Files API. Files stores files on the resource for
+// fine-tuning, batch jobs and assistants. Each call returns a new instance of the client,
+// not a shared instance.
+func (c *Client) Files() *files.Client {
+	return files.New(c.restFor(FilesAPI))
+}
+
+// Batch will return a client for the Batch API. Batch runs files of chat or embeddings requests
+// asynchronously at a lower cost. Each call returns a new instance of the client, not a shared
+// instance.
+func (c *Client) Batch() *batch.Client {
+	return batch.New(c.restFor(BatchAPI))
+}
+
+// Feedback records a user's rating and optional comment for the response with id, such as
+// chat.Chats.ID, in the Sink set with WithFeedback().
+func (c *Client) Feedback(ctx context.Context, id string, rating int, comment string) error {
+	if c.feedback == nil {
+		return fmt.Errorf("Feedback() requires WithFeedback() to be set")
+	}
+	f := feedback.Feedback{ID: id, Rating: rating, Comment: comment, Time: time.Now()}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	return c.feedback.Write(ctx, f)
 }
@@ -0,0 +1,241 @@
+package azopenai
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// chatReply is an http.RoundTripper that replies to chat requests and records the URL and
+// temperature of each request.
+type chatReply struct {
+	mu    sync.Mutex
+	urls  []string
+	temps []float64
+}
+
+func (c *chatReply) RoundTrip(req *http.Request) (*http.Response, error) {
+	var in struct {
+		Temperature float64 `json:"temperature"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.urls = append(c.urls, req.URL.String())
+	c.temps = append(c.temps, in.Temperature)
+	c.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestSharedClients(t *testing.T) {
+	reply := &chatReply{}
+	c, err := New(
+		"resource",
+		auth.Authorizer{ApiKey: "key"},
+		WithClient(&http.Client{Transport: reply}),
+		WithSharedClients(),
+		WithDeployments("pool", rest.RoundRobin, []DeploymentSpec{{DeploymentID: "a"}, {DeploymentID: "b"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"deployment", "pool"} {
+		const n = 50
+		var (
+			wg      sync.WaitGroup
+			chats   = make([]*chat.Client, n)
+			compls  = make([]any, n)
+			embeds  = make([]any, n)
+			started = make(chan struct{})
+		)
+		for i := 0; i < n; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-started
+				chats[i] = c.Chat(id)
+				compls[i] = c.Completions(id)
+				embeds[i] = c.Embeddings(id)
+			}()
+		}
+		close(started)
+		wg.Wait()
+
+		for i := 1; i < n; i++ {
+			if chats[i] != chats[0] || compls[i] != compls[0] || embeds[i] != embeds[0] {
+				t.Fatalf("TestSharedClients(%s): goroutine %d got a different sub-client, want the same one", id, i)
+			}
+		}
+		if c.Chat(id+"-other") == chats[0] {
+			t.Errorf("TestSharedClients(%s): got the same sub-client for another deployment ID", id)
+		}
+	}
+
+	// SetParams() on one caller's sub-client applies to every caller.
+	params := chat.CallParams{}.Defaults()
+	params.Temperature = 0.3
+	c.Chat("deployment").SetParams(params)
+	if _, err := c.Chat("deployment").Call(context.Background(), []chat.SendMsg{{Role: chat.User, Content: "hi"}}); err != nil {
+		t.Fatalf("TestSharedClients: got err == %s, want err == nil", err)
+	}
+	if reply.temps[0] != 0.3 {
+		t.Errorf("TestSharedClients: got temperature %v, want 0.3 set by another caller", reply.temps[0])
+	}
+
+	// The shared sub-client for a pool sends to the pool's deployments.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Chat("pool").Call(context.Background(), []chat.SendMsg{{Role: chat.User, Content: "hi"}}); err != nil {
+			t.Fatalf("TestSharedClients(pool): got err == %s, want err == nil", err)
+		}
+	}
+	got := strings.Join(reply.urls[1:], " ")
+	if !strings.Contains(got, "/deployments/a/") || !strings.Contains(got, "/deployments/b/") {
+		t.Errorf("TestSharedClients(pool): got requests to %s, want requests to deployments a and b", got)
+	}
+}
+
+func TestNotSharedClients(t *testing.T) {
+	c, err := New("resource", auth.Authorizer{ApiKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Chat("deployment") == c.Chat("deployment") {
+		t.Errorf("TestNotSharedClients: got the same sub-client twice, want a new one for each call")
+	}
+}
+
+func TestWithResource(t *testing.T) {
+	var host, key string
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		host, key = req.URL.Host, req.Header.Get("api-key")
+		// This body decodes as a response of each API.
+		body := `{"id":"1","choices":[{"index":0,"text":"Hi","message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],` +
+			`"data":[{"object":"embedding","embedding":[0.1],"index":0}]}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New(
+		"main",
+		auth.Authorizer{ApiKey: "mainKey"},
+		WithClient(&http.Client{Transport: rt}),
+		WithEndpoint("https://gateway.example.com"),
+		WithExpvar("azopenai-resource-test"),
+		WithResource(ChatAPI, Resource{Name: "chatres"}),
+		WithResource(EmbeddingsAPI, Resource{Name: "embres", Auth: auth.Authorizer{ApiKey: "embKey"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		desc     string
+		call     func() error
+		wantHost string
+		wantKey  string
+	}{
+		{
+			desc: "Completions uses the Client's endpoint and auth",
+			call: func() error {
+				_, err := c.Completions("deployment").Call(ctx, []string{"hi"})
+				return err
+			},
+			wantHost: "gateway.example.com",
+			wantKey:  "mainKey",
+		},
+		{
+			desc: "Chat uses its resource, not the Client's endpoint, with the Client's auth",
+			call: func() error {
+				_, err := c.Chat("deployment").Call(ctx, []chat.SendMsg{{Role: chat.User, Content: "hi"}})
+				return err
+			},
+			wantHost: "chatres.openai.azure.com",
+			wantKey:  "mainKey",
+		},
+		{
+			desc: "Embeddings uses its resource and auth",
+			call: func() error {
+				_, err := c.Embeddings("deployment").Call(ctx, []string{"hi"})
+				return err
+			},
+			wantHost: "embres.openai.azure.com",
+			wantKey:  "embKey",
+		},
+	}
+
+	for _, test := range tests {
+		host, key = "", ""
+		if err := test.call(); err != nil {
+			t.Errorf("TestWithResource(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		}
+		if host != test.wantHost {
+			t.Errorf("TestWithResource(%s): got host %q, want %q", test.desc, host, test.wantHost)
+		}
+		if key != test.wantKey {
+			t.Errorf("TestWithResource(%s): got api-key %q, want %q", test.desc, key, test.wantKey)
+		}
+	}
+
+	for _, name := range []string{"azopenai-resource-test", "azopenai-resource-test.chat", "azopenai-resource-test.embeddings"} {
+		if expvar.Get(name) == nil {
+			t.Errorf("TestWithResource: expvar %q was not published", name)
+		}
+	}
+	if expvar.Get("azopenai-resource-test.completions") != nil {
+		t.Errorf("TestWithResource: got expvar for completions, which has no resource")
+	}
+
+	if _, err := New("main", auth.Authorizer{ApiKey: "key"}, WithResource(ChatAPI, Resource{Name: "a"}), WithResource(ChatAPI, Resource{Name: "b"})); err == nil {
+		t.Errorf("TestWithResource(same API twice): got err == nil, want err != nil")
+	}
+}
+
+func TestExpvarFailedNew(t *testing.T) {
+	// The Embeddings resource fails after the default rest.Client is made.
+	_, err := New(
+		"main",
+		auth.Authorizer{ApiKey: "key"},
+		WithExpvar("TestExpvarFailedNew"),
+		WithResource(EmbeddingsAPI, Resource{Name: "embres", Options: []rest.Option{rest.WithEndpoint("ftp://bad")}}),
+	)
+	if err == nil {
+		t.Fatalf("TestExpvarFailedNew: got err == nil, want err != nil")
+	}
+	if expvar.Get("TestExpvarFailedNew") != nil {
+		t.Errorf("TestExpvarFailedNew: stats were published by a failed New()")
+	}
+
+	if _, err := New("main", auth.Authorizer{ApiKey: "key"}, WithExpvar("TestExpvarFailedNew")); err != nil {
+		t.Errorf("TestExpvarFailedNew: got err == %s reusing the name, want err == nil", err)
+	}
+}
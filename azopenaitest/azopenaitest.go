@@ -0,0 +1,367 @@
+/*
+Package azopenaitest provides an in-memory fake of the Azure OpenAI service for hermetic tests
+of code built on azopenai. The fake implements the chat, completions and embeddings APIs,
+including streaming, and returns scripted replies in order.
+
+The fake is an http.RoundTripper, so the real clients are used and no network is involved:
+
+	fake := azopenaitest.New()
+	fake.Chat("gpt-35-turbo", azopenaitest.Reply{Text: []string{"Hello, gopher!"}})
+
+	client, err := fake.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Chat("gpt-35-turbo").Call(ctx, []chat.SendMsg{{Role: chat.User, Content: "Hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// resp.Text[0] == "Hello, gopher!"
+
+	reqs := fake.Requests()
+	// reqs[0].Body holds the JSON request that was sent.
+
+Replies can also be errors, to test error handling:
+
+	fake.Chat("gpt-35-turbo", azopenaitest.Reply{StatusCode: http.StatusTooManyRequests, Message: "slow down"})
+
+Embeddings do not need to be scripted. Each input gets a deterministic vector derived from its
+text, which can be replaced with SetEmbedder().
+*/
+package azopenaitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai"
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/completions"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+// API is the API a request was sent to.
+type API string
+
+const (
+	// Chat is the chat API.
+	Chat API = "chat"
+	// Completions is the completions API.
+	Completions API = "completions"
+	// Embeddings is the embeddings API.
+	Embeddings API = "embeddings"
+)
+
+// Reply is a scripted reply to a chat or completions request.
+type Reply struct {
+	// Text is the text of each choice. If empty, there is a single choice with no text.
+	Text []string
+	// FinishReason is the finish reason of each choice. Defaults to custom.Stop.
+	FinishReason custom.FinishReason
+	// StatusCode, if set to a code that is not 2xx, returns an error response with Message instead.
+	StatusCode int
+	// Message is the error message when StatusCode is set.
+	Message string
+}
+
+// Request is a request received by the Fake.
+type Request struct {
+	// API is the API the request was sent to.
+	API API
+	// DeploymentID is the deployment the request was sent to.
+	DeploymentID string
+	// Header is the header of the request.
+	Header http.Header
+	// Body is the JSON body of the request.
+	Body []byte
+	// Stream is true if the request asked for a stream.
+	Stream bool
+}
+
+type key struct {
+	api          API
+	deploymentID string
+}
+
+// Fake is an in-memory fake of the Azure OpenAI service. It is safe for concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	replies  map[key][]Reply
+	embed    func(input string) []float64
+	requests []Request
+}
+
+// New creates a new Fake.
+func New() *Fake {
+	return &Fake{replies: map[key][]Reply{}, embed: Vector}
+}
+
+// Chat adds replies for chat requests to deploymentID. Each request receives the next reply.
+// A request without a reply receives a 500 error.
+func (f *Fake) Chat(deploymentID string, replies ...Reply) {
+	f.add(key{Chat, deploymentID}, replies)
+}
+
+// Completions adds replies for completions requests to deploymentID. See Chat().
+func (f *Fake) Completions(deploymentID string, replies ...Reply) {
+	f.add(key{Completions, deploymentID}, replies)
+}
+
+func (f *Fake) add(k key, replies []Reply) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replies[k] = append(f.replies[k], replies...)
+}
+
+// SetEmbedder sets the function that returns the vector for an input to the embeddings API.
+// Defaults to Vector().
+func (f *Fake) SetEmbedder(embed func(input string) []float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.embed = embed
+}
+
+// Requests returns the requests received so far.
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
+
+// Client returns an azopenai.Client that sends its requests to the Fake. options are applied
+// after the option that sets the http.Client, which must not be replaced.
+func (f *Fake) Client(options ...azopenai.Option) (*azopenai.Client, error) {
+	opts := append([]azopenai.Option{azopenai.WithClient(&http.Client{Transport: f})}, options...)
+	return azopenai.New("azopenaitest", auth.Authorizer{ApiKey: "azopenaitest"}, opts...)
+}
+
+// Vector returns a deterministic unit vector of 8 dimensions for input. Equal inputs get equal vectors.
+func Vector(input string) []float64 {
+	sum := sha256.Sum256([]byte(input))
+	v := make([]float64, 8)
+	var norm float64
+	for i := range v {
+		v[i] = float64(int32(binary.BigEndian.Uint32(sum[i*4:]))) / (1 << 31)
+		norm += v[i] * v[i]
+	}
+	if norm == 0 {
+		v[0] = 1
+		return v
+	}
+	for i := range v {
+		v[i] /= math.Sqrt(norm)
+	}
+	return v
+}
+
+// RoundTrip implements http.RoundTripper.
+func (f *Fake) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	api, deploymentID, ok := route(req.URL.Path)
+	if !ok {
+		return errResp(req, http.StatusNotFound, fmt.Sprintf("azopenaitest: %s is not supported", req.URL.Path)), nil
+	}
+	var stream struct {
+		Stream bool `json:"stream"`
+	}
+	json.Unmarshal(body, &stream)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, Request{API: api, DeploymentID: deploymentID, Header: req.Header.Clone(), Body: body, Stream: stream.Stream})
+	embed := f.embed
+	var (
+		reply Reply
+		have  bool
+	)
+	if api != Embeddings {
+		k := key{api, deploymentID}
+		if q := f.replies[k]; len(q) > 0 {
+			reply, have = q[0], true
+			f.replies[k] = q[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	if api == Embeddings {
+		return f.embeddings(req, body, embed)
+	}
+	if !have {
+		return errResp(req, http.StatusInternalServerError, fmt.Sprintf("azopenaitest: no reply for %s deployment %q", api, deploymentID)), nil
+	}
+	if reply.StatusCode != 0 && (reply.StatusCode < 200 || reply.StatusCode > 299) {
+		return errResp(req, reply.StatusCode, reply.Message), nil
+	}
+	if reply.FinishReason == custom.Unfinished {
+		reply.FinishReason = custom.Stop
+	}
+	if len(reply.Text) == 0 {
+		reply.Text = []string{""}
+	}
+
+	switch {
+	case api == Chat && stream.Stream:
+		return sse(req, chatEvents(reply)), nil
+	case api == Chat:
+		return jsonResp(req, chatResp(reply))
+	case stream.Stream:
+		return sse(req, completionsEvents(reply)), nil
+	}
+	return jsonResp(req, completionsResp(reply))
+}
+
+// route returns the API and deployment of an Azure OpenAI path.
+func route(path string) (API, string, bool) {
+	rest, ok := strings.CutPrefix(path, "/openai/deployments/")
+	if !ok {
+		return "", "", false
+	}
+	deploymentID, op, ok := strings.Cut(rest, "/")
+	if !ok {
+		return "", "", false
+	}
+	switch op {
+	case "chat/completions", "extensions/chat/completions":
+		return Chat, deploymentID, true
+	case "completions":
+		return Completions, deploymentID, true
+	case "embeddings":
+		return Embeddings, deploymentID, true
+	}
+	return "", "", false
+}
+
+func (f *Fake) embeddings(req *http.Request, body []byte, embed func(string) []float64) (*http.Response, error) {
+	var in embeddings.Req
+	if err := json.Unmarshal(body, &in); err != nil {
+		return errResp(req, http.StatusBadRequest, err.Error()), nil
+	}
+	resp := &embeddings.Resp{Model: "azopenaitest"}
+	for i, input := range in.Input {
+		resp.Data = append(resp.Data, embeddings.Data{Object: "embedding", Embedding: embed(input), Index: i})
+	}
+	return jsonResp(req, resp)
+}
+
+// The responses are returned as pointers, as custom.UnixTime only marshals through a pointer.
+
+func chatResp(r Reply) *chat.Resp {
+	resp := &chat.Resp{ID: "azopenaitest", Object: "chat.completion", Created: now(), Model: "azopenaitest"}
+	for i, t := range r.Text {
+		resp.Choices = append(resp.Choices, chat.Choice{
+			Index:        i,
+			Message:      chat.RecvMsg{Role: chat.Assistant, Content: t},
+			FinishReason: r.FinishReason,
+		})
+	}
+	return resp
+}
+
+func chatEvents(r Reply) []any {
+	var events []any
+	add := func(c chat.Choice) {
+		events = append(events, &chat.Resp{ID: "azopenaitest", Object: "chat.completion.chunk", Created: now(), Model: "azopenaitest", Choices: []chat.Choice{c}})
+	}
+	for i, t := range r.Text {
+		add(chat.Choice{Index: i, Delta: chat.RecvMsg{Role: chat.Assistant}})
+		for _, piece := range pieces(t) {
+			add(chat.Choice{Index: i, Delta: chat.RecvMsg{Content: piece}})
+		}
+		add(chat.Choice{Index: i, FinishReason: r.FinishReason})
+	}
+	return events
+}
+
+func completionsResp(r Reply) *completions.Resp {
+	resp := &completions.Resp{ID: "azopenaitest", Object: "text_completion", Created: now(), Model: "azopenaitest"}
+	for i, t := range r.Text {
+		resp.Choices = append(resp.Choices, completions.Choices{Index: i, Text: t, FinishReason: r.FinishReason})
+	}
+	return resp
+}
+
+func completionsEvents(r Reply) []any {
+	var events []any
+	add := func(c completions.Choices) {
+		events = append(events, &completions.Resp{ID: "azopenaitest", Object: "text_completion", Created: now(), Model: "azopenaitest", Choices: []completions.Choices{c}})
+	}
+	for i, t := range r.Text {
+		for _, piece := range pieces(t) {
+			add(completions.Choices{Index: i, Text: piece})
+		}
+		add(completions.Choices{Index: i, FinishReason: r.FinishReason})
+	}
+	return events
+}
+
+func now() custom.UnixTime {
+	return custom.UnixTime{Time: time.Now()}
+}
+
+// pieces splits text into the deltas of a stream, one per word like the service.
+func pieces(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.SplitAfter(text, " ")
+}
+
+func sse(req *http.Request, events []any) *http.Response {
+	b := &bytes.Buffer{}
+	for _, e := range events {
+		j, err := json.Marshal(e)
+		if err != nil {
+			panic(err) // Can't happen, these are our own types.
+		}
+		fmt.Fprintf(b, "data: %s\n\n", j)
+	}
+	b.WriteString("data: [DONE]\n\n")
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       io.NopCloser(b),
+		Request:    req,
+	}
+}
+
+func jsonResp(req *http.Request, v any) (*http.Response, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(b)),
+		Request:    req,
+	}, nil
+}
+
+func errResp(req *http.Request, code int, msg string) *http.Response {
+	b, _ := json.Marshal(map[string]any{"error": map[string]any{"code": http.StatusText(code), "message": msg}})
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(b)),
+		Request:    req,
+	}
+}
@@ -0,0 +1,81 @@
+package azopenaitest
+
+import (
+	"context"
+	goerrors "errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/errors"
+)
+
+func TestFake(t *testing.T) {
+	ctx := context.Background()
+	fake := New()
+	fake.Chat(
+		"chat",
+		Reply{Text: []string{"Hello, gopher!"}},
+		Reply{Text: []string{"A streamed reply."}},
+		Reply{StatusCode: http.StatusTooManyRequests, Message: "slow down"},
+	)
+	fake.Completions("davinci", Reply{Text: []string{"once upon a time"}})
+
+	client, err := fake.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := []chat.SendMsg{{Role: chat.User, Content: "Hi"}}
+
+	resp, err := client.Chat("chat").Call(ctx, msgs)
+	if err != nil || resp.Text[0] != "Hello, gopher!" || resp.FinishReasons[0] != "stop" {
+		t.Errorf("TestFake(chat call): got %+v, %v, want the scripted reply", resp, err)
+	}
+
+	var streamed strings.Builder
+	for sd := range client.Chat("chat").Stream(ctx, msgs) {
+		if sd.Err != nil {
+			t.Fatalf("TestFake(chat stream): got err == %s, want err == nil", sd.Err)
+		}
+		streamed.WriteString(sd.Data.Text[0])
+	}
+	if streamed.String() != "A streamed reply." {
+		t.Errorf("TestFake(chat stream): got %q, want %q", streamed.String(), "A streamed reply.")
+	}
+
+	_, err = client.Chat("chat").Call(ctx, msgs)
+	var jerr errors.JSON
+	if !goerrors.As(err, &jerr) || jerr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("TestFake(chat error): got err == %v, want a 429", err)
+	}
+	if _, err := client.Chat("chat").Call(ctx, msgs); err == nil {
+		t.Errorf("TestFake(no reply): got err == nil, want err != nil")
+	}
+
+	var final string
+	for sd := range client.Completions("davinci").Stream(ctx, "tell me a story") {
+		if sd.Err != nil {
+			t.Fatalf("TestFake(completions stream): got err == %s, want err == nil", sd.Err)
+		}
+		if sd.Done {
+			final = sd.Data.Text[0]
+		}
+	}
+	if final != "once upon a time" {
+		t.Errorf("TestFake(completions stream): got %q, want %q", final, "once upon a time")
+	}
+
+	emb, err := client.Embeddings("ada").Call(ctx, []string{"a", "b", "a"})
+	if err != nil {
+		t.Fatalf("TestFake(embeddings): got err == %s, want err == nil", err)
+	}
+	if len(emb.Results) != 3 || emb.Results[0][0] != emb.Results[2][0] || emb.Results[0][0] == emb.Results[1][0] {
+		t.Errorf("TestFake(embeddings): got %v, want equal vectors for equal inputs", emb.Results)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) != 6 || reqs[1].API != Chat || !reqs[1].Stream || reqs[5].API != Embeddings {
+		t.Errorf("TestFake(requests): got %+v, want the 6 requests sent", reqs)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Package cache provides caches for responses of the Azure OpenAI service, used with
+azopenai.WithCache().
+
+Identical requests, such as embeddings of the same text, return the cached response instead of
+calling the service. Requests are identical if they are sent to the same deployment and API version
+with the same parameters and input.
+
+Using an in-memory LRU cache of 10,000 responses:
+
+	lru, err := cache.NewLRU(10000)
+	if err != nil {
+		return err
+	}
+	client, err := azopenai.New(resourceName, auth.Authorizer{ApiKey: apiKey}, azopenai.WithCache("myapp", lru))
+
+The Cache interface can be implemented with a shared cache, such as Redis, so that several
+processes share responses. Keys don't include the credentials, so processes with different
+credentials must use different namespaces.
+*/
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Cache stores responses by a key derived from the request. Implementations must be safe
+// for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key. ok is false if there is none.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value for key.
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// LRU is an in-memory Cache that holds a fixed number of entries and evicts the least
+// recently used entry when it is full.
+type LRU struct {
+	size int
+
+	mu sync.Mutex // Protects everything below.
+	ll *list.List
+	m  map[string]*list.Element
+}
+
+type entry struct {
+	key   string
+	value []byte
+}
+
+// NewLRU creates an LRU that holds up to size entries.
+func NewLRU(size int) (*LRU, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("size must be > 0, was %d", size)
+	}
+	return &LRU{size: size, ll: list.New(), m: make(map[string]*list.Element, size)}, nil
+}
+
+// Get implements Cache.Get().
+func (l *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.m[key]
+	if !ok {
+		return nil, false, nil
+	}
+	l.ll.MoveToFront(e)
+	return e.Value.(*entry).value, true, nil
+}
+
+// Set implements Cache.Set().
+func (l *LRU) Set(ctx context.Context, key string, value []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.m[key]; ok {
+		e.Value.(*entry).value = value
+		l.ll.MoveToFront(e)
+		return nil
+	}
+
+	l.m[key] = l.ll.PushFront(&entry{key: key, value: value})
+	if l.ll.Len() > l.size {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.m, oldest.Value.(*entry).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
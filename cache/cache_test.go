@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := NewLRU(0); err == nil {
+		t.Errorf("TestLRU(size 0): got err == nil, want err != nil")
+	}
+
+	l, err := NewLRU(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Set(ctx, "a", []byte("1"))
+	l.Set(ctx, "b", []byte("2"))
+	// Using "a" makes "b" the least recently used.
+	if v, ok, _ := l.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("TestLRU(get a): got %q, %v, want %q, true", v, ok, "1")
+	}
+	l.Set(ctx, "c", []byte("3"))
+
+	if _, ok, _ := l.Get(ctx, "b"); ok {
+		t.Errorf("TestLRU(evicted b): got ok == true, want ok == false")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok, _ := l.Get(ctx, k); !ok {
+			t.Errorf("TestLRU(kept %s): got ok == false, want ok == true", k)
+		}
+	}
+	if l.Len() != 2 {
+		t.Errorf("TestLRU(len): got %d, want 2", l.Len())
+	}
+
+	l.Set(ctx, "a", []byte("4"))
+	if v, _, _ := l.Get(ctx, "a"); string(v) != "4" {
+		t.Errorf("TestLRU(replace a): got %q, want %q", v, "4")
+	}
+}
@@ -0,0 +1,146 @@
+package azopenai
+
+// Feature is a feature of the service that this SDK may support. The values are stable and
+// can be stored or compared across releases.
+type Feature string
+
+const (
+	// ChatStreaming is streaming chat responses with chat.Client.Stream().
+	ChatStreaming Feature = "chat.streaming"
+	// ChatVision is sending images in chat messages.
+	ChatVision Feature = "chat.vision"
+	// ChatAudio is audio input and output in chat messages.
+	ChatAudio Feature = "chat.audio"
+	// ChatTools is tool and function calling in chat requests.
+	ChatTools Feature = "chat.tools"
+	// ChatStructuredOutputs is constraining chat responses to a JSON schema.
+	ChatStructuredOutputs Feature = "chat.structured_outputs"
+	// ChatPredictedOutputs is sending a prediction of the response to speed it up.
+	ChatPredictedOutputs Feature = "chat.predicted_outputs"
+	// ChatReasoning is the reasoning effort and completion token limits of reasoning models.
+	ChatReasoning Feature = "chat.reasoning"
+	// ChatDataSources is chat grounded on data sources, such as Azure AI Search.
+	ChatDataSources Feature = "chat.data_sources"
+	// EmbeddingsDimensions is choosing the number of dimensions of embeddings.
+	EmbeddingsDimensions Feature = "embeddings.dimensions"
+	// AssistantsTools is the code interpreter, file search and function tools of assistants.
+	AssistantsTools Feature = "assistants.tools"
+	// Batch is running files of requests asynchronously with the Batch API.
+	Batch Feature = "batch"
+	// Responses is the Responses API.
+	Responses Feature = "responses"
+	// Realtime is the Realtime API for low latency audio conversations.
+	Realtime Feature = "realtime"
+)
+
+// APISupport is an API the SDK supports.
+type APISupport struct {
+	// API is the API.
+	API API `json:"api"`
+	// APIVersion is the API version the Client uses for it.
+	APIVersion string `json:"apiVersion"`
+}
+
+// FeatureSupport reports if the SDK supports a Feature.
+type FeatureSupport struct {
+	// Feature is the feature.
+	Feature Feature `json:"feature"`
+	// Supported is true if the SDK supports the feature.
+	Supported bool `json:"supported"`
+	// API is the API that provides the feature, if it is supported.
+	API API `json:"api,omitempty"`
+	// APIVersion is the API version the Client uses for the feature, if it is supported.
+	// Some features also need the deployment's model to support them.
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+// Capabilities reports the APIs and features this build of the SDK supports, so code can
+// detect them at runtime instead of parsing versions. It is safe to encode as JSON.
+type Capabilities struct {
+	// APIs are the supported APIs.
+	APIs []APISupport `json:"apis"`
+	// Features are the known features, including those that are not supported.
+	Features []FeatureSupport `json:"features"`
+}
+
+// Supports returns true if f is supported.
+func (c Capabilities) Supports(f Feature) bool {
+	for _, fs := range c.Features {
+		if fs.Feature == f {
+			return fs.Supported
+		}
+	}
+	return false
+}
+
+// APIVersion returns the API version used for api, or false if api is not supported.
+func (c Capabilities) APIVersion(api API) (string, bool) {
+	for _, as := range c.APIs {
+		if as.API == api {
+			return as.APIVersion, true
+		}
+	}
+	return "", false
+}
+
+// features maps each Feature to the API that provides it, or "" if it is not supported.
+// The order is the order of Capabilities.Features.
+var features = []struct {
+	feature Feature
+	api     API
+}{
+	{ChatStreaming, ChatAPI},
+	{ChatVision, ChatAPI},
+	{ChatAudio, ChatAPI},
+	{ChatTools, ""},
+	{ChatStructuredOutputs, ChatAPI},
+	{ChatPredictedOutputs, ChatAPI},
+	{ChatReasoning, ChatAPI},
+	{ChatDataSources, ChatAPI},
+	{EmbeddingsDimensions, EmbeddingsAPI},
+	{AssistantsTools, AssistantsAPI},
+	{Batch, BatchAPI},
+	{Responses, ""},
+	{Realtime, ""},
+}
+
+// Capabilities returns the APIs and features this build of the SDK supports, with the API
+// versions the Client uses for them. These reflect WithAPIVersion() and WithResource().
+func (c *Client) Capabilities() Capabilities {
+	apis := []API{CompletionsAPI, ChatAPI, EmbeddingsAPI, ImagesAPI, IngestionAPI, AudioAPI, AssistantsAPI, FilesAPI, BatchAPI}
+
+	caps := Capabilities{APIs: make([]APISupport, 0, len(apis))}
+	for _, api := range apis {
+		caps.APIs = append(caps.APIs, APISupport{API: api, APIVersion: c.apiVersion(api)})
+	}
+	for _, f := range features {
+		fs := FeatureSupport{Feature: f.feature, Supported: f.api != "", API: f.api}
+		switch {
+		case f.feature == ChatDataSources:
+			// Data sources use the extensions endpoint, which has its own version.
+			fs.APIVersion = c.restFor(ChatAPI).APIVersions().Extensions
+		case f.api != "":
+			fs.APIVersion = c.apiVersion(f.api)
+		}
+		caps.Features = append(caps.Features, fs)
+	}
+	return caps
+}
+
+// apiVersion returns the API version the Client uses for api.
+func (c *Client) apiVersion(api API) string {
+	v := c.restFor(api).APIVersions()
+	switch api {
+	case ImagesAPI:
+		return v.Images
+	case IngestionAPI:
+		return v.Ingestion
+	case AudioAPI:
+		return v.Audio
+	case AssistantsAPI:
+		return v.Assistants
+	case FilesAPI, BatchAPI:
+		return v.Files
+	}
+	return v.Default
+}
@@ -0,0 +1,276 @@
+/*
+Package assistants provides access to the Assistants API. Assistants are configured once with
+a deployment, instructions and tools, then run on threads of messages. The service keeps the
+threads, so you don't need to send the whole conversation on each call.
+
+The simplest way to create a Client is by using the azopenai.Client.Assistants() method.
+
+Creating an assistant and running it on a thread:
+
+	asstClient := client.Assistants()
+	asst, err := asstClient.Create(ctx, assistants.AssistantReq{
+		Model:        "gpt-4o",
+		Name:         "Weather bot",
+		Instructions: "You answer questions about the weather.",
+		Tools: []assistants.Tool{
+			{
+				Type: assistants.Function,
+				Function: &assistants.FunctionDef{
+					Name:       "get_weather",
+					Parameters: json.RawMessage(`{"type": "object", "properties": {"city": {"type": "string"}}}`),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	thread, err := asstClient.CreateThread(ctx, assistants.ThreadReq{
+		Messages: []assistants.MessageReq{{Role: assistants.UserRole, Content: "Is it raining in Seattle?"}},
+	})
+	if err != nil {
+		return err
+	}
+
+Run() waits until the run ends or needs the outputs of function calls. Answer the calls with
+SubmitToolOutputs(), which also waits, until the run ends:
+
+	run, err := asstClient.Run(ctx, thread.ID, assistants.RunReq{AssistantID: asst.ID})
+	for err == nil && run.Status == assistants.RequiresAction {
+		var outputs []assistants.ToolOutput
+		for _, call := range run.RequiredAction.SubmitToolOutputs.ToolCalls {
+			outputs = append(outputs, assistants.ToolOutput{ToolCallID: call.ID, Output: getWeather(call.Function.Arguments)})
+		}
+		run, err = asstClient.SubmitToolOutputs(ctx, run, outputs)
+	}
+	if err != nil {
+		return err
+	}
+	if run.Status != assistants.Completed {
+		return fmt.Errorf("run ended with status %s", run.Status)
+	}
+
+	msgs, err := asstClient.Messages(ctx, thread.ID, assistants.ListOptions{Limit: 1})
+	if err != nil {
+		return err
+	}
+	fmt.Println(msgs.Data[0].Text())
+*/
+package assistants
+
+import (
+	"context"
+
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/assistants"
+)
+
+// AssistantReq creates or updates an assistant.
+type AssistantReq = assistants.AssistantReq
+
+// Assistant is an assistant.
+type Assistant = assistants.Assistant
+
+// Tool is a tool an assistant can use.
+type Tool = assistants.Tool
+
+// FunctionDef describes a function the assistant can call.
+type FunctionDef = assistants.FunctionDef
+
+// ToolType is the type of a Tool.
+type ToolType = assistants.ToolType
+
+const (
+	// CodeInterpreter lets the assistant write and run code.
+	CodeInterpreter = assistants.CodeInterpreter
+	// FileSearch lets the assistant search files attached to it or the thread.
+	FileSearch = assistants.FileSearch
+	// Function lets the assistant call a function you run.
+	Function = assistants.Function
+)
+
+// ThreadReq creates a thread.
+type ThreadReq = assistants.ThreadReq
+
+// Thread is a conversation between a user and assistants.
+type Thread = assistants.Thread
+
+// MessageReq adds a message to a thread.
+type MessageReq = assistants.MessageReq
+
+// Message is a message in a thread.
+type Message = assistants.Message
+
+// Role is the role of the author of a Message.
+type Role = assistants.Role
+
+const (
+	// UserRole is a message from the user.
+	UserRole = assistants.UserRole
+	// AssistantRole is a message from the assistant.
+	AssistantRole = assistants.AssistantRole
+)
+
+// RunReq starts a run of an assistant on a thread.
+type RunReq = assistants.RunReq
+
+// Run is a run of an assistant on a thread.
+type Run = assistants.Run
+
+// RunStep is a step of a run.
+type RunStep = assistants.RunStep
+
+// RunError is the error of a failed run.
+type RunError = assistants.RunError
+
+// ToolOutput is the output of a tool call.
+type ToolOutput = assistants.ToolOutput
+
+// RunStatus is the status of a Run.
+type RunStatus = assistants.RunStatus
+
+const (
+	// Queued indicates the run has not started.
+	Queued = assistants.Queued
+	// InProgress indicates the run is running.
+	InProgress = assistants.InProgress
+	// RequiresAction indicates the run is waiting for tool outputs.
+	RequiresAction = assistants.RequiresAction
+	// Cancelling indicates the run is being cancelled.
+	Cancelling = assistants.Cancelling
+	// Cancelled indicates the run was cancelled.
+	Cancelled = assistants.Cancelled
+	// Failed indicates the run failed, see Run.LastError.
+	Failed = assistants.Failed
+	// Completed indicates the run finished.
+	Completed = assistants.Completed
+	// Incomplete indicates the run ended early, such as when it reached a token limit.
+	Incomplete = assistants.Incomplete
+	// Expired indicates the run was not finished in time.
+	Expired = assistants.Expired
+)
+
+// ListOptions are options to list items.
+type ListOptions = assistants.ListOptions
+
+// Order is the order of a list.
+type Order = assistants.Order
+
+const (
+	// Asc lists the oldest items first.
+	Asc = assistants.Asc
+	// Desc lists the newest items first.
+	Desc = assistants.Desc
+)
+
+// Deleted is the response to deleting an item.
+type Deleted = assistants.Deleted
+
+// Client provides access to the Assistants API.
+type Client struct {
+	rest *rest.Client
+}
+
+// New creates a new instance of the Client type from the rest.Client. This is generally
+// not used directly, but is used by the azopenai.Client.
+func New(rest *rest.Client) *Client {
+	return &Client{rest: rest}
+}
+
+// Create creates an assistant. req.Model is required.
+func (c *Client) Create(ctx context.Context, req AssistantReq) (Assistant, error) {
+	return c.rest.AssistantCreate(ctx, req)
+}
+
+// Get returns the assistant with id.
+func (c *Client) Get(ctx context.Context, id string) (Assistant, error) {
+	return c.rest.Assistant(ctx, id)
+}
+
+// Update updates the assistant with id. Only the fields set in req are changed.
+func (c *Client) Update(ctx context.Context, id string, req AssistantReq) (Assistant, error) {
+	return c.rest.AssistantUpdate(ctx, id, req)
+}
+
+// Delete deletes the assistant with id.
+func (c *Client) Delete(ctx context.Context, id string) (Deleted, error) {
+	return c.rest.AssistantDelete(ctx, id)
+}
+
+// List lists a page of assistants.
+func (c *Client) List(ctx context.Context, opts ListOptions) (assistants.List[Assistant], error) {
+	return c.rest.Assistants(ctx, opts)
+}
+
+// CreateThread creates a thread.
+func (c *Client) CreateThread(ctx context.Context, req ThreadReq) (Thread, error) {
+	return c.rest.ThreadCreate(ctx, req)
+}
+
+// Thread returns the thread with id.
+func (c *Client) Thread(ctx context.Context, id string) (Thread, error) {
+	return c.rest.Thread(ctx, id)
+}
+
+// DeleteThread deletes the thread with id.
+func (c *Client) DeleteThread(ctx context.Context, id string) (Deleted, error) {
+	return c.rest.ThreadDelete(ctx, id)
+}
+
+// AddMessage adds a message to the thread with threadID.
+func (c *Client) AddMessage(ctx context.Context, threadID string, req MessageReq) (Message, error) {
+	return c.rest.MessageCreate(ctx, threadID, req)
+}
+
+// Messages lists a page of the messages of the thread with threadID, newest first unless
+// opts.Order is Asc.
+func (c *Client) Messages(ctx context.Context, threadID string, opts ListOptions) (assistants.List[Message], error) {
+	return c.rest.Messages(ctx, threadID, opts)
+}
+
+// Run starts a run on the thread with threadID and waits until it ends, it requires tool
+// outputs or ctx is cancelled. A run that ends without completing is returned without an error,
+// check Run.Status. Use Start() to not wait.
+func (c *Client) Run(ctx context.Context, threadID string, req RunReq) (Run, error) {
+	run, err := c.rest.RunCreate(ctx, threadID, req)
+	if err != nil {
+		return Run{}, err
+	}
+	return c.rest.RunWait(ctx, run)
+}
+
+// Start starts a run on the thread with threadID without waiting for it. Use Wait() to wait.
+func (c *Client) Start(ctx context.Context, threadID string, req RunReq) (Run, error) {
+	return c.rest.RunCreate(ctx, threadID, req)
+}
+
+// Wait waits until run ends, requires tool outputs or ctx is cancelled.
+func (c *Client) Wait(ctx context.Context, run Run) (Run, error) {
+	return c.rest.RunWait(ctx, run)
+}
+
+// GetRun returns the current state of the run with runID.
+func (c *Client) GetRun(ctx context.Context, threadID, runID string) (Run, error) {
+	return c.rest.Run(ctx, threadID, runID)
+}
+
+// SubmitToolOutputs submits the outputs of the tool calls in run.RequiredAction and waits until
+// the run ends, requires more tool outputs or ctx is cancelled.
+func (c *Client) SubmitToolOutputs(ctx context.Context, run Run, outputs []ToolOutput) (Run, error) {
+	run, err := c.rest.RunSubmitToolOutputs(ctx, run.ThreadID, run.ID, assistants.ToolOutputsReq{ToolOutputs: outputs})
+	if err != nil {
+		return Run{}, err
+	}
+	return c.rest.RunWait(ctx, run)
+}
+
+// Cancel cancels the run with runID.
+func (c *Client) Cancel(ctx context.Context, threadID, runID string) (Run, error) {
+	return c.rest.RunCancel(ctx, threadID, runID)
+}
+
+// Steps lists a page of the steps of the run with runID.
+func (c *Client) Steps(ctx context.Context, threadID, runID string, opts ListOptions) (assistants.List[RunStep], error) {
+	return c.rest.RunSteps(ctx, threadID, runID, opts)
+}
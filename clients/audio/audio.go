@@ -0,0 +1,245 @@
+/*
+Package audio provides access to the Whisper audio APIs. This allows you to transcribe audio
+into text in the language of the audio, or translate audio into English text.
+
+The simplest way to create a Client is by using the azopenai.Client.Audio() method.
+
+Transcribing a file:
+
+	audioClient := client.Audio(deploymentID)
+	resp, err := audioClient.TranscribeFile(context.Background(), "meeting.mp3")
+	if err != nil {
+		return err
+	}
+	fmt.Println(resp.Text)
+
+Translating audio from an io.Reader with timestamps for each segment:
+
+	params := audio.CallParams{}.Defaults()
+	params.Verbose = true
+	resp, err := audioClient.Translate(context.Background(), "call.wav", r, audio.WithCallParams(params))
+	if err != nil {
+		return err
+	}
+	for _, seg := range resp.Segments {
+		fmt.Printf("[%v - %v] %s\n", seg.Start, seg.End, seg.Text)
+	}
+*/
+package audio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/audio"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Client provides access to the Whisper audio APIs.
+type Client struct {
+	deploymentID string
+	rest         *rest.Client
+
+	callParams atomic.Pointer[CallParams]
+}
+
+// New creates a new instance of the Client type from the rest.Client. This is generally
+// not used directly, but is used by the azopenai.Client.
+func New(deploymentID string, rest *rest.Client) *Client {
+	return &Client{deploymentID: deploymentID, rest: rest}
+}
+
+var defaults = CallParams{}
+
+// CallParams are the parameters used on each call to the audio service. These
+// are all optional fields. You can set this on the client and override it on a per-call
+// basis.
+type CallParams struct {
+	// Language is the ISO-639-1 language of the audio, such as "en". Setting this improves accuracy
+	// and latency. This is only used for transcriptions, translations are always to English.
+	Language string
+	// Prompt is optional text to guide the style of the text or continue a previous segment.
+	// It should be in the same language as the audio.
+	Prompt string
+	// Temperature is the sampling temperature between 0 and 1. 0 lets the service raise the
+	// temperature until certain thresholds are hit.
+	Temperature float64
+	// Verbose requests the language, duration and segments with timestamps, in addition to the text.
+	Verbose bool
+}
+
+// Defaults returns a CallParams with default values set. This should be called before
+// setting any values as it will override any values that are set.
+func (c CallParams) Defaults() CallParams {
+	return defaults
+}
+
+func (c CallParams) toAudioRequest(name string, r io.Reader) audio.Req {
+	req := audio.Req{
+		File:        r,
+		FileName:    name,
+		Language:    c.Language,
+		Prompt:      c.Prompt,
+		Temperature: c.Temperature,
+	}
+	if c.Verbose {
+		req.ResponseFormat = audio.VerboseJSON
+	}
+	return req
+}
+
+// SetParams sets the CallParams for the client. This will be used for all calls unless
+// overridden by a CallOption.
+func (c *Client) SetParams(params CallParams) {
+	c.callParams.Store(&params)
+}
+
+// Segment is a segment of the text with timestamps.
+type Segment struct {
+	// Start is the offset of the start of the segment in the audio.
+	Start time.Duration
+	// End is the offset of the end of the segment in the audio.
+	End time.Duration
+	// Text is the text of the segment.
+	Text string
+}
+
+// Text is the text of the audio.
+type Text struct {
+	// Text is the text of the audio.
+	Text string
+	// Language is the language of the audio. Only set if CallParams.Verbose was set.
+	Language string
+	// Duration is the duration of the audio. Only set if CallParams.Verbose was set.
+	Duration time.Duration
+	// Segments are the segments of the text with timestamps. Only set if CallParams.Verbose was set.
+	Segments []Segment
+
+	// Meta is metadata taken from the response headers.
+	Meta custom.ResponseMeta
+
+	// RestReq is the raw request sent to the REST API. This is only provided if a specific
+	// CallOption is used. The File is not included.
+	RestReq audio.Req
+	// RestResp is the raw response from the REST API. This is only provided if a specific
+	// CallOption is used.
+	RestResp audio.Resp
+}
+
+type callOptions struct {
+	CallParams    CallParams
+	setCallParams bool
+
+	RestReq  bool
+	RestResp bool
+}
+
+// CallOption is an optional argument for the Transcribe and Translate methods.
+type CallOption func(options *callOptions) error
+
+// WithCallParams sets the CallParams for the call. If not set, the call params set for
+// the client will be used. If those weren't set, the default call options are used.
+func WithCallParams(params CallParams) CallOption {
+	return func(o *callOptions) error {
+		o.CallParams = params
+		o.setCallParams = true
+		return nil
+	}
+}
+
+// WithRest sets whether to return the raw REST request and response. This is useful for
+// debugging purposes.
+func WithRest(req, resp bool) CallOption {
+	return func(o *callOptions) error {
+		o.RestReq = req
+		o.RestResp = resp
+		return nil
+	}
+}
+
+// Transcribe transcribes the audio read from r into text in the language of the audio. name is
+// the file name of the audio, the service uses the extension to detect the format.
+func (c *Client) Transcribe(ctx context.Context, name string, r io.Reader, options ...CallOption) (Text, error) {
+	return c.call(ctx, c.rest.Transcribe, name, r, options)
+}
+
+// TranscribeFile transcribes the audio file at path into text in the language of the audio.
+func (c *Client) TranscribeFile(ctx context.Context, path string, options ...CallOption) (Text, error) {
+	return c.callFile(ctx, c.rest.Transcribe, path, options)
+}
+
+// Translate translates the audio read from r into English text. name is the file name of the
+// audio, the service uses the extension to detect the format.
+func (c *Client) Translate(ctx context.Context, name string, r io.Reader, options ...CallOption) (Text, error) {
+	return c.call(ctx, c.rest.Translate, name, r, options)
+}
+
+// TranslateFile translates the audio file at path into English text.
+func (c *Client) TranslateFile(ctx context.Context, path string, options ...CallOption) (Text, error) {
+	return c.callFile(ctx, c.rest.Translate, path, options)
+}
+
+type restCall func(ctx context.Context, deploymentID string, req audio.Req) (audio.Resp, error)
+
+func (c *Client) callFile(ctx context.Context, send restCall, path string, options []CallOption) (Text, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Text{}, fmt.Errorf("problem opening audio file: %w", err)
+	}
+	defer f.Close()
+
+	return c.call(ctx, send, filepath.Base(path), f, options)
+}
+
+func (c *Client) call(ctx context.Context, send restCall, name string, r io.Reader, options []CallOption) (Text, error) {
+	callOptions := callOptions{}
+	for _, o := range options {
+		if err := o(&callOptions); err != nil {
+			return Text{}, err
+		}
+	}
+	if !callOptions.setCallParams {
+		callOptions.CallParams = defaults
+		p := c.callParams.Load()
+		if p != nil {
+			callOptions.CallParams = *p
+		}
+	}
+
+	req := callOptions.CallParams.toAudioRequest(name, r)
+	resp, err := send(ctx, c.deploymentID, req)
+	if err != nil {
+		return Text{}, err
+	}
+
+	text := Text{
+		Text:     resp.Text,
+		Language: resp.Language,
+		Duration: seconds(resp.Duration),
+		Meta:     resp.Meta,
+	}
+	for _, seg := range resp.Segments {
+		text.Segments = append(
+			text.Segments,
+			Segment{Start: seconds(seg.Start), End: seconds(seg.End), Text: seg.Text},
+		)
+	}
+	if callOptions.RestReq {
+		req.File = nil
+		text.RestReq = req
+	}
+	if callOptions.RestResp {
+		text.RestResp = resp
+	}
+	return text, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,347 @@
+/*
+Package batch provides access to the Batch API. A batch runs a file of chat or embeddings
+requests asynchronously within 24 hours, at a lower cost than sending them one at a time. This
+suits bulk jobs that don't need an answer right away, such as classifying a backlog of documents.
+
+The simplest way to create a Client is by using the azopenai.Client.Batch() method. The
+deployments used must be Global Batch deployments.
+
+Building the input, running the batch and reading the results:
+
+	in := &batch.Input{}
+	for i, doc := range docs {
+		req := chat.Req{Messages: []chat.SendMsg{
+			{Role: chat.System, Content: "Classify the document as bug, feature or question."},
+			{Role: chat.User, Content: doc},
+		}}
+		if err := in.AddChat(strconv.Itoa(i), "gpt-4o-batch", req); err != nil {
+			return err
+		}
+	}
+
+	batchClient := client.Batch()
+	b, err := batchClient.Submit(ctx, in, nil)
+	if err != nil {
+		return err
+	}
+
+	// Batches take minutes to hours. Wait polls until the batch ends, you can also store b.ID
+	// and check on it later with Get().
+	b, err = batchClient.Wait(ctx, b)
+	if err != nil {
+		return err
+	}
+	if b.Status != batch.Completed {
+		return fmt.Errorf("batch ended with status %s", b.Status)
+	}
+
+	results, err := batchClient.Results(ctx, b)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			log.Printf("document %s: %s", r.CustomID, r.Err)
+			continue
+		}
+		fmt.Println(r.CustomID, r.Chat.Choices[0].Message.Content)
+	}
+*/
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/batch"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+	"github.com/element-of-surprise/azopenai/rest/messages/files"
+)
+
+// Batch is a batch job.
+type Batch = batch.Batch
+
+// ListOptions are options to list batches.
+type ListOptions = batch.ListOptions
+
+// List is a page of batches.
+type List = batch.List
+
+// Endpoint is the API the requests of a batch are sent to.
+type Endpoint = batch.Endpoint
+
+const (
+	// ChatEndpoint sends the requests to the chat API.
+	ChatEndpoint = batch.ChatEndpoint
+	// EmbeddingsEndpoint sends the requests to the embeddings API.
+	EmbeddingsEndpoint = batch.EmbeddingsEndpoint
+)
+
+// Status is the status of a Batch.
+type Status = batch.Status
+
+const (
+	// Validating indicates the input file is being validated.
+	Validating = batch.Validating
+	// Failed indicates the input file failed validation, see Batch.Errors.
+	Failed = batch.Failed
+	// InProgress indicates the requests are running.
+	InProgress = batch.InProgress
+	// Finalizing indicates the requests are done and the output files are being written.
+	Finalizing = batch.Finalizing
+	// Completed indicates the batch finished and its output files are ready.
+	Completed = batch.Completed
+	// Expired indicates the batch was not finished in time. Results() returns the requests
+	// that were finished.
+	Expired = batch.Expired
+	// Cancelling indicates the batch is being cancelled.
+	Cancelling = batch.Cancelling
+	// Cancelled indicates the batch was cancelled.
+	Cancelled = batch.Cancelled
+)
+
+// Input is the JSONL input of a batch. All the requests must be for the same Endpoint.
+// The zero value is ready to use.
+type Input struct {
+	endpoint Endpoint
+	buf      bytes.Buffer
+	ids      map[string]bool
+}
+
+// AddChat adds a chat request with customID, which identifies it in the results, to be sent to
+// deploymentID.
+func (in *Input) AddChat(customID, deploymentID string, req chat.Req) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request %q: %w", customID, err)
+	}
+	return in.add(ChatEndpoint, customID, deploymentID, req)
+}
+
+// AddEmbeddings adds an embeddings request with customID, which identifies it in the results,
+// to be sent to deploymentID.
+func (in *Input) AddEmbeddings(customID, deploymentID string, req embeddings.Req) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request %q: %w", customID, err)
+	}
+	return in.add(EmbeddingsEndpoint, customID, deploymentID, req)
+}
+
+func (in *Input) add(endpoint Endpoint, customID, deploymentID string, req any) error {
+	switch {
+	case customID == "":
+		return fmt.Errorf("customID cannot be empty")
+	case deploymentID == "":
+		return fmt.Errorf("deploymentID cannot be empty")
+	case in.ids[customID]:
+		return fmt.Errorf("customID %q was already added", customID)
+	case in.endpoint != "" && in.endpoint != endpoint:
+		return fmt.Errorf("a batch cannot mix %s and %s requests", in.endpoint, endpoint)
+	}
+
+	body, err := withModel(req, deploymentID)
+	if err != nil {
+		return fmt.Errorf("request %q: %w", customID, err)
+	}
+	line, err := json.Marshal(batch.InputLine{CustomID: customID, Method: "POST", URL: endpoint, Body: body})
+	if err != nil {
+		return fmt.Errorf("request %q: %w", customID, err)
+	}
+
+	if in.ids == nil {
+		in.ids = map[string]bool{}
+	}
+	in.ids[customID] = true
+	in.endpoint = endpoint
+	in.buf.Write(line)
+	in.buf.WriteByte('\n')
+	return nil
+}
+
+// withModel returns req as JSON with its model set to deploymentID, which is how the service
+// knows which deployment to send a line to.
+func withModel(req any, deploymentID string) (json.RawMessage, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	m["model"], err = json.Marshal(deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// Len returns the number of requests.
+func (in *Input) Len() int {
+	return len(in.ids)
+}
+
+// Endpoint returns the Endpoint of the requests, or "" if there are none.
+func (in *Input) Endpoint() Endpoint {
+	return in.endpoint
+}
+
+// Reader returns a reader of the JSONL input.
+func (in *Input) Reader() io.Reader {
+	return bytes.NewReader(in.buf.Bytes())
+}
+
+// Result is the result of a request of a batch.
+type Result struct {
+	// CustomID is the customID the request was added with.
+	CustomID string
+	// StatusCode is the HTTP status code of the response, 0 if the request was not sent.
+	StatusCode int
+	// RequestID is the ID of the request.
+	RequestID string
+	// Chat is the response to a chat request.
+	Chat *chat.Resp
+	// Embeddings is the response to an embeddings request.
+	Embeddings *embeddings.Resp
+	// Err is the error if the request failed.
+	Err error
+}
+
+// Parse reads the lines of an output or error file in r and calls fn with the Result of each.
+// Results() uses this, but it is useful to stream a large file without holding every Result.
+// If fn returns an error, Parse stops and returns it.
+func Parse(r io.Reader, endpoint Endpoint, fn func(Result) error) error {
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var line batch.OutputLine
+		if err := dec.Decode(&line); err != nil {
+			return fmt.Errorf("problem decoding batch output: %w", err)
+		}
+		if err := fn(parseLine(line, endpoint)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseLine(line batch.OutputLine, endpoint Endpoint) Result {
+	res := Result{CustomID: line.CustomID}
+	if line.Error != nil {
+		res.Err = fmt.Errorf("%s: %s", line.Error.Code, line.Error.Message)
+		return res
+	}
+	if line.Response == nil {
+		res.Err = fmt.Errorf("line has no response or error")
+		return res
+	}
+
+	resp := line.Response
+	res.StatusCode = resp.StatusCode
+	res.RequestID = resp.RequestID
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		m := map[string]any{}
+		if err := json.Unmarshal(resp.Body, &m); err != nil {
+			res.Err = errors.StatusCode{Message: string(resp.Body), StatusCode: resp.StatusCode}
+			return res
+		}
+		res.Err = errors.JSON{JSON: m, Message: string(resp.Body), StatusCode: resp.StatusCode}
+		return res
+	}
+
+	var err error
+	switch endpoint {
+	case ChatEndpoint:
+		res.Chat = &chat.Resp{}
+		err = json.Unmarshal(resp.Body, res.Chat)
+	case EmbeddingsEndpoint:
+		res.Embeddings = &embeddings.Resp{}
+		err = json.Unmarshal(resp.Body, res.Embeddings)
+	default:
+		err = fmt.Errorf("endpoint %q is not supported", endpoint)
+	}
+	if err != nil {
+		res.Err = fmt.Errorf("problem unmarshaling the response body: %w", err)
+	}
+	return res
+}
+
+// Client provides access to the Batch API.
+type Client struct {
+	rest *rest.Client
+}
+
+// New creates a new instance of the Client type from the rest.Client. This is generally
+// not used directly, but is used by the azopenai.Client.
+func New(rest *rest.Client) *Client {
+	return &Client{rest: rest}
+}
+
+// Submit uploads in and creates a batch from it. metadata is optional and is stored with the
+// batch. The uploaded input file is kept, delete it with the files Client when it is no longer
+// needed.
+func (c *Client) Submit(ctx context.Context, in *Input, metadata map[string]string) (Batch, error) {
+	if in == nil || in.Len() == 0 {
+		return Batch{}, fmt.Errorf("Input cannot be empty")
+	}
+
+	f, err := c.rest.FileUpload(ctx, files.UploadReq{File: in.Reader(), FileName: "batch.jsonl", Purpose: files.Batch})
+	if err != nil {
+		return Batch{}, fmt.Errorf("problem uploading the batch input: %w", err)
+	}
+	return c.rest.BatchCreate(ctx, batch.CreateReq{InputFileID: f.ID, Endpoint: in.Endpoint(), Metadata: metadata})
+}
+
+// Get returns the batch with id.
+func (c *Client) Get(ctx context.Context, id string) (Batch, error) {
+	return c.rest.Batch(ctx, id)
+}
+
+// Cancel cancels the batch with id.
+func (c *Client) Cancel(ctx context.Context, id string) (Batch, error) {
+	return c.rest.BatchCancel(ctx, id)
+}
+
+// List lists a page of batches, newest first.
+func (c *Client) List(ctx context.Context, opts ListOptions) (List, error) {
+	return c.rest.Batches(ctx, opts)
+}
+
+// Wait waits until b ends or ctx is cancelled. A batch that ends without completing is
+// returned without an error, check Batch.Status.
+func (c *Client) Wait(ctx context.Context, b Batch) (Batch, error) {
+	return c.rest.BatchWait(ctx, b)
+}
+
+// Results downloads the output and error files of b and returns the Result of each request
+// in them, successful requests first. Use Parse() with the files Client to stream large files.
+func (c *Client) Results(ctx context.Context, b Batch) ([]Result, error) {
+	var results []Result
+	add := func(r Result) error {
+		results = append(results, r)
+		return nil
+	}
+
+	for _, id := range []string{b.OutputFileID, b.ErrorFileID} {
+		if id == "" {
+			continue
+		}
+		if err := c.parseFile(ctx, id, b.Endpoint, add); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (c *Client) parseFile(ctx context.Context, id string, endpoint Endpoint, fn func(Result) error) error {
+	r, err := c.rest.FileContent(ctx, id)
+	if err != nil {
+		return fmt.Errorf("problem downloading batch file %s: %w", id, err)
+	}
+	defer r.Close()
+	return Parse(r, endpoint, fn)
+}
@@ -0,0 +1,166 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/batch"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func chatReq(content string) chat.Req {
+	return chat.Req{Messages: []chat.SendMsg{{Role: chat.User, Content: content}}}
+}
+
+func TestInput(t *testing.T) {
+	in := &Input{}
+	if err := in.AddChat("1", "gpt-4o-batch", chatReq("hello")); err != nil {
+		t.Fatalf("TestInput: got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		desc string
+		add  func() error
+	}{
+		{desc: "duplicate customID", add: func() error { return in.AddChat("1", "gpt-4o-batch", chatReq("hi")) }},
+		{desc: "empty customID", add: func() error { return in.AddChat("", "gpt-4o-batch", chatReq("hi")) }},
+		{desc: "empty deploymentID", add: func() error { return in.AddChat("2", "", chatReq("hi")) }},
+		{desc: "invalid request", add: func() error { return in.AddChat("2", "gpt-4o-batch", chat.Req{}) }},
+		{
+			desc: "mixed endpoints",
+			add:  func() error { return in.AddEmbeddings("2", "ada", embeddings.Req{Input: []string{"hi"}}) },
+		},
+	}
+	for _, test := range tests {
+		if err := test.add(); err == nil {
+			t.Errorf("TestInput(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+
+	if in.Len() != 1 || in.Endpoint() != ChatEndpoint {
+		t.Fatalf("TestInput: got Len() %d and Endpoint() %q, want 1 and %q", in.Len(), in.Endpoint(), ChatEndpoint)
+	}
+	b, err := io.ReadAll(in.Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line batch.InputLine
+	if err := json.Unmarshal(b, &line); err != nil {
+		t.Fatalf("TestInput: input is not a JSON line: %s", err)
+	}
+	if line.CustomID != "1" || line.Method != "POST" || line.URL != ChatEndpoint {
+		t.Errorf("TestInput: got line %+v, want custom_id 1, POST to %s", line, ChatEndpoint)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(line.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["model"] != "gpt-4o-batch" {
+		t.Errorf("TestInput: got model %v, want gpt-4o-batch", body["model"])
+	}
+}
+
+func TestSubmitAndResults(t *testing.T) {
+	const (
+		output = `{"id": "r1", "custom_id": "1", "response": {"status_code": 200, "request_id": "req1", "body": {"id": "chatcmpl-1", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "bug"}}]}}, "error": null}
+`
+		errs = `{"id": "r2", "custom_id": "2", "response": {"status_code": 400, "request_id": "req2", "body": {"error": {"code": "invalid_request"}}}, "error": null}
+{"id": "r3", "custom_id": "3", "response": null, "error": {"code": "timeout", "message": "request timed out"}}
+`
+	)
+
+	var gotCreate batch.CreateReq
+	var gotUpload string
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req}
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/openai/files":
+			b, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			gotUpload = string(b)
+			resp.Body = io.NopCloser(strings.NewReader(`{"id": "file-in", "purpose": "batch", "status": "processed"}`))
+		case req.Method == http.MethodPost && req.URL.Path == "/openai/batches":
+			if err := json.NewDecoder(req.Body).Decode(&gotCreate); err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(strings.NewReader(`{"id": "batch-1", "endpoint": "/chat/completions", "status": "validating", "input_file_id": "file-in"}`))
+		case req.URL.Path == "/openai/files/file-out/content":
+			resp.Body = io.NopCloser(strings.NewReader(output))
+		case req.URL.Path == "/openai/files/file-err/content":
+			resp.Body = io.NopCloser(strings.NewReader(errs))
+		default:
+			resp.StatusCode = http.StatusNotFound
+			resp.Body = io.NopCloser(strings.NewReader(`{"error": {"code": "404"}}`))
+		}
+		return resp, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(rc)
+
+	in := &Input{}
+	for _, id := range []string{"1", "2", "3"} {
+		if err := in.AddChat(id, "gpt-4o-batch", chatReq("doc "+id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := c.Submit(context.Background(), in, map[string]string{"job": "triage"})
+	if err != nil {
+		t.Fatalf("TestSubmitAndResults: got err == %s, want err == nil", err)
+	}
+	if b.ID != "batch-1" || b.Status != Validating {
+		t.Errorf("TestSubmitAndResults: got %+v, want a validating batch-1", b)
+	}
+	if gotCreate.InputFileID != "file-in" || gotCreate.Endpoint != ChatEndpoint || gotCreate.CompletionWindow != "24h" || gotCreate.Metadata["job"] != "triage" {
+		t.Errorf("TestSubmitAndResults: got create request %+v, want file-in for %s in 24h with metadata", gotCreate, ChatEndpoint)
+	}
+	if !strings.Contains(gotUpload, `name="purpose"`) || strings.Count(gotUpload, `"custom_id"`) != 3 {
+		t.Errorf("TestSubmitAndResults: got upload %q, want the batch purpose and 3 lines", gotUpload)
+	}
+
+	b.Status = Completed
+	b.OutputFileID = "file-out"
+	b.ErrorFileID = "file-err"
+	results, err := c.Results(context.Background(), b)
+	if err != nil {
+		t.Fatalf("TestSubmitAndResults(Results): got err == %s, want err == nil", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("TestSubmitAndResults(Results): got %d results, want 3", len(results))
+	}
+
+	if r := results[0]; r.CustomID != "1" || r.Err != nil || r.Chat == nil || r.Chat.Choices[0].Message.Content != "bug" {
+		t.Errorf("TestSubmitAndResults(Results): got %+v, want a chat response for 1", r)
+	}
+	var jsonErr errors.JSON
+	if r := results[1]; r.CustomID != "2" || r.StatusCode != http.StatusBadRequest || !errors.As(r.Err, &jsonErr) {
+		t.Errorf("TestSubmitAndResults(Results): got %+v, want a 400 errors.JSON for 2", r)
+	}
+	if r := results[2]; r.CustomID != "3" || r.Err == nil || r.StatusCode != 0 {
+		t.Errorf("TestSubmitAndResults(Results): got %+v, want an error for 3", r)
+	}
+
+	if _, err := c.Submit(context.Background(), &Input{}, nil); err == nil {
+		t.Errorf("TestSubmitAndResults(empty input): got err == nil, want err != nil")
+	}
+}
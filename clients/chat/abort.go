@@ -0,0 +1,102 @@
+package chat
+
+import (
+	"context"
+	"sync"
+)
+
+// Stream is a stream opened with OpenStream() that can be aborted.
+type Stream struct {
+	// C receives the StreamData of the stream, the same as the channel returned by Client.Stream().
+	C <-chan StreamData
+
+	cancel  context.CancelFunc
+	aborted chan struct{}
+	once    sync.Once
+	done    chan struct{}
+
+	// These are only valid after done is closed.
+	result   Chats
+	chunks   int
+	finished bool
+}
+
+// OpenStream is the same as Stream(), but returns a Stream that can be aborted with Abort(),
+// which stops generation and returns the text received so far as a normal result. Abort()
+// must be called or C must be read until it is closed.
+func (c *Client) OpenStream(ctx context.Context, messages []SendMsg, options ...CallOption) *Stream {
+	ctx, cancel := context.WithCancel(ctx)
+
+	out := make(chan StreamData, 1)
+	s := &Stream{
+		C:       out,
+		cancel:  cancel,
+		aborted: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	in := c.Stream(ctx, messages, options...)
+	go func() {
+		defer close(s.done)
+		defer close(out)
+		defer cancel()
+
+		for sd := range in {
+			if sd.Err == nil {
+				s.add(sd)
+			}
+			select {
+			case <-s.aborted:
+				// After an abort the stream ends with the cancellation error, nothing more is sent.
+				continue
+			default:
+			}
+			select {
+			case out <- sd:
+			case <-s.aborted:
+			}
+		}
+		select {
+		case <-s.aborted:
+		default:
+			s.finished = true
+		}
+	}()
+	return s
+}
+
+// add merges sd into the result.
+func (s *Stream) add(sd StreamData) {
+	if sd.Data.Text == nil && sd.Data.FinishReasons == nil {
+		// A raw event the SDK does not decode.
+		return
+	}
+	mergeChats(&s.result, sd.Data)
+	s.result.RestReq = sd.Data.RestReq
+	s.chunks++
+}
+
+// Abort stops generation and returns the full text of each choice received so far, including
+// StreamData that was not read from C. Chats.Partial is set if generation was stopped before
+// it finished. The service does not send usage when streaming, so Chats.Usage is not set, see
+// Chunks(). C is closed after Abort() returns. Calling Abort() more than once returns the same
+// result.
+func (s *Stream) Abort() Chats {
+	s.once.Do(func() {
+		close(s.aborted)
+		s.cancel()
+	})
+	<-s.done
+
+	result := s.result
+	result.Partial = !s.finished
+	return result
+}
+
+// Chunks returns the number of messages received from the service. The service often sends one
+// token per message, but does not promise to, so this is a measure of progress and not a token
+// count. This blocks until the stream has ended, so call it after Abort() or once C is closed.
+func (s *Stream) Chunks() int {
+	<-s.done
+	return s.chunks
+}
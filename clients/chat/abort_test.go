@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+func TestStreamAbort(t *testing.T) {
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		r, w := io.Pipe()
+		go func() {
+			io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+			io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\" world\"}}]}\n\n")
+			// The model is still generating when the stream is aborted.
+			<-req.Context().Done()
+			w.CloseWithError(req.Context().Err())
+		}()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       r,
+			Request:    req,
+		}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	stream := c.OpenStream(context.Background(), []SendMsg{{Role: User, Content: "hi"}})
+	for i := 0; i < 2; i++ {
+		if sd := <-stream.C; sd.Err != nil {
+			t.Fatalf("TestStreamAbort: got err == %s, want err == nil", sd.Err)
+		}
+	}
+
+	chats := stream.Abort()
+	if !chats.Partial {
+		t.Errorf("TestStreamAbort: got Partial == false, want true")
+	}
+	if len(chats.Text) != 1 || chats.Text[0] != "Hello world" {
+		t.Errorf("TestStreamAbort: got text %q, want %q", chats.Text, "Hello world")
+	}
+	if chats.Usage != (Usage{}) {
+		t.Errorf("TestStreamAbort: got Usage %+v, want it unset", chats.Usage)
+	}
+	if got := stream.Chunks(); got != 2 {
+		t.Errorf("TestStreamAbort: got %d chunks, want 2", got)
+	}
+	for range stream.C {
+	}
+}
@@ -0,0 +1,141 @@
+package chat
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+// Voice is the voice the model uses for audio output, such as "alloy".
+type Voice = chat.Voice
+
+// AudioFormat is the encoding of audio.
+type AudioFormat = chat.AudioFormat
+
+const (
+	// WAV is audio in the wav format. This can be used for input and output.
+	WAV = chat.WAV
+	// MP3 is audio in the mp3 format. This can be used for input and output.
+	MP3 = chat.MP3
+	// FLAC is audio in the flac format. This is only used for output.
+	FLAC = chat.FLAC
+	// Opus is audio in the opus format. This is only used for output.
+	Opus = chat.Opus
+	// PCM16 is raw 16-bit PCM audio at 24kHz. This is only used for output and is the
+	// only format supported by Stream().
+	PCM16 = chat.PCM16
+)
+
+// maxAudioSize is the largest audio we will read.
+const maxAudioSize = 25 << 20
+
+// Audio is audio generated by the model.
+type Audio struct {
+	// ID is the ID of the audio, which can be used to refer to it in later messages.
+	ID string
+	// Data is the audio in the format set with WithAudioOutput(). When streaming, this only holds
+	// the audio received since the last StreamData.
+	Data []byte
+	// Transcript is the transcript of the audio. When streaming, this only holds the transcript
+	// received since the last StreamData.
+	Transcript string
+	// ExpiresAt is when the audio can no longer be referred to by ID.
+	ExpiresAt time.Time
+}
+
+// WithAudioOutput has the model generate audio in addition to text, spoken with voice and encoded
+// in format. The audio and its transcript are returned in Chats.Audio, and Chats.Text is usually
+// empty. This requires a deployment of an audio model, such as gpt-4o-audio-preview.
+func WithAudioOutput(voice Voice, format AudioFormat) CallOption {
+	return func(o *callOptions) error {
+		if strings.TrimSpace(string(voice)) == "" {
+			return fmt.Errorf("WithAudioOutput: voice cannot be empty")
+		}
+		switch format {
+		case WAV, MP3, FLAC, Opus, PCM16:
+		default:
+			return fmt.Errorf("WithAudioOutput: unsupported format %q", format)
+		}
+		o.Audio = &chat.AudioParams{Voice: voice, Format: format}
+		return nil
+	}
+}
+
+// AudioPart returns a ContentPart holding audio, which must be in the WAV or MP3 format.
+func AudioPart(audio []byte, format AudioFormat) ContentPart {
+	return ContentPart{
+		Type:       chat.InputAudioPart,
+		InputAudio: &chat.InputAudio{Data: base64.StdEncoding.EncodeToString(audio), Format: format},
+	}
+}
+
+// AudioFromReader returns a ContentPart holding the audio read from r, which must be in the
+// WAV or MP3 format.
+func AudioFromReader(r io.Reader, format AudioFormat) (ContentPart, error) {
+	switch format {
+	case WAV, MP3:
+	default:
+		return ContentPart{}, fmt.Errorf("unsupported audio input format %q", format)
+	}
+	b, err := io.ReadAll(io.LimitReader(r, maxAudioSize+1))
+	if err != nil {
+		return ContentPart{}, fmt.Errorf("problem reading audio: %w", err)
+	}
+	if len(b) > maxAudioSize {
+		return ContentPart{}, fmt.Errorf("audio is larger than %d bytes", maxAudioSize)
+	}
+	return AudioPart(b, format), nil
+}
+
+// AudioFromFile returns a ContentPart holding the audio in the file at path. The format is taken
+// from the file extension, which must be .wav or .mp3.
+func AudioFromFile(path string) (ContentPart, error) {
+	var format AudioFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		format = WAV
+	case ".mp3":
+		format = MP3
+	default:
+		return ContentPart{}, fmt.Errorf("file %q must have a .wav or .mp3 extension", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ContentPart{}, err
+	}
+	defer f.Close()
+
+	return AudioFromReader(f, format)
+}
+
+// toAudio decodes audio received from the service.
+func toAudio(a *chat.AudioOutput) (Audio, error) {
+	out := Audio{ID: a.ID, Transcript: a.Transcript}
+	if a.ExpiresAt != nil {
+		out.ExpiresAt = a.ExpiresAt.Time
+	}
+	if a.Data != "" {
+		b, err := base64.StdEncoding.DecodeString(a.Data)
+		if err != nil {
+			return Audio{}, fmt.Errorf("problem decoding audio: %w", err)
+		}
+		out.Data = b
+	}
+	return out, nil
+}
+
+// setAudio sets the audio of a choice in audio.
+func setAudio(audio []Audio, index int, a Audio) []Audio {
+	for len(audio) <= index {
+		audio = append(audio, Audio{})
+	}
+	audio[index] = a
+	return audio
+}
@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+func TestAudio(t *testing.T) {
+	var got chat.Req
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body: io.NopCloser(strings.NewReader(`{
+				"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant",
+					"audio": {"id": "audio_1", "data": "UklGRg==", "expires_at": 1700000000, "transcript": "Hello there"}}}]
+			}`)),
+			Request: req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	messages := []SendMsg{
+		{Role: User, Parts: []ContentPart{TextPart("Answer this:"), AudioPart([]byte("RIFF"), WAV)}},
+	}
+	chats, err := c.Call(context.Background(), messages, WithAudioOutput("alloy", WAV))
+	if err != nil {
+		t.Fatalf("TestAudio: got err == %s, want err == nil", err)
+	}
+
+	if len(got.Modalities) != 2 || got.Audio == nil || got.Audio.Voice != "alloy" || got.Audio.Format != WAV {
+		t.Errorf("TestAudio: got modalities %v and audio %+v, want text and audio with alloy/wav", got.Modalities, got.Audio)
+	}
+	if p := got.Messages[0].Parts; len(p) != 2 || p[1].InputAudio == nil || p[1].InputAudio.Data != "UklGRg==" {
+		t.Errorf("TestAudio: got parts %+v, want the input audio base64 encoded", p)
+	}
+	if len(chats.Audio) != 1 {
+		t.Fatalf("TestAudio: got %d audio, want 1", len(chats.Audio))
+	}
+	a := chats.Audio[0]
+	if a.ID != "audio_1" || string(a.Data) != "RIFF" || a.Transcript != "Hello there" || a.ExpiresAt.Unix() != 1700000000 {
+		t.Errorf("TestAudio: got audio %+v, want the decoded audio", a)
+	}
+	if chats.EmptyRetries != 0 {
+		t.Errorf("TestAudio: got %d empty retries, want 0", chats.EmptyRetries)
+	}
+
+	bad := []SendMsg{{Role: System, Parts: []ContentPart{AudioPart([]byte("RIFF"), WAV)}}}
+	if _, err := c.Call(context.Background(), bad); err == nil {
+		t.Errorf("TestAudio(system audio): got err == nil, want err != nil")
+	}
+}
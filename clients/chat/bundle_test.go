@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"testing"
+)
+
+func TestWithBundle(t *testing.T) {
+	if err := RegisterBundle("test-base", WithDeploymentID("base"), WithAPIVersion("2024-02-01")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterBundle("test-nested", WithBundle("test-base"), WithRest(true, true)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc    string
+		options []CallOption
+		want    callOptions
+		wantErr bool
+	}{
+		{
+			desc:    "bundle",
+			options: []CallOption{WithBundle("test-base")},
+			want:    callOptions{DeploymentID: "base", APIVersion: "2024-02-01"},
+		},
+		{
+			desc:    "nested bundle",
+			options: []CallOption{WithBundle("test-nested")},
+			want:    callOptions{DeploymentID: "base", APIVersion: "2024-02-01", RestReq: true, RestResp: true},
+		},
+		{
+			desc:    "later option overrides the bundle",
+			options: []CallOption{WithBundle("test-base"), WithDeploymentID("other")},
+			want:    callOptions{DeploymentID: "other", APIVersion: "2024-02-01"},
+		},
+		{
+			desc:    "unknown bundle",
+			options: []CallOption{WithBundle("test-unknown")},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		got := callOptions{}
+		var err error
+		for _, o := range test.options {
+			if err = o(&got); err != nil {
+				break
+			}
+		}
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestWithBundle(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestWithBundle(%s): got err == %s, want err == nil", test.desc, err)
+		case err == nil && (got.DeploymentID != test.want.DeploymentID || got.APIVersion != test.want.APIVersion ||
+			got.RestReq != test.want.RestReq || got.RestResp != test.want.RestResp):
+			t.Errorf("TestWithBundle(%s): got %+v, want %+v", test.desc, got, test.want)
+		}
+	}
+}
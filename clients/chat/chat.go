@@ -51,15 +51,138 @@ You can also override the parameters on a per-call basis:
 		return err
 	}
 	fmt.Println(resp.Text[0])
+
+You can also stream the response as it is generated:
+
+	messages := []chat.SendMsg{{Role: chat.User, Content: "Tell me a story"}}
+	for data := range chatClient.Stream(context.Background(), messages) {
+		if data.Err != nil {
+			return data.Err
+		}
+		fmt.Print(data.Data.Text[0])
+	}
+
+Collect() reads a stream to the end and returns the full result, the same as Call() would:
+
+	resp, err := chat.Collect(chatClient.Stream(ctx, messages))
+
+A stream that fails before any text arrives can be restarted on other deployments with WithFallback().
+If it fails after text arrived, a *StreamError holds the partial text and can continue it:
+
+	stream := chatClient.Stream(ctx, messages, chat.WithFallback("gpt-35-turbo-westus"))
+	for {
+		var streamErr *chat.StreamError
+		for data := range stream {
+			if errors.As(data.Err, &streamErr) {
+				break
+			}
+			...
+		}
+		if streamErr == nil {
+			break
+		}
+		stream = streamErr.Retry(ctx)
+	}
+
+Newer models, such as the o-series, take instructions in Developer messages instead of System
+messages. If you tell the client which model the deployment runs, either role is translated to
+the one the model supports:
+
+	chatClient.SetModel("o1")
+	messages := []chat.SendMsg{
+		{Role: chat.System, Content: "You are a helpful assistant."}, // Sent as chat.Developer.
+		{Role: chat.User, Content: "Tell me a joke"},
+	}
+
+Models with vision support can be sent images along with text:
+
+	img, err := chat.ImageFromFile("chart.png", chat.AutoDetail)
+	if err != nil {
+		return err
+	}
+	messages := []chat.SendMsg{
+		{
+			Role:  chat.User,
+			Parts: []chat.ContentPart{chat.TextPart("What does this chart show?"), img},
+		},
+	}
+
+You can register system prompts per locale. The prompt for the locale of the call is
+prepended to the messages unless they already start with a system message:
+
+	prompts := chat.NewPrompts("en")
+	prompts.Register("en", "You are a helpful assistant.")
+	prompts.Register("fr", "Vous êtes un assistant serviable.")
+	chatClient.SetPrompts(prompts)
+
+	// This uses the "fr" prompt, as there is no "fr-CA" prompt.
+	ctx := chat.ContextWithLocale(context.Background(), "fr-CA")
+	resp, err := chatClient.Call(ctx, []chat.SendMsg{{Role: chat.User, Content: "Bonjour"}})
+
+Prompts registered with RegisterTemplate() are templates that are rendered on every call, so
+variables such as the date stay current:
+
+	prompts.RegisterTemplate("en", "You are a helpful assistant. Today is {{.Date}}.")
+	prompts.SetVars(func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"Date": time.Now().Format("January 2, 2006")}, nil
+	})
+
+You can ground responses in your own data with Azure OpenAI on your data. The documents used
+for each choice are returned in Citations:
+
+	search := chat.DataSource{
+		Type: chat.AzureCognitiveSearch,
+		Parameters: chat.AzureSearchParams{
+			Endpoint:  "https://mysearch.search.windows.net",
+			Key:       searchKey,
+			IndexName: "docs",
+		},
+	}
+	resp, err := chatClient.Call(ctx, messages, chat.WithDataSources(search))
+	if err != nil {
+		return err
+	}
+	fmt.Println(resp.Text[0])
+	for _, c := range resp.Citations[0] {
+		fmt.Println(c.Title, c.URL)
+	}
+
+You can require JSON output and decode it into a struct. An invalid output returns a *chat.JSONError:
+
+	var weather struct {
+		City  string  `json:"city"`
+		TempC float64 `json:"temp_c"`
+	}
+	messages := []chat.SendMsg{{Role: chat.User, Content: "Return the weather in Paris as JSON with city and temp_c."}}
+	_, err := chatClient.Call(ctx, messages, chat.WithJSONResponse(nil, &weather))
+	if err != nil {
+		return err
+	}
+
+CallStructured does this for any type, sending a schema derived from the type and retrying with a
+corrective message if the output does not parse:
+
+	type Weather struct {
+		City  string  `json:"city"`
+		TempC float64 `json:"temp_c"`
+	}
+	w, _, err := chat.CallStructured[Weather](ctx, chatClient, messages, 2)
+	if err != nil {
+		return err
+	}
 */
 package chat
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/element-of-surprise/azopenai/rest"
 	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
 )
 
 // Client provides access to the Chat API. Chat allows you to generate text in response
@@ -69,6 +192,11 @@ type Client struct {
 	rest         *rest.Client
 
 	CallParams atomic.Pointer[CallParams]
+
+	prompts    atomic.Pointer[Prompts]
+	emptyRetry atomic.Pointer[EmptyRetryPolicy]
+	degrade    atomic.Pointer[degrader]
+	model      atomic.Pointer[string]
 }
 
 // New creates a new instance of the Client type from the rest.Client. This is generally
@@ -90,6 +218,10 @@ var defaults = CallParams{
 // CallParams are the parameters used on each call to the chat service. These
 // are all optional fields. You can set this on the client and override it on a per-call
 // basis.
+//
+// Setting fields directly is deprecated in favor of Builder(), which starts from the defaults and
+// validates the values. Because the client stores a copy, changing a CallParams after passing it
+// to SetParams() has no effect.
 type CallParams struct {
 	// Stop provides up to 4 sequences where the API will stop generating further tokens.
 	Stop []string
@@ -101,6 +233,7 @@ type CallParams struct {
 	// The exact effect will vary per model, but values between -1 and 1 should decrease or increase likelihood of selection;
 	// values like -100 or 100 should result in a ban or exclusive selection of the relevant token.
 	// As an example, you can pass {\"50256\" &#58; -100} to prevent the <|endoftext|> token from being generated.
+	// The logitbias package builds this from words instead of token IDs.
 	LogitBias map[string]float64
 
 	// User is a unique identifier representing your end-user, which can help monitoring and detecting abuse.
@@ -115,6 +248,15 @@ type CallParams struct {
 	// Most models have a context length of 2048 tokens (except for the newest models, which support 4096). Has minimum of 0.
 	MaxTokens int
 
+	// MaxCompletionTokens is the maximum number of tokens to generate, including reasoning tokens.
+	// If set, this is sent in place of MaxTokens. With a model set by SetModel() or WithModel(),
+	// MaxTokens is sent as this for reasoning models, such as the o-series, which reject MaxTokens.
+	MaxCompletionTokens int
+
+	// ReasoningEffort limits the reasoning of reasoning models that support it, such as o1 and o3-mini.
+	// With a model set by SetModel() or WithModel(), this is not sent to models that don't support it.
+	ReasoningEffort ReasoningEffort
+
 	// Temperature is the sampling temperature to use. Higher values means the model will take more risks.
 	// Try 0.9 for more creative applications, and 0 (argmax sampling) for ones with a well-defined answer.
 	// It is generally recommend altering this or TopP but not both.
@@ -133,6 +275,14 @@ type CallParams struct {
 	// FrequencyPenalty is a float64 between -2.0 and 2.0. Positive values penalize new tokens based on their
 	// existing frequency in the text so far, decreasing the model's likelihood to repeat the same line verbatim.
 	FrequencyPenalty float64
+
+	// Store indicates the completion should be stored by the service, so it can be used for evals and
+	// distillation. This requires an API version of at least 2024-10-01-preview, see WithAPIVersion().
+	Store bool
+
+	// Metadata are tags for a stored completion, which can be used to filter stored completions.
+	// There can be up to 16 pairs, keys up to 64 characters and values up to 512 characters.
+	Metadata map[string]string
 }
 
 // Defaults returns a CallParams with default values set. This should be called before
@@ -155,6 +305,15 @@ func (c CallParams) toPromptRequest() chat.Req {
 		User:        c.User,
 		N:           c.N,
 		Stop:        c.Stop,
+
+		PresencePenalty:  c.PresencePenalty,
+		FrequencyPenalty: c.FrequencyPenalty,
+
+		Store:    c.Store,
+		Metadata: c.Metadata,
+
+		MaxCompletionTokens: c.MaxCompletionTokens,
+		ReasoningEffort:     c.ReasoningEffort,
 	}
 }
 
@@ -166,8 +325,59 @@ func (c *Client) SetParams(params CallParams) {
 
 // Chats returns the response texts for the text sent.
 type Chats struct {
-	// Text is the response texts from the server.
+	// ID is the ID of the response, such as "chatcmpl-123". Use it to report feedback with
+	// azopenai.Client.Feedback().
+	ID string
+	// Text is the response texts from the server. This is the Content of each of Choices, kept
+	// for convenience, so Text[0] is the text when CallParams.N == 1.
 	Text []string
+	// FinishReasons are the reasons each choice finished, indexed by the choice index. When
+	// streaming, a choice's reason is only set in the StreamData where it finished.
+	FinishReasons []FinishReason
+	// Choices are the choices of the response, indexed by the choice index. Use these when
+	// CallParams.N > 1, such as to rerank the choices. When streaming, a StreamData only holds
+	// the choices in that message, with the text added since the last StreamData.
+	Choices []Choice
+
+	// Model is the model that generated the response, such as "gpt-35-turbo".
+	Model string
+	// Created is when the response was created.
+	Created time.Time
+
+	// Citations are the documents used to ground each choice, indexed by the choice index.
+	// This is only set when WithDataSources() is used.
+	Citations [][]Citation
+	// Intents are the intents the service detected for each choice, indexed by the choice index.
+	// This is only set when WithDataSources() is used.
+	Intents []string
+
+	// ContentFilters are the Azure content filter results for each choice, indexed by the choice index.
+	// When a choice was filtered, its FinishReason is content_filter and Call() returns an
+	// errors.ContentFiltered with the Chats.
+	ContentFilters []ContentFilter
+	// Audio is the audio generated for each choice, indexed by the choice index. This is only set
+	// when WithAudioOutput() is used.
+	Audio []Audio
+
+	// PromptFilters are the Azure content filter results for the prompt. When streaming, these
+	// are set in the first StreamData.
+	PromptFilters []PromptFilter
+
+	// EmptyRetries is the number of times the call was retried because the response had no
+	// content. See Client.SetEmptyRetry().
+	EmptyRetries int
+	// Degraded indicates Text is the canned response of the DegradePolicy because the service
+	// is down. See Client.SetDegrade().
+	Degraded bool
+
+	// Usage is the number of tokens used. This is not set when streaming.
+	Usage Usage
+	// Partial indicates the Text is incomplete because the stream was aborted. See Stream.Abort().
+	Partial bool
+
+	// Meta is metadata taken from the response headers, such as the request ID and
+	// rate limit information.
+	Meta custom.ResponseMeta
 
 	// RestReq is the raw request sent to the REST API. This is only provided if a specific
 	// CallOption is used.
@@ -181,9 +391,28 @@ type callOptions struct {
 	CallParams    CallParams
 	setCallParams bool
 	DeploymentID  string
-
-	RestReq  bool
-	RestResp bool
+	Locale        string
+	APIVersion    string
+	DataSources   []DataSource
+	Hooks         []Hook
+	Coalesce      *coalescer
+	JSON          *jsonResponse
+	Fallbacks     []string
+	Model         string
+	Prediction    *chat.Prediction
+	Trim          *historyTrim
+	Audio         *chat.AudioParams
+	ExtraFields   map[string]any
+	RawResponse   *[]byte
+	Timeout       time.Duration
+	IdleTimeout   time.Duration
+
+	RestReq   bool
+	RestResp  bool
+	RawEvents bool
+
+	// after are the funcs returned by Hooks.
+	after []func(ctx context.Context, result Chats) error
 }
 
 // CallOption is an optional argument for the Call method.
@@ -199,6 +428,18 @@ func WithCallParams(params CallParams) CallOption {
 	}
 }
 
+// WithAPIVersion sets the API version of the service to use for the call, such as "2024-02-01".
+// This overrides azopenai.WithAPIVersion().
+func WithAPIVersion(version string) CallOption {
+	return func(o *callOptions) error {
+		if _, err := rest.ContextWithAPIVersion(context.Background(), version); err != nil {
+			return err
+		}
+		o.APIVersion = version
+		return nil
+	}
+}
+
 // WithDeploymentID sets the deployment ID to use for the call. If not set, the deploymentID
 // set on the client will be used.
 func WithDeploymentID(deploymentID string) CallOption {
@@ -208,6 +449,39 @@ func WithDeploymentID(deploymentID string) CallOption {
 	}
 }
 
+// WithLocale sets the locale used to select the system prompt from the Prompts set
+// with SetPrompts(). This overrides a locale set with ContextWithLocale().
+func WithLocale(locale string) CallOption {
+	return func(o *callOptions) error {
+		o.Locale = locale
+		return nil
+	}
+}
+
+// WithDataSources sets data sources used to ground the responses with Azure OpenAI on your data.
+// The call is sent to the extensions endpoint and Chats.Citations and Chats.Intents are set.
+func WithDataSources(sources ...DataSource) CallOption {
+	return func(o *callOptions) error {
+		for _, ds := range sources {
+			if err := ds.Validate(); err != nil {
+				return err
+			}
+		}
+		o.DataSources = append(o.DataSources, sources...)
+		return nil
+	}
+}
+
+// WithRawEvents sets StreamData.Event to the raw server-sent event on streaming calls.
+// Events the SDK does not know how to decode are also sent, with only StreamData.Event set.
+// This allows handling new event types before the SDK supports them. Ignored by Call().
+func WithRawEvents() CallOption {
+	return func(o *callOptions) error {
+		o.RawEvents = true
+		return nil
+	}
+}
+
 // WithRest sets whether to return the raw REST request and response. This is useful for
 // debugging purposes.
 func WithRest(req, resp bool) CallOption {
@@ -228,10 +502,40 @@ const (
 	User Role = "user"
 	// System is a system message.
 	System Role = "system"
+	// Developer is a message with instructions for the model. Newer models, such as the o-series,
+	// use this in place of System.
+	Developer Role = "developer"
 	// Assistant is an assistant message.
 	Assistant Role = "assistant"
+	// Tool is a message from a tool, such as the citations from a DataSource.
+	Tool Role = "tool"
 )
 
+// DataSource is a source of data used to ground responses. See WithDataSources().
+type DataSource = chat.DataSource
+
+// Citation is a document used to ground a response.
+type Citation = chat.Citation
+
+// AzureSearchParams are the parameters for an AzureCognitiveSearch DataSource.
+type AzureSearchParams = chat.AzureSearchParams
+
+// CosmosDBParams are the parameters for an AzureCosmosDB DataSource.
+type CosmosDBParams = chat.CosmosDBParams
+
+const (
+	// AzureCognitiveSearch is an Azure Cognitive Search DataSource.
+	AzureCognitiveSearch = chat.AzureCognitiveSearch
+	// AzureCosmosDB is an Azure Cosmos DB for MongoDB vCore DataSource.
+	AzureCosmosDB = chat.AzureCosmosDB
+)
+
+// FinishReason is the reason the model stopped generating a choice.
+type FinishReason = custom.FinishReason
+
+// Usage is the number of tokens used by a call.
+type Usage = chat.Usage
+
 // SendMsg is a message to send to the chat API.
 type SendMsg struct {
 	// Role of the author of this message.
@@ -239,6 +543,9 @@ type SendMsg struct {
 
 	// Contents of the message.
 	Content string
+	// Parts are the contents of a message with multiple parts, such as text and images.
+	// If set, Content is ignored. See TextPart() and ImageFromFile().
+	Parts []ContentPart
 
 	// Name of the user in chat.
 	Name string
@@ -248,16 +555,371 @@ func (s SendMsg) toSendMsg() chat.SendMsg {
 	return chat.SendMsg{
 		Role:    chat.Role(s.Role),
 		Content: s.Content,
+		Parts:   s.Parts,
 		Name:    s.Name,
 	}
 }
 
 // Call makes a call to the Chat API endpoint and returns the chat results.
 func (c *Client) Call(ctx context.Context, messages []SendMsg, options ...CallOption) (Chats, error) {
+	req, callOptions, err := c.prep(ctx, messages, options...)
+	if err != nil {
+		return Chats{}, err
+	}
+
+	deploymentID := c.deploymentID
+	if callOptions.DeploymentID != "" {
+		deploymentID = callOptions.DeploymentID
+	}
+	ctx = restContext(ctx, callOptions)
+	ctx, cancel := withTimeout(ctx, callOptions)
+	defer cancel()
+
+	degrade := c.degrade.Load()
+	if degrade != nil && degrade.open(time.Now()) {
+		return degrade.response(messages, nil)
+	}
+
+	req, resp, retries, err := c.chat(ctx, deploymentID, req)
+	if degrade != nil {
+		outage := isOutage(ctx, err)
+		degrade.record(outage, time.Now())
+		if outage {
+			return degrade.response(messages, err)
+		}
+	}
+	if err != nil {
+		return Chats{}, err
+	}
+
+	chats := Chats{
+		ID:            resp.ID,
+		Model:         resp.Model,
+		Created:       resp.Created.Time,
+		Usage:         resp.Usage,
+		EmptyRetries:  retries,
+		PromptFilters: resp.PromptFilterResults,
+		Meta:          resp.Meta,
+	}
+	if callOptions.RestReq {
+		chats.RestReq = req
+	}
+	if callOptions.RestResp {
+		chats.RestResp = resp
+	}
+
+	// The fields of Chats are indexed by the choice index, which the service does not promise
+	// is the order of the choices.
+	sort.SliceStable(resp.Choices, func(i, j int) bool { return resp.Choices[i].Index < resp.Choices[j].Index })
+	for _, choice := range resp.Choices {
+		chats.FinishReasons = append(chats.FinishReasons, choice.FinishReason)
+		chats.ContentFilters = setContentFilter(chats.ContentFilters, choice.Index, choice.ContentFilterResults)
+		if choice.Message.Audio != nil {
+			a, err := toAudio(choice.Message.Audio)
+			if err != nil {
+				return Chats{}, fmt.Errorf("choice %d: %w", choice.Index, err)
+			}
+			chats.Audio = setAudio(chats.Audio, choice.Index, a)
+		}
+		if len(req.DataSources) == 0 {
+			chats.Text = append(chats.Text, choice.Message.Content)
+			chats.Choices = append(chats.Choices, Choice{
+				Index:        choice.Index,
+				Role:         Role(choice.Message.Role),
+				Content:      choice.Message.Content,
+				FinishReason: choice.FinishReason,
+			})
+			continue
+		}
+		var text string
+		var tc chat.ToolContent
+		for _, m := range choice.Messages {
+			switch m.Role {
+			case chat.Tool:
+				tc, err = m.ToolContent()
+				if err != nil {
+					return Chats{}, fmt.Errorf("choice %d: %w", choice.Index, err)
+				}
+			case chat.Assistant:
+				text = m.Content
+			}
+		}
+		chats.Text = append(chats.Text, text)
+		chats.Choices = append(chats.Choices, Choice{Index: choice.Index, Role: Assistant, Content: text, FinishReason: choice.FinishReason})
+		chats.Citations = append(chats.Citations, tc.Citations)
+		chats.Intents = append(chats.Intents, tc.Intent)
+	}
+
+	if err := filteredErr(chats.FinishReasons); err != nil {
+		return chats, err
+	}
+
+	if callOptions.JSON != nil {
+		if err := callOptions.JSON.check(chats.Text); err != nil {
+			return chats, err
+		}
+	}
+
+	if err := runAfter(ctx, callOptions, chats); err != nil {
+		return Chats{}, err
+	}
+	return chats, nil
+}
+
+// Choice is one of the choices of a response, see CallParams.N.
+type Choice struct {
+	// Index is the index of the choice.
+	Index int
+	// Role is the role of the author of the message, which is Assistant.
+	Role Role
+	// Content is the text of the message.
+	Content string
+	// FinishReason is the reason the choice finished.
+	FinishReason FinishReason
+}
+
+// StreamData is used to receive data from the stream.
+type StreamData struct {
+	// Err is an error related to the stream. The stream is terminated after this.
+	Err error
+	// Data is data sent by the stream. Data.Text holds the text that was added to each choice
+	// since the last StreamData, indexed by the choice index. So if the CallParams.N == 1, the
+	// new text is always in Data.Text[0]. A choice that did not receive new text will have an
+	// empty string.
+	Data Chats
+	// Event is the raw server-sent event. This is only set if WithRawEvents() was used.
+	Event rest.Event
+}
+
+// Stream makes a call to the Chat API endpoint and returns a channel that will return
+// the chat response as it is generated. Unlike the Call() method, each return value only
+// holds the text that was generated since the last value was returned. The stream can be
+// stopped by cancelling the context.
+func (c *Client) Stream(ctx context.Context, messages []SendMsg, options ...CallOption) chan StreamData {
+	ch := make(chan StreamData, 1)
+
+	req, callOptions, err := c.prep(ctx, messages, options...)
+	if err != nil {
+		ch <- StreamData{Err: err}
+		close(ch)
+		return ch
+	}
+
+	deploymentID := c.deploymentID
+	if callOptions.DeploymentID != "" {
+		deploymentID = callOptions.DeploymentID
+	}
+	ctx = restContext(ctx, callOptions)
+	ctx, cancel := withTimeout(ctx, callOptions)
+
+	go func() {
+		defer close(ch)
+		defer cancel()
+
+		// The role of an extensions message is only sent in its first delta, so we track it
+		// by choice and message index.
+		roles := map[[2]int]chat.Role{}
+
+		co := callOptions.Coalesce
+		// send sends sd, or coalesces it. It returns false if ctx is done.
+		send := func(sd StreamData) bool {
+			if co == nil {
+				return sendData(ctx, ch, sd)
+			}
+			if co.add(sd) {
+				sd, _ = co.take()
+				return sendData(ctx, ch, sd)
+			}
+			return true
+		}
+		// flush sends any coalesced StreamData. It returns false if ctx is done.
+		flush := func() bool {
+			if co == nil {
+				return true
+			}
+			if sd, ok := co.take(); ok {
+				return sendData(ctx, ch, sd)
+			}
+			return true
+		}
+
+		// received holds the text received so far, for a StreamError.
+		var received Chats
+		// promptFilters are sent with the next StreamData, as they arrive in a message without text.
+		var promptFilters []PromptFilter
+		fallbacks := callOptions.Fallbacks
+
+		in := c.rest.ChatStream(ctx, deploymentID, req)
+		for {
+			var resp rest.StreamRecv[chat.Resp]
+			var ok bool
+			if co == nil {
+				resp, ok = <-in
+			} else {
+				select {
+				case resp, ok = <-in:
+				case <-co.flushed():
+					if !flush() {
+						return
+					}
+					continue
+				}
+			}
+			if !ok {
+				flush()
+				return
+			}
+
+			if resp.Err != nil {
+				if !hasText(received.Text) && len(fallbacks) > 0 && ctx.Err() == nil {
+					deploymentID, fallbacks = fallbacks[0], fallbacks[1:]
+					in = c.rest.ChatStream(ctx, deploymentID, req)
+					continue
+				}
+				if !flush() {
+					return
+				}
+				if !hasText(received.Text) {
+					sendData(ctx, ch, StreamData{Err: resp.Err})
+					return
+				}
+				sendData(ctx, ch, StreamData{
+					Err: &StreamError{
+						DeploymentID: deploymentID,
+						Text:         received.Text,
+						Err:          resp.Err,
+						c:            c,
+						messages:     messages,
+						options:      options,
+					},
+				})
+				return
+			}
+			if !resp.Event.IsMessage() {
+				if callOptions.RawEvents && !sendData(ctx, ch, StreamData{Event: resp.Event}) {
+					return
+				}
+				continue
+			}
+
+			promptFilters = append(promptFilters, resp.Data.PromptFilterResults...)
+			chats := Chats{
+				ID:      resp.Data.ID,
+				Model:   resp.Data.Model,
+				Created: resp.Data.Created.Time,
+				Meta:    resp.Data.Meta,
+			}
+			if callOptions.RestReq {
+				chats.RestReq = req
+			}
+			if callOptions.RestResp {
+				chats.RestResp = resp.Data
+			}
+			for _, choice := range resp.Data.Choices {
+				if choice.Index < 0 {
+					continue
+				}
+				for len(chats.Text) <= choice.Index {
+					chats.Text = append(chats.Text, "")
+				}
+				chats.Text[choice.Index] += choice.Delta.Content
+				if choice.Delta.Audio != nil {
+					a, err := toAudio(choice.Delta.Audio)
+					if err != nil {
+						if flush() {
+							sendData(ctx, ch, StreamData{Err: fmt.Errorf("choice %d: %w", choice.Index, err)})
+						}
+						return
+					}
+					chats.Audio = setAudio(chats.Audio, choice.Index, a)
+				}
+				for len(chats.FinishReasons) <= choice.Index {
+					chats.FinishReasons = append(chats.FinishReasons, custom.Unfinished)
+				}
+				chats.FinishReasons[choice.Index] = choice.FinishReason
+				chats.ContentFilters = setContentFilter(chats.ContentFilters, choice.Index, choice.ContentFilterResults)
+
+				for _, m := range choice.Messages {
+					key := [2]int{choice.Index, m.Index}
+					if m.Delta.Role != chat.UnknownRole {
+						roles[key] = m.Delta.Role
+					}
+					if roles[key] != chat.Tool {
+						chats.Text[choice.Index] += m.Delta.Content
+						continue
+					}
+					if m.Delta.Content == "" {
+						continue
+					}
+					tc, err := chat.ExtMsg{Role: chat.Tool, Content: m.Delta.Content}.ToolContent()
+					if err != nil {
+						if flush() {
+							sendData(ctx, ch, StreamData{Err: fmt.Errorf("choice %d: %w", choice.Index, err)})
+						}
+						return
+					}
+					for len(chats.Citations) <= choice.Index {
+						chats.Citations = append(chats.Citations, nil)
+						chats.Intents = append(chats.Intents, "")
+					}
+					chats.Citations[choice.Index] = tc.Citations
+					chats.Intents[choice.Index] = tc.Intent
+				}
+			}
+			for _, choice := range resp.Data.Choices {
+				if choice.Index < 0 {
+					continue
+				}
+				chats.Choices = append(chats.Choices, Choice{
+					Index:        choice.Index,
+					Role:         Role(choice.Delta.Role),
+					Content:      chats.Text[choice.Index],
+					FinishReason: chats.FinishReasons[choice.Index],
+				})
+			}
+			if len(chats.Text) > 0 {
+				mergeChats(&received, chats)
+			}
+			sd := StreamData{Data: chats}
+			if callOptions.RawEvents {
+				sd.Event = resp.Event
+			} else if len(chats.Text) == 0 {
+				// The service sends messages that only contain the role or other metadata, we
+				// skip those.
+				continue
+			}
+			sd.Data.PromptFilters, promptFilters = promptFilters, nil
+			if !send(sd) {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// sendData sends sd on ch unless ctx is done first. It returns false if ctx is done, which means
+// the consumer may have stopped reading and the sender should exit. In that case the context
+// error is sent instead if there is room in the channel, so a consumer that is still reading
+// learns why the stream ended. See rest.sendRecv().
+func sendData(ctx context.Context, ch chan StreamData, sd StreamData) bool {
+	select {
+	case ch <- sd:
+		return true
+	case <-ctx.Done():
+		select {
+		case ch <- StreamData{Err: ctx.Err()}:
+		default:
+		}
+		return false
+	}
+}
+
+func (c *Client) prep(ctx context.Context, messages []SendMsg, options ...CallOption) (chat.Req, callOptions, error) {
 	callOptions := callOptions{}
 	for _, o := range options {
 		if err := o(&callOptions); err != nil {
-			return Chats{}, err
+			return chat.Req{}, callOptions, err
 		}
 	}
 	if !callOptions.setCallParams {
@@ -267,33 +929,52 @@ func (c *Client) Call(ctx context.Context, messages []SendMsg, options ...CallOp
 			callOptions.CallParams = *p
 		}
 	}
+	messages, err := runHooks(ctx, &callOptions, messages)
+	if err != nil {
+		return chat.Req{}, callOptions, err
+	}
 
 	req := callOptions.CallParams.toPromptRequest()
-
-	for _, m := range messages {
-		req.Messages = append(req.Messages, m.toSendMsg())
+	req.DataSources = callOptions.DataSources
+	if callOptions.JSON != nil {
+		f := callOptions.JSON.format
+		req.ResponseFormat = &f
 	}
-
-	deploymentID := c.deploymentID
-	if callOptions.DeploymentID != "" {
-		deploymentID = callOptions.DeploymentID
+	if callOptions.Prediction != nil {
+		if req.N > 1 {
+			return chat.Req{}, callOptions, fmt.Errorf("WithPrediction() cannot be used with CallParams.N > 1")
+		}
+		req.Prediction = callOptions.Prediction
+	}
+	if callOptions.Audio != nil {
+		req.Modalities = []chat.Modality{chat.TextModality, chat.AudioModality}
+		req.Audio = callOptions.Audio
 	}
 
-	resp, err := c.rest.Chat(ctx, deploymentID, req)
+	if err := validateRoles(messages); err != nil {
+		return chat.Req{}, callOptions, err
+	}
+	if err := validateParts(messages); err != nil {
+		return chat.Req{}, callOptions, err
+	}
+	messages, err = c.systemPrompt(ctx, callOptions, messages)
 	if err != nil {
-		return Chats{}, err
+		return chat.Req{}, callOptions, err
 	}
-
-	chats := Chats{}
-	if callOptions.RestReq {
-		chats.RestReq = req
+	model := c.modelFor(callOptions)
+	if callOptions.Trim != nil {
+		messages, err = callOptions.Trim.trim(ctx, model, messages)
+		if err != nil {
+			return chat.Req{}, callOptions, err
+		}
 	}
-	if callOptions.RestResp {
-		chats.RestResp = resp
+	req = c.adaptParams(ctx, model, req)
+	messages = translateRoles(model, messages)
+	for _, m := range messages {
+		req.Messages = append(req.Messages, m.toSendMsg())
 	}
-
-	for _, choice := range resp.Choices {
-		chats.Text = append(chats.Text, choice.Message.Content)
+	if err := req.Validate(); err != nil {
+		return chat.Req{}, callOptions, err
 	}
-	return chats, nil
+	return req, callOptions, nil
 }
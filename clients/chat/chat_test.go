@@ -0,0 +1,120 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+func TestCallChoices(t *testing.T) {
+	// The choices are out of order, which the service is allowed to do.
+	const body = `{"id":"chatcmpl-1","choices":[` +
+		`{"index":1,"message":{"role":"assistant","content":"Hi"},"finish_reason":"length"},` +
+		`{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}]}`
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	params := CallParams{}.Defaults()
+	params.N = 2
+	chats, err := c.Call(context.Background(), []SendMsg{{Role: User, Content: "hi"}}, WithCallParams(params))
+	if err != nil {
+		t.Fatalf("TestCallChoices: got err == %s, want err == nil", err)
+	}
+
+	want := []Choice{
+		{Index: 0, Role: Assistant, Content: "Hello", FinishReason: custom.Stop},
+		{Index: 1, Role: Assistant, Content: "Hi", FinishReason: custom.Length},
+	}
+	if !reflect.DeepEqual(chats.Choices, want) {
+		t.Errorf("TestCallChoices: got %+v, want %+v", chats.Choices, want)
+	}
+	if got := strings.Join(chats.Text, "|"); got != "Hello|Hi" {
+		t.Errorf("TestCallChoices: got text %q, want %q", got, "Hello|Hi")
+	}
+}
+
+// endlessStream returns a roundTripFunc whose response streams event until the body is closed.
+func endlessStream(event string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		r, w := io.Pipe()
+		go func() {
+			for {
+				if _, err := w.Write([]byte(event)); err != nil {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       r,
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	tests := []struct {
+		desc    string
+		options []CallOption
+	}{
+		{desc: "plain"},
+		{desc: "coalesced", options: []CallOption{WithCoalesce(time.Millisecond, 2)}},
+	}
+
+	for _, test := range tests {
+		rt := endlessStream(`data: {"id":"1","choices":[{"index":0,"delta":{"content":"x"}}]}` + "\n\n")
+		rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New("deployment", rc)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := c.Stream(ctx, []SendMsg{{Role: User, Content: "hi"}}, test.options...)
+		if sd := <-ch; sd.Err != nil {
+			t.Fatalf("TestStreamCancel(%s): got err == %s, want err == nil", test.desc, sd.Err)
+		}
+		cancel()
+		// Stop reading, as a consumer that cancels usually does. The stream must not block on a
+		// send, so after this it can only have its buffered value left.
+		time.Sleep(100 * time.Millisecond)
+
+		closed := false
+		for i := 0; !closed; i++ {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					closed = true
+					break
+				}
+				if i > 0 {
+					t.Fatalf("TestStreamCancel(%s): got more than the buffered value after cancel, the stream kept sending", test.desc)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("TestStreamCancel(%s): channel was not closed after cancel", test.desc)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package chat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// WithCoalesce coalesces the deltas of a stream so that a StreamData is sent at most every
+// interval, or sooner once chars characters of new text are waiting. This reduces channel
+// sends and re-rendering for very fast models. No text is lost, anything waiting is sent
+// before the stream ends. An interval or chars of 0 disables that trigger, but one must be set.
+// With WithRawEvents(), StreamData.Event is the last event coalesced into the StreamData and
+// events the SDK does not decode are sent immediately. Ignored by Call().
+func WithCoalesce(interval time.Duration, chars int) CallOption {
+	return func(o *callOptions) error {
+		if interval < 0 || chars < 0 {
+			return fmt.Errorf("WithCoalesce(%v, %d): values cannot be < 0", interval, chars)
+		}
+		if interval == 0 && chars == 0 {
+			return fmt.Errorf("WithCoalesce(): interval or chars must be set")
+		}
+		o.Coalesce = &coalescer{interval: interval, chars: chars}
+		return nil
+	}
+}
+
+// coalescer merges StreamData until it should be flushed.
+type coalescer struct {
+	interval time.Duration
+	chars    int
+
+	pending *StreamData
+	n       int
+	last    time.Time
+	timer   *time.Timer
+}
+
+// add merges sd into the pending StreamData. It returns true if the pending StreamData should
+// be sent now.
+func (c *coalescer) add(sd StreamData) bool {
+	if c.pending == nil {
+		c.pending = &sd
+		if c.interval > 0 {
+			c.timer = time.NewTimer(c.interval - time.Since(c.last))
+		}
+	} else {
+		mergeChats(&c.pending.Data, sd.Data)
+		c.pending.Event = sd.Event
+	}
+	for _, t := range sd.Data.Text {
+		c.n += len(t)
+	}
+	return c.chars > 0 && c.n >= c.chars
+}
+
+// flushed returns a channel that receives when the pending StreamData should be sent because
+// the interval passed. It returns nil if nothing is pending.
+func (c *coalescer) flushed() <-chan time.Time {
+	if c.timer == nil {
+		return nil
+	}
+	return c.timer.C
+}
+
+// take returns the pending StreamData and resets the coalescer.
+func (c *coalescer) take() (StreamData, bool) {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if c.pending == nil {
+		return StreamData{}, false
+	}
+	sd := *c.pending
+	c.pending = nil
+	c.n = 0
+	c.last = time.Now()
+	return sd, true
+}
+
+// mergeChats merges the stream delta src into dst.
+func mergeChats(dst *Chats, src Chats) {
+	if dst.ID == "" {
+		dst.ID = src.ID
+	}
+	for i, t := range src.Text {
+		for len(dst.Text) <= i {
+			dst.Text = append(dst.Text, "")
+		}
+		dst.Text[i] += t
+	}
+	for i, r := range src.FinishReasons {
+		for len(dst.FinishReasons) <= i {
+			dst.FinishReasons = append(dst.FinishReasons, custom.Unfinished)
+		}
+		if r != custom.Unfinished {
+			dst.FinishReasons[i] = r
+		}
+	}
+	// src.Choices only holds the choices in its message, dst.Choices is indexed by the choice index.
+	for _, c := range src.Choices {
+		for len(dst.Choices) <= c.Index {
+			dst.Choices = append(dst.Choices, Choice{Index: len(dst.Choices)})
+		}
+		d := &dst.Choices[c.Index]
+		if c.Role != UnknownRole {
+			d.Role = c.Role
+		}
+		d.Content += c.Content
+		if c.FinishReason != custom.Unfinished {
+			d.FinishReason = c.FinishReason
+		}
+	}
+	for i := range src.Citations {
+		for len(dst.Citations) <= i {
+			dst.Citations = append(dst.Citations, nil)
+			dst.Intents = append(dst.Intents, "")
+		}
+		if src.Citations[i] != nil {
+			dst.Citations[i] = src.Citations[i]
+		}
+		if src.Intents[i] != "" {
+			dst.Intents[i] = src.Intents[i]
+		}
+	}
+	for i, cf := range src.ContentFilters {
+		if cf != (ContentFilter{}) {
+			dst.ContentFilters = setContentFilter(dst.ContentFilters, i, &cf)
+		}
+	}
+	dst.PromptFilters = append(dst.PromptFilters, src.PromptFilters...)
+	for i, a := range src.Audio {
+		for len(dst.Audio) <= i {
+			dst.Audio = append(dst.Audio, Audio{})
+		}
+		d := &dst.Audio[i]
+		if a.ID != "" {
+			d.ID = a.ID
+		}
+		if !a.ExpiresAt.IsZero() {
+			d.ExpiresAt = a.ExpiresAt
+		}
+		d.Data = append(d.Data, a.Data...)
+		d.Transcript += a.Transcript
+	}
+	if dst.Model == "" {
+		dst.Model = src.Model
+		dst.Created = src.Created
+	}
+	dst.Meta = src.Meta
+	dst.RestResp = src.RestResp
+}
@@ -0,0 +1,70 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+func TestCoalesce(t *testing.T) {
+	words := []string{"The ", "quick ", "brown ", "fox ", "jumps ", "over ", "the ", "lazy ", "dog."}
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b := &strings.Builder{}
+		for i, w := range words {
+			reason := "null"
+			if i == len(words)-1 {
+				reason = `"stop"`
+			}
+			fmt.Fprintf(b, "data: {\"choices\":[{\"index\":0,\"finish_reason\":%s,\"delta\":{\"content\":%q}}]}\n\n", reason, w)
+		}
+		b.WriteString("data: [DONE]\n\n")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(b.String())),
+			Request:    req,
+		}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	var (
+		got    strings.Builder
+		sends  int
+		reason FinishReason
+	)
+	stream := c.Stream(context.Background(), []SendMsg{{Role: User, Content: "hi"}}, WithCoalesce(time.Hour, 10))
+	for sd := range stream {
+		if sd.Err != nil {
+			t.Fatalf("TestCoalesce: got err == %s, want err == nil", sd.Err)
+		}
+		sends++
+		got.WriteString(sd.Data.Text[0])
+		if r := sd.Data.FinishReasons[0]; r != "" {
+			reason = r
+		}
+	}
+
+	if want := strings.Join(words, ""); got.String() != want {
+		t.Errorf("TestCoalesce: got text %q, want %q", got.String(), want)
+	}
+	// Deltas are sent in groups of at least 10 characters, plus whatever remains at the end.
+	if sends >= len(words) || sends < 2 {
+		t.Errorf("TestCoalesce: got %d sends, want fewer than %d", sends, len(words))
+	}
+	if reason != "stop" {
+		t.Errorf("TestCoalesce: got finish reason %q, want stop", reason)
+	}
+}
@@ -0,0 +1,41 @@
+package chat
+
+// Collect reads stream until it is closed and returns the full result, as if Call() had been used:
+// the text of each choice is concatenated, FinishReasons hold the reason each choice finished and
+// citations, content filter results and audio are merged. stream can be the channel returned by
+// Stream() or Stream.C.
+//
+// If the stream returns an error, Collect returns the result received so far with Chats.Partial
+// set and the error. Like Call(), an errors.ContentFiltered is returned with the result if a choice
+// was filtered. The service does not send usage when streaming, so Chats.Usage is not set.
+func Collect(stream <-chan StreamData) (Chats, error) {
+	var (
+		result Chats
+		err    error
+	)
+	for sd := range stream {
+		if err != nil {
+			// Drain the stream so its goroutine can exit.
+			continue
+		}
+		if sd.Err != nil {
+			err = sd.Err
+			continue
+		}
+		if sd.Data.Text == nil && sd.Data.FinishReasons == nil {
+			// A raw event the SDK does not decode.
+			continue
+		}
+		mergeChats(&result, sd.Data)
+		result.RestReq = sd.Data.RestReq
+	}
+
+	if err != nil {
+		result.Partial = true
+		return result, err
+	}
+	if err := filteredErr(result.FinishReasons); err != nil {
+		return result, err
+	}
+	return result, nil
+}
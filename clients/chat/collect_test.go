@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+func TestCollect(t *testing.T) {
+	events := strings.Join([]string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant"}},{"index":1,"delta":{"role":"assistant"}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"content":"Hello"}},{"index":1,"delta":{"content":"Hi"}}]}`,
+		`data: {"choices":[{"index":0,"delta":{"content":" world"},"finish_reason":null}]}`,
+		`data: {"choices":[{"index":1,"delta":{"content":" there"},"finish_reason":"length"}]}`,
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`data: [DONE]`,
+	}, "\n\n") + "\n\n"
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(events)),
+			Request:    req,
+		}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	chats, err := Collect(c.Stream(context.Background(), []SendMsg{{Role: User, Content: "hi"}}))
+	if err != nil {
+		t.Fatalf("TestCollect: got err == %s, want err == nil", err)
+	}
+	if got, want := strings.Join(chats.Text, "|"), "Hello world|Hi there"; got != want {
+		t.Errorf("TestCollect: got text %q, want %q", got, want)
+	}
+	if len(chats.FinishReasons) != 2 || chats.FinishReasons[0] != custom.Stop || chats.FinishReasons[1] != custom.Length {
+		t.Errorf("TestCollect: got finish reasons %v, want [stop length]", chats.FinishReasons)
+	}
+	wantChoices := []Choice{
+		{Index: 0, Role: Assistant, Content: "Hello world", FinishReason: custom.Stop},
+		{Index: 1, Role: Assistant, Content: "Hi there", FinishReason: custom.Length},
+	}
+	if !reflect.DeepEqual(chats.Choices, wantChoices) {
+		t.Errorf("TestCollect: got choices %+v, want %+v", chats.Choices, wantChoices)
+	}
+	if chats.Partial {
+		t.Errorf("TestCollect: got Partial == true, want false")
+	}
+	if chats.Usage != (Usage{}) {
+		t.Errorf("TestCollect: got Usage %+v, want it unset", chats.Usage)
+	}
+}
+
+func TestCollectErr(t *testing.T) {
+	streamErr := errors.New("connection reset")
+
+	tests := []struct {
+		desc        string
+		data        []StreamData
+		wantText    string
+		wantPartial bool
+		wantErr     func(error) bool
+	}{
+		{
+			desc: "stream error",
+			data: []StreamData{
+				{Data: Chats{Text: []string{"Hello"}, FinishReasons: []FinishReason{custom.Unfinished}}},
+				{Err: streamErr},
+			},
+			wantText:    "Hello",
+			wantPartial: true,
+			wantErr:     func(err error) bool { return errors.Is(err, streamErr) },
+		},
+		{
+			desc: "content filtered",
+			data: []StreamData{
+				{Data: Chats{Text: []string{"Hel"}, FinishReasons: []FinishReason{custom.Unfinished}}},
+				{Data: Chats{Text: []string{""}, FinishReasons: []FinishReason{custom.ContentFilter}}},
+			},
+			wantText: "Hel",
+			wantErr: func(err error) bool {
+				var cf errors.ContentFiltered
+				return errors.As(err, &cf)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		ch := make(chan StreamData, len(test.data))
+		for _, sd := range test.data {
+			ch <- sd
+		}
+		close(ch)
+
+		chats, err := Collect(ch)
+		if !test.wantErr(err) {
+			t.Errorf("TestCollectErr(%s): got err == %v, want a different error", test.desc, err)
+		}
+		if got := strings.Join(chats.Text, ""); got != test.wantText {
+			t.Errorf("TestCollectErr(%s): got text %q, want %q", test.desc, got, test.wantText)
+		}
+		if chats.Partial != test.wantPartial {
+			t.Errorf("TestCollectErr(%s): got Partial == %v, want %v", test.desc, chats.Partial, test.wantPartial)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// DegradePolicy returns a canned response instead of an error when the service is down, so
+// user facing products stay up during an outage. A call is degraded when it fails with a 5xx
+// status or a network error, which for a pool means every deployment failed. After Failures
+// such calls in a row the circuit opens and calls return the canned response without calling
+// the service until Cooldown has passed.
+type DegradePolicy struct {
+	// Response is the text of the canned response. It is a text/template rendered with a
+	// DegradeData, such as "Sorry, I can't answer {{printf "%q" .Prompt}} right now." This
+	// is required.
+	Response string
+	// Failures is the number of degraded calls in a row that open the circuit. Defaults to 5.
+	Failures int
+	// Cooldown is how long the circuit stays open. Defaults to 30 seconds.
+	Cooldown time.Duration
+}
+
+// DegradeData is the data the DegradePolicy.Response template is rendered with.
+type DegradeData struct {
+	// Prompt is the content of the last user message.
+	Prompt string
+	// Err is the error of the call, or nil if the circuit was open.
+	Err error
+}
+
+func (p DegradePolicy) defaults() DegradePolicy {
+	if p.Failures == 0 {
+		p.Failures = 5
+	}
+	if p.Cooldown == 0 {
+		p.Cooldown = 30 * time.Second
+	}
+	return p
+}
+
+func (p DegradePolicy) validate() error {
+	if p.Response == "" {
+		return fmt.Errorf("DegradePolicy.Response cannot be empty")
+	}
+	if p.Failures < 1 {
+		return fmt.Errorf("DegradePolicy.Failures must be > 0, was %d", p.Failures)
+	}
+	if p.Cooldown < 0 {
+		return fmt.Errorf("DegradePolicy.Cooldown cannot be < 0")
+	}
+	return nil
+}
+
+// degrader holds the circuit state of a DegradePolicy.
+type degrader struct {
+	policy DegradePolicy
+	tmpl   *template.Template
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// open returns true if the circuit is open at now.
+func (d *degrader) open(now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return now.Before(d.openUntil)
+}
+
+// record records the result of a call that was sent to the service. Only outages count as
+// failures, other errors are not the service being down.
+func (d *degrader) record(outage bool, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !outage {
+		d.failures = 0
+		return
+	}
+	d.failures++
+	if d.failures >= d.policy.Failures {
+		d.openUntil = now.Add(d.policy.Cooldown)
+	}
+}
+
+// response returns the canned response for messages.
+func (d *degrader) response(messages []SendMsg, err error) (Chats, error) {
+	data := DegradeData{Err: err}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == User {
+			data.Prompt = messages[i].Content
+			break
+		}
+	}
+
+	b := &strings.Builder{}
+	if terr := d.tmpl.Execute(b, data); terr != nil {
+		return Chats{}, fmt.Errorf("problem rendering DegradePolicy.Response: %w", terr)
+	}
+	return Chats{
+		Text:          []string{b.String()},
+		FinishReasons: []FinishReason{custom.Stop},
+		Choices:       []Choice{{Role: Assistant, Content: b.String(), FinishReason: custom.Stop}},
+		Created:       time.Now(),
+		Degraded:      true,
+	}, nil
+}
+
+// SetDegrade sets the policy for returning a canned response on Call() when the service is
+// down. Degraded responses have Chats.Degraded set. Streams are not degraded. This resets the
+// circuit.
+func (c *Client) SetDegrade(policy DegradePolicy) error {
+	policy = policy.defaults()
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	t, err := template.New("degrade").Option("missingkey=error").Parse(policy.Response)
+	if err != nil {
+		return fmt.Errorf("problem parsing DegradePolicy.Response: %w", err)
+	}
+	c.degrade.Store(&degrader{policy: policy, tmpl: t})
+	return nil
+}
+
+// CircuitOpen returns true if the circuit of the DegradePolicy is open, so calls return the
+// canned response without calling the service.
+func (c *Client) CircuitOpen() bool {
+	d := c.degrade.Load()
+	return d != nil && d.open(time.Now())
+}
+
+// isOutage returns true if err means the service is down, not that the request was bad.
+func isOutage(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var (
+		jErr errors.JSON
+		sErr errors.StatusCode
+		nErr net.Error
+	)
+	switch {
+	case errors.As(err, &jErr):
+		return jErr.StatusCode >= http.StatusInternalServerError
+	case errors.As(err, &sErr):
+		return sErr.StatusCode >= http.StatusInternalServerError
+	case errors.As(err, &nErr):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+func TestDegrade(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	calls := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		body := `{"error": {"code": "unavailable"}}`
+		if status == http.StatusOK {
+			body = `{"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}), rest.WithRetryPolicy(rest.RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	if err := c.SetDegrade(DegradePolicy{}); err == nil {
+		t.Errorf("TestDegrade(no Response): got err == nil, want err != nil")
+	}
+	if err := c.SetDegrade(DegradePolicy{Response: `Sorry, I can't answer {{printf "%q" .Prompt}} right now.`, Failures: 2, Cooldown: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := []SendMsg{{Role: User, Content: "hello"}}
+	const want = `Sorry, I can't answer "hello" right now.`
+	for i := 0; i < 3; i++ {
+		chats, err := c.Call(context.Background(), msgs)
+		if err != nil {
+			t.Fatalf("TestDegrade(call %d): got err == %s, want err == nil", i, err)
+		}
+		if !chats.Degraded || len(chats.Text) != 1 || chats.Text[0] != want {
+			t.Errorf("TestDegrade(call %d): got %+v, want the degraded response", i, chats)
+		}
+	}
+	// The third call is served by the open circuit.
+	if calls != 2 {
+		t.Errorf("TestDegrade: got %d calls to the service, want 2", calls)
+	}
+	if !c.CircuitOpen() {
+		t.Errorf("TestDegrade: got CircuitOpen() == false, want true")
+	}
+
+	// Resetting the policy closes the circuit, so the service is called again.
+	if err := c.SetDegrade(DegradePolicy{Response: "down"}); err != nil {
+		t.Fatal(err)
+	}
+	status = http.StatusOK
+	chats, err := c.Call(context.Background(), msgs)
+	if err != nil {
+		t.Fatalf("TestDegrade(recovered): got err == %s, want err == nil", err)
+	}
+	if chats.Degraded || chats.Text[0] != "hi" {
+		t.Errorf("TestDegrade(recovered): got %+v, want the service response", chats)
+	}
+
+	// Errors that are not outages are returned.
+	status = http.StatusBadRequest
+	if _, err := c.Call(context.Background(), msgs); err == nil {
+		t.Errorf("TestDegrade(bad request): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,140 @@
+package chat
+
+import (
+	"context"
+	"sync"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// ChoiceData is the data for a single choice of a stream split with Demux().
+type ChoiceData struct {
+	// Err is an error related to the stream. It is sent to every choice and the choice's
+	// stream is terminated after this.
+	Err error
+	// Text is the text that was added to the choice since the last ChoiceData.
+	Text string
+	// FinishReason is set in the ChoiceData where the choice finished.
+	FinishReason FinishReason
+}
+
+// Demux splits a stream with n choices, such as one returned by Stream() with CallParams.N == n,
+// into a stream per choice, indexed by the choice index. Each choice's stream receives its text in
+// order and is closed when in is closed. Choices with an index >= n are dropped.
+// Streams are buffered without limit, so they can be read one after the other or concurrently.
+// When ctx is done, the choice streams are closed without sending what is left, so streams that
+// are no longer read don't leak. in is still read until it is closed, so ctx should also be the
+// Context of the stream.
+func Demux(ctx context.Context, in <-chan StreamData, n int) []<-chan ChoiceData {
+	queues := make([]*choiceQueue, n)
+	outs := make([]<-chan ChoiceData, n)
+	for i := range queues {
+		queues[i] = newChoiceQueue(ctx)
+		outs[i] = queues[i].out
+	}
+
+	go func() {
+		defer func() {
+			for _, q := range queues {
+				q.close()
+			}
+		}()
+
+		for sd := range in {
+			if ctx.Err() != nil {
+				// Drain in so the stream can exit.
+				continue
+			}
+			if sd.Err != nil {
+				for _, q := range queues {
+					q.push(ChoiceData{Err: sd.Err})
+				}
+				continue
+			}
+			for i, t := range sd.Data.Text {
+				if i >= n {
+					break
+				}
+				cd := ChoiceData{Text: t, FinishReason: custom.Unfinished}
+				if i < len(sd.Data.FinishReasons) {
+					cd.FinishReason = sd.Data.FinishReasons[i]
+				}
+				if cd.Text == "" && cd.FinishReason == custom.Unfinished {
+					continue
+				}
+				queues[i].push(cd)
+			}
+		}
+	}()
+
+	return outs
+}
+
+// choiceQueue is an unbounded queue of ChoiceData that is sent on out.
+type choiceQueue struct {
+	out chan ChoiceData
+
+	mu     sync.Mutex
+	items  []ChoiceData
+	closed bool
+	signal chan struct{}
+}
+
+func newChoiceQueue(ctx context.Context) *choiceQueue {
+	q := &choiceQueue{
+		out:    make(chan ChoiceData, 1),
+		signal: make(chan struct{}, 1),
+	}
+	go q.run(ctx)
+	return q
+}
+
+func (q *choiceQueue) push(cd ChoiceData) {
+	q.mu.Lock()
+	q.items = append(q.items, cd)
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *choiceQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *choiceQueue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// run sends queued items on out until the queue is closed and empty or ctx is done.
+func (q *choiceQueue) run(ctx context.Context) {
+	defer close(q.out)
+	for {
+		q.mu.Lock()
+		items, closed := q.items, q.closed
+		q.items = nil
+		q.mu.Unlock()
+
+		for _, cd := range items {
+			select {
+			case q.out <- cd:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if closed && len(items) == 0 {
+			return
+		}
+		if len(items) == 0 {
+			select {
+			case <-q.signal:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
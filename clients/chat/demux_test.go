@@ -0,0 +1,104 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+func TestDemux(t *testing.T) {
+	in := make(chan StreamData, 10)
+	in <- StreamData{Data: Chats{Text: []string{"Hel", ""}}}
+	in <- StreamData{Data: Chats{Text: []string{"", "Good"}}}
+	in <- StreamData{Data: Chats{Text: []string{"lo", "bye"}, FinishReasons: []FinishReason{custom.Unfinished, custom.Stop}}}
+	in <- StreamData{Data: Chats{Text: []string{"!", "", "dropped"}, FinishReasons: []FinishReason{custom.Stop}}}
+	in <- StreamData{Err: errors.New("boom")}
+	close(in)
+
+	choices := Demux(context.Background(), in, 2)
+
+	// The choices are read one after the other, which must not block.
+	want := []string{"Hello!", "Goodbye"}
+	for i, ch := range choices {
+		var text string
+		var reason FinishReason
+		var err error
+		for cd := range ch {
+			if cd.Err != nil {
+				err = cd.Err
+				continue
+			}
+			text += cd.Text
+			if cd.FinishReason != custom.Unfinished {
+				reason = cd.FinishReason
+			}
+		}
+		if text != want[i] {
+			t.Errorf("TestDemux(choice %d): got text %q, want %q", i, text, want[i])
+		}
+		if reason != custom.Stop {
+			t.Errorf("TestDemux(choice %d): got finish reason %q, want %q", i, reason, custom.Stop)
+		}
+		if err == nil {
+			t.Errorf("TestDemux(choice %d): got err == nil, want err != nil", i)
+		}
+	}
+}
+
+func TestDemuxCancel(t *testing.T) {
+	in := make(chan StreamData)
+	ctx, cancel := context.WithCancel(context.Background())
+	choices := Demux(ctx, in, 2)
+
+	// Choice 1 is abandoned: its data is never read.
+	go func() {
+		for i := 0; i < 10; i++ {
+			in <- StreamData{Data: Chats{Text: []string{"a", "b"}}}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		<-choices[0]
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// After cancel, in is drained so the stream can exit, and both choices are closed.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			in <- StreamData{Data: Chats{Text: []string{"a", "b"}}}
+		}
+		close(in)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("TestDemuxCancel: Demux stopped reading in after cancel")
+	}
+
+	// Choice 1 had 10 items queued, but only what was buffered before cancel is sent.
+	time.Sleep(50 * time.Millisecond)
+	for i, ch := range choices {
+		n := 0
+		timeout := time.After(time.Second)
+	drain:
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					break drain
+				}
+				n++
+			case <-timeout:
+				t.Fatalf("TestDemuxCancel: choice %d was not closed after cancel", i)
+			}
+		}
+		if n > 1 {
+			t.Errorf("TestDemuxCancel: choice %d sent %d items after cancel, want at most the 1 buffered", i, n)
+		}
+	}
+}
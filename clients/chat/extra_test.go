@@ -0,0 +1,68 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+func TestExtraFields(t *testing.T) {
+	const respBody = `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "hi"}}], "new_field": 42}`
+
+	var got map[string]any
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(respBody)),
+			Request:    req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	var raw []byte
+	chats, err := c.Call(
+		context.Background(),
+		[]SendMsg{{Role: User, Content: "hello"}},
+		WithExtraFields(map[string]any{"new_parameter": "on", "n": 2}),
+		WithRawResponse(&raw),
+	)
+	if err != nil {
+		t.Fatalf("TestExtraFields: got err == %s, want err == nil", err)
+	}
+	if got["new_parameter"] != "on" {
+		t.Errorf("TestExtraFields: got request %v, want new_parameter set", got)
+	}
+	if got["n"] != float64(2) {
+		t.Errorf("TestExtraFields: got n == %v, want the extra field to replace the SDK's", got["n"])
+	}
+	if got["messages"] == nil {
+		t.Errorf("TestExtraFields: got request %v, want the SDK fields kept", got)
+	}
+	if string(raw) != respBody {
+		t.Errorf("TestExtraFields: got raw response %s, want %s", raw, respBody)
+	}
+	if chats.Text[0] != "hi" {
+		t.Errorf("TestExtraFields: got text %q, want %q", chats.Text[0], "hi")
+	}
+
+	if _, err := c.Call(context.Background(), []SendMsg{{Role: User, Content: "hello"}}, WithExtraFields(nil)); err == nil {
+		t.Errorf("TestExtraFields(no fields): got err == nil, want err != nil")
+	}
+	if _, err := c.Call(context.Background(), []SendMsg{{Role: User, Content: "hello"}}, WithExtraFields(map[string]any{"bad": func() {}})); err == nil {
+		t.Errorf("TestExtraFields(not JSON): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// continuePrompt is sent by StreamError.Retry() to ask the model to continue a partial answer.
+const continuePrompt = "Your previous answer was cut off. Continue exactly where it stopped, without repeating any text."
+
+// WithFallback sets deployments that Stream() restarts on, in order, if the stream fails before
+// any text is received. This is transparent to the caller. A stream that fails after text was
+// received returns a *StreamError instead. Ignored by Call().
+func WithFallback(deploymentIDs ...string) CallOption {
+	return func(o *callOptions) error {
+		for _, id := range deploymentIDs {
+			if strings.TrimSpace(id) == "" {
+				return fmt.Errorf("WithFallback: deploymentID cannot be empty")
+			}
+		}
+		o.Fallbacks = append(o.Fallbacks, deploymentIDs...)
+		return nil
+	}
+}
+
+// StreamError is returned in StreamData.Err when a stream fails after text was received.
+type StreamError struct {
+	// DeploymentID is the deployment the stream failed on.
+	DeploymentID string
+	// Text is the text of each choice received before the failure, indexed by the choice index.
+	Text []string
+	// Err is the error that ended the stream.
+	Err error
+
+	c        *Client
+	messages []SendMsg
+	options  []CallOption
+}
+
+// Error implements error.
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("stream on deployment %q failed after receiving text: %s", e.DeploymentID, e.Err)
+}
+
+// Unwrap returns the error that ended the stream.
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// Retry starts a new stream with the same messages and options that continues the text of the
+// first choice. The partial text is sent as an assistant message followed by a request to continue,
+// so the new stream only holds the rest of the answer. This is only meaningful when CallParams.N == 1.
+func (e *StreamError) Retry(ctx context.Context) chan StreamData {
+	if e.c == nil {
+		ch := make(chan StreamData, 1)
+		ch <- StreamData{Err: fmt.Errorf("StreamError.Retry: error was not returned by Stream()")}
+		close(ch)
+		return ch
+	}
+
+	messages := make([]SendMsg, 0, len(e.messages)+2)
+	messages = append(messages, e.messages...)
+	if len(e.Text) > 0 && e.Text[0] != "" {
+		messages = append(
+			messages,
+			SendMsg{Role: Assistant, Content: e.Text[0]},
+			SendMsg{Role: User, Content: continuePrompt},
+		)
+	}
+	return e.c.Stream(ctx, messages, e.options...)
+}
+
+// hasText returns true if any choice has text.
+func hasText(text []string) bool {
+	for _, t := range text {
+		if t != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+func TestStreamFallback(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		paths = append(paths, req.URL.Path)
+		mu.Unlock()
+
+		if strings.Contains(req.URL.Path, "/primary/") {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"error":{"code":"DeploymentNotFound"}}`)),
+				Request:    req,
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body: io.NopCloser(strings.NewReader(
+				"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+					"data: [DONE]\n\n",
+			)),
+			Request: req,
+		}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("primary", rc)
+
+	var text string
+	for sd := range c.Stream(context.Background(), []SendMsg{{Role: User, Content: "hi"}}, WithFallback("backup")) {
+		if sd.Err != nil {
+			t.Fatalf("TestStreamFallback: got err == %s, want err == nil", sd.Err)
+		}
+		text += strings.Join(sd.Data.Text, "")
+	}
+	if text != "Hello" {
+		t.Errorf("TestStreamFallback: got text %q, want %q", text, "Hello")
+	}
+	if len(paths) != 2 || !strings.Contains(paths[1], "/backup/") {
+		t.Errorf("TestStreamFallback: got requests to %v, want primary then backup", paths)
+	}
+}
+
+func TestStreamErrorRetry(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(req.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		first := len(bodies) == 1
+		mu.Unlock()
+
+		r, w := io.Pipe()
+		go func() {
+			if first {
+				io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+				w.CloseWithError(errors.New("connection reset"))
+				return
+			}
+			io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\" world\"}}]}\n\n")
+			io.WriteString(w, "data: [DONE]\n\n")
+			w.Close()
+		}()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       r,
+			Request:    req,
+		}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	var streamErr *StreamError
+	for sd := range c.Stream(context.Background(), []SendMsg{{Role: User, Content: "hi"}}, WithFallback("backup")) {
+		if sd.Err != nil && !errors.As(sd.Err, &streamErr) {
+			t.Fatalf("TestStreamErrorRetry: got err == %s, want *StreamError", sd.Err)
+		}
+	}
+	if streamErr == nil {
+		t.Fatalf("TestStreamErrorRetry: got err == nil, want *StreamError")
+	}
+	if streamErr.DeploymentID != "deployment" {
+		t.Errorf("TestStreamErrorRetry: got DeploymentID %q, want %q", streamErr.DeploymentID, "deployment")
+	}
+	if len(streamErr.Text) != 1 || streamErr.Text[0] != "Hello" {
+		t.Errorf("TestStreamErrorRetry: got partial text %q, want %q", streamErr.Text, "Hello")
+	}
+
+	var text string
+	for sd := range streamErr.Retry(context.Background()) {
+		if sd.Err != nil {
+			t.Fatalf("TestStreamErrorRetry: got retry err == %s, want err == nil", sd.Err)
+		}
+		text += strings.Join(sd.Data.Text, "")
+	}
+	if text != " world" {
+		t.Errorf("TestStreamErrorRetry: got retry text %q, want %q", text, " world")
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[1], `"content":"Hello"`) || !strings.Contains(bodies[1], continuePrompt) {
+		t.Errorf("TestStreamErrorRetry: retry request did not continue the partial text: %v", bodies)
+	}
+}
@@ -0,0 +1,70 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+func TestContentFilter(t *testing.T) {
+	const body = `{
+		"id": "1",
+		"created": 1700000000,
+		"model": "gpt-35-turbo",
+		"prompt_filter_results": [
+			{"prompt_index": 0, "content_filter_results": {"hate": {"filtered": false, "severity": "safe"}, "jailbreak": {"filtered": false, "detected": false}}}
+		],
+		"choices": [
+			{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "Hello"},
+			 "content_filter_results": {"violence": {"filtered": false, "severity": "low"}}},
+			{"index": 1, "finish_reason": "content_filter", "message": {"role": "assistant", "content": ""},
+			 "content_filter_results": {"violence": {"filtered": true, "severity": "high"}}}
+		]
+	}`
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	chats, err := c.Call(context.Background(), []SendMsg{{Role: User, Content: "hi"}})
+
+	var filtered errors.ContentFiltered
+	if !errors.As(err, &filtered) {
+		t.Fatalf("TestContentFilter: got err == %v, want errors.ContentFiltered", err)
+	}
+	if len(filtered.Choices) != 1 || filtered.Choices[0] != 1 {
+		t.Errorf("TestContentFilter: got filtered choices %v, want [1]", filtered.Choices)
+	}
+	if len(chats.Text) != 2 || chats.Text[0] != "Hello" {
+		t.Errorf("TestContentFilter: got text %q, want the response returned with the error", chats.Text)
+	}
+	if len(chats.ContentFilters) != 2 {
+		t.Fatalf("TestContentFilter: got %d content filters, want 2", len(chats.ContentFilters))
+	}
+	if chats.ContentFilters[0].Filtered() {
+		t.Errorf("TestContentFilter: got choice 0 filtered, want not filtered")
+	}
+	if v := chats.ContentFilters[1].Violence; v == nil || !v.Filtered || v.Severity != custom.High {
+		t.Errorf("TestContentFilter: got choice 1 violence %+v, want filtered with high severity", v)
+	}
+	if len(chats.PromptFilters) != 1 || chats.PromptFilters[0].ContentFilterResults.Jailbreak == nil {
+		t.Errorf("TestContentFilter: got prompt filters %+v, want the jailbreak result", chats.PromptFilters)
+	}
+}
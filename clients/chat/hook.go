@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+)
+
+// HookCall is the part of a call that a Hook can change.
+type HookCall struct {
+	// Messages are the messages of the call. The system prompt from SetPrompts() has not been
+	// added yet, and is not added if the Hook adds one.
+	Messages []SendMsg
+	// Params are the CallParams of the call.
+	Params CallParams
+}
+
+// Hook lets another package, such as profiles, take part in a call without the client depending
+// on it. It is called before anything else is done with the call and can change it. If it
+// returns a non-nil func, Call() calls that with the result of a call that succeeded before
+// returning it. Stream() does not call it. An error from either is returned by the call.
+type Hook func(ctx context.Context, call *HookCall) (after func(ctx context.Context, result Chats) error, err error)
+
+// WithHook adds h to the call. Hooks are called in the order they were added.
+func WithHook(h Hook) CallOption {
+	return func(o *callOptions) error {
+		if h == nil {
+			return fmt.Errorf("WithHook: hook cannot be nil")
+		}
+		o.Hooks = append(o.Hooks, h)
+		return nil
+	}
+}
+
+// runHooks calls the hooks in opts with messages and opts.CallParams and returns the messages
+// they changed. opts.CallParams is updated and the funcs the hooks returned are added to opts.after.
+func runHooks(ctx context.Context, opts *callOptions, messages []SendMsg) ([]SendMsg, error) {
+	if len(opts.Hooks) == 0 {
+		return messages, nil
+	}
+	call := &HookCall{Messages: messages, Params: opts.CallParams}
+	for _, h := range opts.Hooks {
+		after, err := h(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+		if after != nil {
+			opts.after = append(opts.after, after)
+		}
+	}
+	opts.CallParams = call.Params
+	return call.Messages, nil
+}
+
+// runAfter calls the funcs the hooks returned with the result of a call.
+func runAfter(ctx context.Context, opts callOptions, result Chats) error {
+	for _, f := range opts.after {
+		if err := f(ctx, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
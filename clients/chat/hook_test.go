@@ -0,0 +1,109 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+func TestWithHook(t *testing.T) {
+	var sent struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+		MaxTokens int `json:"max_tokens"`
+	}
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)),
+			Request:    req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	afterErr := errors.New("after failed")
+	tests := []struct {
+		desc      string
+		hook      Hook
+		wantErr   error
+		wantAfter bool
+	}{
+		{
+			desc: "changes the call",
+			hook: func(ctx context.Context, call *HookCall) (func(ctx context.Context, result Chats) error, error) {
+				call.Params.MaxTokens = 42
+				call.Messages = append([]SendMsg{{Role: System, Content: "hooked"}}, call.Messages...)
+				return nil, nil
+			},
+		},
+		{
+			desc: "after",
+			hook: func(ctx context.Context, call *HookCall) (func(ctx context.Context, result Chats) error, error) {
+				return func(ctx context.Context, result Chats) error { return nil }, nil
+			},
+			wantAfter: true,
+		},
+		{
+			desc: "after error",
+			hook: func(ctx context.Context, call *HookCall) (func(ctx context.Context, result Chats) error, error) {
+				return func(ctx context.Context, result Chats) error { return afterErr }, nil
+			},
+			wantErr: afterErr,
+		},
+	}
+
+	for _, test := range tests {
+		var called bool
+		hook := func(ctx context.Context, call *HookCall) (func(ctx context.Context, result Chats) error, error) {
+			after, err := test.hook(ctx, call)
+			if after == nil {
+				return nil, err
+			}
+			return func(ctx context.Context, result Chats) error {
+				called = true
+				if result.Text[0] != "Hi" {
+					t.Errorf("TestWithHook(%s): got result text %q, want Hi", test.desc, result.Text[0])
+				}
+				return after(ctx, result)
+			}, err
+		}
+
+		_, err := c.Call(context.Background(), []SendMsg{{Role: User, Content: "hello"}}, WithHook(hook))
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("TestWithHook(%s): got err == %v, want err == %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if test.wantErr != nil {
+			continue
+		}
+		if called != test.wantAfter {
+			t.Errorf("TestWithHook(%s): got after called == %v, want %v", test.desc, called, test.wantAfter)
+		}
+		if test.desc == "changes the call" {
+			if sent.MaxTokens != 42 || len(sent.Messages) != 2 || sent.Messages[0].Content != "hooked" {
+				t.Errorf("TestWithHook(%s): got request %+v, want the hook's changes", test.desc, sent)
+			}
+		}
+	}
+
+	if _, err := c.Call(context.Background(), []SendMsg{{Role: User, Content: "hello"}}, WithHook(nil)); err == nil {
+		t.Errorf("TestWithHook(nil hook): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+// ContentPart is a part of a message with multiple parts, such as text and images. See SendMsg.Parts.
+type ContentPart = chat.ContentPart
+
+// ImageURL is an image in a ContentPart.
+type ImageURL = chat.ImageURL
+
+// ImageDetail is the level of detail the model uses to view an image.
+type ImageDetail = chat.ImageDetail
+
+const (
+	// AutoDetail lets the model choose the level of detail. This is the default.
+	AutoDetail = chat.AutoDetail
+	// LowDetail views a low resolution version of the image, which uses fewer tokens.
+	LowDetail = chat.LowDetail
+	// HighDetail views the image at high resolution.
+	HighDetail = chat.HighDetail
+)
+
+// maxImageSize is the largest image we will read, the service limit is 20 MiB.
+const maxImageSize = 20 << 20
+
+// TextPart returns a ContentPart holding text.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: chat.TextPart, Text: text}
+}
+
+// ImagePart returns a ContentPart holding the image at url. The model must be able to download it.
+func ImagePart(url string, detail ImageDetail) ContentPart {
+	return ContentPart{Type: chat.ImagePart, ImageURL: &ImageURL{URL: url, Detail: detail}}
+}
+
+// ImageFromReader returns a ContentPart holding the image read from r, sent as a base64 data URL.
+// The image type is detected from its content and must be a PNG, JPEG, GIF or WEBP.
+func ImageFromReader(r io.Reader, detail ImageDetail) (ContentPart, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxImageSize+1))
+	if err != nil {
+		return ContentPart{}, fmt.Errorf("problem reading image: %w", err)
+	}
+	if len(b) > maxImageSize {
+		return ContentPart{}, fmt.Errorf("image is larger than %d bytes", maxImageSize)
+	}
+
+	mime := http.DetectContentType(b)
+	switch mime {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return ContentPart{}, fmt.Errorf("unsupported image type %q", mime)
+	}
+	return ImagePart("data:"+mime+";base64,"+base64.StdEncoding.EncodeToString(b), detail), nil
+}
+
+// ImageFromFile returns a ContentPart holding the image in the file at path. See ImageFromReader().
+func ImageFromFile(path string, detail ImageDetail) (ContentPart, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ContentPart{}, err
+	}
+	defer f.Close()
+
+	return ImageFromReader(f, detail)
+}
+
+// validateParts returns an error if a message has invalid Parts. Images and audio can only be sent by the User.
+func validateParts(messages []SendMsg) error {
+	for i, m := range messages {
+		for j, p := range m.Parts {
+			if err := p.Validate(); err != nil {
+				return fmt.Errorf("message %d: part %d: %w", i, j, err)
+			}
+			if (p.Type == chat.ImagePart || p.Type == chat.InputAudioPart) && m.Role != User {
+				return fmt.Errorf("message %d: part %d: images and audio can only be sent in %q messages", i, j, User)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+// pngHeader is enough of a PNG for content detection.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestSendMsgJSON(t *testing.T) {
+	img, err := ImageFromReader(bytes.NewReader(pngHeader), LowDetail)
+	if err != nil {
+		t.Fatalf("TestSendMsgJSON: got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		desc string
+		msg  SendMsg
+		want string
+	}{
+		{
+			desc: "String content",
+			msg:  SendMsg{Role: User, Content: "hi"},
+			want: `{"role":"user","content":"hi"}`,
+		},
+		{
+			desc: "Content parts",
+			msg:  SendMsg{Role: User, Content: "ignored", Parts: []ContentPart{TextPart("what is this?"), ImagePart("https://example.com/a.png", HighDetail)}},
+			want: `{"role":"user","content":[{"type":"text","text":"what is this?"},{"type":"image_url","image_url":{"url":"https://example.com/a.png","detail":"high"}}]}`,
+		},
+		{
+			desc: "Image from reader",
+			msg:  SendMsg{Role: User, Parts: []ContentPart{img}},
+			want: `{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgoAAAANSUhEUg==","detail":"low"}}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		b, err := json.Marshal(test.msg.toSendMsg())
+		if err != nil {
+			t.Errorf("TestSendMsgJSON(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		}
+		if string(b) != test.want {
+			t.Errorf("TestSendMsgJSON(%s): got %s, want %s", test.desc, b, test.want)
+		}
+
+		var got chat.SendMsg
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Errorf("TestSendMsgJSON(%s): got unmarshal err == %s, want err == nil", test.desc, err)
+			continue
+		}
+		if len(got.Parts) != len(test.msg.Parts) {
+			t.Errorf("TestSendMsgJSON(%s): got %d parts after unmarshal, want %d", test.desc, len(got.Parts), len(test.msg.Parts))
+		}
+	}
+}
+
+func TestImageFromReaderUnsupported(t *testing.T) {
+	if _, err := ImageFromReader(strings.NewReader("not an image"), AutoDetail); err == nil {
+		t.Errorf("TestImageFromReaderUnsupported: got err == nil, want err != nil")
+	}
+}
+
+func TestValidateParts(t *testing.T) {
+	tests := []struct {
+		desc    string
+		msg     SendMsg
+		wantErr bool
+	}{
+		{desc: "User image", msg: SendMsg{Role: User, Parts: []ContentPart{ImagePart("https://example.com/a.png", "")}}},
+		{desc: "System text", msg: SendMsg{Role: System, Parts: []ContentPart{TextPart("be brief")}}},
+		{desc: "System image", msg: SendMsg{Role: System, Parts: []ContentPart{ImagePart("https://example.com/a.png", "")}}, wantErr: true},
+		{desc: "Image without URL", msg: SendMsg{Role: User, Parts: []ContentPart{ImagePart("", "")}}, wantErr: true},
+		{desc: "Bad detail", msg: SendMsg{Role: User, Parts: []ContentPart{ImagePart("https://example.com/a.png", "ultra")}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := validateParts([]SendMsg{test.msg})
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestValidateParts(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestValidateParts(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+}
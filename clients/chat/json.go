@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+// JSONSchema is a JSON schema the model output must match. See WithJSONResponse().
+type JSONSchema = chat.JSONSchema
+
+// JSONError is returned by Call() when WithJSONResponse() is used and a choice is not valid
+// JSON or cannot be unmarshaled into the value passed to WithJSONResponse().
+type JSONError struct {
+	// Choice is the index of the choice.
+	Choice int
+	// Text is the text of the choice.
+	Text string
+	// Err is the error from decoding the text.
+	Err error
+}
+
+// Error implements error.
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("choice %d is not valid JSON output: %s", e.Choice, e.Err)
+}
+
+// Unwrap implements errors.Unwrap().
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// jsonResponse implements WithJSONResponse().
+type jsonResponse struct {
+	format chat.ResponseFormat
+	out    any
+}
+
+// WithJSONResponse constrains the output of the call to JSON. If schema is nil, this uses JSON
+// mode, which requires the messages to ask for JSON. Otherwise the output must match schema, which
+// can be a JSONSchema, a JSON schema document as a string, []byte or json.RawMessage, or a value
+// that marshals to one, such as a map[string]any. Schemas require an API version that supports
+// them, see WithAPIVersion().
+//
+// Call() checks each choice is valid JSON and, if out is not nil, unmarshals the first choice
+// into out, which must be a pointer. If either fails, Call() returns the Chats with a *JSONError.
+// Stream() sets the format, but does not check the output.
+func WithJSONResponse(schema any, out any) CallOption {
+	return func(o *callOptions) error {
+		if out != nil {
+			if v := reflect.ValueOf(out); v.Kind() != reflect.Pointer || v.IsNil() {
+				return fmt.Errorf("WithJSONResponse(): out must be a non-nil pointer, was %T", out)
+			}
+		}
+
+		format, err := responseFormat(schema)
+		if err != nil {
+			return fmt.Errorf("WithJSONResponse(): %w", err)
+		}
+		if err := format.Validate(); err != nil {
+			return fmt.Errorf("WithJSONResponse(): %w", err)
+		}
+		o.JSON = &jsonResponse{format: format, out: out}
+		return nil
+	}
+}
+
+func responseFormat(schema any) (chat.ResponseFormat, error) {
+	var raw json.RawMessage
+	switch s := schema.(type) {
+	case nil:
+		return chat.ResponseFormat{Type: chat.JSONObjectFormat}, nil
+	case JSONSchema:
+		return chat.ResponseFormat{Type: chat.JSONSchemaFormat, JSONSchema: &s}, nil
+	case *JSONSchema:
+		return chat.ResponseFormat{Type: chat.JSONSchemaFormat, JSONSchema: s}, nil
+	case json.RawMessage:
+		raw = s
+	case []byte:
+		raw = s
+	case string:
+		raw = json.RawMessage(s)
+	default:
+		b, err := json.Marshal(schema)
+		if err != nil {
+			return chat.ResponseFormat{}, fmt.Errorf("problem marshaling the schema: %w", err)
+		}
+		raw = b
+	}
+	return chat.ResponseFormat{
+		Type:       chat.JSONSchemaFormat,
+		JSONSchema: &JSONSchema{Name: "response", Schema: raw},
+	}, nil
+}
+
+// check checks the text of each choice is JSON and unmarshals the first choice into j.out.
+func (j *jsonResponse) check(text []string) error {
+	for i, t := range text {
+		if !json.Valid([]byte(t)) {
+			return &JSONError{Choice: i, Text: t, Err: fmt.Errorf("invalid JSON")}
+		}
+	}
+	if j.out == nil || len(text) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(text[0]), j.out); err != nil {
+		return &JSONError{Choice: 0, Text: text[0], Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+func TestJSONResponse(t *testing.T) {
+	tests := []struct {
+		desc       string
+		schema     any
+		content    string
+		wantFormat chat.ResponseFormatType
+		wantName   string
+		wantErr    bool
+	}{
+		{
+			desc:       "JSON mode",
+			content:    `{\"name\": \"gopher\", \"age\": 14}`,
+			wantFormat: chat.JSONObjectFormat,
+			wantName:   "gopher",
+		},
+		{
+			desc:       "schema from a map",
+			schema:     map[string]any{"type": "object"},
+			content:    `{\"name\": \"gopher\"}`,
+			wantFormat: chat.JSONSchemaFormat,
+			wantName:   "gopher",
+		},
+		{
+			desc:       "invalid output",
+			content:    `{\"name\": `,
+			wantFormat: chat.JSONObjectFormat,
+			wantErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		var gotFormat chat.ResponseFormatType
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var in chat.Req
+			if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+				return nil, err
+			}
+			if in.ResponseFormat != nil {
+				gotFormat = in.ResponseFormat.Type
+			}
+			body := `{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"` + test.content + `"}}]}`
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+		})
+		rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New("deployment", rc)
+
+		var out struct {
+			Name string `json:"name"`
+		}
+		_, err = c.Call(context.Background(), []SendMsg{{Role: User, Content: "hi"}}, WithJSONResponse(test.schema, &out))
+		switch {
+		case test.wantErr:
+			var jsonErr *JSONError
+			if !errors.As(err, &jsonErr) {
+				t.Errorf("TestJSONResponse(%s): got err == %v, want *JSONError", test.desc, err)
+			}
+			continue
+		case err != nil:
+			t.Errorf("TestJSONResponse(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		}
+		if gotFormat != test.wantFormat {
+			t.Errorf("TestJSONResponse(%s): got format %q, want %q", test.desc, gotFormat, test.wantFormat)
+		}
+		if out.Name != test.wantName {
+			t.Errorf("TestJSONResponse(%s): got name %q, want %q", test.desc, out.Name, test.wantName)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package chat
+
+import (
+	"fmt"
+)
+
+// ParamsBuilder builds a CallParams. A ParamsBuilder is immutable, each method returns a new
+// ParamsBuilder, so a partially built ParamsBuilder can be shared between goroutines and used
+// as a base for other ParamsBuilders. Use Builder() to create one.
+type ParamsBuilder struct {
+	p CallParams
+}
+
+// Builder returns a ParamsBuilder that starts with the default CallParams. This is the preferred
+// way to create CallParams, as it avoids mistakes with zero values and validates the result:
+//
+//	params, err := chat.Builder().Temperature(0.3).MaxTokens(256).Build()
+func Builder() ParamsBuilder {
+	return ParamsBuilder{p: CallParams{}.Defaults()}
+}
+
+// Stop sets CallParams.Stop.
+func (b ParamsBuilder) Stop(stop ...string) ParamsBuilder {
+	b.p.Stop = append([]string(nil), stop...)
+	return b
+}
+
+// LogitBias sets CallParams.LogitBias.
+func (b ParamsBuilder) LogitBias(bias map[string]float64) ParamsBuilder {
+	m := make(map[string]float64, len(bias))
+	for k, v := range bias {
+		m[k] = v
+	}
+	b.p.LogitBias = m
+	return b
+}
+
+// User sets CallParams.User.
+func (b ParamsBuilder) User(user string) ParamsBuilder {
+	b.p.User = user
+	return b
+}
+
+// N sets CallParams.N.
+func (b ParamsBuilder) N(n int) ParamsBuilder {
+	b.p.N = n
+	return b
+}
+
+// MaxTokens sets CallParams.MaxTokens.
+func (b ParamsBuilder) MaxTokens(n int) ParamsBuilder {
+	b.p.MaxTokens = n
+	return b
+}
+
+// MaxCompletionTokens sets CallParams.MaxCompletionTokens.
+func (b ParamsBuilder) MaxCompletionTokens(n int) ParamsBuilder {
+	b.p.MaxCompletionTokens = n
+	return b
+}
+
+// ReasoningEffort sets CallParams.ReasoningEffort.
+func (b ParamsBuilder) ReasoningEffort(effort ReasoningEffort) ParamsBuilder {
+	b.p.ReasoningEffort = effort
+	return b
+}
+
+// Temperature sets CallParams.Temperature.
+func (b ParamsBuilder) Temperature(t float64) ParamsBuilder {
+	b.p.Temperature = t
+	return b
+}
+
+// TopP sets CallParams.TopP.
+func (b ParamsBuilder) TopP(p float64) ParamsBuilder {
+	b.p.TopP = p
+	return b
+}
+
+// PresencePenalty sets CallParams.PresencePenalty.
+func (b ParamsBuilder) PresencePenalty(p float64) ParamsBuilder {
+	b.p.PresencePenalty = p
+	return b
+}
+
+// FrequencyPenalty sets CallParams.FrequencyPenalty.
+func (b ParamsBuilder) FrequencyPenalty(p float64) ParamsBuilder {
+	b.p.FrequencyPenalty = p
+	return b
+}
+
+// Store sets CallParams.Store.
+func (b ParamsBuilder) Store(store bool) ParamsBuilder {
+	b.p.Store = store
+	return b
+}
+
+// Metadata sets CallParams.Metadata.
+func (b ParamsBuilder) Metadata(metadata map[string]string) ParamsBuilder {
+	m := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		m[k] = v
+	}
+	b.p.Metadata = m
+	return b
+}
+
+// Build validates and returns the CallParams.
+func (b ParamsBuilder) Build() (CallParams, error) {
+	if err := b.p.Validate(); err != nil {
+		return CallParams{}, err
+	}
+	// Copy so the returned CallParams can't change the ParamsBuilder.
+	return b.Stop(b.p.Stop...).LogitBias(b.p.LogitBias).Metadata(b.p.Metadata).p, nil
+}
+
+// Validate returns an error if the CallParams are not valid. Build() does this, so it only needs
+// to be called for CallParams that are not made with Builder(), such as ones decoded from a file.
+func (c CallParams) Validate() error {
+	if c.N < 1 || c.N > 128 {
+		return fmt.Errorf("N must be between 1 and 128, was %d", c.N)
+	}
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("MaxTokens cannot be < 0")
+	}
+	if c.MaxCompletionTokens < 0 {
+		return fmt.Errorf("MaxCompletionTokens cannot be < 0")
+	}
+	switch c.ReasoningEffort {
+	case UnknownEffort, LowEffort, MediumEffort, HighEffort:
+	default:
+		return fmt.Errorf("ReasoningEffort %q is not valid", c.ReasoningEffort)
+	}
+	if c.Temperature < 0 || c.Temperature > 2 {
+		return fmt.Errorf("Temperature must be between 0 and 2, was %v", c.Temperature)
+	}
+	if c.TopP < 0 || c.TopP > 1 {
+		return fmt.Errorf("TopP must be between 0 and 1, was %v", c.TopP)
+	}
+	if c.PresencePenalty < -2 || c.PresencePenalty > 2 {
+		return fmt.Errorf("PresencePenalty must be between -2 and 2, was %v", c.PresencePenalty)
+	}
+	if c.FrequencyPenalty < -2 || c.FrequencyPenalty > 2 {
+		return fmt.Errorf("FrequencyPenalty must be between -2 and 2, was %v", c.FrequencyPenalty)
+	}
+	if len(c.Stop) > 4 {
+		return fmt.Errorf("Stop cannot have more than 4 entries")
+	}
+	for k, v := range c.LogitBias {
+		if v < -100 || v > 100 {
+			return fmt.Errorf("LogitBias[%s] must be between -100 and 100, was %v", k, v)
+		}
+	}
+	if len(c.Metadata) > 16 {
+		return fmt.Errorf("Metadata cannot have more than 16 entries")
+	}
+	for k, v := range c.Metadata {
+		if len(k) > 64 {
+			return fmt.Errorf("Metadata key %q cannot be longer than 64 characters", k)
+		}
+		if len(v) > 512 {
+			return fmt.Errorf("Metadata[%s] cannot be longer than 512 characters", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		desc    string
+		b       ParamsBuilder
+		wantErr bool
+	}{
+		{desc: "Defaults", b: Builder()},
+		{
+			desc: "Valid",
+			b: Builder().Stop("a", "b").LogitBias(map[string]float64{"1": -100}).User("u").N(2).MaxTokens(10).
+				MaxCompletionTokens(20).ReasoningEffort(HighEffort).Temperature(2).TopP(0).PresencePenalty(-2).FrequencyPenalty(2),
+		},
+		{desc: "N is 0", b: Builder().N(0), wantErr: true},
+		{desc: "N above 128", b: Builder().N(129), wantErr: true},
+		{desc: "Negative MaxTokens", b: Builder().MaxTokens(-1), wantErr: true},
+		{desc: "Negative MaxCompletionTokens", b: Builder().MaxCompletionTokens(-1), wantErr: true},
+		{desc: "Unknown ReasoningEffort", b: Builder().ReasoningEffort("extreme"), wantErr: true},
+		{desc: "Temperature above 2", b: Builder().Temperature(2.1), wantErr: true},
+		{desc: "Negative Temperature", b: Builder().Temperature(-0.1), wantErr: true},
+		{desc: "TopP above 1", b: Builder().TopP(1.1), wantErr: true},
+		{desc: "PresencePenalty above 2", b: Builder().PresencePenalty(2.1), wantErr: true},
+		{desc: "FrequencyPenalty below -2", b: Builder().FrequencyPenalty(-2.1), wantErr: true},
+		{desc: "Too many Stop sequences", b: Builder().Stop("a", "b", "c", "d", "e"), wantErr: true},
+		{desc: "LogitBias out of range", b: Builder().LogitBias(map[string]float64{"1": 101}), wantErr: true},
+	}
+
+	for _, test := range tests {
+		params, err := test.b.Build()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if err := params.Validate(); err != nil {
+			t.Errorf("TestBuilder(%s): built CallParams failed Validate(): %s", test.desc, err)
+		}
+	}
+}
+
+func TestBuilderImmutable(t *testing.T) {
+	stop := []string{"a"}
+	base := Builder().Stop(stop...).Temperature(0.5)
+	stop[0] = "changed"
+
+	derived := base.Temperature(1).Stop("b")
+	p, err := base.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Temperature != 0.5 || p.Stop[0] != "a" {
+		t.Errorf("TestBuilderImmutable: got base Temperature %v and Stop %v, want 0.5 and [a]", p.Temperature, p.Stop)
+	}
+	p.Stop[0] = "changed"
+	if p, _ := base.Build(); p.Stop[0] != "a" {
+		t.Errorf("TestBuilderImmutable: changing a built CallParams changed the ParamsBuilder")
+	}
+	if p, _ := derived.Build(); p.Temperature != 1 || p.Stop[0] != "b" {
+		t.Errorf("TestBuilderImmutable: got derived Temperature %v and Stop %v, want 1 and [b]", p.Temperature, p.Stop)
+	}
+}
+
+func TestToPromptRequestPenalties(t *testing.T) {
+	params, err := Builder().PresencePenalty(0.5).FrequencyPenalty(-1.5).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := params.toPromptRequest()
+	if req.PresencePenalty != 0.5 || req.FrequencyPenalty != -1.5 {
+		t.Errorf("TestToPromptRequestPenalties: got PresencePenalty %v and FrequencyPenalty %v, want 0.5 and -1.5", req.PresencePenalty, req.FrequencyPenalty)
+	}
+}
+
+func TestBuilderMetadata(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i < 17; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+
+	tests := []struct {
+		desc     string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{desc: "Valid", metadata: map[string]string{"app": "support-bot", "version": "3"}},
+		{desc: "Too many entries", metadata: tooMany, wantErr: true},
+		{desc: "Key too long", metadata: map[string]string{strings.Repeat("k", 65): "v"}, wantErr: true},
+		{desc: "Value too long", metadata: map[string]string{"k": strings.Repeat("v", 513)}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		params, err := Builder().Store(true).Metadata(test.metadata).Build()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestBuilderMetadata(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestBuilderMetadata(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		b, err := json.Marshal(params.toPromptRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `"store":true,"metadata":{"app":"support-bot","version":"3"}`
+		if !strings.Contains(string(b), want) {
+			t.Errorf("TestBuilderMetadata(%s): got request %s, want it to contain %s", test.desc, b, want)
+		}
+	}
+}
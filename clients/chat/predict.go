@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+// CompletionTokensDetails breaks down the completion tokens of a call, including how many tokens of a
+// prediction were accepted. See Usage.CompletionTokensDetails.
+type CompletionTokensDetails = chat.CompletionTokensDetails
+
+// WithPrediction sets content the response is expected to mostly match, such as the previous
+// version of a document being edited. Tokens that match the prediction are generated much faster,
+// but rejected prediction tokens are billed as completion tokens. Usage.CompletionTokensDetails
+// reports how much of the prediction was used. This cannot be used with CallParams.N > 1 and
+// requires an API version of at least 2025-01-01-preview, see WithAPIVersion().
+func WithPrediction(content string) CallOption {
+	return func(o *callOptions) error {
+		if content == "" {
+			return fmt.Errorf("WithPrediction: content cannot be empty")
+		}
+		o.Prediction = &chat.Prediction{Type: chat.ContentPrediction, Content: content}
+		return nil
+	}
+}
+
+// Edit asks the model to change document as described by instructions and returns the new version
+// of the document. document is passed as the prediction with WithPrediction(), as most of it is
+// usually unchanged, which reduces latency. Instructions should ask for the full document in return,
+// such as "Rename the Username property to Email. Respond only with the code."
+func (c *Client) Edit(ctx context.Context, document, instructions string, options ...CallOption) (Chats, error) {
+	messages := []SendMsg{
+		{Role: User, Content: instructions},
+		{Role: User, Content: document},
+	}
+	options = append(options[:len(options):len(options)], WithPrediction(document))
+	return c.Call(ctx, messages, options...)
+}
@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+func TestEdit(t *testing.T) {
+	const doc = "type User struct {\n\tUsername string\n}"
+
+	var got chat.Req
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body: io.NopCloser(strings.NewReader(`{
+				"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "type User struct {\n\tEmail string\n}"}}],
+				"usage": {"prompt_tokens": 20, "completion_tokens": 12, "total_tokens": 32,
+					"completion_tokens_details": {"accepted_prediction_tokens": 10, "rejected_prediction_tokens": 1}}
+			}`)),
+			Request: req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	chats, err := c.Edit(context.Background(), doc, "Rename Username to Email. Respond only with the code.")
+	if err != nil {
+		t.Fatalf("TestEdit: got err == %s, want err == nil", err)
+	}
+	if got.Prediction == nil || got.Prediction.Type != chat.ContentPrediction || got.Prediction.Content != doc {
+		t.Errorf("TestEdit: got prediction %+v, want the document", got.Prediction)
+	}
+	if len(got.Messages) != 2 || got.Messages[1].Content != doc {
+		t.Errorf("TestEdit: got messages %+v, want the instructions then the document", got.Messages)
+	}
+	if d := chats.Usage.CompletionTokensDetails; d == nil || d.AcceptedPredictionTokens != 10 || d.RejectedPredictionTokens != 1 {
+		t.Errorf("TestEdit: got completion token details %+v, want 10 accepted and 1 rejected", d)
+	}
+
+	params, err := Builder().N(2).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Edit(context.Background(), doc, "Rename Username to Email.", WithCallParams(params)); err == nil {
+		t.Errorf("TestEdit(N > 1): got err == nil, want err != nil")
+	}
+}
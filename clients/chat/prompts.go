@@ -0,0 +1,210 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Prompts is a set of system prompts registered per locale. When a Prompts is set on a Client
+// with SetPrompts(), the Client will prepend the system prompt for the locale of the call to
+// the messages that are sent. Locales are resolved using a fallback chain, so "fr-CA" will
+// try "fr-CA", then "fr" and finally the default locale. Prompts is safe for concurrent use.
+//
+// Prompts registered with Register() are sent as is. Prompts registered with RegisterTemplate()
+// are text/template templates that are rendered with the result of the VarsFunc set with
+// SetVars() on every call, so values like the current date never go stale.
+type Prompts struct {
+	defLocale string
+
+	mu      sync.RWMutex // Protects prompts and vars
+	prompts map[string]prompt
+	vars    VarsFunc
+}
+
+// prompt is a registered system prompt. tmpl is set if it was registered as a template.
+type prompt struct {
+	text string
+	tmpl *template.Template
+}
+
+// VarsFunc returns the variables used to render a system prompt template for a call, such as
+// the current date or the user's name. It is called on every call that uses a template.
+type VarsFunc func(ctx context.Context) (map[string]any, error)
+
+// NewPrompts creates a new Prompts. defaultLocale is the locale that is used when a locale
+// is not provided or no prompt for a locale or its parents are registered, such as "en".
+func NewPrompts(defaultLocale string) *Prompts {
+	return &Prompts{
+		defLocale: normLocale(defaultLocale),
+		prompts:   map[string]prompt{},
+	}
+}
+
+// Register registers the system prompt for the locale. This will replace an existing
+// prompt for the same locale. The prompt is sent as is, use RegisterTemplate() for a
+// prompt with variables.
+func (p *Prompts) Register(locale, prompt string) error {
+	return p.register(locale, prompt, nil)
+}
+
+// RegisterTemplate registers a system prompt for the locale that is a text/template, with
+// variables referenced like {{.Date}}. It is rendered with the variables from the VarsFunc on
+// every call, and a variable that is missing is an error. This will replace an existing
+// prompt for the same locale.
+func (p *Prompts) RegisterTemplate(locale, tmpl string) error {
+	t, err := template.New(normLocale(locale)).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("problem parsing prompt for locale %q: %w", normLocale(locale), err)
+	}
+	return p.register(locale, tmpl, t)
+}
+
+func (p *Prompts) register(locale, text string, t *template.Template) error {
+	locale = normLocale(locale)
+	if locale == "" {
+		return fmt.Errorf("locale cannot be empty")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompts[locale] = prompt{text: text, tmpl: t}
+	return nil
+}
+
+// SetVars sets the VarsFunc used to render the prompts registered with RegisterTemplate().
+// Pass nil to remove it.
+func (p *Prompts) SetVars(f VarsFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.vars = f
+}
+
+// Lookup returns the system prompt for locale, following the fallback chain, as it was
+// registered. Templates are not rendered, use Render() for that. If no prompt can be found,
+// it returns false.
+func (p *Prompts) Lookup(locale string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pr, ok := p.find(locale)
+	return pr.text, ok
+}
+
+// Render returns the system prompt for locale, following the fallback chain. Prompts
+// registered with RegisterTemplate() are rendered with the variables from the VarsFunc. If no
+// prompt can be found, it returns false.
+func (p *Prompts) Render(ctx context.Context, locale string) (string, bool, error) {
+	p.mu.RLock()
+	pr, ok := p.find(locale)
+	vars := p.vars
+	p.mu.RUnlock()
+
+	if !ok {
+		return "", false, nil
+	}
+	if pr.tmpl == nil {
+		return pr.text, true, nil
+	}
+
+	var data map[string]any
+	if vars != nil {
+		var err error
+		data, err = vars(ctx)
+		if err != nil {
+			return "", false, fmt.Errorf("problem getting prompt variables: %w", err)
+		}
+	}
+
+	b := &strings.Builder{}
+	if err := pr.tmpl.Execute(b, data); err != nil {
+		return "", false, fmt.Errorf("problem rendering prompt: %w", err)
+	}
+	return b.String(), true, nil
+}
+
+// find returns the prompt for the locale. p.mu must be held.
+func (p *Prompts) find(locale string) (prompt, bool) {
+	for _, l := range fallbacks(normLocale(locale)) {
+		if pr, ok := p.prompts[l]; ok {
+			return pr, true
+		}
+	}
+	pr, ok := p.prompts[p.defLocale]
+	return pr, ok
+}
+
+// normLocale normalizes locales such as "fr_CA" to "fr-ca".
+func normLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	locale = strings.ReplaceAll(locale, "_", "-")
+	return strings.ToLower(locale)
+}
+
+// fallbacks returns the fallback chain for a normalized locale. "zh-hant-tw" will return
+// []string{"zh-hant-tw", "zh-hant", "zh"}.
+func fallbacks(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+	chain := []string{locale}
+	for {
+		i := strings.LastIndex(locale, "-")
+		if i < 1 {
+			return chain
+		}
+		locale = locale[:i]
+		chain = append(chain, locale)
+	}
+}
+
+type localeKey struct{}
+
+// ContextWithLocale returns a new context that carries the locale used to select a system
+// prompt from the Prompts set on a Client. WithLocale() overrides this on a per-call basis.
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored with ContextWithLocale().
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	l, ok := ctx.Value(localeKey{}).(string)
+	return l, ok
+}
+
+// SetPrompts sets the locale specific system prompts for the client. Pass nil to remove them.
+func (c *Client) SetPrompts(p *Prompts) {
+	c.prompts.Store(p)
+}
+
+// systemPrompt prepends the locale specific system prompt if the client has Prompts and the
+// messages do not already start with a system or developer message.
+func (c *Client) systemPrompt(ctx context.Context, opts callOptions, messages []SendMsg) ([]SendMsg, error) {
+	hasSystem := len(messages) > 0 && (messages[0].Role == System || messages[0].Role == Developer)
+	p := c.prompts.Load()
+	if p == nil || hasSystem {
+		return messages, nil
+	}
+
+	locale := opts.Locale
+	if locale == "" {
+		locale, _ = LocaleFromContext(ctx)
+	}
+	prompt, ok, err := p.Render(ctx, locale)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return messages, nil
+	}
+	return prepend(prompt, messages), nil
+}
+
+// prepend returns messages with a system message holding prompt before them.
+func prepend(prompt string, messages []SendMsg) []SendMsg {
+	n := make([]SendMsg, 0, len(messages)+1)
+	n = append(n, SendMsg{Role: System, Content: prompt})
+	return append(n, messages...)
+}
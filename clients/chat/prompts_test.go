@@ -0,0 +1,207 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSystemPromptLocale(t *testing.T) {
+	var sent []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := struct {
+			Messages any `json:"messages"`
+		}{Messages: &sent}
+		sent = nil
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)),
+			Request:    req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full := NewPrompts("en")
+	full.Register("en", "english")
+	full.Register("fr", "french")
+	full.Register("fr-CA", "canadian french")
+	full.Register("zh-Hant", "traditional chinese")
+
+	noDefault := NewPrompts("en")
+	noDefault.Register("fr", "french")
+
+	tests := []struct {
+		desc       string
+		prompts    *Prompts
+		ctxLocale  string
+		optLocale  string
+		messages   []SendMsg
+		wantSystem string
+	}{
+		{desc: "Exact locale", prompts: full, ctxLocale: "fr-CA", wantSystem: "canadian french"},
+		{desc: "Exact locale with underscore and case", prompts: full, ctxLocale: "FR_ca", wantSystem: "canadian french"},
+		{desc: "Falls back to the parent", prompts: full, ctxLocale: "fr-BE", wantSystem: "french"},
+		{desc: "Falls back through several parents", prompts: full, ctxLocale: "zh-Hant-TW", wantSystem: "traditional chinese"},
+		{desc: "Falls back to the default", prompts: full, ctxLocale: "de-DE", wantSystem: "english"},
+		{desc: "No locale uses the default", prompts: full, wantSystem: "english"},
+		{desc: "Option overrides the context", prompts: full, ctxLocale: "en", optLocale: "fr-CA", wantSystem: "canadian french"},
+		{desc: "Option without a context", prompts: full, optLocale: "fr", wantSystem: "french"},
+		{desc: "Unknown locale without a default", prompts: noDefault, ctxLocale: "de"},
+		{desc: "Known locale without a default", prompts: noDefault, ctxLocale: "fr-FR", wantSystem: "french"},
+		{
+			desc:      "Messages with a system prompt are not changed",
+			prompts:   full,
+			ctxLocale: "fr",
+			messages:  []SendMsg{{Role: System, Content: "mine"}, {Role: User, Content: "hi"}},
+		},
+	}
+
+	for _, test := range tests {
+		c := New("deployment", rc)
+		c.SetPrompts(test.prompts)
+
+		ctx := context.Background()
+		if test.ctxLocale != "" {
+			ctx = ContextWithLocale(ctx, test.ctxLocale)
+		}
+		var options []CallOption
+		if test.optLocale != "" {
+			options = append(options, WithLocale(test.optLocale))
+		}
+		messages := test.messages
+		if messages == nil {
+			messages = []SendMsg{{Role: User, Content: "hi"}}
+		}
+
+		if _, err := c.Call(ctx, messages, options...); err != nil {
+			t.Errorf("TestSystemPromptLocale(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		}
+
+		wantLen := len(messages)
+		if test.wantSystem != "" {
+			wantLen++
+		}
+		if len(sent) != wantLen {
+			t.Errorf("TestSystemPromptLocale(%s): got %d messages sent, want %d", test.desc, len(sent), wantLen)
+			continue
+		}
+		if test.wantSystem == "" {
+			if sent[0].Content != messages[0].Content {
+				t.Errorf("TestSystemPromptLocale(%s): got first message %q, want %q", test.desc, sent[0].Content, messages[0].Content)
+			}
+			continue
+		}
+		if sent[0].Role != string(System) || sent[0].Content != test.wantSystem {
+			t.Errorf("TestSystemPromptLocale(%s): got first message %s %q, want system %q", test.desc, sent[0].Role, sent[0].Content, test.wantSystem)
+		}
+	}
+}
+
+func TestPromptsRender(t *testing.T) {
+	vars := func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"Date": "January 2, 2006"}, nil
+	}
+
+	tests := []struct {
+		desc     string
+		template bool
+		prompt   string
+		vars     VarsFunc
+		want     string
+		wantErr  bool
+	}{
+		{
+			desc:   "Literal prompt",
+			prompt: "You are a helpful assistant.",
+			want:   "You are a helpful assistant.",
+		},
+		{
+			desc:   "Literal prompt with braces",
+			prompt: "Reply with JSON like {{\"answer\": 1}} and keep {{.Date}} as is.",
+			vars:   vars,
+			want:   "Reply with JSON like {{\"answer\": 1}} and keep {{.Date}} as is.",
+		},
+		{
+			desc:     "Template",
+			template: true,
+			prompt:   "Today is {{.Date}}.",
+			vars:     vars,
+			want:     "Today is January 2, 2006.",
+		},
+		{
+			desc:     "Template with a missing variable",
+			template: true,
+			prompt:   "Hello {{.Name}}.",
+			vars:     vars,
+			wantErr:  true,
+		},
+		{
+			desc:     "Template without a VarsFunc",
+			template: true,
+			prompt:   "Today is {{.Date}}.",
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		p := NewPrompts("en")
+		p.SetVars(test.vars)
+		register := p.Register
+		if test.template {
+			register = p.RegisterTemplate
+		}
+		if err := register("en", test.prompt); err != nil {
+			t.Fatalf("TestPromptsRender(%s): got err == %s, want err == nil", test.desc, err)
+		}
+
+		got, ok, err := p.Render(context.Background(), "en")
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestPromptsRender(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestPromptsRender(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if !ok {
+			t.Errorf("TestPromptsRender(%s): got ok == false, want ok == true", test.desc)
+		}
+		if got != test.want {
+			t.Errorf("TestPromptsRender(%s): got %q, want %q", test.desc, got, test.want)
+		}
+
+		// Lookup returns the prompt as it was registered.
+		if got, _ := p.Lookup("en"); got != test.prompt {
+			t.Errorf("TestPromptsRender(%s): got Lookup() == %q, want %q", test.desc, got, test.prompt)
+		}
+	}
+
+	if err := NewPrompts("en").RegisterTemplate("en", "{{.Date"); err == nil {
+		t.Errorf("TestPromptsRender(bad template): got err == nil, want err != nil")
+	}
+}
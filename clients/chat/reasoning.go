@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/element-of-surprise/azopenai/models"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+// ReasoningEffort is how much a reasoning model reasons before responding. See CallParams.ReasoningEffort.
+type ReasoningEffort = chat.ReasoningEffort
+
+const (
+	// UnknownEffort uses the service default.
+	UnknownEffort = chat.UnknownEffort
+	// LowEffort favors speed and fewer reasoning tokens.
+	LowEffort = chat.LowEffort
+	// MediumEffort balances speed and reasoning. This is the service default.
+	MediumEffort = chat.MediumEffort
+	// HighEffort favors more complete reasoning.
+	HighEffort = chat.HighEffort
+)
+
+// adaptParams changes req to use the parameters model supports. For reasoning models, MaxTokens
+// is sent as MaxCompletionTokens and sampling parameters they reject are removed. ReasoningEffort
+// is removed for models that don't support it. If the model is not known, only MaxTokens is
+// removed when MaxCompletionTokens is set, as the service rejects both.
+func (c *Client) adaptParams(ctx context.Context, model string, req chat.Req) chat.Req {
+	if req.MaxCompletionTokens > 0 {
+		req.MaxTokens = 0
+	}
+
+	caps, ok := models.Lookup(model)
+	if model == "" || !ok {
+		return req
+	}
+
+	if !caps.ReasoningEffort {
+		req.ReasoningEffort = UnknownEffort
+	}
+	if !caps.Reasoning {
+		return req
+	}
+
+	if req.MaxTokens > 0 {
+		if c.rest != nil {
+			c.rest.Deprecated(ctx, rest.Deprecation{
+				API:     "chat.CallParams.MaxTokens with reasoning models",
+				Instead: "chat.CallParams.MaxCompletionTokens",
+			})
+		}
+		req.MaxCompletionTokens = req.MaxTokens
+		req.MaxTokens = 0
+	}
+	req.Temperature = 0
+	req.TopP = 0
+	req.PresencePenalty = 0
+	req.FrequencyPenalty = 0
+	req.LogitBias = nil
+	return req
+}
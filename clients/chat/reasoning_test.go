@@ -0,0 +1,93 @@
+package chat
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+func TestAdaptParams(t *testing.T) {
+	params := CallParams{}.Defaults()
+	params.ReasoningEffort = LowEffort
+	params.LogitBias = map[string]float64{"1": -100}
+
+	withCompletion := params
+	withCompletion.MaxCompletionTokens = 100
+
+	tests := []struct {
+		desc           string
+		model          string
+		params         CallParams
+		want           chat.Req
+		wantDeprecated bool
+	}{
+		{
+			desc:   "Unknown model",
+			model:  "my-model",
+			params: params,
+			want:   params.toPromptRequest(),
+		},
+		{
+			desc:   "Unknown model with MaxCompletionTokens",
+			params: withCompletion,
+			want: func() chat.Req {
+				r := withCompletion.toPromptRequest()
+				r.MaxTokens = 0
+				return r
+			}(),
+		},
+		{
+			desc:   "Model without reasoning",
+			model:  "gpt-4o",
+			params: params,
+			want: func() chat.Req {
+				r := params.toPromptRequest()
+				r.ReasoningEffort = UnknownEffort
+				return r
+			}(),
+		},
+		{
+			desc:   "Reasoning model",
+			model:  "o3-mini-2025-01-31",
+			params: params,
+			want: chat.Req{
+				N:                   1,
+				MaxCompletionTokens: params.MaxTokens,
+				ReasoningEffort:     LowEffort,
+			},
+			wantDeprecated: true,
+		},
+		{
+			desc:   "Reasoning model without ReasoningEffort",
+			model:  "o1-mini",
+			params: withCompletion,
+			want: chat.Req{
+				N:                   1,
+				MaxCompletionTokens: 100,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var deprecated []rest.Deprecation
+		r, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithDeprecationHandler(func(ctx context.Context, d rest.Deprecation) {
+			deprecated = append(deprecated, d)
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New("deployment", r)
+
+		got := c.adaptParams(context.Background(), test.model, test.params.toPromptRequest())
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TestAdaptParams(%s): got %+v, want %+v", test.desc, got, test.want)
+		}
+		if (len(deprecated) > 0) != test.wantDeprecated {
+			t.Errorf("TestAdaptParams(%s): got deprecations %v, want deprecation == %v", test.desc, deprecated, test.wantDeprecated)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// EmptyRetryPolicy retries calls where the model stopped without generating any content, which
+// models occasionally do. Each retry raises the temperature slightly, as repeating the same
+// request often gives the same empty result.
+type EmptyRetryPolicy struct {
+	// MaxRetries is the number of times to retry an empty response. This must be > 0.
+	MaxRetries int
+	// TemperatureStep is how much the temperature is raised on each retry. A random jitter of up
+	// to half the step is added. Defaults to 0.1.
+	TemperatureStep float64
+	// MaxTemperature is the highest temperature a retry will raise the temperature to. A call with
+	// a higher temperature is retried with its own temperature. Defaults to 1.5.
+	MaxTemperature float64
+}
+
+func (p EmptyRetryPolicy) defaults() EmptyRetryPolicy {
+	if p.TemperatureStep == 0 {
+		p.TemperatureStep = 0.1
+	}
+	if p.MaxTemperature == 0 {
+		p.MaxTemperature = 1.5
+	}
+	return p
+}
+
+func (p EmptyRetryPolicy) validate() error {
+	if p.MaxRetries < 1 {
+		return fmt.Errorf("EmptyRetryPolicy.MaxRetries must be > 0, was %d", p.MaxRetries)
+	}
+	if p.TemperatureStep < 0 {
+		return fmt.Errorf("EmptyRetryPolicy.TemperatureStep cannot be < 0")
+	}
+	if p.MaxTemperature < 0 || p.MaxTemperature > 2 {
+		return fmt.Errorf("EmptyRetryPolicy.MaxTemperature must be between 0 and 2, was %v", p.MaxTemperature)
+	}
+	return nil
+}
+
+// temperature returns the temperature to use for the next attempt. This is never lower than t.
+func (p EmptyRetryPolicy) temperature(t float64) float64 {
+	next := t + p.TemperatureStep + rand.Float64()*p.TemperatureStep/2
+	return max(t, min(next, p.MaxTemperature))
+}
+
+// SetEmptyRetry sets the policy for retrying responses without content on Call(). Retries are
+// reported in Chats.EmptyRetries. Streams are not retried.
+func (c *Client) SetEmptyRetry(policy EmptyRetryPolicy) error {
+	policy = policy.defaults()
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	c.emptyRetry.Store(&policy)
+	return nil
+}
+
+// chat sends req, retrying empty responses according to the EmptyRetryPolicy. It returns the
+// request that was sent last and the number of retries.
+func (c *Client) chat(ctx context.Context, deploymentID string, req chat.Req) (chat.Req, chat.Resp, int, error) {
+	policy := c.emptyRetry.Load()
+	for attempt := 0; ; attempt++ {
+		resp, err := c.rest.Chat(ctx, deploymentID, req)
+		if err != nil || policy == nil || attempt >= policy.MaxRetries || !isEmpty(resp) {
+			return req, resp, attempt, err
+		}
+		req.Temperature = policy.temperature(req.Temperature)
+	}
+}
+
+// isEmpty returns true if every choice stopped normally without any content.
+func isEmpty(resp chat.Resp) bool {
+	if len(resp.Choices) == 0 {
+		return false
+	}
+	for _, choice := range resp.Choices {
+		if choice.FinishReason != custom.Stop || choice.Message.Content != "" || choice.Message.Audio != nil {
+			return false
+		}
+		for _, m := range choice.Messages {
+			if m.Role == chat.Assistant && m.Content != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
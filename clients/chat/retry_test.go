@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+func TestEmptyRetry(t *testing.T) {
+	var temps []float64
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var in chat.Req
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			return nil, err
+		}
+		temps = append(temps, in.Temperature)
+
+		content := ""
+		if len(temps) == 3 {
+			content = "hello"
+		}
+		body := `{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"` + content + `"}}]}`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+	if err := c.SetEmptyRetry(EmptyRetryPolicy{MaxRetries: 3, MaxTemperature: 1.15}); err != nil {
+		t.Fatal(err)
+	}
+
+	chats, err := c.Call(context.Background(), []SendMsg{{Role: User, Content: "hi"}})
+	if err != nil {
+		t.Fatalf("TestEmptyRetry: got err == %s, want err == nil", err)
+	}
+	if chats.Text[0] != "hello" || chats.EmptyRetries != 2 {
+		t.Errorf("TestEmptyRetry: got text %q with %d retries, want %q with 2 retries", chats.Text[0], chats.EmptyRetries, "hello")
+	}
+	if temps[0] != 1 || temps[1] <= temps[0] || temps[2] != 1.15 {
+		t.Errorf("TestEmptyRetry: got temperatures %v, want 1, then rising and capped at 1.15", temps)
+	}
+}
+
+func TestEmptyRetryTemperature(t *testing.T) {
+	policy := EmptyRetryPolicy{MaxRetries: 1, MaxTemperature: 1.5}.defaults()
+
+	tests := []struct {
+		desc    string
+		start   float64
+		wantMin float64
+		wantMax float64
+	}{
+		{desc: "Raised by the step", start: 1, wantMin: 1.1, wantMax: 1.15},
+		{desc: "Capped at MaxTemperature", start: 1.45, wantMin: 1.5, wantMax: 1.5},
+		{desc: "At MaxTemperature", start: 1.5, wantMin: 1.5, wantMax: 1.5},
+		{desc: "Above MaxTemperature is not lowered", start: 1.8, wantMin: 1.8, wantMax: 1.8},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			got := policy.temperature(test.start)
+			if got < test.wantMin-1e-9 || got > test.wantMax+1e-9 {
+				t.Errorf("TestEmptyRetryTemperature(%s): got %v, want between %v and %v", test.desc, got, test.wantMin, test.wantMax)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/element-of-surprise/azopenai/models"
+)
+
+// SetModel sets the name of the model the deployment runs, such as "gpt-4o" or "o1". This is
+// used to look up the model's models.Capabilities. With a model set, System and Developer
+// messages are translated to the role the model supports, so the same messages work across
+// model generations. This can be overridden per call with WithModel().
+func (c *Client) SetModel(model string) {
+	c.model.Store(&model)
+}
+
+// WithModel sets the name of the model the deployment of the call runs. This overrides
+// SetModel() and should be used with WithDeploymentID() when the deployments run different models.
+func WithModel(model string) CallOption {
+	return func(o *callOptions) error {
+		o.Model = model
+		return nil
+	}
+}
+
+// modelFor returns the model set for a call, or the one set on the client.
+func (c *Client) modelFor(o callOptions) string {
+	if o.Model != "" {
+		return o.Model
+	}
+	if m := c.model.Load(); m != nil {
+		return *m
+	}
+	return ""
+}
+
+// validateRoles returns an error if a message has a role that cannot be sent.
+func validateRoles(messages []SendMsg) error {
+	for i, m := range messages {
+		switch m.Role {
+		case User, System, Developer, Assistant, Tool:
+		case UnknownRole:
+			return fmt.Errorf("message %d: Role must be set", i)
+		default:
+			return fmt.Errorf("message %d: unknown Role %q", i, m.Role)
+		}
+	}
+	return nil
+}
+
+// translateRoles changes System messages to Developer messages for models that use the
+// developer role, and Developer messages to System messages for models that don't.
+// Messages are not changed if the model is not known.
+func translateRoles(model string, messages []SendMsg) []SendMsg {
+	if model == "" {
+		return messages
+	}
+	caps, ok := models.Lookup(model)
+	if !ok {
+		return messages
+	}
+
+	from, to := Developer, System
+	if caps.DeveloperRole {
+		from, to = System, Developer
+	}
+
+	var n []SendMsg
+	for i, m := range messages {
+		if m.Role != from {
+			continue
+		}
+		if n == nil {
+			// Don't change the caller's slice.
+			n = make([]SendMsg, len(messages))
+			copy(n, messages)
+		}
+		n[i].Role = to
+	}
+	if n == nil {
+		return messages
+	}
+	return n
+}
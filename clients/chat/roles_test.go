@@ -0,0 +1,80 @@
+package chat
+
+import (
+	"testing"
+)
+
+func TestTranslateRoles(t *testing.T) {
+	tests := []struct {
+		desc  string
+		model string
+		in    []Role
+		want  []Role
+	}{
+		{
+			desc:  "No model",
+			model: "",
+			in:    []Role{System, Developer, User},
+			want:  []Role{System, Developer, User},
+		},
+		{
+			desc:  "Unknown model",
+			model: "my-model",
+			in:    []Role{System, Developer, User},
+			want:  []Role{System, Developer, User},
+		},
+		{
+			desc:  "Model uses developer role",
+			model: "o1-2024-12-17",
+			in:    []Role{System, User, Assistant},
+			want:  []Role{Developer, User, Assistant},
+		},
+		{
+			desc:  "Model uses system role",
+			model: "gpt-4o",
+			in:    []Role{Developer, User, Assistant},
+			want:  []Role{System, User, Assistant},
+		},
+	}
+
+	for _, test := range tests {
+		var in []SendMsg
+		for _, r := range test.in {
+			in = append(in, SendMsg{Role: r, Content: "hi"})
+		}
+		got := translateRoles(test.model, in)
+		for i, m := range got {
+			if m.Role != test.want[i] {
+				t.Errorf("TestTranslateRoles(%s): message %d: got role %q, want %q", test.desc, i, m.Role, test.want[i])
+			}
+		}
+		for i, m := range in {
+			if m.Role != test.in[i] {
+				t.Errorf("TestTranslateRoles(%s): input message %d was changed", test.desc, i)
+			}
+		}
+	}
+}
+
+func TestValidateRoles(t *testing.T) {
+	tests := []struct {
+		desc    string
+		role    Role
+		wantErr bool
+	}{
+		{desc: "Developer", role: Developer},
+		{desc: "System", role: System},
+		{desc: "Unset", role: UnknownRole, wantErr: true},
+		{desc: "Unknown", role: "narrator", wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := validateRoles([]SendMsg{{Role: User, Content: "hi"}, {Role: test.role, Content: "hi"}})
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestValidateRoles(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestValidateRoles(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/internal/jsonschema"
+)
+
+// CallStructured sends messages with an instruction to respond with JSON matching a schema
+// derived from T, then unmarshals the first choice into a T. If the response is not valid JSON
+// for T and retries > 0, the invalid response and a corrective message are sent up to retries
+// more times. If every attempt fails, the error is a *JSONError for the last attempt. The
+// returned Chats are from the last attempt.
+//
+// The schema is derived from the exported fields of T and their json tags. Fields without
+// omitempty are required. Markdown code fences around the JSON are removed before unmarshaling.
+func CallStructured[T any](ctx context.Context, c *Client, messages []SendMsg, retries int, options ...CallOption) (T, Chats, error) {
+	var zero T
+	if retries < 0 {
+		return zero, Chats{}, fmt.Errorf("retries cannot be < 0")
+	}
+
+	schema, err := json.Marshal(jsonschema.For(reflect.TypeOf(zero)))
+	if err != nil {
+		return zero, Chats{}, fmt.Errorf("problem creating a schema for %T: %w", zero, err)
+	}
+
+	msgs := append([]SendMsg(nil), messages...)
+	msgs = append(msgs, SendMsg{
+		Role:    System,
+		Content: "Respond only with JSON that matches this JSON schema, without any other text:\n" + string(schema),
+	})
+
+	for attempt := 0; ; attempt++ {
+		chats, err := c.Call(ctx, msgs, options...)
+		if err != nil {
+			return zero, Chats{}, err
+		}
+		if len(chats.Text) == 0 {
+			return zero, chats, errors.New("the response had no choices")
+		}
+
+		var v T
+		text := trimFences(chats.Text[0])
+		err = json.Unmarshal([]byte(text), &v)
+		if err == nil {
+			return v, chats, nil
+		}
+		jerr := &JSONError{Choice: 0, Text: chats.Text[0], Err: err}
+		if attempt >= retries {
+			return zero, chats, jerr
+		}
+
+		msgs = append(
+			msgs,
+			SendMsg{Role: Assistant, Content: chats.Text[0]},
+			SendMsg{
+				Role:    User,
+				Content: fmt.Sprintf("That response could not be parsed: %s. Respond again with only JSON that matches the schema.", err),
+			},
+		)
+	}
+}
+
+// trimFences removes a markdown code fence around s, which models often add around JSON.
+func trimFences(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	// Remove the language of the fence, such as "json".
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+type weather struct {
+	City  string   `json:"city"`
+	TempC float64  `json:"temp_c"`
+	Notes []string `json:"notes,omitempty"`
+}
+
+func TestCallStructured(t *testing.T) {
+	tests := []struct {
+		desc      string
+		responses []string
+		retries   int
+		want      weather
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			desc:      "valid JSON in a code fence",
+			responses: []string{"```json\n{\"city\": \"Paris\", \"temp_c\": 21.5}\n```"},
+			want:      weather{City: "Paris", TempC: 21.5},
+			wantCalls: 1,
+		},
+		{
+			desc:      "corrected after a retry",
+			responses: []string{"It is 21.5C in Paris", `{"city": "Paris", "temp_c": 21.5}`},
+			retries:   1,
+			want:      weather{City: "Paris", TempC: 21.5},
+			wantCalls: 2,
+		},
+		{
+			desc:      "out of retries",
+			responses: []string{"no", "still no"},
+			retries:   1,
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		var reqs []chat.Req
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var in chat.Req
+			if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+				return nil, err
+			}
+			content, _ := json.Marshal(test.responses[len(reqs)])
+			reqs = append(reqs, in)
+			body := `{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(content) + `}}]}`
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+		})
+		rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New("deployment", rc)
+
+		got, _, err := CallStructured[weather](context.Background(), c, []SendMsg{{Role: User, Content: "weather in Paris?"}}, test.retries)
+		switch {
+		case test.wantErr:
+			var jerr *JSONError
+			if !errors.As(err, &jerr) {
+				t.Errorf("TestCallStructured(%s): got err == %v, want *JSONError", test.desc, err)
+			}
+		case err != nil:
+			t.Errorf("TestCallStructured(%s): got err == %s, want err == nil", test.desc, err)
+		case got.City != test.want.City || got.TempC != test.want.TempC:
+			t.Errorf("TestCallStructured(%s): got %+v, want %+v", test.desc, got, test.want)
+		}
+		if len(reqs) != test.wantCalls {
+			t.Errorf("TestCallStructured(%s): got %d calls, want %d", test.desc, len(reqs), test.wantCalls)
+			continue
+		}
+
+		instr := reqs[0].Messages[len(reqs[0].Messages)-1].Content
+		if !strings.Contains(instr, `"required":["city","temp_c"]`) {
+			t.Errorf("TestCallStructured(%s): instruction %q does not have the schema", test.desc, instr)
+		}
+		if test.wantCalls > 1 && len(reqs[1].Messages) != len(reqs[0].Messages)+2 {
+			t.Errorf("TestCallStructured(%s): retry did not include the corrective messages", test.desc)
+		}
+	}
+}
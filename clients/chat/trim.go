@@ -0,0 +1,152 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/element-of-surprise/azopenai/models"
+)
+
+// Summarizer summarizes the messages trimmed from a history into a single message, which is
+// sent in their place. It is usually a call to a cheaper model asking for a summary.
+type Summarizer func(ctx context.Context, trimmed []SendMsg) (SendMsg, error)
+
+// TrimStrategy is how WithHistoryTrimming() removes messages. Use DropOldest or Summarize().
+type TrimStrategy struct {
+	summarize Summarizer
+}
+
+// DropOldest drops the oldest messages.
+var DropOldest = TrimStrategy{}
+
+// Summarize replaces the oldest messages with the message fn returns for them.
+func Summarize(fn Summarizer) TrimStrategy {
+	return TrimStrategy{summarize: fn}
+}
+
+// historyTrim implements WithHistoryTrimming().
+type historyTrim struct {
+	max      int
+	strategy TrimStrategy
+}
+
+// WithHistoryTrimming removes the oldest messages before sending if the prompt is over
+// maxPromptTokens, so a long conversation fits the context window of the model. System and
+// developer messages, including those from SetPrompts(), and the last message are never removed.
+// If the prompt does not fit after removing every other message, the call returns an error.
+//
+// Tokens are counted with the Tokenizer of the model registered with models.RegisterTokenizer(),
+// see SetModel(). Otherwise they are estimated as 4 characters a token, so leave some headroom.
+// Images and audio are not counted.
+func WithHistoryTrimming(maxPromptTokens int, strategy TrimStrategy) CallOption {
+	return func(o *callOptions) error {
+		if maxPromptTokens < 1 {
+			return fmt.Errorf("WithHistoryTrimming(): maxPromptTokens must be > 0, was %d", maxPromptTokens)
+		}
+		o.Trim = &historyTrim{max: maxPromptTokens, strategy: strategy}
+		return nil
+	}
+}
+
+// trim returns messages with the oldest removed so that they fit in t.max tokens.
+func (t *historyTrim) trim(ctx context.Context, model string, messages []SendMsg) ([]SendMsg, error) {
+	count := tokenCounter(model)
+	total := promptTokens(count, messages)
+	if total <= t.max {
+		return messages, nil
+	}
+
+	// Indexes of the messages that can be removed, oldest first.
+	var removable []int
+	for i, m := range messages[:len(messages)-1] {
+		if m.Role != System && m.Role != Developer {
+			removable = append(removable, i)
+		}
+	}
+
+	removed := map[int]bool{}
+	var trimmed []SendMsg
+	for _, i := range removable {
+		removed[i] = true
+		trimmed = append(trimmed, messages[i])
+		total -= msgTokens(count, messages[i])
+
+		if t.strategy.summarize == nil {
+			if total <= t.max {
+				return keep(messages, removed, nil, 0), nil
+			}
+			continue
+		}
+		if total > t.max {
+			continue
+		}
+		// The summary takes space too, so if it doesn't fit another message is removed and
+		// everything removed is summarized again.
+		summary, err := t.strategy.summarize(ctx, trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("problem summarizing the trimmed history: %w", err)
+		}
+		if total+msgTokens(count, summary) <= t.max {
+			return keep(messages, removed, &summary, removable[0]), nil
+		}
+	}
+	return nil, fmt.Errorf("the prompt does not fit in %d tokens after trimming the history, it is %d tokens", t.max, total)
+}
+
+// keep returns the messages that were not removed, with summary in place of the first removed
+// message at index at if it is not nil.
+func keep(messages []SendMsg, removed map[int]bool, summary *SendMsg, at int) []SendMsg {
+	out := make([]SendMsg, 0, len(messages)-len(removed)+1)
+	for i, m := range messages {
+		if summary != nil && i == at {
+			out = append(out, *summary)
+		}
+		if !removed[i] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// tokenCounter returns a function that counts the tokens of text with the Tokenizer of model,
+// or estimates them if there is none.
+func tokenCounter(model string) func(text string) int {
+	estimate := func(text string) int { return (len(text) + 3) / 4 }
+	tok, err := models.TokenizerFor(model)
+	if err != nil {
+		return estimate
+	}
+	return func(text string) int {
+		ids, err := tok.Encode(text)
+		if err != nil {
+			return estimate(text)
+		}
+		return len(ids)
+	}
+}
+
+// msgOverhead is the tokens the chat format adds to each message, and replyOverhead the
+// tokens that prime the reply.
+const (
+	msgOverhead   = 4
+	replyOverhead = 3
+)
+
+func msgTokens(count func(string) int, m SendMsg) int {
+	n := msgOverhead + count(m.Name)
+	if len(m.Parts) == 0 {
+		return n + count(m.Content)
+	}
+	for _, p := range m.Parts {
+		n += count(p.Text)
+	}
+	return n
+}
+
+func promptTokens(count func(string) int, messages []SendMsg) int {
+	n := replyOverhead
+	for _, m := range messages {
+		n += msgTokens(count, m)
+	}
+	return n
+}
@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistoryTrim(t *testing.T) {
+	// Each message is 10 estimated tokens of content and 4 of overhead.
+	msg := func(r Role, name string) SendMsg {
+		return SendMsg{Role: r, Content: name + strings.Repeat(".", 40-len(name))}
+	}
+	history := []SendMsg{
+		msg(System, "sys"),
+		msg(User, "u1"),
+		msg(Assistant, "a1"),
+		msg(User, "u2"),
+		msg(Assistant, "a2"),
+		msg(User, "u3"),
+	}
+	var summarized [][]SendMsg
+	summarize := func(ctx context.Context, trimmed []SendMsg) (SendMsg, error) {
+		summarized = append(summarized, trimmed)
+		return SendMsg{Role: System, Content: "sum"}, nil
+	}
+
+	tests := []struct {
+		desc           string
+		max            int
+		strategy       TrimStrategy
+		want           []SendMsg
+		wantSummarized int
+		wantErr        bool
+	}{
+		{
+			desc: "fits",
+			max:  87,
+			want: history,
+		},
+		{
+			desc: "drop oldest",
+			max:  60,
+			want: []SendMsg{history[0], history[3], history[4], history[5]},
+		},
+		{
+			desc:     "summarize",
+			max:      60,
+			strategy: Summarize(summarize),
+			// Dropping u1 and a1 fits, but not with the summary, so u2 is also summarized.
+			want:           []SendMsg{history[0], {Role: System, Content: "sum"}, history[4], history[5]},
+			wantSummarized: 2,
+		},
+		{
+			desc:    "does not fit",
+			max:     30,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		summarized = nil
+		h := &historyTrim{max: test.max, strategy: test.strategy}
+		got, err := h.trim(context.Background(), "", history)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestHistoryTrim(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestHistoryTrim(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TestHistoryTrim(%s): got %v, want %v", test.desc, got, test.want)
+		}
+		if len(summarized) != test.wantSummarized {
+			t.Errorf("TestHistoryTrim(%s): summarized %d times, want %d", test.desc, len(summarized), test.wantSummarized)
+		}
+	}
+}
@@ -41,16 +41,49 @@ You can also override the parameters on a per-call basis:
 		return err
 	}
 	fmt.Println(resp.Text[0])
+
+You can also stream the completion as it is generated. The last value holds the full text:
+
+	for data := range completionsClient.Stream(ctx, "Tell me a story") {
+		if data.Err != nil {
+			return data.Err
+		}
+		if data.Done {
+			story = data.Data.Text[0]
+			break
+		}
+		fmt.Print(data.Data.Text[0])
+	}
+
+Call() sends all prompts in one request. To send each prompt in its own request, in parallel,
+use CallMany(). Each prompt gets a Result, in the order of the prompts:
+
+	results, err := completionsClient.CallMany(ctx, prompts, completions.WithParallelism(8))
+	if err != nil {
+		return err
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			log.Printf("prompt %d failed: %s", i, r.Err)
+			continue
+		}
+		fmt.Println(r.Completions.Text[0])
+	}
 */
 package completions
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/element-of-surprise/azopenai/errors"
 	"github.com/element-of-surprise/azopenai/rest"
 	"github.com/element-of-surprise/azopenai/rest/messages/completions"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
 )
 
 type Client struct {
@@ -80,6 +113,10 @@ var defaults = CallParams{
 // CallParams are the parameters used on each call to the completions service. These
 // are all optional fields. You can set this on the client and override it on a per-call
 // basis.
+//
+// Setting fields directly is deprecated in favor of Builder(), which starts from the defaults and
+// validates the values. Because the client stores a copy, changing a CallParams after passing it
+// to SetParams() has no effect.
 type CallParams struct {
 	// LogitBias is the likelihood of specified tokens appearing in the completion.
 	// This maps tokens (specified by their token ID in the GPT tokenizer) to an associated bias value from -100 to 100.
@@ -88,6 +125,7 @@ type CallParams struct {
 	// The exact effect will vary per model, but values between -1 and 1 should decrease or increase likelihood of selection;
 	// values like -100 or 100 should result in a ban or exclusive selection of the relevant token.
 	// As an example, you can pass {\"50256\" &#58; -100} to prevent the <|endoftext|> token from being generated.
+	// The logitbias package builds this from words instead of token IDs.
 	LogitBias map[string]float64 `json:"logit_bias,omitempty"`
 	// User is a unique identifier representing your end-user, which can help monitoring and detecting abuse.
 	User string `json:"user,omitempty"`
@@ -124,6 +162,10 @@ type CallParams struct {
 	Stream bool `json:"stream,omitempty"`
 	// Echo indicates if the response should echo back the prompt in addition to the completion.
 	Echo bool `json:"echo,omitempty"`
+	// BestOf generates BestOf completions server side and returns the N with the highest log probability per token.
+	// It must be >= N and cannot be used with Stream(). It uses BestOf times the tokens of one completion.
+	// 0 uses the service default of 1.
+	BestOf int `json:"best_of,omitempty"`
 }
 
 // Defaults returns a CallParams with default values set. This should be called before
@@ -141,6 +183,7 @@ func (c CallParams) Defaults() CallParams {
 	c.Suffix = defaults.Suffix
 	c.Echo = defaults.Echo
 	c.Stop = defaults.Stop
+	c.BestOf = defaults.BestOf
 	return c
 }
 
@@ -158,6 +201,7 @@ func (c CallParams) toPromptRequest() completions.Req {
 		Suffix:      c.Suffix,
 		Echo:        c.Echo,
 		Stop:        c.Stop,
+		BestOf:      c.BestOf,
 	}
 }
 
@@ -167,10 +211,43 @@ func (c *Client) SetParams(params CallParams) {
 	c.callParams.Store(&params)
 }
 
+// FinishReason is the reason the model stopped generating a completion.
+type FinishReason = custom.FinishReason
+
+// Usage is the number of tokens used by a call.
+type Usage = completions.Usage
+
 // Completions are the completions returned from the API.
 type Completions struct {
+	// ID is the ID of the response, such as "cmpl-123". Use it to report feedback with
+	// azopenai.Client.Feedback().
+	ID string
 	// Text is the completion texts from the server.
 	Text []string
+	// FinishReasons are the reasons each completion finished, indexed like Text. When
+	// streaming, a choice's reason is only set in the StreamData where it finished.
+	FinishReasons []FinishReason
+
+	// Model is the model that generated the response, such as "gpt-35-turbo".
+	Model string
+	// Created is when the response was created.
+	Created time.Time
+
+	// ContentFilters are the Azure content filter results for each choice, indexed like Text.
+	// When a choice was filtered, its FinishReason is content_filter and Call() returns an
+	// errors.ContentFiltered with the Completions.
+	ContentFilters []ContentFilter
+	// PromptFilters are the Azure content filter results for the prompts.
+	PromptFilters []PromptFilter
+
+	// Usage is the number of tokens used. This is not set when streaming.
+	Usage Usage
+	// Partial indicates the Text is incomplete because the stream was aborted. See Stream.Abort().
+	Partial bool
+
+	// Meta is metadata taken from the response headers, such as the request ID and
+	// rate limit information.
+	Meta custom.ResponseMeta
 
 	// RestReq is the raw request sent to the REST API. This is only provided if a specific
 	// CallOption is used.
@@ -184,9 +261,22 @@ type callOptions struct {
 	CallParams    CallParams
 	setCallParams bool
 	DeploymentID  string
-
-	RestReq  bool
-	RestResp bool
+	Progress      func(chunks int)
+	APIVersion    string
+	Hooks         []Hook
+	ExtraFields   map[string]any
+	RawResponse   *[]byte
+	Timeout       time.Duration
+	IdleTimeout   time.Duration
+	Parallelism   int
+	ErrorMode     errors.Mode
+
+	RestReq   bool
+	RestResp  bool
+	RawEvents bool
+
+	// after are the funcs returned by Hooks.
+	after []func(ctx context.Context, result Completions) error
 }
 
 // CallOption is an optional argument for the Call method.
@@ -202,6 +292,18 @@ func WithCallParams(params CallParams) CallOption {
 	}
 }
 
+// WithAPIVersion sets the API version of the service to use for the call, such as "2024-02-01".
+// This overrides azopenai.WithAPIVersion().
+func WithAPIVersion(version string) CallOption {
+	return func(o *callOptions) error {
+		if _, err := rest.ContextWithAPIVersion(context.Background(), version); err != nil {
+			return err
+		}
+		o.APIVersion = version
+		return nil
+	}
+}
+
 // WithDeploymentID sets the deployment ID to use for the call. If not set, the deploymentID
 // set on the client will be used.
 func WithDeploymentID(deploymentID string) CallOption {
@@ -211,6 +313,29 @@ func WithDeploymentID(deploymentID string) CallOption {
 	}
 }
 
+// WithChunkProgress has Call() use streaming under the hood and call f each time a chunk is
+// received with the number of chunks received so far. The service often sends one token per
+// chunk, but does not promise to, so this is a measure of progress and not a token count.
+// Call() still returns a single final response. This allows displaying progress without using
+// the Stream() method. f is called from the same goroutine as Call() and should not block.
+// As with Stream(), CallParams.BestOf must not be > 1.
+func WithChunkProgress(f func(chunks int)) CallOption {
+	return func(o *callOptions) error {
+		o.Progress = f
+		return nil
+	}
+}
+
+// WithRawEvents sets StreamData.Event to the raw server-sent event on streaming calls.
+// Events the SDK does not know how to decode are also sent, with only StreamData.Event set.
+// This allows handling new event types before the SDK supports them. Ignored by Call().
+func WithRawEvents() CallOption {
+	return func(o *callOptions) error {
+		o.RawEvents = true
+		return nil
+	}
+}
+
 // WithRest sets whether to return the raw REST request and response. This is useful for
 // debugging purposes.
 func WithRest(req, resp bool) CallOption {
@@ -223,22 +348,45 @@ func WithRest(req, resp bool) CallOption {
 
 // Call makes a call to the Completions API endpoint and returns the completions for the prompts.
 func (c *Client) Call(ctx context.Context, prompts []string, options ...CallOption) (Completions, error) {
-	req, callOptions, err := c.prep(prompts, options...)
+	req, callOptions, err := c.prep(ctx, prompts, options...)
 	if err != nil {
 		return Completions{}, err
 	}
 
+	if callOptions.Progress != nil {
+		// Some fields, such as BestOf, are only invalid when streaming.
+		req.Stream = true
+		if err := req.Validate(); err != nil {
+			return Completions{}, fmt.Errorf("WithChunkProgress: %w", err)
+		}
+	}
+
 	deploymentID := c.deploymentID
 	if callOptions.DeploymentID != "" {
 		deploymentID = callOptions.DeploymentID
 	}
-
-	resp, err := c.rest.Completions(ctx, deploymentID, req)
+	ctx = restContext(ctx, callOptions)
+	ctx, cancel := withTimeout(ctx, callOptions)
+	defer cancel()
+
+	var resp completions.Resp
+	if callOptions.Progress != nil {
+		resp, err = c.progress(ctx, deploymentID, req, callOptions.Progress)
+	} else {
+		resp, err = c.rest.Completions(ctx, deploymentID, req)
+	}
 	if err != nil {
 		return Completions{}, err
 	}
 
-	compl := Completions{}
+	compl := Completions{
+		ID:            resp.ID,
+		Model:         resp.Model,
+		Created:       resp.Created.Time,
+		Usage:         resp.Usage,
+		PromptFilters: resp.PromptFilterResults,
+		Meta:          resp.Meta,
+	}
 	if callOptions.RestReq {
 		compl.RestReq = req
 	}
@@ -247,27 +395,123 @@ func (c *Client) Call(ctx context.Context, prompts []string, options ...CallOpti
 	}
 	for _, choice := range resp.Choices {
 		compl.Text = append(compl.Text, choice.Text)
+		compl.FinishReasons = append(compl.FinishReasons, choice.FinishReason)
+		compl.ContentFilters = setContentFilter(compl.ContentFilters, choice.Index, choice.ContentFilterResults)
+	}
+	if err := filteredErr(compl.FinishReasons); err != nil {
+		return compl, err
+	}
+	if err := runAfter(ctx, callOptions, compl); err != nil {
+		return Completions{}, err
 	}
 	return compl, nil
 }
 
+// progress streams the request and aggregates the chunks into a single response, calling f
+// with the number of chunks received after each chunk.
+func (c *Client) progress(ctx context.Context, deploymentID string, req completions.Req, f func(chunks int)) (completions.Resp, error) {
+	var (
+		resp    completions.Resp
+		started bool
+		chunks  int
+		texts   = map[int]*strings.Builder{}
+		choices = map[int]*completions.Choices{}
+	)
+
+	for chunk := range c.rest.CompletionsStream(ctx, deploymentID, req) {
+		if chunk.Err != nil {
+			return completions.Resp{}, chunk.Err
+		}
+		if !chunk.Event.IsMessage() {
+			continue
+		}
+		if !started {
+			// Azure's first chunk may only hold the prompt filter results and have no id, and
+			// some proxies never send an id, so the header is taken from the first chunk and
+			// filled in from later ones.
+			started = true
+			resp = chunk.Data
+			resp.Choices = nil
+		} else {
+			if resp.ID == "" {
+				resp.ID = chunk.Data.ID
+			}
+			if resp.Model == "" {
+				resp.Model = chunk.Data.Model
+			}
+			if resp.Created.Time.IsZero() {
+				resp.Created = chunk.Data.Created
+			}
+			resp.PromptFilterResults = append(resp.PromptFilterResults, chunk.Data.PromptFilterResults...)
+		}
+
+		for _, choice := range chunk.Data.Choices {
+			ch, ok := choices[choice.Index]
+			if !ok {
+				ch = &completions.Choices{Index: choice.Index}
+				choices[choice.Index] = ch
+				texts[choice.Index] = &strings.Builder{}
+			}
+			texts[choice.Index].WriteString(choice.Text)
+			if choice.FinishReason != "" {
+				ch.FinishReason = choice.FinishReason
+			}
+			if choice.ContentFilterResults != nil {
+				ch.ContentFilterResults = choice.ContentFilterResults
+			}
+			lp := choice.Logprobs
+			ch.Logprobs.Tokens = append(ch.Logprobs.Tokens, lp.Tokens...)
+			ch.Logprobs.TokenLogProbs = append(ch.Logprobs.TokenLogProbs, lp.TokenLogProbs...)
+			ch.Logprobs.TopLogProbs = append(ch.Logprobs.TopLogProbs, lp.TopLogProbs...)
+			ch.Logprobs.TextOffset = append(ch.Logprobs.TextOffset, lp.TextOffset...)
+		}
+		chunks++
+		f(chunks)
+	}
+
+	resp.Choices = make([]completions.Choices, 0, len(choices))
+	for i, ch := range choices {
+		ch.Text = texts[i].String()
+		resp.Choices = append(resp.Choices, *ch)
+	}
+	sort.Slice(resp.Choices, func(i, j int) bool {
+		return resp.Choices[i].Index < resp.Choices[j].Index
+	})
+	return resp, nil
+}
+
 // StreamData is used to receive data from the stream.
 type StreamData struct {
 	// Err is an error related to the stream. The stream is terminated after this.
 	Err error
-	// Data is data sent by the stream.
+	// Data is data sent by the stream. Data.Text holds the text that was added to each choice
+	// since the last StreamData, indexed by the choice index. If Done is set, Data instead holds
+	// the full text of each choice.
 	Data Completions
+	// Done indicates this is the final StreamData, which holds the aggregated result of the stream.
+	// It is not sent if the stream ends with an error.
+	Done bool
+	// Event is the raw server-sent event. This is only set if WithRawEvents() was used.
+	Event rest.Event
 }
 
 // Stream makes a call to the Completions API endpoint and returns a channel that will return
 // the completions for the prompt as they are received. Unlike the Call() method, each return
-// value will be part of a single completion.
+// value only holds the text generated since the last value was returned. The last value has
+// StreamData.Done set and holds the full text of each completion. The stream can be stopped
+// by cancelling the context.
 func (c *Client) Stream(ctx context.Context, prompts string, options ...CallOption) chan StreamData {
 	ch := make(chan StreamData, 1)
 
-	req, callOptions, err := c.prep([]string{prompts}, options...)
+	req, callOptions, err := c.prep(ctx, []string{prompts}, options...)
+	if err == nil {
+		// Some fields, such as BestOf, are only invalid when streaming.
+		req.Stream = true
+		err = req.Validate()
+	}
 	if err != nil {
 		ch <- StreamData{Err: err}
+		close(ch)
 		return ch
 	}
 
@@ -275,23 +519,36 @@ func (c *Client) Stream(ctx context.Context, prompts string, options ...CallOpti
 	if callOptions.DeploymentID != "" {
 		deploymentID = callOptions.DeploymentID
 	}
+	ctx = restContext(ctx, callOptions)
+	ctx, cancel := withTimeout(ctx, callOptions)
 
 	go func() {
 		defer close(ch)
+		defer cancel()
 
-		responses := c.rest.CompletionsStream(ctx, deploymentID, req)
-		if err != nil {
-			ch <- StreamData{Err: err}
-			return
+		final := Completions{}
+		if callOptions.RestReq {
+			final.RestReq = req
 		}
-
-		for resp := range responses {
+		for resp := range c.rest.CompletionsStream(ctx, deploymentID, req) {
 			if resp.Err != nil {
-				ch <- StreamData{Err: resp.Err}
+				sendData(ctx, ch, StreamData{Err: resp.Err})
 				return
 			}
+			if !resp.Event.IsMessage() {
+				if callOptions.RawEvents && !sendData(ctx, ch, StreamData{Event: resp.Event}) {
+					return
+				}
+				continue
+			}
 
-			compl := Completions{}
+			compl := Completions{
+				ID:            resp.Data.ID,
+				Model:         resp.Data.Model,
+				Created:       resp.Data.Created.Time,
+				PromptFilters: resp.Data.PromptFilterResults,
+				Meta:          resp.Data.Meta,
+			}
 			if callOptions.RestReq {
 				compl.RestReq = req
 			}
@@ -299,16 +556,73 @@ func (c *Client) Stream(ctx context.Context, prompts string, options ...CallOpti
 				compl.RestResp = resp.Data
 			}
 			for _, choice := range resp.Data.Choices {
-				compl.Text = append(compl.Text, choice.Text)
+				if choice.Index < 0 {
+					continue
+				}
+				for len(compl.Text) <= choice.Index {
+					compl.Text = append(compl.Text, "")
+				}
+				compl.Text[choice.Index] += choice.Text
+				for len(compl.FinishReasons) <= choice.Index {
+					compl.FinishReasons = append(compl.FinishReasons, custom.Unfinished)
+				}
+				compl.FinishReasons[choice.Index] = choice.FinishReason
+				compl.ContentFilters = setContentFilter(compl.ContentFilters, choice.Index, choice.ContentFilterResults)
+				final.ContentFilters = setContentFilter(final.ContentFilters, choice.Index, choice.ContentFilterResults)
+				for len(final.Text) <= choice.Index {
+					final.Text = append(final.Text, "")
+				}
+				final.Text[choice.Index] += choice.Text
+				for len(final.FinishReasons) <= choice.Index {
+					final.FinishReasons = append(final.FinishReasons, custom.Unfinished)
+				}
+				if choice.FinishReason != custom.Unfinished {
+					final.FinishReasons[choice.Index] = choice.FinishReason
+				}
+			}
+			final.PromptFilters = append(final.PromptFilters, compl.PromptFilters...)
+			final.Model = compl.Model
+			if final.ID == "" {
+				final.ID = compl.ID
+			}
+			final.Created = compl.Created
+			final.Meta = compl.Meta
+			sd := StreamData{Data: compl}
+			if callOptions.RawEvents {
+				sd.Event = resp.Event
+			}
+			if !sendData(ctx, ch, sd) {
+				return
 			}
-			ch <- StreamData{Data: compl}
 		}
+		if ctx.Err() != nil {
+			// The stream was cancelled, so final is not complete.
+			return
+		}
+		sendData(ctx, ch, StreamData{Data: final, Done: true})
 	}()
 
 	return ch
 }
 
-func (c *Client) prep(prompts []string, options ...CallOption) (completions.Req, callOptions, error) {
+// sendData sends sd on ch unless ctx is done first. It returns false if ctx is done, which means
+// the consumer may have stopped reading and the sender should exit. In that case the context
+// error is sent instead if there is room in the channel, so a consumer that is still reading
+// learns why the stream ended. See rest.sendRecv().
+func sendData(ctx context.Context, ch chan StreamData, sd StreamData) bool {
+	select {
+	case ch <- sd:
+		return true
+	case <-ctx.Done():
+		select {
+		case ch <- StreamData{Err: ctx.Err()}:
+		default:
+		}
+		return false
+	}
+}
+
+func (c *Client) prep(ctx context.Context, prompts []string, options ...CallOption) (completions.Req, callOptions, error) {
 	callOptions := callOptions{}
 	for _, o := range options {
 		if err := o(&callOptions); err != nil {
@@ -322,6 +636,10 @@ func (c *Client) prep(prompts []string, options ...CallOption) (completions.Req,
 			callOptions.CallParams = *p
 		}
 	}
+	prompts, err := runHooks(ctx, &callOptions, prompts)
+	if err != nil {
+		return completions.Req{}, callOptions, err
+	}
 
 	// Remove any leading or trailing spaces as the OpenAI API doesn't like them.
 	for i := 0; i < len(prompts); i++ {
@@ -330,5 +648,8 @@ func (c *Client) prep(prompts []string, options ...CallOption) (completions.Req,
 
 	req := callOptions.CallParams.toPromptRequest()
 	req.Prompt = prompts
+	if err := req.Validate(); err != nil {
+		return completions.Req{}, callOptions, err
+	}
 	return req, callOptions, nil
 }
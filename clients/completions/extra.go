@@ -0,0 +1,67 @@
+package completions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+// WithExtraFields merges fields into the JSON body of the request, replacing fields the SDK sets
+// with the same name. This allows using parameters the service supports before this SDK does,
+// such as:
+//
+//	WithExtraFields(map[string]any{"new_parameter": true})
+//
+// Stream() sends the extra fields but ignores WithRawResponse(), use WithRawEvents() instead.
+// See rest.ContextWithExtraFields().
+func WithExtraFields(fields map[string]any) CallOption {
+	return func(o *callOptions) error {
+		if len(fields) == 0 {
+			return fmt.Errorf("WithExtraFields: fields cannot be empty")
+		}
+		m := make(map[string]any, len(fields))
+		for k, v := range fields {
+			m[k] = v
+		}
+		o.ExtraFields = m
+		return nil
+	}
+}
+
+// WithRawResponse stores the body of the response in dst, so fields this SDK does not decode
+// can be read. dst must not be shared by concurrent calls. See rest.ContextWithRawResponse().
+func WithRawResponse(dst *[]byte) CallOption {
+	return func(o *callOptions) error {
+		if dst == nil {
+			return fmt.Errorf("WithRawResponse: dst cannot be nil")
+		}
+		o.RawResponse = dst
+		return nil
+	}
+}
+
+// withoutRawResponse disables WithRawResponse(). This is used by CallMany, as prompts are
+// sent concurrently.
+func withoutRawResponse() CallOption {
+	return func(o *callOptions) error {
+		o.RawResponse = nil
+		return nil
+	}
+}
+
+// restContext returns ctx with the rest options set by WithAPIVersion(), WithExtraFields() and
+// WithRawResponse().
+func restContext(ctx context.Context, o callOptions) context.Context {
+	if o.APIVersion != "" {
+		// The version was validated by WithAPIVersion().
+		ctx, _ = rest.ContextWithAPIVersion(ctx, o.APIVersion)
+	}
+	if o.ExtraFields != nil {
+		ctx = rest.ContextWithExtraFields(ctx, o.ExtraFields)
+	}
+	if o.RawResponse != nil {
+		ctx = rest.ContextWithRawResponse(ctx, o.RawResponse)
+	}
+	return ctx
+}
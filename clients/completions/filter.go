@@ -0,0 +1,38 @@
+package completions
+
+import (
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// ContentFilter are the results of the Azure content filters for a prompt or a choice.
+type ContentFilter = custom.ContentFilterResults
+
+// PromptFilter are the results of the Azure content filters for a prompt.
+type PromptFilter = custom.PromptFilterResults
+
+// filteredErr returns an errors.ContentFiltered if any of the choices were filtered.
+func filteredErr(reasons []FinishReason) error {
+	var choices []int
+	for i, r := range reasons {
+		if r.WasFiltered() {
+			choices = append(choices, i)
+		}
+	}
+	if len(choices) == 0 {
+		return nil
+	}
+	return errors.ContentFiltered{Choices: choices}
+}
+
+// setContentFilter sets the content filter results of a choice in filters.
+func setContentFilter(filters []ContentFilter, index int, cf *ContentFilter) []ContentFilter {
+	if cf == nil {
+		return filters
+	}
+	for len(filters) <= index {
+		filters = append(filters, ContentFilter{})
+	}
+	filters[index] = *cf
+	return filters
+}
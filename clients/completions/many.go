@@ -0,0 +1,115 @@
+package completions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/element-of-surprise/azopenai/errors"
+)
+
+// Result is the result of one prompt sent by CallMany().
+type Result struct {
+	// Completions are the completions for the prompt. These are also set when Err is an
+	// errors.ContentFiltered, the same as Call().
+	Completions Completions
+	// Err is the error for the prompt, if any.
+	Err error
+}
+
+// WithParallelism sets how many requests CallMany sends at the same time. Defaults to 4.
+// Ignored by Call() and Stream().
+func WithParallelism(n int) CallOption {
+	return func(o *callOptions) error {
+		if n < 1 {
+			return fmt.Errorf("WithParallelism(%d): must be >= 1", n)
+		}
+		o.Parallelism = n
+		return nil
+	}
+}
+
+// WithErrorMode sets how CallMany handles a prompt that fails. Defaults to errors.CollectAll.
+// Ignored by Call() and Stream().
+func WithErrorMode(m errors.Mode) CallOption {
+	return func(o *callOptions) error {
+		switch m {
+		case errors.CollectAll, errors.FailFast:
+		default:
+			return fmt.Errorf("WithErrorMode(%v): unknown mode", m)
+		}
+		o.ErrorMode = m
+		return nil
+	}
+}
+
+// CallMany sends each prompt in its own request, in parallel, and returns a Result for each prompt
+// in the order of prompts. Call() sends all prompts in one request, which is cheaper, but a slow or
+// failed prompt holds up or fails them all. Use CallMany when prompts are processed independently,
+// such as in a document processing pipeline. A prompt that fails does not stop the others, check
+// each Result.Err. An error is only returned if the call can't be made, such as for an invalid
+// CallOption. With WithErrorMode(errors.FailFast), the prompts that have not finished are
+// cancelled when a prompt fails and the error of that prompt is also returned with the results.
+// WithChunkProgress() and WithRawResponse() are ignored.
+func (c *Client) CallMany(ctx context.Context, prompts []string, options ...CallOption) ([]Result, error) {
+	if len(prompts) == 0 {
+		return nil, errors.New("prompts are required")
+	}
+
+	opts := callOptions{}
+	for _, o := range options {
+		if err := o(&opts); err != nil {
+			return nil, err
+		}
+	}
+	parallel := opts.Parallelism
+	if parallel < 1 {
+		parallel = 4
+	}
+	// Progress and the raw response would be shared between requests, so they are disabled.
+	options = append(options[:len(options):len(options)], WithChunkProgress(nil), withoutRawResponse())
+
+	var (
+		first error
+		once  sync.Once
+	)
+	// failed records the first error and cancels the prompts that have not finished in FailFast mode.
+	failed := func(err error) {}
+	if opts.ErrorMode == errors.FailFast {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		failed = func(err error) {
+			once.Do(func() {
+				first = err
+				cancel()
+			})
+		}
+	}
+
+	results := make([]Result, len(prompts))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, parallel)
+	)
+	for i := range prompts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			r := &results[i]
+			if r.Err = ctx.Err(); r.Err != nil {
+				return
+			}
+			r.Completions, r.Err = c.Call(ctx, prompts[i:i+1], options...)
+			if r.Err != nil {
+				failed(r.Err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return results, first
+}
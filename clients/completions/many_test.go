@@ -0,0 +1,122 @@
+package completions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/completions"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCallMany(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+	)
+	// Echoes the prompt back in upper case and fails the prompt "bad".
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		var in completions.Req
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			return nil, err
+		}
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req}
+		if len(in.Prompt) != 1 {
+			resp.StatusCode = http.StatusBadRequest
+			resp.Body = io.NopCloser(strings.NewReader(`{"error": {"code": "bad", "message": "want one prompt"}}`))
+			return resp, nil
+		}
+		if in.Prompt[0] == "bad" {
+			resp.StatusCode = http.StatusBadRequest
+			resp.Body = io.NopCloser(strings.NewReader(`{"error": {"code": "bad", "message": "bad prompt"}}`))
+			return resp, nil
+		}
+		// Shorter prompts finish last.
+		time.Sleep(time.Duration(10-len(in.Prompt[0])) * time.Millisecond)
+
+		resp.Body = io.NopCloser(strings.NewReader(fmt.Sprintf(`{"created": 1, "choices": [{"text": %q}]}`, strings.ToUpper(in.Prompt[0]))))
+		return resp, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	prompts := []string{"a", "bb", "bad", "dddd", "eeeee"}
+	results, err := c.CallMany(context.Background(), prompts, WithParallelism(2))
+	if err != nil {
+		t.Fatalf("TestCallMany: got err == %s, want err == nil", err)
+	}
+	if len(results) != len(prompts) {
+		t.Fatalf("TestCallMany: got %d results, want %d", len(results), len(prompts))
+	}
+	for i, p := range prompts {
+		r := results[i]
+		if p == "bad" {
+			if r.Err == nil {
+				t.Errorf("TestCallMany(%s): got err == nil, want err != nil", p)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("TestCallMany(%s): got err == %s, want err == nil", p, r.Err)
+			continue
+		}
+		if got, want := r.Completions.Text[0], strings.ToUpper(p); got != want {
+			t.Errorf("TestCallMany(%s): got text %q, want %q", p, got, want)
+		}
+	}
+	if maxSeen > 2 {
+		t.Errorf("TestCallMany: got %d requests in flight, want <= 2", maxSeen)
+	}
+
+	// Prompts are sent in order with a parallelism of 1, so the prompts after "bad" are cancelled.
+	results, err = c.CallMany(context.Background(), []string{"a", "bad", "cc", "ddd"}, WithParallelism(1), WithErrorMode(errors.FailFast))
+	if err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("TestCallMany(fail fast): got err == %v, want the error of the bad prompt", err)
+	}
+	if results[0].Err != nil {
+		t.Errorf("TestCallMany(fail fast): got err == %s for the first prompt, want err == nil", results[0].Err)
+	}
+	for _, r := range results[2:] {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("TestCallMany(fail fast): got err == %v after the bad prompt, want context.Canceled", r.Err)
+		}
+	}
+
+	if _, err := c.CallMany(context.Background(), nil); err == nil {
+		t.Errorf("TestCallMany(no prompts): got err == nil, want err != nil")
+	}
+	if _, err := c.CallMany(context.Background(), prompts, WithParallelism(0)); err == nil {
+		t.Errorf("TestCallMany(bad parallelism): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,138 @@
+package completions
+
+import (
+	"fmt"
+)
+
+// ParamsBuilder builds a CallParams. A ParamsBuilder is immutable, each method returns a new
+// ParamsBuilder, so a partially built ParamsBuilder can be shared between goroutines and used
+// as a base for other ParamsBuilders. Use Builder() to create one.
+type ParamsBuilder struct {
+	p CallParams
+}
+
+// Builder returns a ParamsBuilder that starts with the default CallParams. This is the preferred
+// way to create CallParams, as it avoids mistakes with zero values and validates the result:
+//
+//	params, err := completions.Builder().Temperature(0.3).MaxTokens(256).Build()
+func Builder() ParamsBuilder {
+	return ParamsBuilder{p: CallParams{}.Defaults()}
+}
+
+// LogitBias sets CallParams.LogitBias.
+func (b ParamsBuilder) LogitBias(bias map[string]float64) ParamsBuilder {
+	m := make(map[string]float64, len(bias))
+	for k, v := range bias {
+		m[k] = v
+	}
+	b.p.LogitBias = m
+	return b
+}
+
+// User sets CallParams.User.
+func (b ParamsBuilder) User(user string) ParamsBuilder {
+	b.p.User = user
+	return b
+}
+
+// Model sets CallParams.Model.
+func (b ParamsBuilder) Model(model string) ParamsBuilder {
+	b.p.Model = model
+	return b
+}
+
+// Suffix sets CallParams.Suffix.
+func (b ParamsBuilder) Suffix(suffix string) ParamsBuilder {
+	b.p.Suffix = suffix
+	return b
+}
+
+// Stop sets CallParams.Stop.
+func (b ParamsBuilder) Stop(stop ...string) ParamsBuilder {
+	b.p.Stop = append([]string(nil), stop...)
+	return b
+}
+
+// MaxTokens sets CallParams.MaxTokens.
+func (b ParamsBuilder) MaxTokens(n int) ParamsBuilder {
+	b.p.MaxTokens = n
+	return b
+}
+
+// Temperature sets CallParams.Temperature.
+func (b ParamsBuilder) Temperature(t float64) ParamsBuilder {
+	b.p.Temperature = t
+	return b
+}
+
+// TopP sets CallParams.TopP.
+func (b ParamsBuilder) TopP(p float64) ParamsBuilder {
+	b.p.TopP = p
+	return b
+}
+
+// N sets CallParams.N.
+func (b ParamsBuilder) N(n int) ParamsBuilder {
+	b.p.N = n
+	return b
+}
+
+// Logprobs sets CallParams.Logprobs.
+func (b ParamsBuilder) Logprobs(n int) ParamsBuilder {
+	b.p.Logprobs = n
+	return b
+}
+
+// Echo sets CallParams.Echo.
+func (b ParamsBuilder) Echo(echo bool) ParamsBuilder {
+	b.p.Echo = echo
+	return b
+}
+
+// BestOf sets CallParams.BestOf.
+func (b ParamsBuilder) BestOf(n int) ParamsBuilder {
+	b.p.BestOf = n
+	return b
+}
+
+// Build validates and returns the CallParams.
+func (b ParamsBuilder) Build() (CallParams, error) {
+	if err := b.p.validate(); err != nil {
+		return CallParams{}, err
+	}
+	// Copy so the returned CallParams can't change the ParamsBuilder.
+	return b.Stop(b.p.Stop...).LogitBias(b.p.LogitBias).p, nil
+}
+
+func (c CallParams) validate() error {
+	if c.N < 1 || c.N > 128 {
+		return fmt.Errorf("N must be between 1 and 128, was %d", c.N)
+	}
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("MaxTokens cannot be < 0")
+	}
+	if c.Temperature < 0 || c.Temperature > 2 {
+		return fmt.Errorf("Temperature must be between 0 and 2, was %v", c.Temperature)
+	}
+	if c.TopP < 0 || c.TopP > 1 {
+		return fmt.Errorf("TopP must be between 0 and 1, was %v", c.TopP)
+	}
+	if c.Logprobs < 0 || c.Logprobs > 5 {
+		return fmt.Errorf("Logprobs must be between 0 and 5, was %d", c.Logprobs)
+	}
+	if len(c.Stop) > 4 {
+		return fmt.Errorf("Stop cannot have more than 4 entries")
+	}
+	if c.BestOf != 0 && (c.BestOf < c.N || c.BestOf > 128) {
+		return fmt.Errorf("BestOf must be between N (%d) and 128, was %d", c.N, c.BestOf)
+	}
+	if c.BestOf > 1 && c.Stream {
+		return fmt.Errorf("BestOf cannot be > 1 with Stream")
+	}
+	for k, v := range c.LogitBias {
+		if v < -100 || v > 100 {
+			return fmt.Errorf("LogitBias[%s] must be between -100 and 100, was %v", k, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package completions
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		desc    string
+		b       ParamsBuilder
+		wantErr bool
+	}{
+		{desc: "Defaults", b: Builder()},
+		{
+			desc: "Valid",
+			b: Builder().LogitBias(map[string]float64{"1": 100}).User("u").Suffix("s").Stop("a").MaxTokens(10).
+				Temperature(0).TopP(1).N(2).Logprobs(5).Echo(true).BestOf(3),
+		},
+		{desc: "N is 0", b: Builder().N(0), wantErr: true},
+		{desc: "N above 128", b: Builder().N(129), wantErr: true},
+		{desc: "Negative MaxTokens", b: Builder().MaxTokens(-1), wantErr: true},
+		{desc: "Temperature above 2", b: Builder().Temperature(2.1), wantErr: true},
+		{desc: "TopP above 1", b: Builder().TopP(1.1), wantErr: true},
+		{desc: "Logprobs above 5", b: Builder().Logprobs(6), wantErr: true},
+		{desc: "Too many Stop sequences", b: Builder().Stop("a", "b", "c", "d", "e"), wantErr: true},
+		{desc: "BestOf below N", b: Builder().N(3).BestOf(2), wantErr: true},
+		{desc: "BestOf above 128", b: Builder().BestOf(129), wantErr: true},
+		{desc: "LogitBias out of range", b: Builder().LogitBias(map[string]float64{"1": -101}), wantErr: true},
+	}
+
+	for _, test := range tests {
+		params, err := test.b.Build()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if err := params.validate(); err != nil {
+			t.Errorf("TestBuilder(%s): built CallParams failed validate(): %s", test.desc, err)
+		}
+	}
+}
+
+func TestBuilderImmutable(t *testing.T) {
+	base := Builder().Stop("a").LogitBias(map[string]float64{"1": 1})
+	derived := base.Stop("b").LogitBias(map[string]float64{"1": 2})
+
+	p, err := base.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Stop[0] = "changed"
+	p.LogitBias["1"] = 50
+
+	p, _ = base.Build()
+	if p.Stop[0] != "a" || p.LogitBias["1"] != 1 {
+		t.Errorf("TestBuilderImmutable: got base Stop %v and LogitBias %v, want [a] and map[1:1]", p.Stop, p.LogitBias)
+	}
+	if p, _ := derived.Build(); p.Stop[0] != "b" || p.LogitBias["1"] != 2 {
+		t.Errorf("TestBuilderImmutable: got derived Stop %v and LogitBias %v, want [b] and map[1:2]", p.Stop, p.LogitBias)
+	}
+}
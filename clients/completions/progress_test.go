@@ -0,0 +1,156 @@
+package completions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/completions"
+)
+
+// sseStream returns a roundTripFunc whose response is events as server-sent events.
+func sseStream(events ...string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		b := strings.Builder{}
+		for _, e := range events {
+			b.WriteString("data: " + e + "\n\n")
+		}
+		b.WriteString("data: [DONE]\n\n")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(b.String())),
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestProgress(t *testing.T) {
+	tests := []struct {
+		desc          string
+		events        []string
+		wantID        string
+		wantText      []string
+		wantFinish    []FinishReason
+		wantPrompts   int
+		wantLogprobs  []completions.LogProbs
+		wantCallbacks []int
+	}{
+		{
+			desc: "Empty ids",
+			events: []string{
+				`{"id":"","choices":[],"prompt_filter_results":[{"prompt_index":0,"content_filter_results":{}}]}`,
+				`{"id":"","choices":[{"index":0,"text":"Hello"}]}`,
+				`{"id":"","choices":[{"index":0,"text":" world","finish_reason":"stop"}]}`,
+			},
+			wantText:      []string{"Hello world"},
+			wantFinish:    []FinishReason{"stop"},
+			wantPrompts:   1,
+			wantLogprobs:  []completions.LogProbs{{}},
+			wantCallbacks: []int{1, 2, 3},
+		},
+		{
+			desc: "Id after the prompt filter chunk",
+			events: []string{
+				`{"id":"","choices":[],"prompt_filter_results":[{"prompt_index":0,"content_filter_results":{}}]}`,
+				`{"id":"cmpl-1","model":"gpt-35-turbo","choices":[{"index":0,"text":"Hi","finish_reason":"stop"}]}`,
+			},
+			wantID:        "cmpl-1",
+			wantText:      []string{"Hi"},
+			wantFinish:    []FinishReason{"stop"},
+			wantPrompts:   1,
+			wantLogprobs:  []completions.LogProbs{{}},
+			wantCallbacks: []int{1, 2},
+		},
+		{
+			desc: "n > 1 with interleaved choices and logprobs",
+			events: []string{
+				`{"id":"cmpl-2","choices":[{"index":1,"text":"B","logprobs":{"tokens":["B"],"token_logprobs":[-0.5],"text_offset":[0]}}]}`,
+				`{"id":"cmpl-2","choices":[{"index":0,"text":"A","logprobs":{"tokens":["A"],"token_logprobs":[-0.1],"text_offset":[0]}}]}`,
+				`{"id":"cmpl-2","choices":[{"index":1,"text":"b","finish_reason":"length","logprobs":{"tokens":["b"],"token_logprobs":[-0.6],"text_offset":[1]}}]}`,
+				`{"id":"cmpl-2","choices":[{"index":0,"text":"a","finish_reason":"stop","logprobs":{"tokens":["a"],"token_logprobs":[-0.2],"text_offset":[1]}}]}`,
+			},
+			wantID:     "cmpl-2",
+			wantText:   []string{"Aa", "Bb"},
+			wantFinish: []FinishReason{"stop", "length"},
+			wantLogprobs: []completions.LogProbs{
+				{Tokens: []string{"A", "a"}, TokenLogProbs: []float64{-0.1, -0.2}, TextOffset: []int{0, 1}},
+				{Tokens: []string{"B", "b"}, TokenLogProbs: []float64{-0.5, -0.6}, TextOffset: []int{0, 1}},
+			},
+			wantCallbacks: []int{1, 2, 3, 4},
+		},
+	}
+
+	for _, test := range tests {
+		rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: sseStream(test.events...)}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New("deployment", rc)
+
+		var calls []int
+		got, err := c.Call(
+			context.Background(),
+			[]string{"hi"},
+			WithChunkProgress(func(chunks int) { calls = append(calls, chunks) }),
+			WithRest(false, true),
+		)
+		if err != nil {
+			t.Errorf("TestProgress(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		}
+
+		if got.ID != test.wantID {
+			t.Errorf("TestProgress(%s): got ID == %q, want ID == %q", test.desc, got.ID, test.wantID)
+		}
+		if !reflect.DeepEqual(got.Text, test.wantText) {
+			t.Errorf("TestProgress(%s): got Text == %q, want Text == %q", test.desc, got.Text, test.wantText)
+		}
+		if !reflect.DeepEqual(got.FinishReasons, test.wantFinish) {
+			t.Errorf("TestProgress(%s): got FinishReasons == %v, want FinishReasons == %v", test.desc, got.FinishReasons, test.wantFinish)
+		}
+		if len(got.PromptFilters) != test.wantPrompts {
+			t.Errorf("TestProgress(%s): got %d PromptFilters, want %d", test.desc, len(got.PromptFilters), test.wantPrompts)
+		}
+		var logprobs []completions.LogProbs
+		for _, choice := range got.RestResp.Choices {
+			logprobs = append(logprobs, choice.Logprobs)
+		}
+		if !reflect.DeepEqual(logprobs, test.wantLogprobs) {
+			t.Errorf("TestProgress(%s): got Logprobs == %+v, want Logprobs == %+v", test.desc, logprobs, test.wantLogprobs)
+		}
+		if !reflect.DeepEqual(calls, test.wantCallbacks) {
+			t.Errorf("TestProgress(%s): got callbacks == %v, want callbacks == %v", test.desc, calls, test.wantCallbacks)
+		}
+	}
+}
+
+func TestProgressBestOf(t *testing.T) {
+	sent := false
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = true
+		return sseStream()(req)
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	params, err := Builder().BestOf(2).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Call(context.Background(), []string{"hi"}, WithCallParams(params), WithChunkProgress(func(int) {}))
+	if err == nil {
+		t.Errorf("TestProgressBestOf: got err == nil, want err != nil")
+	}
+	if sent {
+		t.Errorf("TestProgressBestOf: got a request sent, want none")
+	}
+}
@@ -0,0 +1,66 @@
+package completions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+// endlessStream returns a roundTripFunc whose response streams event until the body is closed.
+func endlessStream(event string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		r, w := io.Pipe()
+		go func() {
+			for {
+				if _, err := w.Write([]byte(event)); err != nil {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       r,
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	rt := endlessStream(`data: {"id":"1","choices":[{"index":0,"text":"x"}]}` + "\n\n")
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := c.Stream(ctx, "hi")
+	if sd := <-ch; sd.Err != nil {
+		t.Fatalf("TestStreamCancel: got err == %s, want err == nil", sd.Err)
+	}
+	cancel()
+	// Stop reading, as a consumer that cancels usually does. The stream must not block on a
+	// send, so after this it can only have its buffered value left.
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; ; i++ {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			if i > 0 {
+				t.Fatalf("TestStreamCancel: got more than the buffered value after cancel, the stream kept sending")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("TestStreamCancel: channel was not closed after cancel")
+		}
+	}
+}
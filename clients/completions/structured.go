@@ -0,0 +1,98 @@
+package completions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/internal/jsonschema"
+)
+
+// JSONError is returned by CallStructured() when the completion is not JSON that matches the
+// schema.
+type JSONError struct {
+	// Text is the text of the completion.
+	Text string
+	// Err is the error from extracting, validating or decoding the text.
+	Err error
+}
+
+// Error implements error.
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("completion is not valid JSON output: %s", e.Err)
+}
+
+// Unwrap implements errors.Unwrap().
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// CallStructured is the completions version of chat.CallStructured, for deployments without a
+// JSON mode. It appends an instruction to prompt to respond with JSON matching a schema derived
+// from T, then unmarshals the first choice into a T. The JSON is extracted from the completion,
+// ignoring a markdown code fence and any text around it, and is validated against the schema.
+// If that fails and retries > 0, the prompt is sent again with the invalid completion and what
+// was wrong with it, up to retries more times. If every attempt fails, the error is a *JSONError
+// for the last attempt. The returned Completions are from the last attempt.
+//
+// The schema is derived from the exported fields of T and their json tags. Fields without
+// omitempty are required. Set CallParams.MaxTokens high enough for the whole object, the
+// default of 16 is not.
+func CallStructured[T any](ctx context.Context, c *Client, prompt string, retries int, options ...CallOption) (T, Completions, error) {
+	var zero T
+	if retries < 0 {
+		return zero, Completions{}, fmt.Errorf("retries cannot be < 0")
+	}
+
+	schema := jsonschema.For(reflect.TypeOf(zero))
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return zero, Completions{}, fmt.Errorf("problem creating a schema for %T: %w", zero, err)
+	}
+	instruction := "\n\nRespond only with JSON that matches this JSON schema, without any other text:\n" + string(b)
+
+	p := prompt + instruction + "\n\nJSON:\n"
+	for attempt := 0; ; attempt++ {
+		compl, err := c.Call(ctx, []string{p}, options...)
+		if err != nil {
+			return zero, Completions{}, err
+		}
+		if len(compl.Text) == 0 {
+			return zero, compl, errors.New("the response had no choices")
+		}
+
+		v, err := decodeStructured[T](compl.Text[0], schema)
+		if err == nil {
+			return v, compl, nil
+		}
+		jerr := &JSONError{Text: compl.Text[0], Err: err}
+		if attempt >= retries {
+			return zero, compl, jerr
+		}
+
+		p = prompt + instruction + fmt.Sprintf(
+			"\n\nA previous response was:\n%s\nThat response could not be used: %s. Respond again with only JSON that matches the schema.\n\nJSON:\n",
+			strings.TrimSpace(compl.Text[0]),
+			err,
+		)
+	}
+}
+
+// decodeStructured extracts the JSON in text, validates it against schema and decodes it.
+func decodeStructured[T any](text string, schema map[string]any) (T, error) {
+	var v T
+	raw, err := jsonschema.Extract(text)
+	if err != nil {
+		return v, err
+	}
+	if err := jsonschema.Validate(raw, schema); err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
@@ -0,0 +1,97 @@
+package completions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/completions"
+)
+
+type weather struct {
+	City  string   `json:"city"`
+	TempC float64  `json:"temp_c"`
+	Notes []string `json:"notes,omitempty"`
+}
+
+func TestCallStructured(t *testing.T) {
+	tests := []struct {
+		desc      string
+		responses []string
+		retries   int
+		want      weather
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			desc:      "JSON in a code fence with trailing text",
+			responses: []string{"```json\n{\"city\": \"Paris\", \"temp_c\": 21.5}\n```\nHope this helps!"},
+			want:      weather{City: "Paris", TempC: 21.5},
+			wantCalls: 1,
+		},
+		{
+			desc:      "missing required property corrected after a retry",
+			responses: []string{`{"city": "Paris"}`, ` {"city": "Paris", "temp_c": 21.5} The end.`},
+			retries:   1,
+			want:      weather{City: "Paris", TempC: 21.5},
+			wantCalls: 2,
+		},
+		{
+			desc:      "out of retries",
+			responses: []string{"no", `{"city": 1, "temp_c": 2}`},
+			retries:   1,
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		var reqs []completions.Req
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var in completions.Req
+			if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+				return nil, err
+			}
+			text, _ := json.Marshal(test.responses[len(reqs)])
+			reqs = append(reqs, in)
+			body := `{"choices":[{"index":0,"finish_reason":"stop","text":` + string(text) + `}]}`
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+		})
+		rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New("deployment", rc)
+
+		got, _, err := CallStructured[weather](context.Background(), c, "Weather in Paris?", test.retries)
+		switch {
+		case test.wantErr:
+			var jerr *JSONError
+			if !errors.As(err, &jerr) {
+				t.Errorf("TestCallStructured(%s): got err == %v, want *JSONError", test.desc, err)
+			}
+		case err != nil:
+			t.Errorf("TestCallStructured(%s): got err == %s, want err == nil", test.desc, err)
+		case got.City != test.want.City || got.TempC != test.want.TempC:
+			t.Errorf("TestCallStructured(%s): got %+v, want %+v", test.desc, got, test.want)
+		}
+		if len(reqs) != test.wantCalls {
+			t.Errorf("TestCallStructured(%s): got %d calls, want %d", test.desc, len(reqs), test.wantCalls)
+			continue
+		}
+
+		prompt := reqs[0].Prompt[0]
+		if !strings.HasPrefix(prompt, "Weather in Paris?") || !strings.Contains(prompt, `"required":["city","temp_c"]`) {
+			t.Errorf("TestCallStructured(%s): prompt %q does not have the schema", test.desc, prompt)
+		}
+		if test.wantCalls > 1 && !strings.Contains(reqs[1].Prompt[0], test.responses[0]) {
+			t.Errorf("TestCallStructured(%s): retry did not include the previous response", test.desc)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package completions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+// WithTimeout sets a deadline of d on the call, on top of the deadline of ctx. For Stream(),
+// the deadline is for the whole stream, see WithStreamIdleTimeout() to fail a stream that
+// stops sending. This overrides azopenai.WithTimeout().
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("WithTimeout: timeout must be > 0")
+		}
+		o.Timeout = d
+		return nil
+	}
+}
+
+// WithStreamIdleTimeout fails Stream() with rest.ErrStreamIdle if the next event does not
+// arrive within d. This overrides azopenai.WithStreamIdleTimeout(). Call() ignores it.
+func WithStreamIdleTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("WithStreamIdleTimeout: timeout must be > 0")
+		}
+		o.IdleTimeout = d
+		return nil
+	}
+}
+
+// withTimeout returns ctx with the deadline set by WithTimeout() and the idle timeout set by
+// WithStreamIdleTimeout(). cancel must be called when the call is done.
+func withTimeout(ctx context.Context, o callOptions) (context.Context, context.CancelFunc) {
+	if o.IdleTimeout > 0 {
+		ctx = rest.ContextWithStreamIdleTimeout(ctx, o.IdleTimeout)
+	}
+	if o.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.Timeout)
+}
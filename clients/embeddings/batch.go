@@ -0,0 +1,193 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/element-of-surprise/azopenai/errors"
+)
+
+// MaxBatchSize is the maximum number of inputs the service accepts in a single request.
+const MaxBatchSize = 2048
+
+// ChunkError is the error for a chunk of inputs sent by CallBatch.
+type ChunkError struct {
+	// Start is the index in the input of the first text in the chunk. If WithDedup() was used,
+	// this is an index into Embeddings.Dedup.Unique instead.
+	Start int
+	// End is the index after the last text in the chunk, with the same meaning as Start.
+	End int
+	// Err is the error returned for the chunk.
+	Err error
+}
+
+// Error implements error.
+func (c ChunkError) Error() string {
+	return fmt.Sprintf("inputs [%d:%d]: %s", c.Start, c.End, c.Err)
+}
+
+// Unwrap returns the underlying error.
+func (c ChunkError) Unwrap() error {
+	return c.Err
+}
+
+// PartialError is returned by CallBatch when one or more chunks fail. It holds the vectors
+// for the chunks that succeeded so that only the failed inputs need to be retried.
+type PartialError struct {
+	// Embeddings holds the results. Embeddings.Results, or Results32 with WithFloat32(), is
+	// indexed by input index and is nil for inputs that failed.
+	Embeddings Embeddings
+	// Failed are the indices of the inputs that failed, in order. This includes duplicates
+	// of inputs that failed when WithDedup() is used.
+	Failed []int
+	// Chunks are the errors for each chunk that failed.
+	Chunks []ChunkError
+}
+
+// Error implements error.
+func (p *PartialError) Error() string {
+	msgs := make([]string, 0, len(p.Chunks))
+	for _, c := range p.Chunks {
+		msgs = append(msgs, c.Error())
+	}
+	return fmt.Sprintf("%d of %d inputs failed: %s", len(p.Failed), p.Embeddings.len(), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors for each chunk, so errors.Is() and errors.As() can be used to
+// check for a specific error.
+func (p *PartialError) Unwrap() []error {
+	errs := make([]error, 0, len(p.Chunks))
+	for _, c := range p.Chunks {
+		errs = append(errs, c)
+	}
+	return errs
+}
+
+// CallBatch is like Call, but splits text into chunks of up to size inputs and sends a request
+// for each chunk. If size is <= 0 or > MaxBatchSize, MaxBatchSize is used. If any chunk fails,
+// a *PartialError is returned that contains the vectors for the chunks that succeeded. The
+// returned Embeddings.Meta is from the last successful chunk and Embeddings.Usage is the sum
+// of all successful chunks. Chunks are sent one at a time unless WithParallelism() is used.
+// By default every chunk is sent, use WithErrorMode() to stop at the first failed chunk.
+// WithRest() and WithRawResponse() are ignored.
+func (c *Client) CallBatch(ctx context.Context, text []string, size int, options ...CallOption) (Embeddings, error) {
+	if size <= 0 || size > MaxBatchSize {
+		size = MaxBatchSize
+	}
+	if len(text) == 0 {
+		return Embeddings{}, errors.New("text is required")
+	}
+
+	opts := callOptions{}
+	for _, o := range options {
+		if err := o(&opts); err != nil {
+			return Embeddings{}, err
+		}
+	}
+
+	// Dedup across all chunks instead of within each chunk.
+	var report DedupReport
+	if opts.Dedup {
+		text, report = dedup(text, opts.DedupThreshold)
+	}
+
+	// Override WithRest() and WithRawResponse(), holding the raw request and response for each
+	// chunk isn't useful.
+	options = append(options, WithRest(false, false), withoutRawResponse(), withoutDedup(), withoutFloat32())
+
+	parallel := opts.Parallelism
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// failed cancels the chunks that have not finished in FailFast mode.
+	failed := func() {}
+	if opts.ErrorMode == errors.FailFast {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		failed = cancel
+	}
+
+	type result struct {
+		start, end int
+		resp       Embeddings
+		err        error
+	}
+	var starts []int
+	for start := 0; start < len(text); start += size {
+		starts = append(starts, start)
+	}
+	results := make([]result, len(starts))
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, parallel)
+	)
+	for i, start := range starts {
+		end := start + size
+		if end > len(text) {
+			end = len(text)
+		}
+		results[i] = result{start: start, end: end}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *result) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			r.err = ctx.Err()
+			if r.err == nil {
+				r.resp, r.err = c.Call(ctx, text[r.start:r.end], options...)
+			}
+			if r.err == nil && len(r.resp.Results) != r.end-r.start {
+				r.err = fmt.Errorf("got %d results, want %d", len(r.resp.Results), r.end-r.start)
+			}
+			if r.err != nil {
+				failed()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	// Results are gathered in input order, so the output does not depend on which request
+	// finished first.
+	emb := Embeddings{Results: make([][]float64, len(text))}
+	perr := &PartialError{}
+	for _, r := range results {
+		if r.err != nil {
+			perr.Chunks = append(perr.Chunks, ChunkError{Start: r.start, End: r.end, Err: r.err})
+			for i := r.start; i < r.end; i++ {
+				perr.Failed = append(perr.Failed, i)
+			}
+			continue
+		}
+		copy(emb.Results[r.start:r.end], r.resp.Results)
+		emb.Meta = r.resp.Meta
+		emb.Usage.PromptTokens += r.resp.Usage.PromptTokens
+		emb.Usage.TotalTokens += r.resp.Usage.TotalTokens
+	}
+
+	emb.finish(opts, report)
+	if opts.Dedup && len(perr.Failed) > 0 {
+		failed := make(map[int]bool, len(perr.Failed))
+		for _, i := range perr.Failed {
+			failed[report.Unique[i]] = true
+		}
+		perr.Failed = perr.Failed[:0]
+		for i, src := range report.Source {
+			if failed[src] {
+				perr.Failed = append(perr.Failed, i)
+			}
+		}
+	}
+
+	if len(perr.Chunks) > 0 {
+		perr.Embeddings = emb
+		return Embeddings{}, perr
+	}
+	return emb, nil
+}
@@ -0,0 +1,172 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCallBatch(t *testing.T) {
+	// Fails any request that has the input "bad".
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var in embeddings.Req
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			return nil, err
+		}
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req}
+
+		var out embeddings.Resp
+		for i, s := range in.Input {
+			if s == "bad" {
+				resp.StatusCode = http.StatusBadRequest
+				resp.Body = io.NopCloser(strings.NewReader(`{"error": {"code": "bad", "message": "bad input"}}`))
+				return resp, nil
+			}
+			out.Data = append(out.Data, embeddings.Data{Index: i, Embedding: []float64{float64(len(s))}})
+		}
+		b, _ := json.Marshal(out)
+		resp.Body = io.NopCloser(strings.NewReader(string(b)))
+		return resp, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	emb, err := c.CallBatch(context.Background(), []string{"a", "bb", "ccc"}, 2)
+	if err != nil {
+		t.Fatalf("TestCallBatch(success): got err == %s, want err == nil", err)
+	}
+	for i, want := range []float64{1, 2, 3} {
+		if got := emb.Results[i][0]; got != want {
+			t.Errorf("TestCallBatch(success): Results[%d] = %v, want %v", i, got, want)
+		}
+	}
+
+	_, err = c.CallBatch(context.Background(), []string{"a", "bad", "ccc", "dddd", "bad"}, 2)
+	var perr *PartialError
+	if !errors.As(err, &perr) {
+		t.Fatalf("TestCallBatch(partial): got err == %v, want *PartialError", err)
+	}
+	if got, want := fmt.Sprint(perr.Failed), "[0 1 4]"; got != want {
+		t.Errorf("TestCallBatch(partial): Failed = %s, want %s", got, want)
+	}
+	if len(perr.Chunks) != 2 || perr.Chunks[0].Start != 0 || perr.Chunks[1].Start != 4 {
+		t.Errorf("TestCallBatch(partial): Chunks = %+v, want chunks starting at 0 and 4", perr.Chunks)
+	}
+	res := perr.Embeddings.Results
+	if res[0] != nil || res[1] != nil || res[4] != nil {
+		t.Errorf("TestCallBatch(partial): failed inputs have results, want nil")
+	}
+	if res[2][0] != 3 || res[3][0] != 4 {
+		t.Errorf("TestCallBatch(partial): Results[2:4] = %v, want [[3] [4]]", res[2:4])
+	}
+
+	emb, err = c.CallBatch(context.Background(), []string{"a", "bb", "a", "ccc"}, 2, WithFloat32(), WithDedup(0))
+	if err != nil {
+		t.Fatalf("TestCallBatch(float32): got err == %s, want err == nil", err)
+	}
+	if emb.Results != nil {
+		t.Errorf("TestCallBatch(float32): Results = %v, want nil", emb.Results)
+	}
+	if got, want := fmt.Sprint(emb.Results32), "[[1] [2] [1] [3]]"; got != want {
+		t.Errorf("TestCallBatch(float32): Results32 = %s, want %s", got, want)
+	}
+	if &emb.Results32[0][0] == &emb.Results32[2][0] {
+		t.Errorf("TestCallBatch(float32): duplicates share a vector, want a copy each")
+	}
+
+	// Chunks are sent in order with a parallelism of 1, so the chunks after "bad" are not sent.
+	_, err = c.CallBatch(context.Background(), []string{"a", "bad", "ccc", "dddd"}, 1, WithErrorMode(errors.FailFast))
+	if !errors.As(err, &perr) {
+		t.Fatalf("TestCallBatch(fail fast): got err == %v, want *PartialError", err)
+	}
+	if got, want := fmt.Sprint(perr.Failed), "[1 2 3]"; got != want {
+		t.Errorf("TestCallBatch(fail fast): Failed = %s, want %s", got, want)
+	}
+	for _, c := range perr.Chunks[1:] {
+		if !errors.Is(c.Err, context.Canceled) {
+			t.Errorf("TestCallBatch(fail fast): chunk %d: got err == %v, want context.Canceled", c.Start, c.Err)
+		}
+	}
+}
+
+func TestCallBatchParallel(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+	)
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		var in embeddings.Req
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			return nil, err
+		}
+		// Later chunks finish first.
+		time.Sleep(time.Duration(10-len(in.Input[0])) * time.Millisecond)
+
+		out := embeddings.Resp{Usage: embeddings.Usage{PromptTokens: len(in.Input), TotalTokens: len(in.Input)}}
+		for i, s := range in.Input {
+			out.Data = append(out.Data, embeddings.Data{Index: i, Embedding: []float64{float64(len(s))}})
+		}
+		b, _ := json.Marshal(out)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(string(b))), Request: req}, nil
+	})
+
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("deployment", rc)
+
+	var text []string
+	for i := 1; i <= 9; i++ {
+		text = append(text, strings.Repeat("a", i))
+	}
+	emb, err := c.CallBatch(context.Background(), text, 1, WithParallelism(3))
+	if err != nil {
+		t.Fatalf("TestCallBatchParallel: got err == %s, want err == nil", err)
+	}
+	for i := range text {
+		if got := emb.Results[i][0]; got != float64(i+1) {
+			t.Errorf("TestCallBatchParallel: Results[%d] = %v, want %v", i, got, i+1)
+		}
+	}
+	if emb.Usage.PromptTokens != 9 {
+		t.Errorf("TestCallBatchParallel: got %d prompt tokens, want 9", emb.Usage.PromptTokens)
+	}
+	if maxSeen > 3 {
+		t.Errorf("TestCallBatchParallel: got %d requests in flight, want at most 3", maxSeen)
+	}
+}
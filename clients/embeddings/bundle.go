@@ -0,0 +1,34 @@
+package embeddings
+
+import (
+	"fmt"
+
+	"github.com/element-of-surprise/azopenai/internal/bundle"
+)
+
+var bundles bundle.Registry[CallOption]
+
+// RegisterBundle registers options under name, so they can be applied with WithBundle(name).
+// Registering a name again replaces its options. azopenai.RegisterBundle() registers a bundle
+// for all the sub-clients.
+func RegisterBundle(name string, options ...CallOption) error {
+	return bundles.Register(name, options)
+}
+
+// WithBundle applies the options registered under name, in the order they were registered.
+// Options after WithBundle() override it. The bundle is looked up when WithBundle() is called,
+// so a bundle can include other bundles but not itself.
+func WithBundle(name string) CallOption {
+	options, err := bundles.Get(name)
+	return func(o *callOptions) error {
+		if err != nil {
+			return err
+		}
+		for _, opt := range options {
+			if err := opt(o); err != nil {
+				return fmt.Errorf("bundle %q: %w", name, err)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package embeddings
+
+import (
+	"crypto/sha256"
+	"hash/fnv"
+	"strings"
+)
+
+const (
+	// minhashes is the number of hashes in a minhash signature.
+	minhashes = 64
+	// bandRows is the number of signature rows in each locality sensitive hashing band.
+	bandRows = 4
+	// shingleSize is the number of words in a shingle.
+	shingleSize = 3
+)
+
+// DedupReport reports what was removed by WithDedup() before the inputs were embedded.
+type DedupReport struct {
+	// Inputs is the number of inputs.
+	Inputs int
+	// Exact is the number of inputs that were identical to an earlier input.
+	Exact int
+	// Near is the number of inputs that were near duplicates of an earlier input.
+	Near int
+	// Source is indexed by input index and holds the index of the input that was embedded for it.
+	// For an input that was embedded, this is its own index.
+	Source []int
+	// Unique are the indices of the inputs that were embedded, in order.
+	Unique []int
+	// SavedChars is the number of characters that were not sent because of deduplication.
+	SavedChars int
+}
+
+// Saved returns the number of inputs that were not embedded.
+func (d DedupReport) Saved() int {
+	return d.Exact + d.Near
+}
+
+type signature [minhashes]uint64
+
+// similarity estimates the Jaccard similarity of the shingles the signatures were made from.
+func (s *signature) similarity(o *signature) float64 {
+	same := 0
+	for i := range s {
+		if s[i] == o[i] {
+			same++
+		}
+	}
+	return float64(same) / minhashes
+}
+
+// band returns a key for band b of the signature.
+func (s *signature) band(b int) [bandRows]uint64 {
+	var k [bandRows]uint64
+	copy(k[:], s[b*bandRows:(b+1)*bandRows])
+	return k
+}
+
+// minhash returns the minhash signature of the word shingles in text.
+func minhash(text string) *signature {
+	words := strings.Fields(strings.ToLower(text))
+
+	var shingles []string
+	if len(words) < shingleSize {
+		shingles = []string{strings.Join(words, " ")}
+	} else {
+		for i := 0; i+shingleSize <= len(words); i++ {
+			shingles = append(shingles, strings.Join(words[i:i+shingleSize], " "))
+		}
+	}
+
+	sig := &signature{}
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, sh := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(sh))
+		h1 := h.Sum64()
+		// Use double hashing to simulate a family of hash functions.
+		h2 := h1*0x9E3779B97F4A7C15 | 1
+		for i := range sig {
+			v := h1 + uint64(i)*h2
+			if v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// dedup returns the texts in text that should be embedded and a report of what was removed.
+// Texts that are identical are always removed. If threshold > 0, texts whose estimated Jaccard
+// similarity with an earlier text is >= threshold are also removed.
+func dedup(text []string, threshold float64) ([]string, DedupReport) {
+	report := DedupReport{Inputs: len(text), Source: make([]int, len(text))}
+
+	exact := make(map[[sha256.Size]byte]int, len(text))
+	var (
+		sigs    map[int]*signature
+		buckets []map[[bandRows]uint64][]int
+	)
+	if threshold > 0 {
+		sigs = map[int]*signature{}
+		buckets = make([]map[[bandRows]uint64][]int, minhashes/bandRows)
+		for i := range buckets {
+			buckets[i] = map[[bandRows]uint64][]int{}
+		}
+	}
+
+	unique := make([]string, 0, len(text))
+	for i, t := range text {
+		sum := sha256.Sum256([]byte(t))
+		if src, ok := exact[sum]; ok {
+			report.Source[i] = src
+			report.Exact++
+			report.SavedChars += len(t)
+			continue
+		}
+		exact[sum] = i
+
+		if threshold > 0 {
+			sig := minhash(t)
+			if src, ok := nearest(sig, sigs, buckets, threshold); ok {
+				report.Source[i] = src
+				report.Near++
+				report.SavedChars += len(t)
+				continue
+			}
+			sigs[i] = sig
+			for b := range buckets {
+				k := sig.band(b)
+				buckets[b][k] = append(buckets[b][k], i)
+			}
+		}
+
+		report.Source[i] = i
+		report.Unique = append(report.Unique, i)
+		unique = append(unique, t)
+	}
+	return unique, report
+}
+
+// nearest returns the earliest embedded text that shares a band with sig and has a similarity >= threshold.
+func nearest(sig *signature, sigs map[int]*signature, buckets []map[[bandRows]uint64][]int, threshold float64) (int, bool) {
+	best := -1
+	for b := range buckets {
+		for _, c := range buckets[b][sig.band(b)] {
+			if (best == -1 || c < best) && sig.similarity(sigs[c]) >= threshold {
+				best = c
+			}
+		}
+	}
+	return best, best != -1
+}
+
+// expand returns the results for all inputs of d from the results for the unique inputs.
+// Each duplicate gets its own copy of the vector, so changing one result doesn't change another.
+func expand[T float32 | float64](d DedupReport, results [][]T) [][]T {
+	pos := make(map[int]int, len(d.Unique))
+	for i, u := range d.Unique {
+		pos[u] = i
+	}
+	out := make([][]T, d.Inputs)
+	for i, src := range d.Source {
+		if i == src {
+			out[i] = results[pos[src]]
+			continue
+		}
+		out[i] = append([]T(nil), results[pos[src]]...)
+	}
+	return out
+}
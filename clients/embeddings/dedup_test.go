@@ -0,0 +1,78 @@
+package embeddings
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDedup(t *testing.T) {
+	text := []string{
+		"the quick brown fox jumps over the lazy dog by the river bank",
+		"a completely different sentence about embeddings and vectors",
+		"the quick brown fox jumps over the lazy dog by the river bank",
+		"The quick brown fox jumps over the lazy dog by the river bank!",
+		"short",
+	}
+
+	tests := []struct {
+		desc       string
+		threshold  float64
+		wantUnique string
+		wantSource string
+		wantExact  int
+		wantNear   int
+	}{
+		{
+			desc:       "exact only",
+			wantUnique: "[0 1 3 4]",
+			wantSource: "[0 1 0 3 4]",
+			wantExact:  1,
+		},
+		{
+			desc:       "near duplicates",
+			threshold:  0.7,
+			wantUnique: "[0 1 4]",
+			wantSource: "[0 1 0 0 4]",
+			wantExact:  1,
+			wantNear:   1,
+		},
+	}
+
+	for _, test := range tests {
+		unique, report := dedup(text, test.threshold)
+		if got := fmt.Sprint(report.Unique); got != test.wantUnique {
+			t.Errorf("TestDedup(%s): Unique = %s, want %s", test.desc, got, test.wantUnique)
+		}
+		if got := fmt.Sprint(report.Source); got != test.wantSource {
+			t.Errorf("TestDedup(%s): Source = %s, want %s", test.desc, got, test.wantSource)
+		}
+		if report.Exact != test.wantExact || report.Near != test.wantNear {
+			t.Errorf("TestDedup(%s): Exact, Near = %d, %d, want %d, %d", test.desc, report.Exact, report.Near, test.wantExact, test.wantNear)
+		}
+		if len(unique) != len(report.Unique) {
+			t.Errorf("TestDedup(%s): got %d unique texts, want %d", test.desc, len(unique), len(report.Unique))
+		}
+
+		results := make([][]float64, len(unique))
+		for i := range results {
+			results[i] = []float64{float64(report.Unique[i])}
+		}
+		expanded := expand(report, results)
+		for i, r := range expanded {
+			if int(r[0]) != report.Source[i] {
+				t.Errorf("TestDedup(%s): expand()[%d] = %v, want vector of input %d", test.desc, i, r, report.Source[i])
+			}
+		}
+
+		// Duplicates don't share a vector with their source.
+		for i, r := range expanded {
+			r[0] = -1
+			for j, o := range expanded {
+				if j != i && o[0] == -1 {
+					t.Errorf("TestDedup(%s): changing expand()[%d] changed expand()[%d]", test.desc, i, j)
+				}
+			}
+			r[0] = float64(report.Source[i])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+
+	"github.com/element-of-surprise/azopenai/extract"
+)
+
+// Document holds the embeddings of a file.
+type Document struct {
+	// Document is the text extracted from the file.
+	Document extract.Document
+	// Chunks are the chunks of the Document that were embedded.
+	Chunks []extract.Chunk
+	// Embeddings holds the vector of each chunk, indexed like Chunks.
+	Embeddings
+}
+
+// CallDocument extracts the text from b, a file of type mimeType, splits it into chunks of up to
+// chunkSize characters and embeds each chunk with CallBatch(). The chunks overlap by a tenth of
+// chunkSize. If mimeType is empty, it is detected from b. Files other than plain text and HTML
+// need an Extractor registered with extract.Register(). If some chunks fail, the error is a
+// *PartialError and the Document holds the vectors that succeeded.
+func (c *Client) CallDocument(ctx context.Context, b []byte, mimeType string, chunkSize int, options ...CallOption) (Document, error) {
+	if chunkSize <= 0 {
+		return Document{}, errors.New("chunkSize must be > 0")
+	}
+
+	doc, err := extract.Extract(ctx, b, mimeType)
+	if err != nil {
+		return Document{}, err
+	}
+	chunks := extract.Split(doc, chunkSize, chunkSize/10)
+	if len(chunks) == 0 {
+		return Document{}, errors.New("the document has no text")
+	}
+
+	text := make([]string, len(chunks))
+	for i, ch := range chunks {
+		text[i] = ch.Text
+	}
+
+	emb, err := c.CallBatch(ctx, text, 0, options...)
+	out := Document{Document: doc, Chunks: chunks, Embeddings: emb}
+	if err != nil {
+		var perr *PartialError
+		if errors.As(err, &perr) {
+			return out, err
+		}
+		return Document{}, err
+	}
+	return out, nil
+}
@@ -0,0 +1,171 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DriftReport compares how two embeddings models see the same probe texts. Vectors from different
+// models can't be compared directly, so the report compares the similarity of the probes to each
+// other under each model. If the models agree on which probes are similar, a corpus embedded with
+// the old model can still be searched well enough. If not, it should be re-embedded.
+type DriftReport struct {
+	// Probes is the number of probe texts.
+	Probes int
+	// K is the number of nearest neighbors compared for each probe.
+	K int
+
+	// MeanCosineDelta is the mean absolute difference between the cosine similarity of each pair
+	// of probes under the two models. 0 means the models agree completely.
+	MeanCosineDelta float64
+	// MaxCosineDelta is the largest absolute difference for a pair of probes.
+	MaxCosineDelta float64
+	// NeighborOverlap is the mean fraction of each probe's K nearest neighbors that are the same
+	// under both models, between 0 and 1. 1 means searches return the same results.
+	NeighborOverlap float64
+	// ProbeOverlap is the fraction of the K nearest neighbors that are the same for each probe,
+	// indexed like the probes. Probes with a low overlap show where the models disagree most.
+	ProbeOverlap []float64
+
+	// OldUsage and NewUsage are the tokens used to embed the probes with each model. These are
+	// not set by CompareEmbeddings().
+	OldUsage, NewUsage Usage
+}
+
+// Drift embeds probes with the deployments of old and new and compares them with
+// CompareEmbeddings(). probes should be a fixed set of texts that represent your corpus, so
+// reports for different model versions can be compared over time. options are passed to both
+// CallBatch() calls. If k is 0, the 10 nearest neighbors are compared, or all other probes if
+// there are fewer.
+func Drift(ctx context.Context, old, new *Client, probes []string, k int, options ...CallOption) (DriftReport, error) {
+	if old == nil || new == nil {
+		return DriftReport{}, fmt.Errorf("Drift: clients cannot be nil")
+	}
+
+	// CompareEmbeddings() takes float64s.
+	options = append(options[:len(options):len(options)], withoutFloat32())
+	o, err := old.CallBatch(ctx, probes, 0, options...)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("problem embedding probes with the old model: %w", err)
+	}
+	n, err := new.CallBatch(ctx, probes, 0, options...)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("problem embedding probes with the new model: %w", err)
+	}
+
+	r, err := CompareEmbeddings(o.Results, n.Results, k)
+	if err != nil {
+		return DriftReport{}, err
+	}
+	r.OldUsage, r.NewUsage = o.Usage, n.Usage
+	return r, nil
+}
+
+// CompareEmbeddings compares the embeddings of the same probes made by two models, indexed
+// alike. The vectors of each model may have different dimensions. See Drift() for k.
+func CompareEmbeddings(old, new [][]float64, k int) (DriftReport, error) {
+	if len(old) != len(new) {
+		return DriftReport{}, fmt.Errorf("old has %d embeddings and new has %d, they must be the same", len(old), len(new))
+	}
+	n := len(old)
+	if n < 2 {
+		return DriftReport{}, fmt.Errorf("must have at least 2 probes, had %d", n)
+	}
+	switch {
+	case k < 0:
+		return DriftReport{}, fmt.Errorf("k must be >= 0, was %d", k)
+	case k == 0:
+		k = 10
+	}
+	if k > n-1 {
+		k = n - 1
+	}
+
+	simOld, err := similarities(old)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("old: %w", err)
+	}
+	simNew, err := similarities(new)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("new: %w", err)
+	}
+
+	r := DriftReport{Probes: n, K: k, ProbeOverlap: make([]float64, n)}
+	pairs := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d := math.Abs(simOld[i][j] - simNew[i][j])
+			r.MeanCosineDelta += d
+			r.MaxCosineDelta = math.Max(r.MaxCosineDelta, d)
+			pairs++
+		}
+
+		a, b := neighbors(simOld[i], i, k), neighbors(simNew[i], i, k)
+		same := 0
+		for id := range a {
+			if b[id] {
+				same++
+			}
+		}
+		r.ProbeOverlap[i] = float64(same) / float64(k)
+		r.NeighborOverlap += r.ProbeOverlap[i]
+	}
+	r.MeanCosineDelta /= float64(pairs)
+	r.NeighborOverlap /= float64(n)
+	return r, nil
+}
+
+// similarities returns the cosine similarity of every pair of vectors.
+func similarities(vecs [][]float64) ([][]float64, error) {
+	norms := make([]float64, len(vecs))
+	for i, v := range vecs {
+		if len(v) != len(vecs[0]) {
+			return nil, fmt.Errorf("embedding %d has %d dimensions, embedding 0 has %d", i, len(v), len(vecs[0]))
+		}
+		for _, x := range v {
+			norms[i] += x * x
+		}
+		norms[i] = math.Sqrt(norms[i])
+		if norms[i] == 0 {
+			return nil, fmt.Errorf("embedding %d is a zero vector", i)
+		}
+	}
+
+	sim := make([][]float64, len(vecs))
+	for i := range vecs {
+		sim[i] = make([]float64, len(vecs))
+	}
+	for i := range vecs {
+		for j := i; j < len(vecs); j++ {
+			var dot float64
+			for d := range vecs[i] {
+				dot += vecs[i][d] * vecs[j][d]
+			}
+			s := dot / (norms[i] * norms[j])
+			sim[i][j], sim[j][i] = s, s
+		}
+	}
+	return sim, nil
+}
+
+// neighbors returns the k most similar probes to probe i, given its similarity to every probe.
+func neighbors(sim []float64, i, k int) map[int]bool {
+	ids := make([]int, 0, len(sim)-1)
+	for j := range sim {
+		if j != i {
+			ids = append(ids, j)
+		}
+	}
+	// Sort by index on ties, so the result is stable.
+	sort.SliceStable(ids, func(a, b int) bool {
+		return sim[ids[a]] > sim[ids[b]]
+	})
+
+	m := make(map[int]bool, k)
+	for _, id := range ids[:k] {
+		m[id] = true
+	}
+	return m
+}
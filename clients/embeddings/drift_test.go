@@ -0,0 +1,107 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+func TestCompareEmbeddings(t *testing.T) {
+	old := [][]float64{{1, 0}, {0.9, 0.1}, {0, 1}, {0.1, 0.9}}
+
+	tests := []struct {
+		desc        string
+		new         [][]float64
+		k           int
+		wantNoDelta bool
+		wantOverlap float64
+		wantErr     bool
+	}{
+		{
+			desc:        "Same structure in more dimensions",
+			new:         [][]float64{{2, 0, 0}, {1.8, 0.2, 0}, {0, 2, 0}, {0.2, 1.8, 0}},
+			k:           1,
+			wantNoDelta: true,
+			wantOverlap: 1,
+		},
+		{
+			desc: "Neighbors changed",
+			// Probe 0 is now closest to probe 2 and probe 1 to probe 3.
+			new:         [][]float64{{1, 0}, {0, 1}, {0.9, 0.1}, {0.1, 0.9}},
+			k:           1,
+			wantOverlap: 0,
+		},
+		{desc: "Different lengths", new: old[:3], wantErr: true},
+		{desc: "Zero vector", new: [][]float64{{1, 0}, {0, 0}, {0, 1}, {1, 1}}, wantErr: true},
+		{desc: "Negative k", new: old, k: -1, wantErr: true},
+	}
+
+	for _, test := range tests {
+		r, err := CompareEmbeddings(old, test.new, test.k)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestCompareEmbeddings(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestCompareEmbeddings(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if noDelta := r.MeanCosineDelta < 1e-9; noDelta != test.wantNoDelta {
+			t.Errorf("TestCompareEmbeddings(%s): got MeanCosineDelta %v, want no delta == %v", test.desc, r.MeanCosineDelta, test.wantNoDelta)
+		}
+		if math.Abs(r.NeighborOverlap-test.wantOverlap) > 1e-9 {
+			t.Errorf("TestCompareEmbeddings(%s): got NeighborOverlap %v, want %v", test.desc, r.NeighborOverlap, test.wantOverlap)
+		}
+		if r.Probes != len(old) || len(r.ProbeOverlap) != len(old) {
+			t.Errorf("TestCompareEmbeddings(%s): got %d probes and %d overlaps, want %d", test.desc, r.Probes, len(r.ProbeOverlap), len(old))
+		}
+	}
+}
+
+func TestDrift(t *testing.T) {
+	// The old deployment embeds by length, the new one the same in two dimensions.
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var in embeddings.Req
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			return nil, err
+		}
+		newModel := strings.Contains(req.URL.Path, "/new/")
+
+		out := embeddings.Resp{Usage: embeddings.Usage{PromptTokens: len(in.Input), TotalTokens: len(in.Input)}}
+		for i, s := range in.Input {
+			v := []float64{1, float64(len(s))}
+			if newModel {
+				v = []float64{1, float64(len(s)), 0}
+			}
+			out.Data = append(out.Data, embeddings.Data{Index: i, Embedding: v})
+		}
+		b, _ := json.Marshal(out)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(string(b))), Request: req}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Drift(context.Background(), New("old", rc), New("new", rc), []string{"a", "bb", "cccc", "dddddddd"}, 0)
+	if err != nil {
+		t.Fatalf("TestDrift: got err == %s, want err == nil", err)
+	}
+	if r.K != 3 || r.NeighborOverlap != 1 || r.MeanCosineDelta > 1e-9 {
+		t.Errorf("TestDrift: got %+v, want K 3 with no drift", r)
+	}
+	if r.OldUsage.TotalTokens != 4 || r.NewUsage.TotalTokens != 4 {
+		t.Errorf("TestDrift: got usage %+v and %+v, want 4 tokens each", r.OldUsage, r.NewUsage)
+	}
+}
@@ -41,15 +41,66 @@ You can also override the parameters on a per-call basis:
 		return err
 	}
 	fmt.Printf("%v", resp.Results)
+
+text-embedding-3 models can return smaller vectors, which can be sent as base64 to shrink the
+response. Results are decoded to []float64 either way:
+
+	params, err := embeddings.Builder().Dimensions(256).EncodingFormat(embeddings.Base64).Build()
+	if err != nil {
+		return err
+	}
+	resp, err := embeddingsClient.Call(ctx, text, embeddings.WithCallParams(params))
+
+Large inputs can be split into several requests with CallBatch, which can send them
+concurrently. If some requests fail, a *PartialError holds the vectors that succeeded and the
+inputs to retry:
+
+	resp, err := embeddingsClient.CallBatch(ctx, docs, 512, embeddings.WithParallelism(4))
+	var perr *embeddings.PartialError
+	if errors.As(err, &perr) {
+		retry := make([]string, 0, len(perr.Failed))
+		for _, i := range perr.Failed {
+			retry = append(retry, docs[i])
+		}
+		...
+	}
+
+Duplicate inputs can be removed before they are embedded with WithDedup. Removed inputs receive
+the vector of the input they duplicate:
+
+	resp, err := embeddingsClient.CallBatch(ctx, docs, 512, embeddings.WithDedup(0.9))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("skipped %d of %d inputs", resp.Dedup.Saved(), resp.Dedup.Inputs)
+
+Files can be embedded end-to-end with CallDocument, which extracts the text with the extract
+package and embeds it in chunks:
+
+	b, err := os.ReadFile("guide.html")
+	if err != nil {
+		return err
+	}
+	doc, err := embeddingsClient.CallDocument(ctx, b, "text/html", 2000)
+	if err != nil {
+		return err
+	}
+	for i, chunk := range doc.Chunks {
+		fmt.Println(chunk.Title, doc.Results[i][:4])
+	}
 */
 package embeddings
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/element-of-surprise/azopenai/errors"
 	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
 	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
 )
 
@@ -74,6 +125,10 @@ func New(deploymentID string, rest *rest.Client) *Client {
 // CallParams are the parameters used on each call to the embeddings service. These
 // are all optional fields. You can set this on the client and override it on a per-call
 // basis.
+//
+// Setting fields directly is deprecated in favor of Builder(), which starts from the defaults and
+// validates the values. Because the client stores a copy, changing a CallParams after passing it
+// to SetParams() has no effect.
 type CallParams struct {
 	// User is a unique identifier representing your end-user, which can help monitoring and detecting abuse.
 	User string `json:"user,omitempty"`
@@ -81,26 +136,63 @@ type CallParams struct {
 	Type string `json:"input_type,omitempty"`
 	// Model is the model ID to use. This is optional.
 	Model string `json:"model,omitempty"`
+	// Dimensions is the number of dimensions of the Results. Smaller vectors use less storage
+	// and are faster to compare, at some loss of accuracy. Only text-embedding-3 and later models
+	// support this. Defaults to the full size of the model.
+	Dimensions int `json:"dimensions,omitempty"`
+	// EncodingFormat is the format the service sends the embeddings in. Base64 is about a quarter
+	// of the size of Float and is decoded, so Results are the same either way.
+	EncodingFormat EncodingFormat `json:"encoding_format,omitempty"`
 }
 
 func (c CallParams) toEmbeddingsRequest() embeddings.Req {
 	return embeddings.Req{
-		User:  c.User,
-		Type:  c.Type,
-		Model: c.Model,
+		User:           c.User,
+		Type:           c.Type,
+		Model:          c.Model,
+		Dimensions:     c.Dimensions,
+		EncodingFormat: c.EncodingFormat,
 	}
 }
 
+// EncodingFormat is the format the service sends the embeddings in.
+type EncodingFormat = embeddings.EncodingFormat
+
+const (
+	// Float sends the embeddings as JSON arrays of numbers. This is the service default.
+	Float = embeddings.Float
+	// Base64 sends the embeddings as base64 encoded float32s.
+	Base64 = embeddings.Base64
+)
+
 // SetParams sets the CallParams for the client. This will be used for all calls unless
 // overridden by a CallOption.
 func (c *Client) SetParams(params CallParams) {
 	c.CallParams.Store(&params)
 }
 
+// Usage is the number of tokens used by a call.
+type Usage = embeddings.Usage
+
 // Embeddings returns the embeddings for the given set of text.
 type Embeddings struct {
-	// Results is a set of embeddings([]float64), one for each input sent.
+	// Results is a set of embeddings([]float64), one for each input sent. Each has
+	// CallParams.Dimensions entries if that was set.
 	Results [][]float64
+	// Results32 is Results as float32s, which is half the memory and what most vector databases
+	// store. This is set instead of Results if WithFloat32() is used.
+	Results32 [][]float32
+
+	// Meta is metadata taken from the response headers, such as the request ID and
+	// rate limit information.
+	Meta custom.ResponseMeta
+
+	// Usage is the number of tokens used. For CallBatch, this is the sum over all requests that succeeded.
+	Usage Usage
+
+	// Dedup reports the inputs that were not embedded because they were duplicates. This is
+	// only set if WithDedup() is used.
+	Dedup DedupReport
 
 	// RestReq is the raw REST request sent to the server. This is only set if requested
 	// with a CallOption.
@@ -114,10 +206,22 @@ type callOptions struct {
 	CallParams    CallParams
 	DeploymentID  string
 	setCallParams bool
+	APIVersion    string
+	ExtraFields   map[string]any
+	RawResponse   *[]byte
+	Timeout       time.Duration
 
 	RestReq        bool
 	RestResp       bool
 	RemoveNewlines bool
+
+	Dedup          bool
+	DedupThreshold float64
+
+	Parallelism int
+	ErrorMode   errors.Mode
+
+	Float32 bool
 }
 
 // CallOption is an optional argument for the Call method.
@@ -133,6 +237,18 @@ func WithCallParams(params CallParams) CallOption {
 	}
 }
 
+// WithAPIVersion sets the API version of the service to use for the call, such as "2024-02-01".
+// This overrides azopenai.WithAPIVersion().
+func WithAPIVersion(version string) CallOption {
+	return func(o *callOptions) error {
+		if _, err := rest.ContextWithAPIVersion(context.Background(), version); err != nil {
+			return err
+		}
+		o.APIVersion = version
+		return nil
+	}
+}
+
 // WithDeploymentID sets the deployment ID to use for the call. If not set, the deploymentID
 // set on the client will be used.
 func WithDeploymentID(deploymentID string) CallOption {
@@ -162,6 +278,75 @@ func WithNewlineRemoval() CallOption {
 	}
 }
 
+// WithDedup removes duplicate inputs before they are embedded. Each removed input receives the
+// vector of the input it duplicates and Embeddings.Dedup reports the savings. Identical inputs
+// are always removed. If threshold > 0, inputs that are near duplicates of an earlier input are
+// also removed, where threshold is the minimum estimated Jaccard similarity of their word
+// shingles, between 0 and 1. A threshold of 0.9 is a good starting point.
+func WithDedup(threshold float64) CallOption {
+	return func(o *callOptions) error {
+		if threshold < 0 || threshold > 1 {
+			return fmt.Errorf("WithDedup threshold must be between 0 and 1, was %v", threshold)
+		}
+		o.Dedup = true
+		o.DedupThreshold = threshold
+		return nil
+	}
+}
+
+// WithFloat32 sets Embeddings.Results32 instead of Embeddings.Results. The service has float32
+// precision, so nothing is lost.
+func WithFloat32() CallOption {
+	return func(o *callOptions) error {
+		o.Float32 = true
+		return nil
+	}
+}
+
+// withoutFloat32 disables WithFloat32(). This is used by CallBatch, which converts once all
+// chunks are gathered.
+func withoutFloat32() CallOption {
+	return func(o *callOptions) error {
+		o.Float32 = false
+		return nil
+	}
+}
+
+// withoutDedup disables WithDedup(). This is used by CallBatch, which dedups across all chunks.
+func withoutDedup() CallOption {
+	return func(o *callOptions) error {
+		o.Dedup = false
+		return nil
+	}
+}
+
+// WithParallelism sets how many requests CallBatch sends at the same time. Defaults to 1.
+// The results are in the order of the inputs regardless. Ignored by Call().
+func WithParallelism(n int) CallOption {
+	return func(o *callOptions) error {
+		if n < 1 {
+			return fmt.Errorf("WithParallelism(%d): must be >= 1", n)
+		}
+		o.Parallelism = n
+		return nil
+	}
+}
+
+// WithErrorMode sets how CallBatch handles a chunk that fails. Defaults to errors.CollectAll.
+// With errors.FailFast, chunks that have not been sent when a chunk fails are not sent and
+// are included in the *PartialError. Ignored by Call().
+func WithErrorMode(m errors.Mode) CallOption {
+	return func(o *callOptions) error {
+		switch m {
+		case errors.CollectAll, errors.FailFast:
+		default:
+			return fmt.Errorf("WithErrorMode(%v): unknown mode", m)
+		}
+		o.ErrorMode = m
+		return nil
+	}
+}
+
 // Call makes a call to the Embeddings API endpoint and returns the embeddings for the tokens.
 func (c *Client) Call(ctx context.Context, text []string, options ...CallOption) (Embeddings, error) {
 	callOptions := callOptions{}
@@ -185,25 +370,40 @@ func (c *Client) Call(ctx context.Context, text []string, options ...CallOption)
 		}
 	}
 
+	var report DedupReport
+	if callOptions.Dedup {
+		text, report = dedup(text, callOptions.DedupThreshold)
+	}
+
 	req := callOptions.CallParams.toEmbeddingsRequest()
 	req.Input = text
+	if err := req.Validate(); err != nil {
+		return Embeddings{}, err
+	}
 
 	deploymentID := c.deploymentID
 	if callOptions.DeploymentID != "" {
 		deploymentID = callOptions.DeploymentID
 	}
+	ctx = restContext(ctx, callOptions)
+	ctx, cancel := withTimeout(ctx, callOptions)
+	defer cancel()
 
 	resp, err := c.rest.Embeddings(ctx, deploymentID, req)
 	if err != nil {
 		return Embeddings{}, err
 	}
 
-	emb := Embeddings{Results: make([][]float64, len(resp.Data))}
+	emb := Embeddings{Results: make([][]float64, len(resp.Data)), Meta: resp.Meta, Usage: resp.Usage}
 	for i, data := range resp.Data {
 		r := emb.Results[i]
 		r = append(r, data.Embedding...)
 		emb.Results[i] = r
 	}
+	if callOptions.Dedup && len(emb.Results) != len(report.Unique) {
+		return Embeddings{}, fmt.Errorf("got %d results, want %d", len(emb.Results), len(report.Unique))
+	}
+	emb.finish(callOptions, report)
 
 	if callOptions.RestReq {
 		emb.RestReq = req
@@ -214,3 +414,51 @@ func (c *Client) Call(ctx context.Context, text []string, options ...CallOption)
 
 	return emb, nil
 }
+
+// finish converts the results to float32 if WithFloat32() is used and expands the results of
+// deduped inputs. The results are converted first so that each unique vector is only converted once.
+func (e *Embeddings) finish(opts callOptions, report DedupReport) {
+	if opts.Float32 {
+		e.Results32 = toFloat32(e.Results)
+		e.Results = nil
+	}
+	if !opts.Dedup {
+		return
+	}
+	if opts.Float32 {
+		e.Results32 = expand(report, e.Results32)
+	} else {
+		e.Results = expand(report, e.Results)
+	}
+	e.Dedup = report
+}
+
+// len returns the number of results.
+func (e Embeddings) len() int {
+	if e.Results32 != nil {
+		return len(e.Results32)
+	}
+	return len(e.Results)
+}
+
+// toFloat32 converts results to float32s in a single allocation. Nil vectors stay nil.
+func toFloat32(results [][]float64) [][]float32 {
+	total := 0
+	for _, r := range results {
+		total += len(r)
+	}
+	buf := make([]float32, total)
+	out := make([][]float32, len(results))
+	for i, r := range results {
+		if r == nil {
+			continue
+		}
+		v := buf[:len(r):len(r)]
+		buf = buf[len(r):]
+		for j, f := range r {
+			v[j] = float32(f)
+		}
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package embeddings
+
+import "fmt"
+
+// ParamsBuilder builds a CallParams. A ParamsBuilder is immutable, each method returns a new
+// ParamsBuilder, so a partially built ParamsBuilder can be shared between goroutines and used
+// as a base for other ParamsBuilders. Use Builder() to create one.
+type ParamsBuilder struct {
+	p CallParams
+}
+
+// Builder returns a ParamsBuilder. This is the preferred way to create CallParams:
+//
+//	params, err := embeddings.Builder().User("element-of-surprise").Build()
+func Builder() ParamsBuilder {
+	return ParamsBuilder{}
+}
+
+// User sets CallParams.User.
+func (b ParamsBuilder) User(user string) ParamsBuilder {
+	b.p.User = user
+	return b
+}
+
+// Type sets CallParams.Type.
+func (b ParamsBuilder) Type(t string) ParamsBuilder {
+	b.p.Type = t
+	return b
+}
+
+// Model sets CallParams.Model.
+func (b ParamsBuilder) Model(model string) ParamsBuilder {
+	b.p.Model = model
+	return b
+}
+
+// Dimensions sets CallParams.Dimensions.
+func (b ParamsBuilder) Dimensions(n int) ParamsBuilder {
+	b.p.Dimensions = n
+	return b
+}
+
+// EncodingFormat sets CallParams.EncodingFormat.
+func (b ParamsBuilder) EncodingFormat(f EncodingFormat) ParamsBuilder {
+	b.p.EncodingFormat = f
+	return b
+}
+
+// Build validates and returns the CallParams.
+func (b ParamsBuilder) Build() (CallParams, error) {
+	if err := b.p.validate(); err != nil {
+		return CallParams{}, err
+	}
+	return b.p, nil
+}
+
+func (c CallParams) validate() error {
+	if c.Dimensions < 0 {
+		return fmt.Errorf("Dimensions must be >= 0, was %d", c.Dimensions)
+	}
+	switch c.EncodingFormat {
+	case "", Float, Base64:
+	default:
+		return fmt.Errorf("EncodingFormat %q is not supported", c.EncodingFormat)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package embeddings
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		desc    string
+		b       ParamsBuilder
+		wantErr bool
+	}{
+		{desc: "Defaults", b: Builder()},
+		{desc: "Valid", b: Builder().User("u").Type("query").Model("m").Dimensions(256).EncodingFormat(Base64)},
+		{desc: "Float", b: Builder().EncodingFormat(Float)},
+		{desc: "Negative Dimensions", b: Builder().Dimensions(-1), wantErr: true},
+		{desc: "Unknown EncodingFormat", b: Builder().EncodingFormat("int8"), wantErr: true},
+	}
+
+	for _, test := range tests {
+		params, err := test.b.Build()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if err := params.validate(); err != nil {
+			t.Errorf("TestBuilder(%s): built CallParams failed validate(): %s", test.desc, err)
+		}
+	}
+
+	base := Builder().Dimensions(256)
+	if p, _ := base.Dimensions(512).Build(); p.Dimensions != 512 {
+		t.Errorf("TestBuilder(derived): got Dimensions %d, want 512", p.Dimensions)
+	}
+	if p, _ := base.Build(); p.Dimensions != 256 {
+		t.Errorf("TestBuilder(base): got Dimensions %d, want 256", p.Dimensions)
+	}
+}
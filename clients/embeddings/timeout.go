@@ -0,0 +1,28 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithTimeout sets a deadline of d on the call, on top of the deadline of ctx. CallBatch() and
+// CallDocument() apply it to each request they make. This overrides azopenai.WithTimeout().
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("WithTimeout: timeout must be > 0")
+		}
+		o.Timeout = d
+		return nil
+	}
+}
+
+// withTimeout returns ctx with the deadline set by WithTimeout(). cancel must be called when
+// the call is done.
+func withTimeout(ctx context.Context, o callOptions) (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.Timeout)
+}
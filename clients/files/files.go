@@ -0,0 +1,125 @@
+/*
+Package files provides access to the Files API, which stores files on the resource for
+fine-tuning, batch jobs and assistants file search.
+
+The simplest way to create a Client is by using the azopenai.Client.Files() method.
+
+Uploads and downloads are streamed, so large JSONL files are not held in memory. Uploading
+training data for fine-tuning:
+
+	f, err := os.Open("training.jsonl")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	file, err := client.Files().Upload(ctx, "training.jsonl", f, files.FineTune)
+	if err != nil {
+		return err
+	}
+	fmt.Println(file.ID)
+
+Downloading the results of a job:
+
+	r, err := client.Files().Content(ctx, resultsID)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(out, r)
+*/
+package files
+
+import (
+	"context"
+	"io"
+
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/files"
+)
+
+// File is a file stored on the resource.
+type File = files.File
+
+// List is a list of files.
+type List = files.List
+
+// DeleteResp is the response to deleting a file.
+type DeleteResp = files.DeleteResp
+
+// Purpose is what a file is used for.
+type Purpose = files.Purpose
+
+const (
+	// FineTune is a JSONL file of training or validation data for fine-tuning.
+	FineTune = files.FineTune
+	// FineTuneResults is a file of results written by a fine-tuning job.
+	FineTuneResults = files.FineTuneResults
+	// Assistants is a file used by assistants, such as for file search.
+	Assistants = files.Assistants
+	// AssistantsOutput is a file written by an assistant.
+	AssistantsOutput = files.AssistantsOutput
+	// Batch is a JSONL file of requests for a batch job.
+	Batch = files.Batch
+	// BatchOutput is a file of results written by a batch job.
+	BatchOutput = files.BatchOutput
+)
+
+// Status is the processing status of a file.
+type Status = files.Status
+
+const (
+	// Uploaded indicates the file was uploaded and has not been processed.
+	Uploaded = files.Uploaded
+	// Pending indicates the file is waiting to be processed.
+	Pending = files.Pending
+	// Running indicates the file is being processed.
+	Running = files.Running
+	// Processed indicates the file was processed and is ready to use.
+	Processed = files.Processed
+	// Error indicates the file could not be processed, see File.StatusDetails.
+	Error = files.Error
+	// Deleting indicates the file is being deleted.
+	Deleting = files.Deleting
+	// Deleted indicates the file was deleted.
+	Deleted = files.Deleted
+)
+
+// Client provides access to the Files API.
+type Client struct {
+	rest *rest.Client
+}
+
+// New creates a new instance of the Client type from the rest.Client. This is generally
+// not used directly, but is used by the azopenai.Client.
+func New(rest *rest.Client) *Client {
+	return &Client{rest: rest}
+}
+
+// Upload uploads the content of r as a file named name. r is streamed to the service as it is
+// read. Uploads are not retried, as r can only be read once.
+func (c *Client) Upload(ctx context.Context, name string, r io.Reader, purpose Purpose) (File, error) {
+	return c.rest.FileUpload(ctx, files.UploadReq{File: r, FileName: name, Purpose: purpose})
+}
+
+// Get returns the file with id.
+func (c *Client) Get(ctx context.Context, id string) (File, error) {
+	return c.rest.File(ctx, id)
+}
+
+// List lists the files. If purpose is set, only files with that purpose are listed.
+func (c *Client) List(ctx context.Context, purpose Purpose) (List, error) {
+	return c.rest.Files(ctx, purpose)
+}
+
+// Delete deletes the file with id.
+func (c *Client) Delete(ctx context.Context, id string) (DeleteResp, error) {
+	return c.rest.FileDelete(ctx, id)
+}
+
+// Content returns the content of the file with id, streamed as it is read. The caller must
+// close it.
+func (c *Client) Content(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.rest.FileContent(ctx, id)
+}
@@ -0,0 +1,225 @@
+/*
+Package images provides access to the image generation API. This allows you to generate
+images from a text description using DALL-E.
+
+The simplest way to create a Client is by using the azopenai.Client.Images() method.
+
+Using this API is simple:
+
+	imagesClient := client.Images()
+	resp, err := imagesClient.Call(context.Background(), "A painting of a gopher reading a book")
+	if err != nil {
+		return err
+	}
+	fmt.Println(resp.URLs[0])
+
+You can also set the default parameters for the client:
+
+	params := images.CallParams{}.Defaults()
+	params.N = 2
+	params.Size = images.Size512x512
+	imagesClient.SetParams(params)
+
+You can also override the parameters on a per-call basis:
+
+	params := images.CallParams{}.Defaults()
+	params.Format = images.B64JSON
+	resp, err := imagesClient.Call(context.Background(), "A gopher", images.WithCallParams(params))
+	if err != nil {
+		return err
+	}
+	os.WriteFile("gopher.png", resp.Data[0], 0644)
+*/
+package images
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+	"github.com/element-of-surprise/azopenai/rest/messages/images"
+)
+
+// Size is the size of the generated images.
+type Size = images.Size
+
+const (
+	// Size256x256 is a 256x256 image.
+	Size256x256 = images.Size256x256
+	// Size512x512 is a 512x512 image.
+	Size512x512 = images.Size512x512
+	// Size1024x1024 is a 1024x1024 image.
+	Size1024x1024 = images.Size1024x1024
+)
+
+// Format is the format the generated images are returned in.
+type Format = images.Format
+
+const (
+	// URL returns a URL to each image. The images will be deleted after 24 hours.
+	URL = images.URL
+	// B64JSON returns the image bytes in the response.
+	B64JSON = images.B64JSON
+)
+
+// Client provides access to the image generation API.
+type Client struct {
+	rest *rest.Client
+
+	callParams atomic.Pointer[CallParams]
+}
+
+// New creates a new instance of the Client type from the rest.Client. This is generally
+// not used directly, but is used by the azopenai.Client.
+func New(rest *rest.Client) *Client {
+	return &Client{rest: rest}
+}
+
+var defaults = CallParams{
+	N:      1,
+	Size:   Size1024x1024,
+	Format: URL,
+}
+
+// CallParams are the parameters used on each call to the image generation service. These
+// are all optional fields. You can set this on the client and override it on a per-call
+// basis.
+type CallParams struct {
+	// N is the number of images to generate. Must be between 1 and 5.
+	N int
+	// Size is the size of the generated images.
+	Size Size
+	// Format is the format the images are returned in. If URL, Images.URLs is set. If B64JSON,
+	// Images.Data is set.
+	Format Format
+	// User is a unique identifier representing your end-user, which can help monitoring and detecting abuse.
+	User string
+}
+
+// Defaults returns a CallParams with default values set. This should be called before
+// setting any values as it will override any values that are set.
+func (c CallParams) Defaults() CallParams {
+	c.N = defaults.N
+	c.Size = defaults.Size
+	c.Format = defaults.Format
+	c.User = defaults.User
+	return c
+}
+
+func (c CallParams) toImagesRequest() images.Req {
+	return images.Req{
+		N:              c.N,
+		Size:           c.Size,
+		ResponseFormat: c.Format,
+		User:           c.User,
+	}
+}
+
+// SetParams sets the CallParams for the client. This will be used for all calls unless
+// overridden by a CallOption.
+func (c *Client) SetParams(params CallParams) {
+	c.callParams.Store(&params)
+}
+
+// Images are the images generated by the API.
+type Images struct {
+	// URLs are the URLs of the images. Only set if the CallParams.Format was URL.
+	URLs []string
+	// Data are the bytes of the images. Only set if the CallParams.Format was B64JSON.
+	Data [][]byte
+	// RevisedPrompts are the prompts used to generate each image, if the service revised them.
+	RevisedPrompts []string
+
+	// Meta is metadata taken from the response headers of the final poll of the operation.
+	Meta custom.ResponseMeta
+
+	// RestReq is the raw request sent to the REST API. This is only provided if a specific
+	// CallOption is used.
+	RestReq images.Req
+	// RestResp is the raw response from the REST API. This is only provided if a specific
+	// CallOption is used.
+	RestResp images.Resp
+}
+
+type callOptions struct {
+	CallParams    CallParams
+	setCallParams bool
+
+	RestReq  bool
+	RestResp bool
+}
+
+// CallOption is an optional argument for the Call method.
+type CallOption func(options *callOptions) error
+
+// WithCallParams sets the CallParams for the call. If not set, the call params set for
+// the client will be used. If those weren't set, the default call options are used.
+func WithCallParams(params CallParams) CallOption {
+	return func(o *callOptions) error {
+		o.CallParams = params
+		o.setCallParams = true
+		return nil
+	}
+}
+
+// WithRest sets whether to return the raw REST request and response. This is useful for
+// debugging purposes.
+func WithRest(req, resp bool) CallOption {
+	return func(o *callOptions) error {
+		o.RestReq = req
+		o.RestResp = resp
+		return nil
+	}
+}
+
+// Call generates images from the prompt. This blocks until the images have been generated,
+// which can take several seconds. Use a context with a deadline to limit how long to wait.
+func (c *Client) Call(ctx context.Context, prompt string, options ...CallOption) (Images, error) {
+	callOptions := callOptions{}
+	for _, o := range options {
+		if err := o(&callOptions); err != nil {
+			return Images{}, err
+		}
+	}
+	if !callOptions.setCallParams {
+		callOptions.CallParams = defaults
+		p := c.callParams.Load()
+		if p != nil {
+			callOptions.CallParams = *p
+		}
+	}
+
+	req := callOptions.CallParams.toImagesRequest()
+	req.Prompt = prompt
+
+	resp, err := c.rest.Images(ctx, req)
+	if err != nil {
+		return Images{}, err
+	}
+
+	imgs := Images{Meta: resp.Meta}
+	if callOptions.RestReq {
+		imgs.RestReq = req
+	}
+	if callOptions.RestResp {
+		imgs.RestResp = resp
+	}
+
+	for i, d := range resp.Result.Data {
+		if d.URL != "" {
+			imgs.URLs = append(imgs.URLs, d.URL)
+		}
+		if d.B64JSON != "" {
+			b, err := base64.StdEncoding.DecodeString(d.B64JSON)
+			if err != nil {
+				return Images{}, fmt.Errorf("problem decoding image %d: %w", i, err)
+			}
+			imgs.Data = append(imgs.Data, b)
+		}
+		imgs.RevisedPrompts = append(imgs.RevisedPrompts, d.RevisedPrompt)
+	}
+	return imgs, nil
+}
@@ -0,0 +1,102 @@
+/*
+Package ingestion provides access to the ingestion jobs API. Ingestion jobs create an Azure
+Cognitive Search index from documents in blob storage, which can then be used as a chat
+DataSource with Azure OpenAI on your data.
+
+The simplest way to create a Client is by using the azopenai.Client.Ingestion() method.
+
+Running a job and waiting for it to finish:
+
+	ingestClient := client.Ingestion()
+	job, err := ingestClient.Run(ctx, "docs-index", ingestion.Req{
+		SearchServiceEndpoint:   "https://mysearch.search.windows.net",
+		SearchServiceAdminKey:   searchKey,
+		StorageConnectionString: storageConn,
+		StorageContainer:        "docs",
+	})
+	if err != nil {
+		var jobErr *ingestion.Error
+		if errors.As(err, &jobErr) {
+			// The job ran, but failed.
+		}
+		return err
+	}
+	fmt.Println(job.Warnings)
+
+Jobs take minutes to hours, so you can also create a job and check on it later:
+
+	if _, err := ingestClient.Create(ctx, "docs-index", req); err != nil {
+		return err
+	}
+	...
+	job, err := ingestClient.Status(ctx, "docs-index")
+*/
+package ingestion
+
+import (
+	"context"
+
+	"github.com/element-of-surprise/azopenai/rest"
+	"github.com/element-of-surprise/azopenai/rest/messages/ingestion"
+)
+
+// Req is a request to create an ingestion job.
+type Req = ingestion.Req
+
+// Job is the state of an ingestion job.
+type Job = ingestion.Resp
+
+// Error is the error returned when a job fails.
+type Error = ingestion.Error
+
+// Status is the status of an ingestion job.
+type Status = ingestion.Status
+
+const (
+	// NotRunning indicates the job has not started.
+	NotRunning = ingestion.NotRunning
+	// Running indicates the job is running.
+	Running = ingestion.Running
+	// Succeeded indicates the job finished and the index is ready.
+	Succeeded = ingestion.Succeeded
+	// Failed indicates the job failed.
+	Failed = ingestion.Failed
+)
+
+// CompletionAction is what to do with the intermediate assets when a job finishes.
+type CompletionAction = ingestion.CompletionAction
+
+const (
+	// CleanUpAssets removes the intermediate assets, such as the indexer and skillset.
+	CleanUpAssets = ingestion.CleanUpAssets
+	// KeepAllAssets keeps the intermediate assets.
+	KeepAllAssets = ingestion.KeepAllAssets
+)
+
+// Client provides access to the ingestion jobs API.
+type Client struct {
+	rest *rest.Client
+}
+
+// New creates a new instance of the Client type from the rest.Client. This is generally
+// not used directly, but is used by the azopenai.Client.
+func New(rest *rest.Client) *Client {
+	return &Client{rest: rest}
+}
+
+// Run creates a job named jobID and waits for it to finish or for ctx to be cancelled. jobID is
+// also the name of the index that is created. If the job fails, the error is an *Error if the
+// service provided one.
+func (c *Client) Run(ctx context.Context, jobID string, req Req) (Job, error) {
+	return c.rest.Ingest(ctx, jobID, req)
+}
+
+// Create creates a job named jobID without waiting for it to finish.
+func (c *Client) Create(ctx context.Context, jobID string, req Req) (Job, error) {
+	return c.rest.IngestionJobCreate(ctx, jobID, req)
+}
+
+// Status returns the current state of the job named jobID.
+func (c *Client) Status(ctx context.Context, jobID string) (Job, error) {
+	return c.rest.IngestionJob(ctx, jobID)
+}
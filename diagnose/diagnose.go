@@ -0,0 +1,208 @@
+/*
+Package diagnose explains failed requests to the Azure OpenAI service in plain language, for
+support tooling and debugging. It works on the exchanges captured by the replay package.
+
+Diagnose() applies local rules that cover the common failures, such as bad credentials, missing
+deployments, throttling and parameters a model does not support. It never calls the service.
+
+Diagnosing the failures in a recorded run:
+
+	for _, e := range run.Exchanges {
+		if d, ok := diagnose.Diagnose(e); ok {
+			fmt.Println(d)
+		}
+	}
+
+Explain() also asks a model for an explanation, which helps with failures the rules do not know.
+Use a cheap deployment for this:
+
+	d, err := diagnose.Explain(ctx, client.Chat("gpt-4o-mini"), e)
+	if err != nil {
+		return err
+	}
+	fmt.Println(d.Explanation)
+
+The model is sent the URL, the status, the response and the parameters of the request, but not
+the prompt, messages or input of the request.
+*/
+package diagnose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/replay"
+)
+
+// Diagnosis is the explanation of a failed request.
+type Diagnosis struct {
+	// Status is the HTTP status code of the response, or 0 if there was no response.
+	Status int
+	// Code is the error code in the response body, such as "DeploymentNotFound".
+	Code string
+	// Message is the error message in the response body, or the transport error.
+	Message string
+	// RequestID is the ID of the request to give to Azure support, if the service sent one.
+	RequestID string
+
+	// Summary is a short description of what went wrong.
+	Summary string
+	// Suggestions are things to try to fix the problem.
+	Suggestions []string
+	// Explanation is the model's explanation. This is only set by Explain().
+	Explanation string
+}
+
+// String implements fmt.Stringer.
+func (d Diagnosis) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(d.Summary)
+	if d.Status != 0 {
+		fmt.Fprintf(sb, " (status %d", d.Status)
+		if d.Code != "" {
+			fmt.Fprintf(sb, ", code %s", d.Code)
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString("\n")
+	if d.Message != "" {
+		fmt.Fprintf(sb, "Message: %s\n", d.Message)
+	}
+	if d.RequestID != "" {
+		fmt.Fprintf(sb, "Request ID: %s\n", d.RequestID)
+	}
+	for _, s := range d.Suggestions {
+		fmt.Fprintf(sb, "  - %s\n", s)
+	}
+	if d.Explanation != "" {
+		fmt.Fprintf(sb, "\n%s\n", d.Explanation)
+	}
+	return sb.String()
+}
+
+// errorBody is the error response of the service.
+type errorBody struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		InnerError struct {
+			Code string `json:"code"`
+		} `json:"innererror"`
+	} `json:"error"`
+}
+
+// Diagnose returns the Diagnosis of e using local rules. ok is false if e did not fail.
+func Diagnose(e replay.Exchange) (d Diagnosis, ok bool) {
+	if e.Err == "" && e.Status >= 200 && e.Status < 300 {
+		return Diagnosis{}, false
+	}
+
+	d = Diagnosis{Status: e.Status, Message: e.Err}
+	if e.RespHeader != nil {
+		d.RequestID = e.RespHeader.Get("x-ms-request-id")
+		if d.RequestID == "" {
+			d.RequestID = e.RespHeader.Get("apim-request-id")
+		}
+	}
+	var body errorBody
+	if json.Unmarshal(e.RespBody, &body) == nil {
+		d.Code = body.Error.Code
+		d.Message = body.Error.Message
+		if body.Error.InnerError.Code != "" {
+			d.Code = body.Error.InnerError.Code
+		}
+	}
+	if d.Message == "" && e.Err == "" {
+		d.Message = strings.TrimSpace(string(e.RespBody))
+	}
+
+	for _, r := range rules {
+		if r.match(e, d) {
+			d.Summary = r.summary
+			d.Suggestions = r.suggestions
+			return d, true
+		}
+	}
+	d.Summary = "The request failed for a reason these rules do not know"
+	d.Suggestions = []string{
+		"Read the Message, or use Explain() for a model's explanation.",
+		"Open a support ticket with the Request ID if the problem persists.",
+	}
+	return d, true
+}
+
+// Explain returns the Diagnosis of e with an Explanation from the model of client. An error is
+// returned if e did not fail.
+func Explain(ctx context.Context, client *chat.Client, e replay.Exchange) (Diagnosis, error) {
+	d, ok := Diagnose(e)
+	if !ok {
+		return Diagnosis{}, fmt.Errorf("the exchange did not fail")
+	}
+
+	messages := []chat.SendMsg{
+		{Role: chat.System, Content: systemPrompt},
+		{Role: chat.User, Content: describe(e, d)},
+	}
+	resp, err := client.Call(ctx, messages)
+	if err != nil {
+		return d, fmt.Errorf("problem asking the model for an explanation: %w", err)
+	}
+	if len(resp.Text) > 0 {
+		d.Explanation = strings.TrimSpace(resp.Text[0])
+	}
+	return d, nil
+}
+
+const systemPrompt = `You are a support engineer for the Azure OpenAI service and its Go SDK.
+Explain why the described request failed and how to fix it, in a few short sentences.
+If the local diagnosis is correct, add detail to it. If you are not sure, say so.`
+
+// maxDescribed is the most bytes of a body that is sent to the model.
+const maxDescribed = 4 << 10
+
+// describe returns the description of e and d that is sent to the model.
+func describe(e replay.Exchange, d Diagnosis) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "Request: %s %s\n", e.Method, e.URL)
+	if p := params(e.ReqBody); p != "" {
+		fmt.Fprintf(sb, "Request parameters: %s\n", truncate(p))
+	}
+	if e.Err != "" {
+		fmt.Fprintf(sb, "Transport error: %s\n", e.Err)
+	} else {
+		fmt.Fprintf(sb, "Response status: %d %s\n", e.Status, http.StatusText(e.Status))
+		fmt.Fprintf(sb, "Response body: %s\n", truncate(string(e.RespBody)))
+	}
+	fmt.Fprintf(sb, "Local diagnosis: %s\n", d.Summary)
+	return sb.String()
+}
+
+// contentKeys are the request fields that hold user content and are not sent to the model.
+var contentKeys = []string{"messages", "prompt", "input", "prediction", "dataSources", "data_sources"}
+
+// params returns the JSON request body without its content, or "" if it is not a JSON object.
+func params(body []byte) string {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return ""
+	}
+	for _, k := range contentKeys {
+		delete(m, k)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func truncate(s string) string {
+	if len(s) > maxDescribed {
+		return s[:maxDescribed] + "...<truncated>"
+	}
+	return s
+}
@@ -0,0 +1,144 @@
+package diagnose
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/replay"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		desc        string
+		e           replay.Exchange
+		wantOK      bool
+		wantSummary string
+		wantCode    string
+	}{
+		{
+			desc: "Success",
+			e:    replay.Exchange{Status: http.StatusOK},
+		},
+		{
+			desc:        "Transport error",
+			e:           replay.Exchange{Err: "dial tcp: lookup nosuch.openai.azure.com: no such host"},
+			wantOK:      true,
+			wantSummary: "The request did not reach the service",
+		},
+		{
+			desc: "Deployment not found",
+			e: replay.Exchange{
+				Status:   http.StatusNotFound,
+				RespBody: []byte(`{"error": {"code": "DeploymentNotFound", "message": "The API deployment for this resource does not exist."}}`),
+			},
+			wantOK:      true,
+			wantSummary: "The deployment does not exist",
+			wantCode:    "DeploymentNotFound",
+		},
+		{
+			desc: "Content filter uses the inner error code",
+			e: replay.Exchange{
+				Status:   http.StatusBadRequest,
+				RespBody: []byte(`{"error": {"code": "content_filter", "message": "filtered", "innererror": {"code": "ResponsibleAIPolicyViolation"}}}`),
+			},
+			wantOK:      true,
+			wantSummary: "The content filters blocked the prompt",
+			wantCode:    "ResponsibleAIPolicyViolation",
+		},
+		{
+			desc: "Unsupported parameter",
+			e: replay.Exchange{
+				Status:   http.StatusBadRequest,
+				RespBody: []byte(`{"error": {"code": "unsupported_parameter", "message": "Unsupported parameter: 'max_tokens' is not supported with this model."}}`),
+			},
+			wantOK:      true,
+			wantSummary: "The model does not support a parameter of the request",
+			wantCode:    "unsupported_parameter",
+		},
+		{
+			desc:        "Throttled",
+			e:           replay.Exchange{Status: http.StatusTooManyRequests, RespBody: []byte(`{"error": {"code": "429"}}`)},
+			wantOK:      true,
+			wantSummary: "The deployment is throttled because it exceeded its quota",
+			wantCode:    "429",
+		},
+		{
+			desc:        "Unknown",
+			e:           replay.Exchange{Status: http.StatusConflict, RespBody: []byte("conflict")},
+			wantOK:      true,
+			wantSummary: "The request failed for a reason these rules do not know",
+		},
+	}
+
+	for _, test := range tests {
+		d, ok := Diagnose(test.e)
+		if ok != test.wantOK {
+			t.Errorf("TestDiagnose(%s): got ok == %v, want %v", test.desc, ok, test.wantOK)
+			continue
+		}
+		if d.Summary != test.wantSummary {
+			t.Errorf("TestDiagnose(%s): got Summary %q, want %q", test.desc, d.Summary, test.wantSummary)
+		}
+		if d.Code != test.wantCode {
+			t.Errorf("TestDiagnose(%s): got Code %q, want %q", test.desc, d.Code, test.wantCode)
+		}
+		if ok && len(d.Suggestions) == 0 {
+			t.Errorf("TestDiagnose(%s): got no Suggestions", test.desc)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	var sent string
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(req.Body)
+		sent = string(b)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices": [{"index": 0, "message": {"role": "assistant", "content": "Use max_completion_tokens."}}]}`)),
+			Request:    req,
+		}, nil
+	})
+	r, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := replay.Exchange{
+		Method:   http.MethodPost,
+		URL:      "https://test.openai.azure.com/openai/deployments/o1/chat/completions",
+		ReqBody:  []byte(`{"messages": [{"role": "user", "content": "secret prompt"}], "max_tokens": 100}`),
+		Status:   http.StatusBadRequest,
+		RespBody: []byte(`{"error": {"code": "unsupported_parameter", "message": "max_tokens is not supported"}}`),
+	}
+	d, err := Explain(context.Background(), chat.New("gpt-4o-mini", r), e)
+	if err != nil {
+		t.Fatalf("TestExplain: got err == %s, want err == nil", err)
+	}
+	if d.Explanation != "Use max_completion_tokens." {
+		t.Errorf("TestExplain: got Explanation %q, want %q", d.Explanation, "Use max_completion_tokens.")
+	}
+	if strings.Contains(sent, "secret prompt") {
+		t.Errorf("TestExplain: the request content was sent to the model: %s", sent)
+	}
+	if !strings.Contains(sent, "max_tokens") {
+		t.Errorf("TestExplain: the request parameters were not sent to the model: %s", sent)
+	}
+
+	if _, err := Explain(context.Background(), chat.New("gpt-4o-mini", r), replay.Exchange{Status: http.StatusOK}); err == nil {
+		t.Errorf("TestExplain(success): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,149 @@
+package diagnose
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/replay"
+)
+
+// rule is a local rule that diagnoses a failure. Rules are tried in order and the first that
+// matches is used.
+type rule struct {
+	match       func(e replay.Exchange, d Diagnosis) bool
+	summary     string
+	suggestions []string
+}
+
+// status returns a match func for any of the status codes.
+func status(codes ...int) func(e replay.Exchange, d Diagnosis) bool {
+	return func(e replay.Exchange, d Diagnosis) bool {
+		for _, c := range codes {
+			if e.Status == c {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// code returns a match func for an error code, or a message, that contains any of substrs.
+func code(substrs ...string) func(e replay.Exchange, d Diagnosis) bool {
+	return func(e replay.Exchange, d Diagnosis) bool {
+		c, m := strings.ToLower(d.Code), strings.ToLower(d.Message)
+		for _, s := range substrs {
+			s = strings.ToLower(s)
+			if strings.Contains(c, s) || strings.Contains(m, s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var rules = []rule{
+	{
+		match:   func(e replay.Exchange, d Diagnosis) bool { return e.Err != "" },
+		summary: "The request did not reach the service",
+		suggestions: []string{
+			"Check the resource name, or the URL passed to WithEndpoint(), resolves and is reachable.",
+			"Check WithCloud() is set if the resource is not in the Azure public cloud.",
+			"Check proxies and firewalls allow HTTPS to the resource.",
+		},
+	},
+	{
+		match:   status(http.StatusUnauthorized),
+		summary: "The service rejected the credentials",
+		suggestions: []string{
+			"Check the API key belongs to this resource, keys are not shared between resources.",
+			"With AzIdentity, request a token for the https://cognitiveservices.azure.com/.default scope.",
+			"Keys that were regenerated in the portal stop working immediately.",
+		},
+	},
+	{
+		match:   status(http.StatusForbidden),
+		summary: "The credentials are valid but not allowed to use this resource",
+		suggestions: []string{
+			"With AzIdentity, grant the identity the Cognitive Services OpenAI User role on the resource.",
+			"Check the resource's networking settings allow your network, such as a private endpoint.",
+		},
+	},
+	{
+		match: func(e replay.Exchange, d Diagnosis) bool {
+			return status(http.StatusNotFound)(e, d) && code("DeploymentNotFound")(e, d)
+		},
+		summary: "The deployment does not exist",
+		suggestions: []string{
+			"Check the deployment ID, which is the name you gave the deployment, not the model name.",
+			"New deployments can take up to 5 minutes before they accept requests.",
+		},
+	},
+	{
+		match:   status(http.StatusNotFound),
+		summary: "The URL was not found",
+		suggestions: []string{
+			"Check the API version supports this API, see WithAPIVersion().",
+			"Check the resource name, or the URL passed to WithEndpoint().",
+		},
+	},
+	{
+		match:   code("content_filter", "ResponsibleAIPolicyViolation"),
+		summary: "The content filters blocked the prompt",
+		suggestions: []string{
+			"Rephrase the prompt, the message says which category was triggered.",
+			"Content filter settings can be changed for the deployment in Azure AI Foundry.",
+		},
+	},
+	{
+		match:   code("context_length_exceeded", "maximum context length"),
+		summary: "The prompt and MaxTokens are larger than the model's context window",
+		suggestions: []string{
+			"Shorten the prompt or the chat history.",
+			"Lower CallParams.MaxTokens, or use profiles.ChatHook().",
+		},
+	},
+	{
+		match:   code("unsupported_parameter", "unsupported_value", "max_tokens", "reasoning_effort"),
+		summary: "The model does not support a parameter of the request",
+		suggestions: []string{
+			"Set the model with chat.Client.SetModel(), so parameters are adapted to it.",
+			"Reasoning models need CallParams.MaxCompletionTokens and reject Temperature and TopP.",
+			"Newer parameters need a newer API version, see WithAPIVersion().",
+		},
+	},
+	{
+		match:   status(http.StatusBadRequest),
+		summary: "The service rejected the request as invalid",
+		suggestions: []string{
+			"Read the Message for the invalid field.",
+			"Build CallParams with Builder(), which validates them before they are sent.",
+		},
+	},
+	{
+		match:   status(http.StatusTooManyRequests),
+		summary: "The deployment is throttled because it exceeded its quota",
+		suggestions: []string{
+			"Retry after the Retry-After header, WithRetryPolicy() does this for you.",
+			"Limit requests on the client to the quota with WithRateLimit().",
+			"Spread requests over deployments in several regions with WithDeployments().",
+			"Raise the deployment's tokens-per-minute quota in the portal.",
+		},
+	},
+	{
+		match:   status(http.StatusRequestTimeout, http.StatusGatewayTimeout),
+		summary: "The request timed out",
+		suggestions: []string{
+			"Lower MaxTokens or stream the response, long completions can take minutes.",
+			"Retry the request, WithRetryPolicy() does this for you.",
+		},
+	},
+	{
+		match:   func(e replay.Exchange, d Diagnosis) bool { return e.Status >= 500 },
+		summary: "The service had an internal error",
+		suggestions: []string{
+			"Retry the request, WithRetryPolicy() does this for you.",
+			"Fail over to another deployment with WithFallback() or WithDeployments().",
+			"Check Azure status for incidents, and open a support ticket with the Request ID if this persists.",
+		},
+	},
+}
@@ -0,0 +1,356 @@
+/*
+Package drafts provides a two phase draft and commit flow for generated content that must be
+reviewed before it is published, such as in human-in-the-loop products.
+
+Generated content is stored as a Draft with an ID. A reviewer, human or automated, later calls
+Commit() with the ID, which sends the Draft to the configured Sinks, or Reject(), which records
+the Draft with the rejection log. Drafts that are not decided before their TTL expires are
+rejected with the reason "expired".
+
+Using a Store with the chat client:
+
+	store, err := drafts.New(
+		drafts.WithSink(drafts.WebhookSink("https://example.com/publish", nil)),
+		drafts.WithRejectLog(func(d drafts.Draft) {
+			log.Printf("draft %s rejected: %s", d.ID, d.Reason)
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	var draft drafts.Draft
+	resp, err := chatClient.Call(ctx, messages, chat.WithHook(store.ChatHook(&draft)))
+	if err != nil {
+		return err
+	}
+	// Send draft.ID to a reviewer. When they approve:
+	if _, err := store.Commit(ctx, draft.ID); err != nil {
+		return err
+	}
+*/
+package drafts
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+)
+
+// ErrNotFound is returned when a Draft does not exist, has already been decided or has expired.
+var ErrNotFound = errors.New("draft not found")
+
+// State is the state of a Draft.
+type State int
+
+const (
+	// Pending indicates the Draft is waiting for review.
+	Pending State = iota
+	// Committed indicates the Draft was approved and sent to the Sinks.
+	Committed
+	// Rejected indicates the Draft was rejected or expired.
+	Rejected
+)
+
+// String implements fmt.Stringer.
+func (s State) String() string {
+	switch s {
+	case Pending:
+		return "Pending"
+	case Committed:
+		return "Committed"
+	case Rejected:
+		return "Rejected"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Draft is generated content that is waiting for review.
+type Draft struct {
+	// ID identifies the Draft.
+	ID string `json:"id"`
+	// Content is the generated content, such as the text of each choice.
+	Content []string `json:"content"`
+	// Meta is application defined metadata about the Draft.
+	Meta map[string]string `json:"meta,omitempty"`
+	// State is the state of the Draft.
+	State State `json:"state"`
+	// Created is when the Draft was created.
+	Created time.Time `json:"created"`
+	// Decided is when the Draft was committed or rejected.
+	Decided time.Time `json:"decided,omitempty"`
+	// Reason is the reason the Draft was rejected.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Sink receives Drafts when they are committed.
+type Sink interface {
+	// Publish is called with the committed Draft.
+	Publish(ctx context.Context, d Draft) error
+}
+
+// SinkFunc is a function that implements Sink.
+type SinkFunc func(ctx context.Context, d Draft) error
+
+// Publish implements Sink.
+func (f SinkFunc) Publish(ctx context.Context, d Draft) error {
+	return f(ctx, d)
+}
+
+// WebhookSink returns a Sink that POSTs the Draft as JSON to url. Any non-2xx response is
+// an error. If client is nil, http.DefaultClient is used.
+func WebhookSink(url string, client *http.Client) Sink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return SinkFunc(func(ctx context.Context, d Draft) error {
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("problem sending draft %s to webhook: %w", d.ID, err)
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook returned status %d for draft %s", resp.StatusCode, d.ID)
+		}
+		return nil
+	})
+}
+
+// Store holds Drafts until they are committed or rejected. It is safe for concurrent use.
+type Store struct {
+	sinks     []Sink
+	rejectLog func(d Draft)
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	drafts map[string]Draft
+}
+
+// Option is an optional argument for New().
+type Option func(s *Store) error
+
+// WithSink adds a Sink that receives committed Drafts. Sinks are called in the order they were added.
+func WithSink(sink Sink) Option {
+	return func(s *Store) error {
+		if sink == nil {
+			return errors.New("WithSink: sink cannot be nil")
+		}
+		s.sinks = append(s.sinks, sink)
+		return nil
+	}
+}
+
+// WithRejectLog sets a function that is called with each Draft that is rejected or expires.
+func WithRejectLog(f func(d Draft)) Option {
+	return func(s *Store) error {
+		s.rejectLog = f
+		return nil
+	}
+}
+
+// WithTTL sets how long a Draft can wait for review before it expires. Defaults to 24 hours.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Store) error {
+		if ttl <= 0 {
+			return fmt.Errorf("WithTTL(%v): must be > 0", ttl)
+		}
+		s.ttl = ttl
+		return nil
+	}
+}
+
+// New creates a new Store.
+func New(options ...Option) (*Store, error) {
+	s := &Store{
+		ttl:    24 * time.Hour,
+		now:    time.Now,
+		drafts: map[string]Draft{},
+	}
+	for _, o := range options {
+		if err := o(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Draft stores content as a Pending Draft and returns it.
+func (s *Store) Draft(content []string, meta map[string]string) (Draft, error) {
+	id, err := newID()
+	if err != nil {
+		return Draft{}, err
+	}
+
+	m := make(map[string]string, len(meta))
+	for k, v := range meta {
+		m[k] = v
+	}
+	d := Draft{
+		ID:      id,
+		Content: append([]string(nil), content...),
+		Meta:    m,
+		State:   Pending,
+		Created: s.now(),
+	}
+
+	expired := s.expire()
+	s.mu.Lock()
+	s.drafts[id] = d
+	s.mu.Unlock()
+	s.logRejects(expired)
+
+	return d, nil
+}
+
+// ChatHook returns a chat.Hook that stores the response texts of Call() as a Pending Draft, with
+// the request ID in its Meta, and sets draft to it. The Draft can then be reviewed before it is
+// published with Commit(). Stream() does not store a Draft.
+func (s *Store) ChatHook(draft *Draft) chat.Hook {
+	return func(ctx context.Context, call *chat.HookCall) (func(ctx context.Context, result chat.Chats) error, error) {
+		if draft == nil {
+			return nil, fmt.Errorf("ChatHook: draft cannot be nil")
+		}
+		after := func(ctx context.Context, result chat.Chats) error {
+			d, err := s.Draft(result.Text, map[string]string{"requestID": result.Meta.RequestID})
+			if err != nil {
+				return err
+			}
+			*draft = d
+			return nil
+		}
+		return after, nil
+	}
+}
+
+// Get returns the Pending Draft with id.
+func (s *Store) Get(id string) (Draft, bool) {
+	s.logRejects(s.expire())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.drafts[id]
+	return d, ok
+}
+
+// Pending returns all Pending Drafts, oldest first.
+func (s *Store) Pending() []Draft {
+	s.logRejects(s.expire())
+
+	s.mu.Lock()
+	out := make([]Draft, 0, len(s.drafts))
+	for _, d := range s.drafts {
+		out = append(out, d)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out
+}
+
+// Commit approves the Draft with id and sends it to every Sink. The Draft is committed even if
+// a Sink returns an error, in which case the errors from all failed Sinks are returned.
+func (s *Store) Commit(ctx context.Context, id string) (Draft, error) {
+	d, err := s.decide(id, Committed, "")
+	if err != nil {
+		return Draft{}, err
+	}
+
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Publish(ctx, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return d, errors.Join(errs...)
+}
+
+// Reject rejects the Draft with id for reason and sends it to the rejection log.
+func (s *Store) Reject(id string, reason string) (Draft, error) {
+	d, err := s.decide(id, Rejected, reason)
+	if err != nil {
+		return Draft{}, err
+	}
+	s.logRejects([]Draft{d})
+	return d, nil
+}
+
+// decide removes the Pending Draft with id and returns it in state.
+func (s *Store) decide(id string, state State, reason string) (Draft, error) {
+	s.logRejects(s.expire())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.drafts[id]
+	if !ok {
+		return Draft{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	delete(s.drafts, id)
+
+	d.State = state
+	d.Reason = reason
+	d.Decided = s.now()
+	return d, nil
+}
+
+// expire removes and returns Drafts that are older than the TTL.
+func (s *Store) expire() []Draft {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []Draft
+	for id, d := range s.drafts {
+		if now.Sub(d.Created) < s.ttl {
+			continue
+		}
+		delete(s.drafts, id)
+		d.State = Rejected
+		d.Reason = "expired"
+		d.Decided = now
+		expired = append(expired, d)
+	}
+	return expired
+}
+
+func (s *Store) logRejects(drafts []Draft) {
+	if s.rejectLog == nil {
+		return
+	}
+	for _, d := range drafts {
+		s.rejectLog(d)
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("problem creating draft ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,92 @@
+package drafts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+)
+
+func TestStore(t *testing.T) {
+	var (
+		published []Draft
+		rejected  []Draft
+		sinkErr   = errors.New("sink failed")
+	)
+	store, err := New(
+		WithSink(SinkFunc(func(ctx context.Context, d Draft) error {
+			published = append(published, d)
+			return nil
+		})),
+		WithSink(SinkFunc(func(ctx context.Context, d Draft) error { return sinkErr })),
+		WithRejectLog(func(d Draft) { rejected = append(rejected, d) }),
+		WithTTL(time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	a, _ := store.Draft([]string{"a"}, nil)
+	b, _ := store.Draft([]string{"b"}, nil)
+	c, _ := store.Draft([]string{"c"}, nil)
+	if got := len(store.Pending()); got != 3 {
+		t.Fatalf("TestStore: got %d pending drafts, want 3", got)
+	}
+
+	d, err := store.Commit(context.Background(), a.ID)
+	if !errors.Is(err, sinkErr) {
+		t.Errorf("TestStore: Commit() got err == %v, want the sink error", err)
+	}
+	if d.State != Committed || len(published) != 1 || published[0].Content[0] != "a" {
+		t.Errorf("TestStore: Commit() did not publish the committed draft: %+v, %+v", d, published)
+	}
+	if _, err := store.Commit(context.Background(), a.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("TestStore: second Commit() got err == %v, want ErrNotFound", err)
+	}
+
+	if _, err := store.Reject(b.ID, "off topic"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rejected) != 1 || rejected[0].Reason != "off topic" || rejected[0].State != Rejected {
+		t.Errorf("TestStore: Reject() got rejection log %+v, want draft b rejected for off topic", rejected)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := store.Get(c.ID); ok {
+		t.Errorf("TestStore: Get() found an expired draft")
+	}
+	if len(rejected) != 2 || rejected[1].ID != c.ID || rejected[1].Reason != "expired" {
+		t.Errorf("TestStore: expired draft was not sent to the rejection log: %+v", rejected)
+	}
+}
+
+func TestChatHook(t *testing.T) {
+	ctx := context.Background()
+	store, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var draft Draft
+	after, err := store.ChatHook(&draft)(ctx, &chat.HookCall{})
+	if err != nil {
+		t.Fatalf("TestChatHook: got err == %s, want err == nil", err)
+	}
+	result := chat.Chats{Text: []string{"hello"}}
+	result.Meta.RequestID = "req-1"
+	if err := after(ctx, result); err != nil {
+		t.Fatalf("TestChatHook(after): got err == %s, want err == nil", err)
+	}
+	got, ok := store.Get(draft.ID)
+	if !ok || got.Content[0] != "hello" || got.Meta["requestID"] != "req-1" {
+		t.Errorf("TestChatHook: got draft %+v, want a pending draft of the response", got)
+	}
+
+	if _, err := store.ChatHook(nil)(ctx, &chat.HookCall{}); err == nil {
+		t.Errorf("TestChatHook(nil draft): got err == nil, want err != nil")
+	}
+}
@@ -5,6 +5,10 @@ package errors
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
 )
 
 // New returns an error that formats as the given text. Each call to New returns a distinct
@@ -64,3 +68,107 @@ type StatusCode struct {
 func (s StatusCode) Error() string {
 	return s.Message
 }
+
+// ContentFiltered is returned when the content filters omitted content from one or more choices
+// of a response, which have a FinishReason of content_filter. The response is returned with it.
+type ContentFiltered struct {
+	// Choices are the indexes of the choices that were filtered.
+	Choices []int
+}
+
+// Error implements error.
+func (c ContentFiltered) Error() string {
+	return fmt.Sprintf("content was filtered from choices %v by the content filters", c.Choices)
+}
+
+// PromptFiltered is returned when the service rejects a request with a 400 because the content
+// filters blocked the prompt. Results say which categories were filtered, so an application can
+// tell the user why and adapt the prompt.
+type PromptFiltered struct {
+	// Code is the code of the inner error, such as "ResponsibleAIPolicyViolation".
+	Code string
+	// Message is the message of the error.
+	Message string
+	// Results are the results of the content filters for the prompt.
+	Results custom.ContentFilterResults
+	// Err is the JSON error with the details.
+	Err error
+}
+
+// Error implements error.
+func (p PromptFiltered) Error() string {
+	cats := p.Results.FilteredCategories()
+	if len(cats) == 0 {
+		return fmt.Sprintf("the prompt was blocked by the content filters: %s", p.Message)
+	}
+	return fmt.Sprintf("the prompt was blocked by the content filters (%s): %s", strings.Join(cats, ", "), p.Message)
+}
+
+// Unwrap returns the JSON error.
+func (p PromptFiltered) Unwrap() error {
+	return p.Err
+}
+
+// Conflict is returned when the service rejects a change to a resource with a 409 (Conflict),
+// because of the resource's current state. An example is adding a message to an assistants
+// thread that has an active run. Re-read the resource and retry the change, see
+// rest.RetryConflict().
+type Conflict struct {
+	// ETag is the current ETag of the resource, if the service sent one.
+	ETag string
+	// Err is the JSON or StatusCode error with the details.
+	Err error
+}
+
+// Error implements error.
+func (c Conflict) Error() string {
+	return fmt.Sprintf("conflict with the current state of the resource: %s", c.Err)
+}
+
+// Unwrap returns the JSON or StatusCode error.
+func (c Conflict) Unwrap() error {
+	return c.Err
+}
+
+// PreconditionFailed is returned when the service rejects a change with a 412 (Precondition
+// Failed), because the resource changed since the ETag set with rest.ContextWithIfMatch() was
+// read. Re-read the resource and retry the change, see rest.RetryConflict().
+type PreconditionFailed struct {
+	// ETag is the current ETag of the resource, if the service sent one.
+	ETag string
+	// Err is the JSON or StatusCode error with the details.
+	Err error
+}
+
+// Error implements error.
+func (p PreconditionFailed) Error() string {
+	return fmt.Sprintf("the resource changed since it was read: %s", p.Err)
+}
+
+// Unwrap returns the JSON or StatusCode error.
+func (p PreconditionFailed) Unwrap() error {
+	return p.Err
+}
+
+// Mode is how helpers that make many calls, such as embeddings.Client.CallBatch() and
+// completions.Client.CallMany(), handle a call that fails.
+type Mode int
+
+const (
+	// CollectAll makes every call and returns the error of each call that failed. This is the default.
+	CollectAll Mode = iota
+	// FailFast cancels the calls that have not finished when a call fails. Those calls fail
+	// with context.Canceled.
+	FailFast
+)
+
+// String implements fmt.Stringer.
+func (m Mode) String() string {
+	switch m {
+	case CollectAll:
+		return "CollectAll"
+	case FailFast:
+		return "FailFast"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
@@ -0,0 +1,87 @@
+package extract
+
+import "strings"
+
+// Chunk is a piece of a Document small enough to embed.
+type Chunk struct {
+	// Text is the text of the chunk.
+	Text string
+	// Section is the index of the Section in Document.Sections the chunk is from.
+	Section int
+	// Title is the Title of the Section.
+	Title string
+}
+
+// Split splits the sections of doc into chunks of up to size characters, breaking between
+// words. Each chunk after the first in a section starts with up to overlap characters from the
+// end of the previous chunk, so text that spans chunks is not lost. Chunks never span sections.
+// A word longer than size is its own chunk. If size <= 0, each section is a single chunk.
+func Split(doc Document, size, overlap int) []Chunk {
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []Chunk
+	for i, sec := range doc.Sections {
+		words := strings.Fields(sec.Text)
+		if len(words) == 0 {
+			continue
+		}
+		add := func(words []string) {
+			chunks = append(chunks, Chunk{Text: strings.Join(words, " "), Section: i, Title: sec.Title})
+		}
+		if size <= 0 {
+			add(words)
+			continue
+		}
+
+		var (
+			cur []string
+			n   int
+			// fresh is the number of words in cur that are not overlap from the previous chunk.
+			fresh int
+		)
+		for _, w := range words {
+			if fresh > 0 && n+1+len(w) > size {
+				add(cur)
+				cur, n = tail(cur, overlap)
+				fresh = 0
+				// The overlap must leave room for the next word.
+				for len(cur) > 0 && n+1+len(w) > size {
+					if len(cur) == 1 {
+						n = 0
+					} else {
+						n -= len(cur[0]) + 1
+					}
+					cur = cur[1:]
+				}
+			}
+			if len(cur) > 0 {
+				n++
+			}
+			cur = append(cur, w)
+			n += len(w)
+			fresh++
+		}
+		add(cur)
+	}
+	return chunks
+}
+
+// tail returns the words at the end of words that fit in max characters, and their length.
+func tail(words []string, max int) ([]string, int) {
+	n := 0
+	i := len(words)
+	for i > 0 {
+		l := len(words[i-1])
+		if n > 0 {
+			l++
+		}
+		if n+l > max {
+			break
+		}
+		n += l
+		i--
+	}
+	return append([]string(nil), words[i:]...), n
+}
@@ -0,0 +1,143 @@
+/*
+Package extract converts files, such as HTML pages, into plain text with section metadata so they
+can be chunked and embedded.
+
+Extractors are registered by MIME type. Plain text and HTML are built in. Other formats, such as
+PDF or DOCX, can be added by registering an Extractor, usually in the init() of a plugin package:
+
+	func init() {
+		extract.Register("application/pdf", pdfExtractor{})
+	}
+
+Extracting and chunking a file:
+
+	b, err := os.ReadFile("guide.html")
+	if err != nil {
+		return err
+	}
+	doc, err := extract.Extract(ctx, b, "text/html")
+	if err != nil {
+		return err
+	}
+	for _, chunk := range extract.Split(doc, 2000, 200) {
+		fmt.Println(chunk.Title, len(chunk.Text))
+	}
+
+The embeddings client can do this end-to-end with CallDocument().
+*/
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupported is returned when there is no Extractor registered for a MIME type.
+var ErrUnsupported = errors.New("no extractor registered for MIME type")
+
+// Section is a section of a Document, such as the text under a heading.
+type Section struct {
+	// Title is the title of the section, such as the text of its heading. This may be empty.
+	Title string
+	// Level is the level of the heading of the section, 1 for the highest. 0 means there is no heading.
+	Level int
+	// Text is the plain text of the section.
+	Text string
+	// Meta is Extractor defined metadata, such as the page number.
+	Meta map[string]string
+}
+
+// Document is the plain text extracted from a file.
+type Document struct {
+	// Title is the title of the document. This may be empty.
+	Title string
+	// MIME is the MIME type of the file the Document was extracted from.
+	MIME string
+	// Sections are the sections of the document in order.
+	Sections []Section
+	// Meta is Extractor defined metadata, such as the author.
+	Meta map[string]string
+}
+
+// Extractor extracts plain text from a file.
+type Extractor interface {
+	// Extract extracts the Document from b, which has MIME type mimeType. mimeType has no parameters,
+	// such as charset.
+	Extract(ctx context.Context, b []byte, mimeType string) (Document, error)
+}
+
+// ExtractorFunc is a function that implements Extractor.
+type ExtractorFunc func(ctx context.Context, b []byte, mimeType string) (Document, error)
+
+// Extract implements Extractor.
+func (f ExtractorFunc) Extract(ctx context.Context, b []byte, mimeType string) (Document, error) {
+	return f(ctx, b, mimeType)
+}
+
+var (
+	mu         sync.RWMutex
+	extractors = map[string]Extractor{
+		"text/plain": Text{},
+		"text/html":  HTML{},
+	}
+)
+
+// Register registers e for mimeType, such as "application/pdf". This replaces any Extractor
+// registered for mimeType, including the built in ones.
+func Register(mimeType string, e Extractor) error {
+	if e == nil {
+		return errors.New("Register: Extractor cannot be nil")
+	}
+	mt, err := mediaType(mimeType)
+	if err != nil {
+		return fmt.Errorf("Register: %w", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	extractors[mt] = e
+	return nil
+}
+
+// Extract extracts the Document from b with the Extractor registered for mimeType. If mimeType
+// is empty, it is detected from the content of b. If no Extractor is registered, the error is
+// ErrUnsupported.
+func Extract(ctx context.Context, b []byte, mimeType string) (Document, error) {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(b)
+	}
+	mt, err := mediaType(mimeType)
+	if err != nil {
+		return Document{}, err
+	}
+
+	mu.RLock()
+	e, ok := extractors[mt]
+	mu.RUnlock()
+	if !ok {
+		return Document{}, fmt.Errorf("%w: %s", ErrUnsupported, mt)
+	}
+
+	doc, err := e.Extract(ctx, b, mt)
+	if err != nil {
+		return Document{}, fmt.Errorf("problem extracting %s: %w", mt, err)
+	}
+	if doc.MIME == "" {
+		doc.MIME = mt
+	}
+	return doc, nil
+}
+
+// mediaType returns the media type of mimeType without parameters.
+func mediaType(mimeType string) (string, error) {
+	mt, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return "", fmt.Errorf("bad MIME type %q: %w", mimeType, err)
+	}
+	return strings.ToLower(mt), nil
+}
@@ -0,0 +1,85 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	const page = `<html><head><title>Gophers</title><style>body { color: red; }</style></head>
+<body>
+<p>Intro   text.</p>
+<h1>Habitat</h1>
+<p>Gophers live<br>underground.</p>
+<script>var x = "hidden";</script>
+<h2>Diet</h2>
+<ul><li>Roots</li><li>Bulbs</li></ul>
+</body></html>`
+
+	doc, err := Extract(context.Background(), []byte(page), "text/html; charset=utf-8")
+	if err != nil {
+		t.Fatalf("TestHTML: got err == %s, want err == nil", err)
+	}
+
+	if doc.Title != "Gophers" || doc.MIME != "text/html" {
+		t.Errorf("TestHTML: got title %q and MIME %q, want %q and %q", doc.Title, doc.MIME, "Gophers", "text/html")
+	}
+	want := []Section{
+		{Text: "Intro text."},
+		{Title: "Habitat", Level: 1, Text: "Gophers live\nunderground."},
+		{Title: "Diet", Level: 2, Text: "Roots\nBulbs"},
+	}
+	if len(doc.Sections) != len(want) {
+		t.Fatalf("TestHTML: got sections %+v, want %+v", doc.Sections, want)
+	}
+	for i, w := range want {
+		got := doc.Sections[i]
+		if got.Title != w.Title || got.Level != w.Level || got.Text != w.Text {
+			t.Errorf("TestHTML: section %d: got %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if _, err := Extract(context.Background(), []byte("%PDF-1.4"), "application/x-test"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("TestRegister: got err == %v, want ErrUnsupported", err)
+	}
+
+	err := Register("application/x-test", ExtractorFunc(func(ctx context.Context, b []byte, mimeType string) (Document, error) {
+		return Document{Sections: []Section{{Text: strings.ToUpper(string(b))}}}, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Extract(context.Background(), []byte("pdf"), "application/x-test")
+	if err != nil || doc.Sections[0].Text != "PDF" || doc.MIME != "application/x-test" {
+		t.Errorf("TestRegister: got %+v, %v, want the registered extractor to be used", doc, err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	doc := Document{
+		Sections: []Section{
+			{Title: "a", Text: "one two three four five six"},
+			{Title: "b", Text: "seven"},
+		},
+	}
+
+	got := Split(doc, 14, 5)
+	want := []Chunk{
+		{Text: "one two three", Section: 0, Title: "a"},
+		{Text: "three four", Section: 0, Title: "a"},
+		{Text: "four five six", Section: 0, Title: "a"},
+		{Text: "seven", Section: 1, Title: "b"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("TestSplit: got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("TestSplit: chunk %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTML is the Extractor for HTML. Each heading (h1 to h6) starts a new Section with the heading
+// as its Title. Text before the first heading is in a Section without a Title. Scripts, styles
+// and other content that is not displayed are skipped. Document.Title is the <title> of the page.
+type HTML struct{}
+
+// Extract implements Extractor.
+func (HTML) Extract(ctx context.Context, b []byte, mimeType string) (Document, error) {
+	doc := Document{MIME: mimeType}
+
+	var (
+		z       = html.NewTokenizer(bytes.NewReader(b))
+		sec     = Section{}
+		text    = &strings.Builder{}
+		heading = &strings.Builder{}
+		// skip counts the open elements whose content is not displayed.
+		skip      int
+		inTitle   bool
+		inHeading bool
+	)
+	flush := func() {
+		sec.Text = collapse(text.String())
+		if sec.Text != "" || sec.Title != "" {
+			doc.Sections = append(doc.Sections, sec)
+		}
+		text.Reset()
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return Document{}, err
+		}
+
+		switch tt := z.Next(); tt {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return Document{}, z.Err()
+			}
+			flush()
+			return doc, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			switch {
+			case hidden(a) && tt == html.StartTagToken:
+				skip++
+			case a == atom.Title:
+				inTitle = true
+			case headingLevel(a) > 0 && skip == 0:
+				flush()
+				sec = Section{Level: headingLevel(a)}
+				inHeading = true
+				heading.Reset()
+			case block(a):
+				text.WriteString("\n")
+			}
+			if a == atom.Br {
+				text.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			switch {
+			case hidden(a):
+				if skip > 0 {
+					skip--
+				}
+			case a == atom.Title:
+				inTitle = false
+			case headingLevel(a) > 0 && inHeading:
+				sec.Title = collapse(heading.String())
+				inHeading = false
+			case block(a):
+				text.WriteString("\n")
+			}
+		case html.TextToken:
+			t := string(z.Text())
+			switch {
+			case inTitle:
+				doc.Title += collapse(t)
+			case skip > 0:
+			case inHeading:
+				heading.WriteString(t)
+			default:
+				text.WriteString(t)
+			}
+		}
+	}
+}
+
+// hidden returns true for elements whose content is not displayed.
+func hidden(a atom.Atom) bool {
+	switch a {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg:
+		return true
+	}
+	return false
+}
+
+// block returns true for elements that break the text onto a new line.
+func block(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Li, atom.Tr, atom.Table, atom.Ul, atom.Ol, atom.Section,
+		atom.Article, atom.Blockquote, atom.Pre, atom.Hr, atom.Dd, atom.Dt, atom.Header, atom.Footer:
+		return true
+	}
+	return false
+}
+
+func headingLevel(a atom.Atom) int {
+	switch a {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	return 0
+}
+
+// collapse collapses runs of spaces into one space and runs of blank lines into one newline.
+func collapse(s string) string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.Join(strings.Fields(l), " "); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
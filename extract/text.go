@@ -0,0 +1,18 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+)
+
+// Text is the Extractor for plain text. The Document has a single Section holding the text.
+type Text struct{}
+
+// Extract implements Extractor.
+func (Text) Extract(ctx context.Context, b []byte, mimeType string) (Document, error) {
+	if !utf8.Valid(b) {
+		return Document{}, errors.New("text is not valid UTF-8")
+	}
+	return Document{MIME: mimeType, Sections: []Section{{Text: string(b)}}}, nil
+}
@@ -0,0 +1,192 @@
+/*
+Package feedback records what users think of responses, so you can track quality over time and
+build datasets for evaluations. Feedback is tied to the ID of a response, such as Chats.ID, and
+written to a Sink. JSONL writes to a file and Webhook posts to a URL. Any type with a Write method
+can be a Sink.
+
+Recording feedback with the azopenai.Client:
+
+	f, err := os.OpenFile("feedback.jsonl", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	client, err := azopenai.New(resourceName, auth, azopenai.WithFeedback(feedback.NewJSONL(f)))
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Chat(deploymentID).Call(ctx, messages)
+	if err != nil {
+		return err
+	}
+	...
+	// Later, when the user rates the answer.
+	if err := client.Feedback(ctx, resp.ID, feedback.ThumbsUp, "clear and correct"); err != nil {
+		return err
+	}
+*/
+package feedback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// ThumbsDown is a negative rating.
+	ThumbsDown = -1
+	// ThumbsUp is a positive rating.
+	ThumbsUp = 1
+)
+
+// Feedback is feedback on a response.
+type Feedback struct {
+	// ID is the ID of the response, such as Chats.ID.
+	ID string `json:"id"`
+	// Rating is the rating. The scale is up to the application, such as ThumbsUp and
+	// ThumbsDown or 1 to 5 stars.
+	Rating int `json:"rating"`
+	// Comment is an optional comment from the user.
+	Comment string `json:"comment,omitempty"`
+	// Time is when the feedback was given.
+	Time time.Time `json:"time"`
+}
+
+// Validate validates the Feedback.
+func (f Feedback) Validate() error {
+	if f.ID == "" {
+		return fmt.Errorf("ID is required")
+	}
+	return nil
+}
+
+// Sink receives Feedback.
+type Sink interface {
+	// Write writes f. It must be safe for concurrent use.
+	Write(ctx context.Context, f Feedback) error
+}
+
+// SinkFunc is an adapter to use a function as a Sink.
+type SinkFunc func(ctx context.Context, f Feedback) error
+
+// Write implements Sink.
+func (s SinkFunc) Write(ctx context.Context, f Feedback) error {
+	return s(ctx, f)
+}
+
+// JSONL is a Sink that writes each Feedback as a line of JSON.
+type JSONL struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONL creates a JSONL that writes to w.
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{w: w}
+}
+
+// Write implements Sink.
+func (j *JSONL) Write(ctx context.Context, f Feedback) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.w.Write(b); err != nil {
+		return fmt.Errorf("problem writing feedback: %w", err)
+	}
+	return nil
+}
+
+// Webhook is a Sink that posts each Feedback as JSON to a URL.
+type Webhook struct {
+	url    string
+	client *http.Client
+	header http.Header
+}
+
+// WebhookOption is an optional argument for NewWebhook().
+type WebhookOption func(w *Webhook) error
+
+// WithClient sets the http.Client used to post. Defaults to an http.Client with a 10 second timeout.
+func WithClient(c *http.Client) WebhookOption {
+	return func(w *Webhook) error {
+		if c == nil {
+			return fmt.Errorf("WithClient: client cannot be nil")
+		}
+		w.client = c
+		return nil
+	}
+}
+
+// WithHeader sets a header on each post, such as an Authorization header.
+func WithHeader(key, value string) WebhookOption {
+	return func(w *Webhook) error {
+		w.header.Set(key, value)
+		return nil
+	}
+}
+
+// NewWebhook creates a Webhook that posts to addr.
+func NewWebhook(addr string, options ...WebhookOption) (*Webhook, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("problem parsing webhook URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook URL must be http or https, was %q", addr)
+	}
+
+	w := &Webhook{
+		url:    addr,
+		client: &http.Client{Timeout: 10 * time.Second},
+		header: http.Header{},
+	}
+	for _, o := range options {
+		if err := o(w); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Write implements Sink. A response that is not 2xx is an error.
+func (w *Webhook) Write(ctx context.Context, f Feedback) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, v := range w.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("problem posting feedback: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("problem posting feedback: webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
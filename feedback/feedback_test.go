@@ -0,0 +1,96 @@
+package feedback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestJSONL(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewJSONL(buf)
+
+	want := []Feedback{
+		{ID: "chatcmpl-1", Rating: ThumbsUp, Comment: "great", Time: time.Unix(1700000000, 0).UTC()},
+		{ID: "chatcmpl-2", Rating: ThumbsDown, Time: time.Unix(1700000001, 0).UTC()},
+	}
+	for _, f := range want {
+		if err := sink.Write(context.Background(), f); err != nil {
+			t.Fatalf("TestJSONL: got err == %s, want err == nil", err)
+		}
+	}
+
+	var got []Feedback
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var f Feedback
+		if err := dec.Decode(&f); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, f)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TestJSONL: got %+v, want %+v", got, want)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	tests := []struct {
+		desc    string
+		status  int
+		wantErr bool
+	}{
+		{desc: "success", status: http.StatusNoContent},
+		{desc: "error status", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, test := range tests {
+		var got Feedback
+		var gotAuth string
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			gotAuth = req.Header.Get("Authorization")
+			if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: test.status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		})
+
+		w, err := NewWebhook("https://example.com/feedback", WithClient(&http.Client{Transport: rt}), WithHeader("Authorization", "Bearer token"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f := Feedback{ID: "chatcmpl-1", Rating: 5, Comment: "helpful"}
+		err = w.Write(context.Background(), f)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestWebhook(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestWebhook(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		}
+		if got.ID != f.ID || got.Rating != f.Rating || got.Comment != f.Comment {
+			t.Errorf("TestWebhook(%s): got %+v, want %+v", test.desc, got, f)
+		}
+		if gotAuth != "Bearer token" {
+			t.Errorf("TestWebhook(%s): got Authorization %q, want %q", test.desc, gotAuth, "Bearer token")
+		}
+	}
+
+	if _, err := NewWebhook("ftp://example.com"); err == nil {
+		t.Errorf("TestWebhook(bad scheme): got err == nil, want err != nil")
+	}
+}
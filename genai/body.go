@@ -0,0 +1,227 @@
+package genai
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// event is a span event.
+type event struct {
+	name  string
+	attrs []Attr
+}
+
+// req holds the fields of a chat, completions or embeddings request that are traced.
+type req struct {
+	Model               string    `json:"model"`
+	Messages            []message `json:"messages"`
+	Prompt              []string  `json:"prompt"`
+	MaxTokens           *int      `json:"max_tokens"`
+	MaxCompletionTokens *int      `json:"max_completion_tokens"`
+	Temperature         *float64  `json:"temperature"`
+	TopP                *float64  `json:"top_p"`
+	PresencePenalty     *float64  `json:"presence_penalty"`
+	FrequencyPenalty    *float64  `json:"frequency_penalty"`
+	Stop                []string  `json:"stop"`
+	N                   *int      `json:"n"`
+}
+
+type message struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text returns the content of the message, which is a string or, for messages with parts,
+// the parts as JSON.
+func (m message) text() string {
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return s
+	}
+	return string(m.Content)
+}
+
+func parseReq(b []byte) req {
+	var r req
+	// A request we can't decode is still traced, without the request attributes.
+	json.Unmarshal(b, &r)
+	return r
+}
+
+func (r req) attrs() []Attr {
+	var attrs []Attr
+	maxTokens := r.MaxTokens
+	if maxTokens == nil {
+		maxTokens = r.MaxCompletionTokens
+	}
+	if maxTokens != nil && *maxTokens > 0 {
+		attrs = append(attrs, Attr{Key: "gen_ai.request.max_tokens", Value: *maxTokens})
+	}
+	if r.Temperature != nil {
+		attrs = append(attrs, Attr{Key: "gen_ai.request.temperature", Value: *r.Temperature})
+	}
+	if r.TopP != nil {
+		attrs = append(attrs, Attr{Key: "gen_ai.request.top_p", Value: *r.TopP})
+	}
+	if r.PresencePenalty != nil {
+		attrs = append(attrs, Attr{Key: "gen_ai.request.presence_penalty", Value: *r.PresencePenalty})
+	}
+	if r.FrequencyPenalty != nil {
+		attrs = append(attrs, Attr{Key: "gen_ai.request.frequency_penalty", Value: *r.FrequencyPenalty})
+	}
+	if len(r.Stop) > 0 {
+		attrs = append(attrs, Attr{Key: "gen_ai.request.stop_sequences", Value: r.Stop})
+	}
+	if r.N != nil && *r.N > 1 {
+		attrs = append(attrs, Attr{Key: "gen_ai.request.choice.count", Value: *r.N})
+	}
+	return attrs
+}
+
+// events returns an event for each prompt message.
+func (r req) events() []event {
+	var events []event
+	for _, m := range r.Messages {
+		role := m.Role
+		if role == "developer" {
+			role = "system"
+		}
+		events = append(events, event{
+			name:  "gen_ai." + role + ".message",
+			attrs: []Attr{{Key: "gen_ai.system", Value: System}, {Key: "content", Value: m.text()}},
+		})
+	}
+	for _, p := range r.Prompt {
+		events = append(events, event{
+			name:  "gen_ai.user.message",
+			attrs: []Attr{{Key: "gen_ai.system", Value: System}, {Key: "content", Value: p}},
+		})
+	}
+	return events
+}
+
+// resp holds the fields of a response that are traced.
+type resp struct {
+	ID      string   `json:"id"`
+	Model   string   `json:"model"`
+	Choices []choice `json:"choices"`
+	Usage   *usage   `json:"usage"`
+}
+
+type choice struct {
+	Index        int     `json:"index"`
+	FinishReason *string `json:"finish_reason"`
+	Text         string  `json:"text"`
+	Message      struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Delta struct {
+		Content string `json:"content"`
+	} `json:"delta"`
+}
+
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func parseResp(b []byte) resp {
+	var r resp
+	json.Unmarshal(b, &r)
+	return r
+}
+
+// parseStream merges the server-sent events of a stream into a single resp.
+func parseStream(b []byte) resp {
+	var out resp
+	byIndex := map[int]int{}
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || string(data) == "[DONE]" {
+			continue
+		}
+		var r resp
+		if err := json.Unmarshal(data, &r); err != nil {
+			continue
+		}
+		if out.ID == "" {
+			out.ID = r.ID
+		}
+		if out.Model == "" {
+			out.Model = r.Model
+		}
+		if r.Usage != nil {
+			out.Usage = r.Usage
+		}
+		for _, c := range r.Choices {
+			i, ok := byIndex[c.Index]
+			if !ok {
+				i = len(out.Choices)
+				byIndex[c.Index] = i
+				out.Choices = append(out.Choices, choice{Index: c.Index})
+			}
+			d := &out.Choices[i]
+			d.Message.Content += c.Delta.Content + c.Message.Content
+			d.Text += c.Text
+			if c.FinishReason != nil && *c.FinishReason != "" {
+				d.FinishReason = c.FinishReason
+			}
+		}
+	}
+	return out
+}
+
+func (r resp) attrs() []Attr {
+	var attrs []Attr
+	if r.ID != "" {
+		attrs = append(attrs, Attr{Key: "gen_ai.response.id", Value: r.ID})
+	}
+	if r.Model != "" {
+		attrs = append(attrs, Attr{Key: "gen_ai.response.model", Value: r.Model})
+	}
+	var reasons []string
+	for _, c := range r.Choices {
+		if c.FinishReason != nil && *c.FinishReason != "" {
+			reasons = append(reasons, *c.FinishReason)
+		}
+	}
+	if len(reasons) > 0 {
+		attrs = append(attrs, Attr{Key: "gen_ai.response.finish_reasons", Value: reasons})
+	}
+	if r.Usage != nil {
+		attrs = append(attrs,
+			Attr{Key: "gen_ai.usage.input_tokens", Value: r.Usage.PromptTokens},
+			Attr{Key: "gen_ai.usage.output_tokens", Value: r.Usage.CompletionTokens},
+		)
+	}
+	return attrs
+}
+
+// events returns a gen_ai.choice event for each choice.
+func (r resp) events() []event {
+	var events []event
+	for _, c := range r.Choices {
+		reason := ""
+		if c.FinishReason != nil {
+			reason = *c.FinishReason
+		}
+		content := c.Message.Content
+		if content == "" {
+			content = c.Text
+		}
+		events = append(events, event{
+			name: "gen_ai.choice",
+			attrs: []Attr{
+				{Key: "gen_ai.system", Value: System},
+				{Key: "index", Value: c.Index},
+				{Key: "finish_reason", Value: reason},
+				{Key: "content", Value: content},
+			},
+		})
+	}
+	return events
+}
@@ -0,0 +1,264 @@
+/*
+Package genai traces requests with spans that follow the OpenTelemetry semantic conventions for
+generative AI, so traces work with LLM observability tools without extra mapping. Each chat,
+completions and embeddings request is a span with attributes such as gen_ai.system,
+gen_ai.request.model, gen_ai.response.finish_reasons and gen_ai.usage.output_tokens.
+
+The prompts and generated text are only recorded, as span events, if WithContent() is used, as
+they may hold personal information.
+
+This package does not depend on OpenTelemetry. Spans are started with a Tracer, which takes a
+few lines to implement with an OpenTelemetry trace.Tracer:
+
+	type otelTracer struct{ t trace.Tracer }
+
+	func (o otelTracer) Start(ctx context.Context, name string, attrs []genai.Attr) (context.Context, genai.Span) {
+		ctx, span := o.t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(otelAttrs(attrs)...))
+		return ctx, otelSpan{span}
+	}
+
+	type otelSpan struct{ s trace.Span }
+
+	func (o otelSpan) SetAttributes(attrs ...genai.Attr) { o.s.SetAttributes(otelAttrs(attrs)...) }
+	func (o otelSpan) AddEvent(name string, attrs ...genai.Attr) {
+		o.s.AddEvent(name, trace.WithAttributes(otelAttrs(attrs)...))
+	}
+	func (o otelSpan) RecordError(err error) {
+		o.s.RecordError(err)
+		o.s.SetStatus(codes.Error, err.Error())
+	}
+	func (o otelSpan) End() { o.s.End() }
+
+	func otelAttrs(attrs []genai.Attr) []attribute.KeyValue {
+		kvs := make([]attribute.KeyValue, 0, len(attrs))
+		for _, a := range attrs {
+			switch v := a.Value.(type) {
+			case string:
+				kvs = append(kvs, attribute.String(a.Key, v))
+			case int:
+				kvs = append(kvs, attribute.Int(a.Key, v))
+			case float64:
+				kvs = append(kvs, attribute.Float64(a.Key, v))
+			case []string:
+				kvs = append(kvs, attribute.StringSlice(a.Key, v))
+			}
+		}
+		return kvs
+	}
+
+The Transport is an http.RoundTripper, so it is used with azopenai.WithClient(). Because it sits
+below the retry logic, each retry is its own span:
+
+	tr := genai.New(otelTracer{otel.Tracer("myapp")}, http.DefaultTransport, genai.WithContent())
+	client, err := azopenai.New(resourceName, auth, azopenai.WithClient(&http.Client{Transport: tr}))
+*/
+package genai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// System is the value of gen_ai.system for Azure OpenAI.
+const System = "az.ai.openai"
+
+// Attr is a span or event attribute. Value is a string, int, float64 or []string.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// Tracer starts spans.
+type Tracer interface {
+	// Start starts a client span named name with attrs. The returned Context holds the span.
+	Start(ctx context.Context, name string, attrs []Attr) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttributes sets attributes on the span.
+	SetAttributes(attrs ...Attr)
+	// AddEvent adds an event to the span.
+	AddEvent(name string, attrs ...Attr)
+	// RecordError records err and sets the status of the span to error.
+	RecordError(err error)
+	// End ends the span.
+	End()
+}
+
+// Option is an optional argument for New().
+type Option func(t *Transport) error
+
+// WithContent records the prompts and generated text as span events, such as gen_ai.user.message
+// and gen_ai.choice. By default they are not recorded, as they may hold personal information.
+func WithContent() Option {
+	return func(t *Transport) error {
+		t.content = true
+		return nil
+	}
+}
+
+// Transport is an http.RoundTripper that traces requests.
+type Transport struct {
+	tracer  Tracer
+	next    http.RoundTripper
+	content bool
+}
+
+// New creates a Transport that traces requests with tracer and sends them with next. If next
+// is nil, http.DefaultTransport is used.
+func New(tracer Tracer, next http.RoundTripper, options ...Option) (*Transport, error) {
+	if tracer == nil {
+		return nil, fmt.Errorf("tracer cannot be nil")
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &Transport{tracer: tracer, next: next}
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper. Requests that are not for chat, completions or
+// embeddings are sent without a span. The span ends when the response body is closed or fully
+// read, so streamed responses are traced until they end.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	op := operation(req.URL.Path)
+	if op == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("problem reading request body: %w", err)
+		}
+		body = b
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	r := parseReq(body)
+	model := r.Model
+	if model == "" {
+		model = deployment(req.URL.Path)
+	}
+	attrs := append([]Attr{
+		{Key: "gen_ai.system", Value: System},
+		{Key: "gen_ai.operation.name", Value: op},
+		{Key: "gen_ai.request.model", Value: model},
+		{Key: "server.address", Value: req.URL.Hostname()},
+	}, r.attrs()...)
+
+	ctx, span := t.tracer.Start(req.Context(), op+" "+model, attrs)
+	req = req.WithContext(ctx)
+	if t.content {
+		for _, e := range r.events() {
+			span.AddEvent(e.name, e.attrs...)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.SetAttributes(Attr{Key: "error.type", Value: fmt.Sprintf("%T", err)})
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		span.SetAttributes(Attr{Key: "error.type", Value: fmt.Sprint(resp.StatusCode)})
+		span.RecordError(fmt.Errorf("status code %d", resp.StatusCode))
+		span.End()
+		return resp, nil
+	}
+
+	stream := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+	resp.Body = &traceBody{ReadCloser: resp.Body, t: t, span: span, stream: stream}
+	return resp, nil
+}
+
+// traceBody records a response body as it is read and ends the span when it is done.
+type traceBody struct {
+	io.ReadCloser
+	t      *Transport
+	span   Span
+	stream bool
+
+	buf  bytes.Buffer
+	once sync.Once
+}
+
+func (b *traceBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err != nil {
+		b.end(err)
+	}
+	return n, err
+}
+
+func (b *traceBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.end(nil)
+	return err
+}
+
+// end sets the response attributes and ends the span. readErr is the error from reading the
+// body, io.EOF if it was fully read.
+func (b *traceBody) end(readErr error) {
+	b.once.Do(func() {
+		defer b.span.End()
+
+		if readErr != nil && readErr != io.EOF {
+			b.span.SetAttributes(Attr{Key: "error.type", Value: fmt.Sprintf("%T", readErr)})
+			b.span.RecordError(readErr)
+		}
+		var r resp
+		if b.stream {
+			r = parseStream(b.buf.Bytes())
+		} else {
+			r = parseResp(b.buf.Bytes())
+		}
+		b.span.SetAttributes(r.attrs()...)
+		if b.t.content {
+			for _, e := range r.events() {
+				b.span.AddEvent(e.name, e.attrs...)
+			}
+		}
+	})
+}
+
+// operation returns the gen_ai.operation.name of a request to path, or "" if it is not traced.
+func operation(path string) string {
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return "chat"
+	case strings.HasSuffix(path, "/completions"):
+		return "text_completion"
+	case strings.HasSuffix(path, "/embeddings"):
+		return "embeddings"
+	}
+	return ""
+}
+
+// deployment returns the deployment in path, which the service uses as the model name.
+func deployment(path string) string {
+	const prefix = "/openai/deployments/"
+	i := strings.Index(path, prefix)
+	if i < 0 {
+		return ""
+	}
+	d, _, _ := strings.Cut(path[i+len(prefix):], "/")
+	return d
+}
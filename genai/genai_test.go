@@ -0,0 +1,205 @@
+package genai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// fakeSpan records what is set on it.
+type fakeSpan struct {
+	name   string
+	attrs  map[string]any
+	events []string
+	err    error
+	ended  bool
+}
+
+func (s *fakeSpan) SetAttributes(attrs ...Attr) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *fakeSpan) AddEvent(name string, attrs ...Attr) {
+	for _, a := range attrs {
+		if a.Key == "content" {
+			name += "=" + a.Value.(string)
+		}
+	}
+	s.events = append(s.events, name)
+}
+
+func (s *fakeSpan) RecordError(err error) { s.err = err }
+func (s *fakeSpan) End()                  { s.ended = true }
+
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, attrs []Attr) (context.Context, Span) {
+	s := &fakeSpan{name: name, attrs: map[string]any{}}
+	s.SetAttributes(attrs...)
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return ctx, s
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		desc        string
+		path        string
+		reqBody     string
+		status      int
+		contentType string
+		respBody    string
+		content     bool
+
+		wantSpan   bool
+		wantName   string
+		wantAttrs  map[string]any
+		wantEvents []string
+		wantErr    bool
+	}{
+		{
+			desc:     "chat with content",
+			path:     "/openai/deployments/gpt4/chat/completions",
+			reqBody:  `{"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hi"}],"max_tokens":10,"temperature":0.5}`,
+			status:   200,
+			respBody: `{"id":"chatcmpl-1","model":"gpt-4","choices":[{"index":0,"finish_reason":"stop","message":{"content":"Hello"}}],"usage":{"prompt_tokens":5,"completion_tokens":1}}`,
+			content:  true,
+			wantSpan: true,
+			wantName: "chat gpt4",
+			wantAttrs: map[string]any{
+				"gen_ai.system":                  System,
+				"gen_ai.operation.name":          "chat",
+				"gen_ai.request.model":           "gpt4",
+				"server.address":                 "test",
+				"gen_ai.request.max_tokens":      10,
+				"gen_ai.request.temperature":     0.5,
+				"gen_ai.response.id":             "chatcmpl-1",
+				"gen_ai.response.model":          "gpt-4",
+				"gen_ai.response.finish_reasons": []string{"stop"},
+				"gen_ai.usage.input_tokens":      5,
+				"gen_ai.usage.output_tokens":     1,
+			},
+			wantEvents: []string{"gen_ai.system.message=Be brief.", "gen_ai.user.message=Hi", "gen_ai.choice=Hello"},
+		},
+		{
+			desc:        "stream without content",
+			path:        "/openai/deployments/gpt4/chat/completions",
+			reqBody:     `{"messages":[{"role":"user","content":"Hi"}],"stream":true}`,
+			status:      200,
+			contentType: "text/event-stream",
+			respBody: "data: {\"id\":\"c1\",\"model\":\"gpt-4\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+				"data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: [DONE]\n\n",
+			wantSpan: true,
+			wantName: "chat gpt4",
+			wantAttrs: map[string]any{
+				"gen_ai.system":                  System,
+				"gen_ai.operation.name":          "chat",
+				"gen_ai.request.model":           "gpt4",
+				"server.address":                 "test",
+				"gen_ai.response.id":             "c1",
+				"gen_ai.response.model":          "gpt-4",
+				"gen_ai.response.finish_reasons": []string{"stop"},
+			},
+		},
+		{
+			desc:     "error status",
+			path:     "/openai/deployments/ada/embeddings",
+			reqBody:  `{"input":["a"]}`,
+			status:   429,
+			respBody: `{"error":{"code":"429"}}`,
+			wantSpan: true,
+			wantName: "embeddings ada",
+			wantAttrs: map[string]any{
+				"gen_ai.system":         System,
+				"gen_ai.operation.name": "embeddings",
+				"gen_ai.request.model":  "ada",
+				"server.address":        "test",
+				"error.type":            "429",
+			},
+			wantErr: true,
+		},
+		{
+			desc:     "not traced",
+			path:     "/openai/images/generations:submit",
+			reqBody:  `{"prompt":"a cat"}`,
+			status:   202,
+			respBody: `{}`,
+		},
+	}
+
+	for _, test := range tests {
+		tracer := &fakeTracer{}
+		var sent string
+		backend := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			sent = string(b)
+			h := http.Header{}
+			if test.contentType != "" {
+				h.Set("Content-Type", test.contentType)
+			}
+			return &http.Response{StatusCode: test.status, Header: h, Body: io.NopCloser(strings.NewReader(test.respBody)), Request: req}, nil
+		})
+		var opts []Option
+		if test.content {
+			opts = append(opts, WithContent())
+		}
+		tr, err := New(tracer, backend, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest(http.MethodPost, "https://test"+test.path, strings.NewReader(test.reqBody))
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("TestTransport(%s): got err == %s, want err == nil", test.desc, err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if sent != test.reqBody {
+			t.Errorf("TestTransport(%s): sent body %q, want %q", test.desc, sent, test.reqBody)
+		}
+		if !test.wantSpan {
+			if len(tracer.spans) != 0 {
+				t.Errorf("TestTransport(%s): got %d spans, want 0", test.desc, len(tracer.spans))
+			}
+			continue
+		}
+		if len(tracer.spans) != 1 {
+			t.Fatalf("TestTransport(%s): got %d spans, want 1", test.desc, len(tracer.spans))
+		}
+		span := tracer.spans[0]
+		if span.name != test.wantName {
+			t.Errorf("TestTransport(%s): got span name %q, want %q", test.desc, span.name, test.wantName)
+		}
+		if !span.ended {
+			t.Errorf("TestTransport(%s): span was not ended", test.desc)
+		}
+		if !reflect.DeepEqual(span.attrs, test.wantAttrs) {
+			t.Errorf("TestTransport(%s): got attrs %v, want %v", test.desc, span.attrs, test.wantAttrs)
+		}
+		if !reflect.DeepEqual(span.events, test.wantEvents) {
+			t.Errorf("TestTransport(%s): got events %q, want %q", test.desc, span.events, test.wantEvents)
+		}
+		if (span.err != nil) != test.wantErr {
+			t.Errorf("TestTransport(%s): got span err %v, wantErr %v", test.desc, span.err, test.wantErr)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 module github.com/element-of-surprise/azopenai
 
-go 1.20
+go 1.21
 
-require github.com/Azure/azure-sdk-for-go/sdk/azcore v1.4.0
+require (
+	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.4.0
+	golang.org/x/net v0.35.0
+)
 
 require (
 	github.com/Azure/azure-sdk-for-go/sdk/internal v1.1.2 // indirect
-	golang.org/x/net v0.7.0 // indirect
-	golang.org/x/text v0.7.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
 )
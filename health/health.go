@@ -0,0 +1,320 @@
+/*
+Package health provides an optional poller that watches the health of the Azure OpenAI
+service, or anything else you can write a probe for, and notifies you when it changes.
+
+This is useful for applications that want to proactively switch to a fallback deployment
+or show a status banner to users when a region or model is degraded.
+
+Using the poller with your own probe:
+
+	probe := func(ctx context.Context) ([]health.Report, error) {
+		// Check whatever you consider to be the health of your service.
+		return []health.Report{{Region: "eastus", Model: "gpt-35-turbo", Status: health.Healthy}}, nil
+	}
+
+	poller, err := health.New(
+		probe,
+		health.WithInterval(30*time.Second),
+		health.WithCallback(func(old, new health.Report) {
+			log.Printf("%s/%s changed from %s to %s", new.Region, new.Model, old.Status, new.Status)
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	defer poller.Close()
+
+	for _, r := range poller.CurrentHealth() {
+		fmt.Println(r.Region, r.Model, r.Status)
+	}
+
+HTTPProbe provides a simple probe that reports the status of an HTTP endpoint.
+*/
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the health status of a region or model.
+type Status int
+
+const (
+	// Unknown indicates the status has not been determined.
+	Unknown Status = iota
+	// Healthy indicates the service is operating normally.
+	Healthy
+	// Degraded indicates the service is working, but is experiencing problems.
+	Degraded
+	// Unavailable indicates the service is not working.
+	Unavailable
+)
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	switch s {
+	case Healthy:
+		return "Healthy"
+	case Degraded:
+		return "Degraded"
+	case Unavailable:
+		return "Unavailable"
+	}
+	return "Unknown"
+}
+
+// Report is the health of a single region and model.
+type Report struct {
+	// Region is the Azure region the report is for, such as "eastus". This may be empty.
+	Region string
+	// Model is the model or deployment the report is for. This may be empty.
+	Model string
+	// Status is the health status.
+	Status Status
+	// Message is an optional human readable message that describes the status.
+	Message string
+	// Checked is the time the status was last checked. This is set by the Poller.
+	Checked time.Time
+}
+
+type key struct {
+	region string
+	model  string
+}
+
+func (r Report) key() key {
+	return key{region: r.Region, model: r.Model}
+}
+
+// Probe is called by the Poller to get the current health. A probe may return reports for
+// any number of regions and models. If an error is returned, the Poller will mark all
+// previously known reports as Unknown.
+type Probe func(ctx context.Context) ([]Report, error)
+
+// Callback is called when the Status of a region/model changes. old will have Status
+// Unknown if this is the first time the region/model was seen.
+type Callback func(old, new Report)
+
+// Poller polls a Probe at an interval and tracks the health it reports.
+type Poller struct {
+	probe     Probe
+	interval  time.Duration
+	timeout   time.Duration
+	callbacks []Callback
+
+	mu      sync.Mutex // Protects current
+	current map[key]Report
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Option provides optional arguments to the New constructor.
+type Option func(*Poller) error
+
+// WithInterval sets the interval between probes. Defaults to 1 minute.
+func WithInterval(d time.Duration) Option {
+	return func(p *Poller) error {
+		if d <= 0 {
+			return fmt.Errorf("interval must be > 0")
+		}
+		p.interval = d
+		return nil
+	}
+}
+
+// WithTimeout sets the maximum time a single probe may take. Defaults to 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Poller) error {
+		if d <= 0 {
+			return fmt.Errorf("timeout must be > 0")
+		}
+		p.timeout = d
+		return nil
+	}
+}
+
+// WithCallback adds a Callback that is called whenever a Status changes. This can be
+// passed multiple times. Callbacks are called serially from the polling goroutine and
+// should not block.
+func WithCallback(cb Callback) Option {
+	return func(p *Poller) error {
+		if cb == nil {
+			return fmt.Errorf("callback cannot be nil")
+		}
+		p.callbacks = append(p.callbacks, cb)
+		return nil
+	}
+}
+
+// New creates a new Poller and starts polling. The first probe is run before New returns.
+// Call Close() to stop polling.
+func New(probe Probe, options ...Option) (*Poller, error) {
+	if probe == nil {
+		return nil, fmt.Errorf("probe cannot be nil")
+	}
+
+	p := &Poller{
+		probe:    probe,
+		interval: 1 * time.Minute,
+		timeout:  10 * time.Second,
+		current:  map[key]Report{},
+		done:     make(chan struct{}),
+	}
+	for _, o := range options {
+		if err := o(p); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.poll(ctx)
+	go p.loop(ctx)
+
+	return p, nil
+}
+
+// Close stops the Poller. It is safe to call Close multiple times.
+func (p *Poller) Close() {
+	p.cancel()
+	<-p.done
+}
+
+// CurrentHealth returns the last known health of every region/model the probe has reported,
+// sorted by region and then model.
+func (p *Poller) CurrentHealth() []Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reports := make([]Report, 0, len(p.current))
+	for _, r := range p.current {
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Region != reports[j].Region {
+			return reports[i].Region < reports[j].Region
+		}
+		return reports[i].Model < reports[j].Model
+	})
+	return reports
+}
+
+// Healthy returns true if every known region/model is Healthy.
+func (p *Poller) Healthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, r := range p.current {
+		if r.Status != Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Poller) loop(ctx context.Context) {
+	defer close(p.done)
+
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+func (p *Poller) poll(ctx context.Context) {
+	pctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	now := time.Now()
+	reports, err := p.probe(pctx)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		p.mu.Lock()
+		reports = make([]Report, 0, len(p.current))
+		for _, r := range p.current {
+			r.Status = Unknown
+			r.Message = fmt.Sprintf("probe failed: %s", err)
+			reports = append(reports, r)
+		}
+		p.mu.Unlock()
+	}
+
+	type change struct {
+		old, new Report
+	}
+	var changes []change
+
+	p.mu.Lock()
+	for _, r := range reports {
+		r.Checked = now
+		old, ok := p.current[r.key()]
+		if !ok {
+			old = Report{Region: r.Region, Model: r.Model}
+		}
+		p.current[r.key()] = r
+		if old.Status != r.Status {
+			changes = append(changes, change{old: old, new: r})
+		}
+	}
+	p.mu.Unlock()
+
+	for _, c := range changes {
+		for _, cb := range p.callbacks {
+			cb(c.old, c.new)
+		}
+	}
+}
+
+// HTTPProbe returns a Probe that sends a GET request to addr and reports on region and model.
+// A 2XX response is Healthy, a 429 or 5XX response is Degraded and a failure to connect is
+// Unavailable. If client is nil, http.DefaultClient is used. This is useful for pointing at
+// a status page or a gateway health endpoint.
+func HTTPProbe(client *http.Client, addr, region, model string) Probe {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context) ([]Report, error) {
+		r := Report{Region: region, Model: model}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			r.Status = Unavailable
+			r.Message = err.Error()
+			return []Report{r}, nil
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			r.Status = Healthy
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			r.Status = Degraded
+			r.Message = resp.Status
+		default:
+			r.Status = Unknown
+			r.Message = resp.Status
+		}
+		return []Report{r}, nil
+	}
+}
@@ -0,0 +1,191 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer returns a server that responds with the status stored in status.
+func statusServer(status *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+}
+
+func TestHTTPProbe(t *testing.T) {
+	status := &atomic.Int32{}
+	srv := statusServer(status)
+	defer srv.Close()
+
+	tests := []struct {
+		desc   string
+		status int
+		want   Status
+	}{
+		{desc: "200", status: http.StatusOK, want: Healthy},
+		{desc: "204", status: http.StatusNoContent, want: Healthy},
+		{desc: "429", status: http.StatusTooManyRequests, want: Degraded},
+		{desc: "503", status: http.StatusServiceUnavailable, want: Degraded},
+		{desc: "404", status: http.StatusNotFound, want: Unknown},
+	}
+
+	probe := HTTPProbe(srv.Client(), srv.URL, "eastus", "gpt-4o")
+	for _, test := range tests {
+		status.Store(int32(test.status))
+		reports, err := probe(context.Background())
+		if err != nil {
+			t.Errorf("TestHTTPProbe(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		}
+		if len(reports) != 1 || reports[0].Status != test.want || reports[0].Region != "eastus" || reports[0].Model != "gpt-4o" {
+			t.Errorf("TestHTTPProbe(%s): got %+v, want one eastus/gpt-4o report with Status %s", test.desc, reports, test.want)
+		}
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	reports, err := HTTPProbe(nil, down.URL, "", "")(context.Background())
+	if err != nil || len(reports) != 1 || reports[0].Status != Unavailable {
+		t.Errorf("TestHTTPProbe(server down): got %+v, %v, want one Unavailable report", reports, err)
+	}
+}
+
+func TestPollerTransitions(t *testing.T) {
+	status := &atomic.Int32{}
+	status.Store(http.StatusOK)
+	srv := statusServer(status)
+	defer srv.Close()
+
+	changes := make(chan [2]Status, 10)
+	p, err := New(
+		HTTPProbe(srv.Client(), srv.URL, "eastus", "gpt-4o"),
+		WithInterval(5*time.Millisecond),
+		WithCallback(func(old, new Report) { changes <- [2]Status{old.Status, new.Status} }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	wait := func(want [2]Status) {
+		t.Helper()
+		select {
+		case got := <-changes:
+			if got != want {
+				t.Fatalf("TestPollerTransitions: got change %v, want %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("TestPollerTransitions: got no change, want %v", want)
+		}
+	}
+
+	// The first probe runs before New() returns.
+	wait([2]Status{Unknown, Healthy})
+	if !p.Healthy() {
+		t.Errorf("TestPollerTransitions: got Healthy() == false, want true")
+	}
+
+	status.Store(http.StatusServiceUnavailable)
+	wait([2]Status{Healthy, Degraded})
+	if p.Healthy() {
+		t.Errorf("TestPollerTransitions: got Healthy() == true while degraded, want false")
+	}
+	if got := p.CurrentHealth(); len(got) != 1 || got[0].Status != Degraded || got[0].Checked.IsZero() {
+		t.Errorf("TestPollerTransitions: got CurrentHealth() == %+v, want one checked Degraded report", got)
+	}
+
+	status.Store(http.StatusOK)
+	wait([2]Status{Degraded, Healthy})
+}
+
+func TestPollerProbeError(t *testing.T) {
+	var fail atomic.Bool
+	probe := func(ctx context.Context) ([]Report, error) {
+		if fail.Load() {
+			return nil, errors.New("broken")
+		}
+		return []Report{{Region: "eastus", Status: Healthy}}, nil
+	}
+
+	changes := make(chan Report, 10)
+	p, err := New(probe, WithInterval(5*time.Millisecond), WithCallback(func(old, new Report) { changes <- new }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	<-changes
+
+	fail.Store(true)
+	select {
+	case r := <-changes:
+		if r.Status != Unknown || r.Message == "" {
+			t.Errorf("TestPollerProbeError: got %+v, want an Unknown report with a message", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("TestPollerProbeError: a failed probe did not change the status")
+	}
+}
+
+func TestPollerCancel(t *testing.T) {
+	var calls atomic.Int32
+	probe := func(ctx context.Context) ([]Report, error) {
+		if calls.Add(1) == 1 {
+			return []Report{{Status: Healthy}}, nil
+		}
+		// Later probes hang until they are cancelled.
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	p, err := New(probe, WithInterval(time.Millisecond), WithTimeout(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wait for a probe to be in flight.
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("TestPollerCancel: Close() did not cancel the probe in flight")
+	}
+
+	// A cancelled probe does not change the health.
+	if !p.Healthy() {
+		t.Errorf("TestPollerCancel: got Healthy() == false after Close(), want true")
+	}
+	n := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	if calls.Load() != n {
+		t.Errorf("TestPollerCancel: the probe was called after Close()")
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	probe := func(ctx context.Context) ([]Report, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	start := time.Now()
+	p, err := New(probe, WithInterval(time.Hour), WithTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("TestProbeTimeout: the first probe took %v, want it cut off by the 20ms timeout", d)
+	}
+}
@@ -0,0 +1,34 @@
+package azopenai
+
+import (
+	"context"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/clients/completions"
+	"github.com/element-of-surprise/azopenai/clients/embeddings"
+)
+
+// Chatter is the interface of *chat.Client that most code needs. Accepting a Chatter instead of
+// a *chat.Client allows substituting a mock in tests. See also the azopenaitest package, which
+// fakes the service so the real clients can be used.
+type Chatter interface {
+	Call(ctx context.Context, messages []chat.SendMsg, options ...chat.CallOption) (chat.Chats, error)
+	Stream(ctx context.Context, messages []chat.SendMsg, options ...chat.CallOption) chan chat.StreamData
+}
+
+// Completer is the interface of *completions.Client that most code needs. See Chatter.
+type Completer interface {
+	Call(ctx context.Context, prompts []string, options ...completions.CallOption) (completions.Completions, error)
+	Stream(ctx context.Context, prompt string, options ...completions.CallOption) chan completions.StreamData
+}
+
+// Embedder is the interface of *embeddings.Client that most code needs. See Chatter.
+type Embedder interface {
+	Call(ctx context.Context, text []string, options ...embeddings.CallOption) (embeddings.Embeddings, error)
+}
+
+var (
+	_ Chatter   = (*chat.Client)(nil)
+	_ Completer = (*completions.Client)(nil)
+	_ Embedder  = (*embeddings.Client)(nil)
+)
@@ -0,0 +1,39 @@
+// Package bundle holds the named option bundles of the sub-clients, see chat.WithBundle().
+package bundle
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry maps bundle names to options. The zero value is ready to use and it is safe for
+// concurrent use.
+type Registry[T any] struct {
+	mu sync.RWMutex
+	m  map[string][]T
+}
+
+// Register registers options under name, replacing any options already registered.
+func (r *Registry[T]) Register(name string, options []T) error {
+	if name == "" {
+		return fmt.Errorf("bundle name cannot be empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.m == nil {
+		r.m = map[string][]T{}
+	}
+	r.m[name] = append([]T(nil), options...)
+	return nil
+}
+
+// Get returns the options registered under name.
+func (r *Registry[T]) Get(name string) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	options, ok := r.m[name]
+	if !ok {
+		return nil, fmt.Errorf("bundle %q is not registered", name)
+	}
+	return options, nil
+}
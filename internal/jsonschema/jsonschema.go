@@ -0,0 +1,229 @@
+// Package jsonschema derives JSON schemas from Go types and checks JSON values against them,
+// for the clients that ask models for structured output.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// For returns the JSON schema for t. The schema is derived from the exported fields of structs
+// and their json tags. Fields without omitempty are required.
+func For(t reflect.Type) map[string]any {
+	return schemaFor(t, map[reflect.Type]bool{})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the JSON schema for t. seen holds the struct types being expanded so that
+// recursive types terminate.
+func schemaFor(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as a base64 string.
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if name == "" && f.Anonymous {
+				// encoding/json promotes the fields of embedded structs.
+				ft := f.Type
+				for ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					embedded := schemaFor(ft, seen)
+					if p, ok := embedded["properties"].(map[string]any); ok {
+						for k, v := range p {
+							props[k] = v
+						}
+						required = append(required, embedded["required"].([]string)...)
+					}
+					continue
+				}
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = schemaFor(f.Type, seen)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": props, "required": required}
+	}
+	// Interfaces and other kinds can hold any value.
+	return map[string]any{}
+}
+
+// Extract returns the first JSON object or array in s. Models without a JSON mode often wrap
+// the JSON in a markdown code fence or add text before or after it, which is removed.
+func Extract(s string) (json.RawMessage, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+		// Remove the language of the fence, such as "json".
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[i+1:]
+		}
+		if i := strings.Index(s, "```"); i >= 0 {
+			s = s[:i]
+		}
+	}
+
+	i := strings.IndexAny(s, "{[")
+	if i < 0 {
+		return nil, fmt.Errorf("no JSON object or array found")
+	}
+	var raw json.RawMessage
+	// The decoder stops after the first value, so trailing text is ignored.
+	dec := json.NewDecoder(strings.NewReader(s[i:]))
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// Validate checks that b is JSON that matches schema, as returned by For(). It checks types,
+// required properties and the schemas of properties, items and additional properties. null
+// matches any type, as encoding/json accepts it for any Go type.
+func Validate(b []byte, schema map[string]any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	return validate(v, schema, "$")
+}
+
+func validate(v any, schema map[string]any, path string) error {
+	typ, _ := schema["type"].(string)
+	if v == nil {
+		return nil
+	}
+	switch typ {
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return typeErr(path, typ, v)
+		}
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return typeErr(path, typ, v)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if typ == "integer" && f != math.Trunc(f) {
+			return fmt.Errorf("%s: must be an integer, was %s", path, n)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return typeErr(path, typ, v)
+		}
+	case "array":
+		a, ok := v.([]any)
+		if !ok {
+			return typeErr(path, typ, v)
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, e := range a {
+			if err := validate(e, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		o, ok := v.(map[string]any)
+		if !ok {
+			return typeErr(path, typ, v)
+		}
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := o[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		// Sorted so the first error is always the same.
+		names := make([]string, 0, len(o))
+		for name := range o {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s, _ := props[name].(map[string]any)
+			if s == nil {
+				s = additional
+			}
+			if err := validate(o[name], s, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func typeErr(path, want string, v any) error {
+	got := "null"
+	switch v.(type) {
+	case bool:
+		got = "boolean"
+	case json.Number:
+		got = "number"
+	case string:
+		got = "string"
+	case []any:
+		got = "array"
+	case map[string]any:
+		got = "object"
+	}
+	return fmt.Errorf("%s: must be %s, was %s", path, want, got)
+}
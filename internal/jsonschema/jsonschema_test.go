@@ -0,0 +1,77 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		desc    string
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{desc: "plain", s: `{"a": 1}`, want: `{"a": 1}`},
+		{desc: "code fence", s: "```json\n{\"a\": 1}\n```", want: `{"a": 1}`},
+		{desc: "text around", s: "Here you go: [1, 2] Anything else?", want: `[1, 2]`},
+		{desc: "fence with text after", s: "```\n{\"a\": \"}\"}\n```\nDone.", want: `{"a": "}"}`},
+		{desc: "no JSON", s: "sorry", wantErr: true},
+		{desc: "truncated", s: `{"a": `, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := Extract(test.s)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestExtract(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestExtract(%s): got err == %s, want err == nil", test.desc, err)
+		case err == nil && string(got) != test.want:
+			t.Errorf("TestExtract(%s): got %s, want %s", test.desc, got, test.want)
+		}
+	}
+}
+
+type sub struct {
+	ID int `json:"id"`
+}
+
+type item struct {
+	Name  string            `json:"name"`
+	Count int               `json:"count"`
+	Tags  []string          `json:"tags,omitempty"`
+	Attrs map[string]bool   `json:"attrs,omitempty"`
+	Sub   *sub              `json:"sub,omitempty"`
+	Extra map[string]string `json:"-"`
+}
+
+func TestValidate(t *testing.T) {
+	schema := For(reflect.TypeOf(item{}))
+
+	tests := []struct {
+		desc    string
+		json    string
+		wantErr bool
+	}{
+		{desc: "valid", json: `{"name": "a", "count": 2, "tags": ["x"], "attrs": {"k": true}, "sub": {"id": 3}}`},
+		{desc: "null is accepted", json: `{"name": null, "count": 1}`},
+		{desc: "missing required", json: `{"name": "a"}`, wantErr: true},
+		{desc: "wrong type", json: `{"name": 1, "count": 1}`, wantErr: true},
+		{desc: "not an integer", json: `{"name": "a", "count": 1.5}`, wantErr: true},
+		{desc: "bad item", json: `{"name": "a", "count": 1, "tags": [1]}`, wantErr: true},
+		{desc: "bad additional property", json: `{"name": "a", "count": 1, "attrs": {"k": "yes"}}`, wantErr: true},
+		{desc: "bad nested", json: `{"name": "a", "count": 1, "sub": {}}`, wantErr: true},
+		{desc: "not an object", json: `[]`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := Validate([]byte(test.json), schema)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestValidate(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestValidate(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Package logitbias builds the logit_bias parameter of the chat and completions APIs from words
+instead of token IDs.
+
+The service only accepts token IDs in logit_bias, which depend on the tokenizer of the model.
+This package uses the models.Tokenizer registered for the model to find them. The SDK does not
+ship tokenizer vocabularies, so you must register one first:
+
+	models.RegisterTokenizer(models.CL100KBase, models.TokenizerFunc(func(text string) ([]int, error) {
+		return enc.Encode(text, nil, nil), nil // Any tokenizer library will do.
+	}))
+
+Banning and forcing words with the chat client:
+
+	bias, err := logitbias.New("gpt-35-turbo").Ban("Contoso", "Fabrikam").Force("Azure").Build()
+	if err != nil {
+		return err
+	}
+	params, err := chat.Builder().LogitBias(bias).Build()
+
+The same map can be passed to completions.Builder().LogitBias().
+
+Words are tokenized as given and with a leading space, as most words in a sentence follow a space
+and tokenize differently. Every token of a word is biased, so banning a word that tokenizes to
+several tokens also affects other words that share those tokens.
+*/
+package logitbias
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/models"
+)
+
+const (
+	// Ban is the bias that prevents a token from being generated.
+	Ban = -100
+	// Force is the bias that makes a token generated whenever possible.
+	Force = 100
+)
+
+// Builder builds a logit_bias map. The first error stops the Builder and is returned by Build().
+// A Builder is not safe for concurrent use.
+type Builder struct {
+	tok  models.Tokenizer
+	bias map[int]float64
+	err  error
+}
+
+// New returns a Builder that uses the Tokenizer registered for model, such as "gpt-4o".
+// See models.TokenizerFor().
+func New(model string) *Builder {
+	b := &Builder{bias: map[int]float64{}}
+	b.tok, b.err = models.TokenizerFor(model)
+	return b
+}
+
+// NewWithTokenizer returns a Builder that uses tok.
+func NewWithTokenizer(tok models.Tokenizer) *Builder {
+	b := &Builder{tok: tok, bias: map[int]float64{}}
+	if tok == nil {
+		b.err = fmt.Errorf("NewWithTokenizer: Tokenizer cannot be nil")
+	}
+	return b
+}
+
+// Ban prevents words from being generated.
+func (b *Builder) Ban(words ...string) *Builder {
+	return b.Set(Ban, words...)
+}
+
+// Force makes words generated whenever possible. Use this with care, forcing even a single
+// common token can make the model repeat it endlessly. Lower biases, such as 5, are usually better.
+func (b *Builder) Force(words ...string) *Builder {
+	return b.Set(Force, words...)
+}
+
+// Set sets the bias of words, which must be between -100 and 100. Negative values make words less
+// likely and positive values more likely. A token biased more than once has the last bias set.
+func (b *Builder) Set(bias float64, words ...string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if bias < Ban || bias > Force {
+		b.err = fmt.Errorf("bias must be between %d and %d, was %v", Ban, Force, bias)
+		return b
+	}
+
+	for _, w := range words {
+		if strings.TrimSpace(w) == "" {
+			b.err = fmt.Errorf("cannot bias an empty word")
+			return b
+		}
+		for _, v := range variants(w) {
+			ids, err := b.tok.Encode(v)
+			if err != nil {
+				b.err = fmt.Errorf("problem tokenizing %q: %w", v, err)
+				return b
+			}
+			for _, id := range ids {
+				b.bias[id] = bias
+			}
+		}
+	}
+	return b
+}
+
+// Build returns the logit_bias map, keyed by token ID, for CallParams.LogitBias.
+func (b *Builder) Build() (map[string]float64, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	m := make(map[string]float64, len(b.bias))
+	for id, v := range b.bias {
+		m[strconv.Itoa(id)] = v
+	}
+	return m, nil
+}
+
+// variants returns the forms of word to tokenize.
+func variants(word string) []string {
+	if strings.HasPrefix(word, " ") {
+		return []string{word}
+	}
+	return []string{word, " " + word}
+}
@@ -0,0 +1,119 @@
+package logitbias
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/models"
+)
+
+// fakeTokens gives every distinct word, with or without a leading space, its own token ID.
+var fakeTokens = map[string]int{
+	"Contoso": 1, " Contoso": 2,
+	"Azure": 3, " Azure": 4,
+	"Fab": 5, "rikam": 6, " Fab": 7,
+}
+
+var fake = models.TokenizerFunc(func(text string) ([]int, error) {
+	if id, ok := fakeTokens[text]; ok {
+		return []int{id}, nil
+	}
+	switch text {
+	case "Fabrikam":
+		return []int{5, 6}, nil
+	case " Fabrikam":
+		return []int{7, 6}, nil
+	}
+	return []int{99}, nil
+})
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		desc    string
+		build   func() *Builder
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			desc: "ban and force",
+			build: func() *Builder {
+				return NewWithTokenizer(fake).Ban("Contoso", "Fabrikam").Force("Azure")
+			},
+			want: map[string]float64{"1": -100, "2": -100, "5": -100, "6": -100, "7": -100, "3": 100, "4": 100},
+		},
+		{
+			desc: "leading space is kept",
+			build: func() *Builder {
+				return NewWithTokenizer(fake).Set(5, " Azure")
+			},
+			want: map[string]float64{"4": 5},
+		},
+		{
+			desc: "bias out of range",
+			build: func() *Builder {
+				return NewWithTokenizer(fake).Set(101, "Azure")
+			},
+			wantErr: true,
+		},
+		{
+			desc: "empty word",
+			build: func() *Builder {
+				return NewWithTokenizer(fake).Ban(" ")
+			},
+			wantErr: true,
+		},
+		{
+			desc: "no tokenizer registered",
+			build: func() *Builder {
+				return New("model-without-tokenizer-registered")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.build().Build()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		if len(got) != len(test.want) {
+			t.Errorf("TestBuilder(%s): got %v, want %v", test.desc, got, test.want)
+			continue
+		}
+		for k, v := range test.want {
+			if got[k] != v {
+				t.Errorf("TestBuilder(%s): got %v, want %v", test.desc, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if err := models.RegisterTokenizer("test_tokenizer", fake); err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Register(models.Capabilities{Name: "test-model", ContextWindow: 10, Tokenizer: "test_tokenizer"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := New("test-model-0613").Ban("Contoso").Build()
+	if err != nil {
+		t.Fatalf("TestNew: got err == %s, want err == nil", err)
+	}
+	if len(got) != 2 || got["1"] != -100 || got["2"] != -100 {
+		t.Errorf("TestNew: got %v, want Contoso banned", got)
+	}
+
+	if _, err := New("gpt-4o").Ban("Contoso").Build(); err == nil || !strings.Contains(err.Error(), models.O200KBase) {
+		t.Errorf("TestNew(no tokenizer): got err == %v, want an error naming %s", err, models.O200KBase)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Package models provides a registry of model capabilities, such as the size of the context window
+and whether a model supports tools or vision.
+
+Remember that a deployment ID is YOUR NAME for a model, so lookups must use the underlying
+model name, such as "gpt-35-turbo" or "gpt-4o". This is also the name returned in the Model
+field of responses.
+
+Looking up a model:
+
+	caps, ok := models.Lookup("gpt-4-32k")
+	if ok {
+		fmt.Println(caps.ContextWindow)
+	}
+
+Lookups match the longest registered name that is a prefix of the model name, so "gpt-4-0613"
+will match "gpt-4" unless a more specific entry is registered.
+
+Registering a custom or fine-tuned model, or overriding a built-in one:
+
+	err := models.Register(models.Capabilities{
+		Name:            "gpt-35-turbo-ft-mycompany",
+		ContextWindow:   16384,
+		MaxOutputTokens: 4096,
+		Tools:           true,
+		JSONMode:        true,
+		Tokenizer:       models.CL100KBase,
+	})
+*/
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of tokenizers used by models.
+const (
+	// P50KBase is the tokenizer used by the GPT-3 family, such as text-davinci-003.
+	P50KBase = "p50k_base"
+	// CL100KBase is the tokenizer used by gpt-35-turbo, gpt-4 and the ada-002 embeddings.
+	CL100KBase = "cl100k_base"
+	// O200KBase is the tokenizer used by gpt-4o and the o-series models.
+	O200KBase = "o200k_base"
+)
+
+// Capabilities details what a model supports.
+type Capabilities struct {
+	// Name is the name of the model, such as "gpt-35-turbo".
+	Name string
+	// ContextWindow is the maximum number of tokens for the prompt and the completion combined.
+	ContextWindow int
+	// MaxOutputTokens is the maximum number of tokens the model can generate. For models without
+	// a separate output limit this is the same as ContextWindow, which Register() uses if it is 0.
+	// This is always 0 for embeddings models, which do not generate tokens.
+	MaxOutputTokens int
+	// Tools indicates the model supports tool and function calling.
+	Tools bool
+	// Vision indicates the model supports image inputs.
+	Vision bool
+	// JSONMode indicates the model supports the json_object response format.
+	JSONMode bool
+	// DeveloperRole indicates the model takes instructions in "developer" messages instead of
+	// "system" messages.
+	DeveloperRole bool
+	// Reasoning indicates the model is a reasoning model, such as the o-series. These take
+	// max_completion_tokens instead of max_tokens and reject sampling parameters such as temperature.
+	Reasoning bool
+	// ReasoningEffort indicates the model supports the reasoning_effort parameter.
+	ReasoningEffort bool
+	// Embeddings indicates the model is an embeddings model.
+	Embeddings bool
+	// Tokenizer is the name of the tokenizer the model uses, such as CL100KBase.
+	Tokenizer string
+}
+
+// Validate validates the Capabilities.
+func (c Capabilities) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("Name cannot be empty")
+	}
+	if c.ContextWindow < 1 {
+		return fmt.Errorf("ContextWindow must be > 0")
+	}
+	if c.MaxOutputTokens < 0 || c.MaxOutputTokens > c.ContextWindow {
+		return fmt.Errorf("MaxOutputTokens must be >= 0 and <= ContextWindow")
+	}
+	if c.Embeddings && c.MaxOutputTokens != 0 {
+		return fmt.Errorf("MaxOutputTokens must be 0 for an embeddings model")
+	}
+	return nil
+}
+
+// defaults returns c with MaxOutputTokens set to ContextWindow if it is 0 and the model
+// generates tokens.
+func (c Capabilities) defaults() Capabilities {
+	if c.MaxOutputTokens == 0 && !c.Embeddings {
+		c.MaxOutputTokens = c.ContextWindow
+	}
+	return c
+}
+
+// builtin are the models we know about at the time of release.
+var builtin = []Capabilities{
+	{Name: "text-davinci-002", ContextWindow: 4097, MaxOutputTokens: 4097, Tokenizer: P50KBase},
+	{Name: "text-davinci-003", ContextWindow: 4097, MaxOutputTokens: 4097, Tokenizer: P50KBase},
+	{Name: "code-davinci-002", ContextWindow: 8001, MaxOutputTokens: 8001, Tokenizer: P50KBase},
+	{Name: "gpt-35-turbo", ContextWindow: 4096, MaxOutputTokens: 4096, Tools: true, Tokenizer: CL100KBase},
+	{Name: "gpt-35-turbo-16k", ContextWindow: 16384, MaxOutputTokens: 16384, Tools: true, Tokenizer: CL100KBase},
+	{Name: "gpt-35-turbo-instruct", ContextWindow: 4097, MaxOutputTokens: 4097, Tokenizer: CL100KBase},
+	{Name: "gpt-4", ContextWindow: 8192, MaxOutputTokens: 8192, Tools: true, Tokenizer: CL100KBase},
+	{Name: "gpt-4-32k", ContextWindow: 32768, MaxOutputTokens: 32768, Tools: true, Tokenizer: CL100KBase},
+	{Name: "gpt-4-turbo", ContextWindow: 128000, MaxOutputTokens: 4096, Tools: true, Vision: true, JSONMode: true, Tokenizer: CL100KBase},
+	{Name: "gpt-4o", ContextWindow: 128000, MaxOutputTokens: 16384, Tools: true, Vision: true, JSONMode: true, Tokenizer: O200KBase},
+	{Name: "gpt-4o-mini", ContextWindow: 128000, MaxOutputTokens: 16384, Tools: true, Vision: true, JSONMode: true, Tokenizer: O200KBase},
+	{Name: "o1", ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Vision: true, JSONMode: true, DeveloperRole: true, Reasoning: true, ReasoningEffort: true, Tokenizer: O200KBase},
+	{Name: "o1-mini", ContextWindow: 128000, MaxOutputTokens: 65536, Reasoning: true, Tokenizer: O200KBase},
+	{Name: "o3-mini", ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, JSONMode: true, DeveloperRole: true, Reasoning: true, ReasoningEffort: true, Tokenizer: O200KBase},
+	{Name: "text-embedding-ada-002", ContextWindow: 8191, Embeddings: true, Tokenizer: CL100KBase},
+	{Name: "text-embedding-3-small", ContextWindow: 8191, Embeddings: true, Tokenizer: CL100KBase},
+	{Name: "text-embedding-3-large", ContextWindow: 8191, Embeddings: true, Tokenizer: CL100KBase},
+}
+
+// Registry holds the Capabilities of models. It is safe for concurrent use.
+type Registry struct {
+	mu sync.RWMutex // Protects m
+	m  map[string]Capabilities
+}
+
+// NewRegistry creates a new Registry that contains the built-in models.
+func NewRegistry() *Registry {
+	r := &Registry{m: make(map[string]Capabilities, len(builtin))}
+	for _, c := range builtin {
+		r.m[c.Name] = c
+	}
+	return r
+}
+
+// Register adds the Capabilities of a model to the registry, overriding any existing entry
+// with the same name. If c.MaxOutputTokens is 0 it is set to c.ContextWindow, unless c is
+// an embeddings model.
+func (r *Registry) Register(c Capabilities) error {
+	c = c.defaults()
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m[c.Name] = c
+	return nil
+}
+
+// Lookup returns the Capabilities for model. This will match the longest registered name that
+// is a prefix of the model.
+func (r *Registry) Lookup(model string) (Capabilities, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if c, ok := r.m[model]; ok {
+		return c, true
+	}
+
+	var (
+		found Capabilities
+		ok    bool
+	)
+	for name, c := range r.m {
+		if !strings.HasPrefix(model, name+"-") {
+			continue
+		}
+		if len(name) > len(found.Name) {
+			found = c
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// List returns all the registered Capabilities sorted by name.
+func (r *Registry) List() []Capabilities {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l := make([]Capabilities, 0, len(r.m))
+	for _, c := range r.m {
+		l = append(l, c)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+	return l
+}
+
+// Default is the Registry used by the package level functions and by the SDK.
+var Default = NewRegistry()
+
+// Register calls Default.Register().
+func Register(c Capabilities) error {
+	return Default.Register(c)
+}
+
+// Lookup calls Default.Lookup().
+func Lookup(model string) (Capabilities, bool) {
+	return Default.Lookup(model)
+}
+
+// List calls Default.List().
+func List() []Capabilities {
+	return Default.List()
+}
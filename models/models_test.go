@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	for _, c := range builtin {
+		if err := c.Validate(); err != nil {
+			t.Errorf("TestBuiltin(%s): got err == %s, want err == nil", c.Name, err)
+		}
+		if !c.Embeddings && c.MaxOutputTokens == 0 {
+			t.Errorf("TestBuiltin(%s): got MaxOutputTokens == 0 for a model that generates tokens", c.Name)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r := NewRegistry()
+
+	tests := []struct {
+		desc     string
+		model    string
+		wantName string
+		wantOK   bool
+	}{
+		{desc: "Exact", model: "gpt-4", wantName: "gpt-4", wantOK: true},
+		{desc: "Version of a model", model: "gpt-4-0613", wantName: "gpt-4", wantOK: true},
+		{desc: "Longest prefix", model: "gpt-4-32k-0613", wantName: "gpt-4-32k", wantOK: true},
+		{desc: "Longest prefix of a longer name", model: "gpt-4o-mini-2024-07-18", wantName: "gpt-4o-mini", wantOK: true},
+		{desc: "Shorter prefix", model: "gpt-4o-2024-05-13", wantName: "gpt-4o", wantOK: true},
+		{desc: "Prefix must end at a dash", model: "gpt-4x", wantOK: false},
+		{desc: "Unknown", model: "llama-3", wantOK: false},
+		{desc: "Empty", model: "", wantOK: false},
+	}
+
+	for _, test := range tests {
+		got, ok := r.Lookup(test.model)
+		if ok != test.wantOK {
+			t.Errorf("TestLookup(%s): got ok == %v, want %v", test.desc, ok, test.wantOK)
+			continue
+		}
+		if got.Name != test.wantName {
+			t.Errorf("TestLookup(%s): got %q, want %q", test.desc, got.Name, test.wantName)
+		}
+	}
+
+	// A registered model is found over a shorter built-in prefix.
+	if err := r.Register(Capabilities{Name: "gpt-4-0613-ft", ContextWindow: 8192}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Lookup("gpt-4-0613-ft-mycompany"); got.Name != "gpt-4-0613-ft" {
+		t.Errorf("TestLookup(registered): got %q, want %q", got.Name, "gpt-4-0613-ft")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		desc      string
+		caps      Capabilities
+		wantMaxOT int
+		wantErr   bool
+	}{
+		{desc: "MaxOutputTokens defaults to ContextWindow", caps: Capabilities{Name: "a", ContextWindow: 4096}, wantMaxOT: 4096},
+		{desc: "MaxOutputTokens set", caps: Capabilities{Name: "a", ContextWindow: 4096, MaxOutputTokens: 1024}, wantMaxOT: 1024},
+		{desc: "Embeddings keep 0", caps: Capabilities{Name: "a", ContextWindow: 8191, Embeddings: true}, wantMaxOT: 0},
+		{desc: "Embeddings with MaxOutputTokens", caps: Capabilities{Name: "a", ContextWindow: 8191, MaxOutputTokens: 10, Embeddings: true}, wantErr: true},
+		{desc: "Empty name", caps: Capabilities{Name: " ", ContextWindow: 4096}, wantErr: true},
+		{desc: "No ContextWindow", caps: Capabilities{Name: "a"}, wantErr: true},
+		{desc: "Negative MaxOutputTokens", caps: Capabilities{Name: "a", ContextWindow: 4096, MaxOutputTokens: -1}, wantErr: true},
+		{desc: "MaxOutputTokens above ContextWindow", caps: Capabilities{Name: "a", ContextWindow: 4096, MaxOutputTokens: 4097}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		r := NewRegistry()
+		err := r.Register(test.caps)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRegister(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRegister(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		got, _ := r.Lookup(test.caps.Name)
+		if got.MaxOutputTokens != test.wantMaxOT {
+			t.Errorf("TestRegister(%s): got MaxOutputTokens %d, want %d", test.desc, got.MaxOutputTokens, test.wantMaxOT)
+		}
+	}
+}
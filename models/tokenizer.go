@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Tokenizer converts text to the token IDs a model uses. The SDK does not ship the tokenizer
+// vocabularies, so register an implementation backed by a tokenizer library, such as a tiktoken port,
+// with RegisterTokenizer().
+type Tokenizer interface {
+	// Encode returns the token IDs of text.
+	Encode(text string) ([]int, error)
+}
+
+// TokenizerFunc is an adapter to allow the use of ordinary functions as Tokenizers.
+type TokenizerFunc func(text string) ([]int, error)
+
+// Encode implements Tokenizer.
+func (f TokenizerFunc) Encode(text string) ([]int, error) {
+	return f(text)
+}
+
+var tokenizers = struct {
+	mu sync.RWMutex // Protects m
+	m  map[string]Tokenizer
+}{m: map[string]Tokenizer{}}
+
+// RegisterTokenizer registers t as the Tokenizer for the tokenizer name, such as CL100KBase,
+// replacing any Tokenizer registered for it.
+func RegisterTokenizer(name string, t Tokenizer) error {
+	if name == "" {
+		return fmt.Errorf("RegisterTokenizer: name cannot be empty")
+	}
+	if t == nil {
+		return fmt.Errorf("RegisterTokenizer: Tokenizer cannot be nil")
+	}
+	tokenizers.mu.Lock()
+	defer tokenizers.mu.Unlock()
+	tokenizers.m[name] = t
+	return nil
+}
+
+// TokenizerFor returns the registered Tokenizer for the model, which is found with Lookup().
+func TokenizerFor(model string) (Tokenizer, error) {
+	caps, ok := Lookup(model)
+	if !ok {
+		return nil, fmt.Errorf("model %q is not registered", model)
+	}
+	if caps.Tokenizer == "" {
+		return nil, fmt.Errorf("model %q does not have a Tokenizer set", model)
+	}
+
+	tokenizers.mu.RLock()
+	defer tokenizers.mu.RUnlock()
+	t, ok := tokenizers.m[caps.Tokenizer]
+	if !ok {
+		return nil, fmt.Errorf("no Tokenizer is registered for %q, used by model %q", caps.Tokenizer, model)
+	}
+	return t, nil
+}
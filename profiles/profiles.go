@@ -0,0 +1,336 @@
+/*
+Package profiles tracks how many tokens completions use for each of your prompt templates, or
+profiles, and suggests a max_tokens for future calls.
+
+The service counts max_tokens against your tokens-per-minute rate limit when a request is made,
+not the tokens that were actually generated. Setting max_tokens much higher than a prompt needs
+reserves quota that is never used. A Tracker observes the completion lengths of past calls and
+suggests a max_tokens of a percentile of those lengths plus a margin.
+
+Using a Tracker with the chat client:
+
+	tracker, err := profiles.New(profiles.WithPercentile(0.95), profiles.WithMargin(0.2))
+	if err != nil {
+		return err
+	}
+
+	// Until enough calls have been observed, the CallParams.MaxTokens is used.
+	resp, err := chatClient.Call(ctx, messages, chat.WithHook(profiles.ChatHook(tracker, "summarize")))
+
+CompletionsHook() does the same for the completions client.
+
+You can also use a Tracker directly:
+
+	tracker.Observe("summarize", 212)
+	if n, ok := tracker.MaxTokens("summarize"); ok {
+		params.MaxTokens = n
+	}
+*/
+package profiles
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/clients/completions"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Tracker tracks completion lengths per profile. It is safe for concurrent use.
+type Tracker struct {
+	window     int
+	percentile float64
+	margin     float64
+	minSamples int
+	minTokens  int
+
+	mu       sync.Mutex
+	profiles map[string]*samples
+}
+
+// Option is an optional argument for New().
+type Option func(t *Tracker) error
+
+// WithWindow sets the number of recent observations kept for each profile. Defaults to 500.
+func WithWindow(n int) Option {
+	return func(t *Tracker) error {
+		if n < 1 {
+			return fmt.Errorf("WithWindow(%d): must be >= 1", n)
+		}
+		t.window = n
+		return nil
+	}
+}
+
+// WithPercentile sets the percentile of observed lengths that MaxTokens() is based on,
+// between 0 and 1. Defaults to 0.95.
+func WithPercentile(p float64) Option {
+	return func(t *Tracker) error {
+		if p <= 0 || p > 1 {
+			return fmt.Errorf("WithPercentile(%v): must be > 0 and <= 1", p)
+		}
+		t.percentile = p
+		return nil
+	}
+}
+
+// WithMargin sets the fraction added to the percentile, so 0.1 adds 10%. Defaults to 0.1.
+func WithMargin(m float64) Option {
+	return func(t *Tracker) error {
+		if m < 0 {
+			return fmt.Errorf("WithMargin(%v): must be >= 0", m)
+		}
+		t.margin = m
+		return nil
+	}
+}
+
+// WithMinSamples sets the number of observations a profile needs before MaxTokens() makes a
+// suggestion. Defaults to 20.
+func WithMinSamples(n int) Option {
+	return func(t *Tracker) error {
+		if n < 1 {
+			return fmt.Errorf("WithMinSamples(%d): must be >= 1", n)
+		}
+		t.minSamples = n
+		return nil
+	}
+}
+
+// WithMinTokens sets the smallest value MaxTokens() will suggest. Defaults to 16.
+func WithMinTokens(n int) Option {
+	return func(t *Tracker) error {
+		if n < 1 {
+			return fmt.Errorf("WithMinTokens(%d): must be >= 1", n)
+		}
+		t.minTokens = n
+		return nil
+	}
+}
+
+// New creates a new Tracker.
+func New(options ...Option) (*Tracker, error) {
+	t := &Tracker{
+		window:     500,
+		percentile: 0.95,
+		margin:     0.1,
+		minSamples: 20,
+		minTokens:  16,
+		profiles:   map[string]*samples{},
+	}
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	if t.minSamples > t.window {
+		return nil, fmt.Errorf("WithMinSamples(%d) cannot be larger than WithWindow(%d)", t.minSamples, t.window)
+	}
+	return t, nil
+}
+
+// Observe records that a completion for profile used tokens completion tokens. Use
+// ObserveTruncated() for completions that were cut off by max_tokens.
+func (t *Tracker) Observe(profile string, tokens int) {
+	t.observe(profile, sample{tokens: tokens})
+}
+
+// ObserveTruncated records that a completion for profile was cut off by max_tokens after tokens
+// completion tokens, so its real length is at least tokens. If the percentile that MaxTokens()
+// uses falls on a truncated observation, it suggests twice its length, so a max_tokens that is
+// too small grows until completions stop being cut off.
+func (t *Tracker) ObserveTruncated(profile string, tokens int) {
+	t.observe(profile, sample{tokens: tokens, truncated: true})
+}
+
+func (t *Tracker) observe(profile string, v sample) {
+	if v.tokens < 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.profiles[profile]
+	if !ok {
+		s = &samples{ring: make([]sample, 0, t.window)}
+		t.profiles[profile] = s
+	}
+	s.add(v)
+}
+
+// MaxTokens returns the suggested max_tokens for profile. This returns false if fewer
+// observations than WithMinSamples() have been made for profile.
+func (t *Tracker) MaxTokens(profile string) (int, bool) {
+	sorted := t.sorted(profile)
+	if len(sorted) < t.minSamples {
+		return 0, false
+	}
+	v := sorted[t.index(len(sorted))]
+
+	n := int(math.Ceil(float64(v.tokens) * (1 + t.margin)))
+	if v.truncated {
+		n = 2 * v.tokens
+	}
+	if n < t.minTokens {
+		n = t.minTokens
+	}
+	return n, true
+}
+
+// Stats are statistics about the observations for a profile.
+type Stats struct {
+	// Samples is the number of observations in the window.
+	Samples int
+	// Percentile is the observed length at the Tracker's percentile.
+	Percentile int
+	// Max is the longest observed length.
+	Max int
+	// Truncated is the number of observations in the window that were cut off by max_tokens.
+	// Their real length is longer than observed.
+	Truncated int
+}
+
+// Stats returns statistics about the observations for profile.
+func (t *Tracker) Stats(profile string) Stats {
+	sorted := t.sorted(profile)
+	if len(sorted) == 0 {
+		return Stats{}
+	}
+
+	stats := Stats{
+		Samples:    len(sorted),
+		Percentile: sorted[t.index(len(sorted))].tokens,
+		Max:        sorted[len(sorted)-1].tokens,
+	}
+	for _, v := range sorted {
+		if v.truncated {
+			stats.Truncated++
+		}
+	}
+	return stats
+}
+
+// sorted returns a copy of the observations for profile, shortest first. A truncated
+// observation sorts after a complete one of the same length, as it was really longer.
+func (t *Tracker) sorted(profile string) []sample {
+	t.mu.Lock()
+	var sorted []sample
+	if s, ok := t.profiles[profile]; ok {
+		sorted = append(sorted, s.ring...)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].tokens != sorted[j].tokens {
+			return sorted[i].tokens < sorted[j].tokens
+		}
+		return !sorted[i].truncated && sorted[j].truncated
+	})
+	return sorted
+}
+
+// index returns the index of the Tracker's percentile in n sorted observations.
+func (t *Tracker) index(n int) int {
+	i := int(math.Ceil(t.percentile*float64(n))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// Reset removes the observations for profile.
+func (t *Tracker) Reset(profile string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.profiles, profile)
+}
+
+// ObserveResponse records a response for profile that used completionTokens for choices that
+// finished with finishReasons. The tokens are split evenly between the choices, and the response
+// is observed as truncated if any choice was cut off. This does nothing if t is nil or
+// completionTokens is 0, such as for streamed responses that have no usage.
+func ObserveResponse(t *Tracker, profile string, completionTokens int, finishReasons []custom.FinishReason) {
+	if t == nil || completionTokens == 0 || len(finishReasons) == 0 {
+		return
+	}
+	tokens := completionTokens / len(finishReasons)
+	for _, r := range finishReasons {
+		if r.IsTruncated() {
+			t.ObserveTruncated(profile, tokens)
+			return
+		}
+	}
+	t.Observe(profile, tokens)
+}
+
+// Suggest returns the max_tokens to use for a call for profile, where maxTokens is the
+// max_tokens the call would otherwise use, or 0 if it has none. The Tracker's suggestion is used
+// if it has one and it is smaller than maxTokens. This returns maxTokens if t is nil.
+func Suggest(t *Tracker, profile string, maxTokens int) int {
+	if t == nil {
+		return maxTokens
+	}
+	if n, ok := t.MaxTokens(profile); ok && (maxTokens == 0 || n < maxTokens) {
+		return n
+	}
+	return maxTokens
+}
+
+// ChatHook returns a chat.Hook that sets max_tokens from the completion lengths t has observed
+// for profile, if that is lower than CallParams.MaxTokens. Call() records the completion length
+// in t, and a choice cut off by max_tokens raises the suggestion. A profile is usually a prompt
+// template. See ObserveResponse().
+func ChatHook(t *Tracker, profile string) chat.Hook {
+	return func(ctx context.Context, call *chat.HookCall) (func(ctx context.Context, result chat.Chats) error, error) {
+		if t == nil {
+			return nil, fmt.Errorf("ChatHook: tracker cannot be nil")
+		}
+		call.Params.MaxTokens = Suggest(t, profile, call.Params.MaxTokens)
+		after := func(ctx context.Context, result chat.Chats) error {
+			ObserveResponse(t, profile, result.Usage.CompletionTokens, result.FinishReasons)
+			return nil
+		}
+		return after, nil
+	}
+}
+
+// CompletionsHook is ChatHook() for the completions client.
+func CompletionsHook(t *Tracker, profile string) completions.Hook {
+	return func(ctx context.Context, call *completions.HookCall) (func(ctx context.Context, result completions.Completions) error, error) {
+		if t == nil {
+			return nil, fmt.Errorf("CompletionsHook: tracker cannot be nil")
+		}
+		call.Params.MaxTokens = Suggest(t, profile, call.Params.MaxTokens)
+		after := func(ctx context.Context, result completions.Completions) error {
+			ObserveResponse(t, profile, result.Usage.CompletionTokens, result.FinishReasons)
+			return nil
+		}
+		return after, nil
+	}
+}
+
+// sample is an observation.
+type sample struct {
+	tokens    int
+	truncated bool
+}
+
+// samples is a ring buffer of observations.
+type samples struct {
+	ring []sample
+	next int
+}
+
+func (s *samples) add(v sample) {
+	if len(s.ring) < cap(s.ring) {
+		s.ring = append(s.ring, v)
+		return
+	}
+	s.ring[s.next] = v
+	s.next = (s.next + 1) % len(s.ring)
+}
@@ -0,0 +1,133 @@
+package profiles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+func TestMaxTokens(t *testing.T) {
+	tracker, err := New(WithWindow(10), WithMinSamples(5), WithPercentile(0.9), WithMargin(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		tracker.Observe("p", i*10)
+	}
+	if _, ok := tracker.MaxTokens("p"); ok {
+		t.Errorf("TestMaxTokens: got ok == true with too few samples, want false")
+	}
+
+	// Fill past the window so the first observations (10..40) roll off.
+	for i := 5; i <= 14; i++ {
+		tracker.Observe("p", i*10)
+	}
+	stats := tracker.Stats("p")
+	if stats.Samples != 10 || stats.Max != 140 || stats.Percentile != 130 {
+		t.Errorf("TestMaxTokens: got stats %+v, want {Samples:10 Percentile:130 Max:140}", stats)
+	}
+	if got, _ := tracker.MaxTokens("p"); got != 195 {
+		t.Errorf("TestMaxTokens: got %d, want 195", got)
+	}
+
+	if _, ok := tracker.MaxTokens("other"); ok {
+		t.Errorf("TestMaxTokens: got ok == true for an unknown profile, want false")
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	tracker, err := New(WithWindow(10), WithMinSamples(4), WithPercentile(0.75), WithMargin(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		tracker.Observe("p", 50)
+	}
+	// The fourth completion was cut off at 100 tokens, so its length is at least 100.
+	ObserveResponse(tracker, "p", 200, []custom.FinishReason{custom.Stop, custom.Length})
+	if got, _ := tracker.MaxTokens("p"); got != 75 {
+		t.Errorf("TestTruncated(below percentile): got %d, want 75", got)
+	}
+
+	tracker.ObserveTruncated("p", 100)
+	stats := tracker.Stats("p")
+	if stats.Samples != 5 || stats.Truncated != 2 || stats.Percentile != 100 {
+		t.Errorf("TestTruncated: got stats %+v, want {Samples:5 Percentile:100 Max:100 Truncated:2}", stats)
+	}
+	if got, _ := tracker.MaxTokens("p"); got != 200 {
+		t.Errorf("TestTruncated(at percentile): got %d, want 200", got)
+	}
+}
+
+func TestObserveResponseAndSuggest(t *testing.T) {
+	tracker, err := New(WithWindow(10), WithMinSamples(1), WithMargin(0), WithMinTokens(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No usage is not observed.
+	ObserveResponse(tracker, "p", 0, []custom.FinishReason{custom.Stop})
+	if got := Suggest(tracker, "p", 500); got != 500 {
+		t.Errorf("TestObserveResponseAndSuggest(no observations): got %d, want 500", got)
+	}
+
+	ObserveResponse(tracker, "p", 60, []custom.FinishReason{custom.Stop, custom.Stop})
+	tests := []struct {
+		desc      string
+		tracker   *Tracker
+		maxTokens int
+		want      int
+	}{
+		{desc: "nil tracker", maxTokens: 500, want: 500},
+		{desc: "suggestion below max_tokens", tracker: tracker, maxTokens: 500, want: 30},
+		{desc: "suggestion above max_tokens", tracker: tracker, maxTokens: 20, want: 20},
+		{desc: "no max_tokens", tracker: tracker, want: 30},
+	}
+	for _, test := range tests {
+		if got := Suggest(test.tracker, "p", test.maxTokens); got != test.want {
+			t.Errorf("TestObserveResponseAndSuggest(%s): got %d, want %d", test.desc, got, test.want)
+		}
+	}
+
+	// A nil Tracker is ignored.
+	ObserveResponse(nil, "p", 60, []custom.FinishReason{custom.Stop})
+}
+
+func TestChatHook(t *testing.T) {
+	ctx := context.Background()
+	tracker, err := New(WithMinSamples(1), WithMargin(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := ChatHook(tracker, "p")
+
+	call := &chat.HookCall{Params: chat.CallParams{MaxTokens: 500}}
+	after, err := hook(ctx, call)
+	if err != nil {
+		t.Fatalf("TestChatHook: got err == %s, want err == nil", err)
+	}
+	if call.Params.MaxTokens != 500 {
+		t.Errorf("TestChatHook(no observations): got MaxTokens %d, want 500", call.Params.MaxTokens)
+	}
+	result := chat.Chats{FinishReasons: []chat.FinishReason{custom.Stop}}
+	result.Usage.CompletionTokens = 40
+	if err := after(ctx, result); err != nil {
+		t.Fatalf("TestChatHook(after): got err == %s, want err == nil", err)
+	}
+
+	call = &chat.HookCall{Params: chat.CallParams{MaxTokens: 500}}
+	if _, err := hook(ctx, call); err != nil {
+		t.Fatalf("TestChatHook: got err == %s, want err == nil", err)
+	}
+	if call.Params.MaxTokens != 40 {
+		t.Errorf("TestChatHook(observed): got MaxTokens %d, want 40", call.Params.MaxTokens)
+	}
+
+	if _, err := ChatHook(nil, "p")(ctx, &chat.HookCall{}); err == nil {
+		t.Errorf("TestChatHook(nil tracker): got err == nil, want err != nil")
+	}
+}
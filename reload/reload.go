@@ -0,0 +1,284 @@
+/*
+Package reload watches a directory of chat prompts and parameters and swaps them into running
+chat clients when they change, so prompts can be iterated on without restarting a development
+server. This is meant for development, production servers should load prompts once at start.
+
+The directory holds a system prompt template per locale, named <locale>.tmpl, and optionally the
+CallParams in params.json:
+
+	prompts/
+		en.tmpl
+		fr-CA.tmpl
+		params.json
+
+params.json holds the CallParams fields to change from the defaults, such as:
+
+	{"Temperature": 0.2, "MaxTokens": 512}
+
+If params.json is removed, the clients keep the last parameters loaded.
+
+Watching the directory:
+
+	w, err := reload.New(
+		"prompts",
+		[]*chat.Client{chatClient},
+		reload.WithDefaultLocale("en"),
+		reload.WithOnError(func(err error) { log.Println(err) }),
+	)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+The directory is loaded before New() returns. After that, it is checked for changes at an interval.
+If a change can't be loaded, such as a template with a syntax error, the clients keep the last
+good prompts and parameters and the error is passed to the WithOnError() func.
+*/
+package reload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+)
+
+const (
+	// templateExt is the extension of prompt template files.
+	templateExt = ".tmpl"
+	// paramsFile is the name of the CallParams file.
+	paramsFile = "params.json"
+)
+
+// Watcher watches a directory and reloads its prompts and parameters into chat clients.
+type Watcher struct {
+	dir       string
+	clients   []*chat.Client
+	interval  time.Duration
+	defLocale string
+	vars      chat.VarsFunc
+	onError   func(error)
+
+	mu      sync.Mutex // Protects version and loaded
+	version string
+	loaded  time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Option provides optional arguments to the New constructor.
+type Option func(*Watcher) error
+
+// WithInterval sets how often the directory is checked for changes. Defaults to 1 second.
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) error {
+		if d <= 0 {
+			return fmt.Errorf("interval must be > 0")
+		}
+		w.interval = d
+		return nil
+	}
+}
+
+// WithDefaultLocale sets the default locale of the prompts, see chat.NewPrompts(). Defaults to "en".
+func WithDefaultLocale(locale string) Option {
+	return func(w *Watcher) error {
+		if strings.TrimSpace(locale) == "" {
+			return fmt.Errorf("locale cannot be empty")
+		}
+		w.defLocale = locale
+		return nil
+	}
+}
+
+// WithVars sets the VarsFunc of the prompts each time they are loaded, see chat.Prompts.SetVars().
+func WithVars(f chat.VarsFunc) Option {
+	return func(w *Watcher) error {
+		w.vars = f
+		return nil
+	}
+}
+
+// WithOnError sets a func that is called with errors from loading changes. Errors are ignored
+// by default. f is called from the watching goroutine and should not block.
+func WithOnError(f func(error)) Option {
+	return func(w *Watcher) error {
+		if f == nil {
+			return fmt.Errorf("func cannot be nil")
+		}
+		w.onError = f
+		return nil
+	}
+}
+
+// New creates a Watcher that loads the prompts and parameters in dir into clients and reloads
+// them when they change. Call Close() to stop watching.
+func New(dir string, clients []*chat.Client, options ...Option) (*Watcher, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("must have at least one client")
+	}
+	for i, c := range clients {
+		if c == nil {
+			return nil, fmt.Errorf("client %d is nil", i)
+		}
+	}
+
+	w := &Watcher{
+		dir:       dir,
+		clients:   clients,
+		interval:  1 * time.Second,
+		defLocale: "en",
+		onError:   func(error) {},
+		done:      make(chan struct{}),
+	}
+	for _, o := range options {
+		if err := o(w); err != nil {
+			return nil, err
+		}
+	}
+
+	v, err := w.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.load(v); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	go w.loop(ctx)
+
+	return w, nil
+}
+
+// Close stops the Watcher. The clients keep the last loaded prompts and parameters. It is safe to
+// call Close multiple times.
+func (w *Watcher) Close() {
+	w.cancel()
+	<-w.done
+}
+
+// Loaded returns when the prompts and parameters were last loaded.
+func (w *Watcher) Loaded() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.loaded
+}
+
+func (w *Watcher) loop(ctx context.Context) {
+	defer close(w.done)
+
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		v, err := w.stat()
+		if err != nil {
+			w.onError(err)
+			continue
+		}
+		w.mu.Lock()
+		changed := v != w.version
+		w.mu.Unlock()
+		if !changed {
+			continue
+		}
+		if err := w.load(v); err != nil {
+			w.onError(err)
+			// Record the version so the same broken files are not reported on every tick.
+			w.mu.Lock()
+			w.version = v
+			w.mu.Unlock()
+		}
+	}
+}
+
+// stat returns a version of the directory that changes when a file is added, removed or modified.
+func (w *Watcher) stat() (string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return "", fmt.Errorf("problem reading directory %s: %w", w.dir, err)
+	}
+
+	var l []string
+	for _, e := range entries {
+		if !watched(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return "", fmt.Errorf("problem reading %s: %w", e.Name(), err)
+		}
+		l = append(l, fmt.Sprintf("%s:%d:%d", e.Name(), info.Size(), info.ModTime().UnixNano()))
+	}
+	sort.Strings(l)
+	return strings.Join(l, "|"), nil
+}
+
+func watched(name string) bool {
+	return name == paramsFile || strings.HasSuffix(name, templateExt)
+}
+
+// load loads the directory at version v into the clients. Nothing is changed if there is an error.
+func (w *Watcher) load(v string) error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("problem reading directory %s: %w", w.dir, err)
+	}
+
+	prompts := chat.NewPrompts(w.defLocale)
+	prompts.SetVars(w.vars)
+	var params *chat.CallParams
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !watched(name) {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(w.dir, name))
+		if err != nil {
+			return fmt.Errorf("problem reading %s: %w", name, err)
+		}
+
+		if name == paramsFile {
+			p := chat.CallParams{}.Defaults()
+			if err := json.Unmarshal(b, &p); err != nil {
+				return fmt.Errorf("problem decoding %s: %w", name, err)
+			}
+			if err := p.Validate(); err != nil {
+				return fmt.Errorf("%s is not valid: %w", name, err)
+			}
+			params = &p
+			continue
+		}
+		if err := prompts.RegisterTemplate(strings.TrimSuffix(name, templateExt), string(b)); err != nil {
+			return fmt.Errorf("problem loading %s: %w", name, err)
+		}
+	}
+
+	for _, c := range w.clients {
+		c.SetPrompts(prompts)
+		if params != nil {
+			c.SetParams(*params)
+		}
+	}
+
+	w.mu.Lock()
+	w.version = v
+	w.loaded = time.Now()
+	w.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,138 @@
+package reload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// write writes content to name in dir with a modification time of mod, so changes are seen even
+// on filesystems with coarse timestamps.
+func write(t *testing.T, dir, name, content string, mod time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent string
+	)
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(req.Body)
+		mu.Lock()
+		sent = string(b)
+		mu.Unlock()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices": [{"index": 0, "message": {"role": "assistant", "content": "hi"}}]}`)),
+			Request:    req,
+		}, nil
+	})
+	r, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := chat.New("deployment", r)
+
+	// call makes a call and returns the request body.
+	call := func() string {
+		if _, err := client.Call(context.Background(), []chat.SendMsg{{Role: chat.User, Content: "hello"}}); err != nil {
+			t.Fatalf("TestWatcher: got err == %s, want err == nil", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return sent
+	}
+
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	write(t, dir, "en.tmpl", "You are version one.", start)
+	write(t, dir, "params.json", `{"Temperature": 0.2}`, start)
+	write(t, dir, "notes.txt", "ignored", start)
+
+	errs := make(chan error, 10)
+	w, err := New(dir, []*chat.Client{client}, WithInterval(10*time.Millisecond), WithOnError(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	got := call()
+	if !strings.Contains(got, "You are version one.") || !strings.Contains(got, `"temperature":0.2`) {
+		t.Fatalf("TestWatcher(initial load): got request %s, want version one with temperature 0.2", got)
+	}
+
+	// waitLoad waits for the Watcher to load after last.
+	waitLoad := func(desc string, last time.Time) {
+		deadline := time.Now().Add(5 * time.Second)
+		for !w.Loaded().After(last) {
+			if time.Now().After(deadline) {
+				t.Fatalf("TestWatcher(%s): the change was not loaded", desc)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	last := w.Loaded()
+	write(t, dir, "en.tmpl", "You are version two.", start.Add(time.Minute))
+	waitLoad("change", last)
+	if got := call(); !strings.Contains(got, "You are version two.") {
+		t.Errorf("TestWatcher(change): got request %s, want version two", got)
+	}
+
+	// A broken template is reported and the last good prompt is kept.
+	write(t, dir, "en.tmpl", "You are {{.Broken", start.Add(2*time.Minute))
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("TestWatcher(broken): got no error, want an error")
+	}
+	if got := call(); !strings.Contains(got, "You are version two.") {
+		t.Errorf("TestWatcher(broken): got request %s, want version two", got)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir, "en.tmpl", "{{.Broken", time.Now())
+
+	r, err := rest.New("test", auth.Authorizer{ApiKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := chat.New("deployment", r)
+
+	if _, err := New(dir, []*chat.Client{client}); err == nil {
+		t.Errorf("TestNewErrors(broken template): got err == nil, want err != nil")
+	}
+	if _, err := New(filepath.Join(dir, "missing"), []*chat.Client{client}); err == nil {
+		t.Errorf("TestNewErrors(missing dir): got err == nil, want err != nil")
+	}
+	if _, err := New(dir, nil); err == nil {
+		t.Errorf("TestNewErrors(no clients): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,422 @@
+/*
+Package replay records every HTTP exchange made by a client into a single artifact and replays
+it deterministically. This is useful for debugging production incidents: record a multi-turn
+conversation, including retries and streamed responses, save it, then step through it locally
+without calling the service.
+
+The Recorder and Replayer are http.RoundTrippers, so they are used with azopenai.WithClient().
+Because they sit below the retry logic, each retry is recorded as its own exchange.
+
+Recording a run:
+
+	rec := replay.NewRecorder(http.DefaultTransport)
+	client, err := azopenai.New(resourceName, auth, azopenai.WithClient(&http.Client{Transport: rec}))
+	if err != nil {
+		return err
+	}
+
+	// ... make calls ...
+
+	f, err := os.Create("run.json")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := rec.Run().Save(f); err != nil {
+		return err
+	}
+
+Replaying a run:
+
+	run, err := replay.Load(f)
+	if err != nil {
+		return err
+	}
+	rep := replay.NewReplayer(run, replay.WithStep(func(e replay.Exchange) {
+		log.Printf("step %d: %s %s -> %d", e.Seq, e.Method, e.URL, e.Status)
+	}))
+	client, err := azopenai.New(resourceName, auth, azopenai.WithClient(&http.Client{Transport: rep}))
+
+Headers and JSON body fields that hold credentials are redacted before they are recorded, such
+as the api-key and Ocp-Apim-Subscription-Key headers and the key of an Azure Search data source.
+See DefaultRedactedHeaders and DefaultRedactedFields. Others can be added with WithRedactHeaders()
+and WithRedactFields().
+*/
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// version is the version of the Run format.
+const version = 1
+
+// DefaultRedactedHeaders are the request and response headers that are not recorded because
+// they hold credentials.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Api-Key",
+	"Ocp-Apim-Subscription-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// DefaultRedactedFields are the JSON fields in request bodies whose values are replaced with
+// Redacted because they hold credentials, such as the key of an Azure Search data source.
+// Fields are matched at any depth without regard to case.
+var DefaultRedactedFields = []string{
+	"key",
+	"api_key",
+	"apiKey",
+	"password",
+	"secret",
+	"client_secret",
+	"connection_string",
+	"connectionString",
+	"access_token",
+	"accessToken",
+}
+
+// Redacted replaces the values of redacted JSON fields.
+const Redacted = "REDACTED"
+
+// Exchange is a single HTTP request and its response.
+type Exchange struct {
+	// Seq is the order the request was made in, starting at 0.
+	Seq int `json:"seq"`
+	// Time is when the request was made.
+	Time time.Time `json:"time"`
+	// Duration is how long it took to receive the full response.
+	Duration time.Duration `json:"duration"`
+
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// URL is the URL of the request.
+	URL string `json:"url"`
+	// ReqHeader is the request header, without credentials.
+	ReqHeader http.Header `json:"reqHeader,omitempty"`
+	// ReqBody is the request body.
+	ReqBody []byte `json:"reqBody,omitempty"`
+
+	// Status is the HTTP status code of the response.
+	Status int `json:"status,omitempty"`
+	// RespHeader is the response header.
+	RespHeader http.Header `json:"respHeader,omitempty"`
+	// RespBody is the response body. For streams, this is all the events that were read.
+	RespBody []byte `json:"respBody,omitempty"`
+	// Err is the error returned by the transport, if any.
+	Err string `json:"err,omitempty"`
+}
+
+// Run is a recording of all the exchanges made through a Recorder.
+type Run struct {
+	// Version is the version of the format.
+	Version int `json:"version"`
+	// Exchanges are the exchanges in the order the requests were made.
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+// Save writes the Run to w as JSON.
+func (r *Run) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("problem encoding run: %w", err)
+	}
+	return nil
+}
+
+// Load reads a Run written by Save().
+func Load(r io.Reader) (*Run, error) {
+	run := &Run{}
+	if err := json.NewDecoder(r).Decode(run); err != nil {
+		return nil, fmt.Errorf("problem decoding run: %w", err)
+	}
+	if run.Version != version {
+		return nil, fmt.Errorf("run has version %d, only version %d is supported", run.Version, version)
+	}
+	return run, nil
+}
+
+// Recorder is an http.RoundTripper that records every exchange.
+type Recorder struct {
+	next    http.RoundTripper
+	headers []string
+	fields  map[string]bool
+
+	mu        sync.Mutex
+	exchanges []*Exchange
+}
+
+// RecordOption is an optional argument to NewRecorder().
+type RecordOption func(r *Recorder)
+
+// WithRedactHeaders adds headers to DefaultRedactedHeaders, such as a custom header a gateway
+// uses for authentication.
+func WithRedactHeaders(headers ...string) RecordOption {
+	return func(r *Recorder) {
+		r.headers = append(r.headers, headers...)
+	}
+}
+
+// WithRedactFields adds JSON field names to DefaultRedactedFields.
+func WithRedactFields(fields ...string) RecordOption {
+	return func(r *Recorder) {
+		for _, f := range fields {
+			r.fields[strings.ToLower(f)] = true
+		}
+	}
+}
+
+// NewRecorder creates a Recorder that sends requests with next. If next is nil,
+// http.DefaultTransport is used.
+func NewRecorder(next http.RoundTripper, options ...RecordOption) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{
+		next:    next,
+		headers: append([]string(nil), DefaultRedactedHeaders...),
+		fields:  map[string]bool{},
+	}
+	for _, f := range DefaultRedactedFields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// RoundTrip implements http.RoundTripper. The exchange is complete when the response body is
+// closed or fully read, so streamed responses are recorded as they are consumed.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := &Exchange{
+		Time:      time.Now(),
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		ReqHeader: r.redactHeader(req.Header),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("problem reading request body: %w", err)
+		}
+		e.ReqBody = r.redactBody(b)
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	r.mu.Lock()
+	e.Seq = len(r.exchanges)
+	r.exchanges = append(r.exchanges, e)
+	r.mu.Unlock()
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		r.mu.Lock()
+		e.Err = err.Error()
+		e.Duration = time.Since(e.Time)
+		r.mu.Unlock()
+		return nil, err
+	}
+
+	r.mu.Lock()
+	e.Status = resp.StatusCode
+	e.RespHeader = r.redactHeader(resp.Header)
+	r.mu.Unlock()
+
+	resp.Body = &recordBody{ReadCloser: resp.Body, r: r, e: e}
+	return resp, nil
+}
+
+// redactHeader returns a copy of h without the redacted headers.
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range r.headers {
+		h.Del(k)
+	}
+	return h
+}
+
+// redactBody returns b with the values of redacted fields replaced by Redacted. b is returned
+// unchanged if it is not JSON or has no redacted fields.
+func (r *Recorder) redactBody(b []byte) []byte {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return b
+	}
+	if !r.redactValue(v) {
+		return b
+	}
+	rb, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return rb
+}
+
+// redactValue replaces the values of redacted fields in v and reports if any were found.
+func (r *Recorder) redactValue(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, fv := range v {
+			if r.fields[strings.ToLower(k)] {
+				if _, ok := fv.(string); ok {
+					v[k] = Redacted
+					found = true
+					continue
+				}
+			}
+			if r.redactValue(fv) {
+				found = true
+			}
+		}
+	case []any:
+		for _, ev := range v {
+			if r.redactValue(ev) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// Run returns a copy of what has been recorded so far.
+func (r *Recorder) Run() *Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run := &Run{Version: version, Exchanges: make([]Exchange, 0, len(r.exchanges))}
+	for _, e := range r.exchanges {
+		c := *e
+		c.RespBody = append([]byte(nil), e.RespBody...)
+		run.Exchanges = append(run.Exchanges, c)
+	}
+	sort.Slice(run.Exchanges, func(i, j int) bool { return run.Exchanges[i].Seq < run.Exchanges[j].Seq })
+	return run
+}
+
+// recordBody records a response body as it is read.
+type recordBody struct {
+	io.ReadCloser
+	r    *Recorder
+	e    *Exchange
+	once sync.Once
+}
+
+func (b *recordBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.r.mu.Lock()
+	b.e.RespBody = append(b.e.RespBody, p[:n]...)
+	b.r.mu.Unlock()
+	if err != nil {
+		b.done(err)
+	}
+	return n, err
+}
+
+func (b *recordBody) Close() error {
+	b.done(nil)
+	return b.ReadCloser.Close()
+}
+
+func (b *recordBody) done(err error) {
+	b.once.Do(func() {
+		b.r.mu.Lock()
+		defer b.r.mu.Unlock()
+		b.e.Duration = time.Since(b.e.Time)
+		if err != nil && err != io.EOF {
+			b.e.Err = err.Error()
+		}
+	})
+}
+
+// Replayer is an http.RoundTripper that returns the responses from a Run in order.
+// Requests must be made in the same order as they were recorded.
+type Replayer struct {
+	run  *Run
+	step func(e Exchange)
+
+	mu  sync.Mutex
+	pos int
+}
+
+// ReplayOption is an optional argument to NewReplayer().
+type ReplayOption func(r *Replayer)
+
+// WithStep calls f with each Exchange before it is replayed. This can be used to log each step
+// or to block until a debugger continues.
+func WithStep(f func(e Exchange)) ReplayOption {
+	return func(r *Replayer) {
+		r.step = f
+	}
+}
+
+// NewReplayer creates a Replayer for run.
+func NewReplayer(run *Run, options ...ReplayOption) *Replayer {
+	r := &Replayer{run: run}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// RoundTrip implements http.RoundTripper. It returns an error if the request does not match
+// the method and URL of the next recorded exchange, which means the run is no longer deterministic.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	if r.pos >= len(r.run.Exchanges) {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("replay: no exchange recorded for request %d: %s %s", r.pos, req.Method, req.URL)
+	}
+	e := r.run.Exchanges[r.pos]
+	r.pos++
+	r.mu.Unlock()
+
+	if e.Method != req.Method || e.URL != req.URL.String() {
+		return nil, fmt.Errorf("replay: request %d is %s %s, but %s %s was recorded", e.Seq, req.Method, req.URL, e.Method, e.URL)
+	}
+	if r.step != nil {
+		r.step(e)
+	}
+	if e.Err != "" && e.Status == 0 {
+		return nil, fmt.Errorf("replay: %s", e.Err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.RespHeader.Clone(),
+		Body:          io.NopCloser(strings.NewReader(string(e.RespBody))),
+		ContentLength: int64(len(e.RespBody)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of exchanges that have not been replayed.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.run.Exchanges) - r.pos
+}
@@ -0,0 +1,133 @@
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	backend := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		status := http.StatusOK
+		if calls == 1 {
+			status = http.StatusTooManyRequests
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"X-Call": []string{strings.Repeat("a", calls)}},
+			Body:       io.NopCloser(strings.NewReader("body")),
+			Request:    req,
+		}, nil
+	})
+
+	send := func(client *http.Client) []string {
+		var got []string
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest(http.MethodPost, "https://test/chat", strings.NewReader(`{"q":1}`))
+			req.Header.Set("api-key", "secret")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("TestRecordReplay: got err == %s, want err == nil", err)
+			}
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			got = append(got, fmt.Sprintf("%s:%d:%s", resp.Header.Get("X-Call"), resp.StatusCode, b))
+		}
+		return got
+	}
+
+	rec := NewRecorder(backend)
+	recorded := send(&http.Client{Transport: rec})
+
+	buf := &bytes.Buffer{}
+	if err := rec.Run().Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("TestRecordReplay: the api-key header was recorded")
+	}
+	run, err := Load(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(run.Exchanges[0].ReqBody) != `{"q":1}` {
+		t.Errorf("TestRecordReplay: got request body %q, want %q", run.Exchanges[0].ReqBody, `{"q":1}`)
+	}
+
+	steps := 0
+	rep := NewReplayer(run, WithStep(func(e Exchange) { steps++ }))
+	replayed := send(&http.Client{Transport: rep})
+
+	for i := range recorded {
+		if recorded[i] != replayed[i] {
+			t.Errorf("TestRecordReplay: exchange %d replayed as %q, want %q", i, replayed[i], recorded[i])
+		}
+	}
+	if steps != 2 || rep.Remaining() != 0 || calls != 2 {
+		t.Errorf("TestRecordReplay: got steps %d, remaining %d, backend calls %d, want 2, 0, 2", steps, rep.Remaining(), calls)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://test/other", nil)
+	if _, err := rep.RoundTrip(req); err == nil {
+		t.Errorf("TestRecordReplay: got err == nil after the run was exhausted, want err != nil")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	backend := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Set-Cookie": []string{"session=secret-cookie"}},
+			Body:       io.NopCloser(strings.NewReader("body")),
+			Request:    req,
+		}, nil
+	})
+
+	body := `{"messages":[{"role":"user","content":"hi"}],"data_sources":[{"type":"AzureCognitiveSearch",` +
+		`"parameters":{"endpoint":"https://search","key":"secret-search-key","indexName":"index"}}],"gatewayToken":"secret-field"}`
+	req, _ := http.NewRequest(http.MethodPost, "https://test/chat", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-bearer")
+	req.Header.Set("api-key", "secret-api-key")
+	req.Header.Set("Ocp-Apim-Subscription-Key", "secret-apim-key")
+	req.Header.Set("X-Gateway-Auth", "secret-header")
+
+	rec := NewRecorder(backend, WithRedactHeaders("X-Gateway-Auth"), WithRedactFields("gatewayToken"))
+	resp, err := (&http.Client{Transport: rec}).Do(req)
+	if err != nil {
+		t.Fatalf("TestRedact: got err == %s, want err == nil", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	buf := &bytes.Buffer{}
+	if err := rec.Run().Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	artifact := buf.String()
+	for _, secret := range []string{"secret-bearer", "secret-api-key", "secret-apim-key", "secret-header", "secret-search-key", "secret-field", "secret-cookie"} {
+		if strings.Contains(artifact, secret) {
+			t.Errorf("TestRedact: %q was recorded", secret)
+		}
+	}
+
+	run, err := Load(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"indexName":"index"`, `"content":"hi"`, `"key":"REDACTED"`} {
+		if !strings.Contains(string(run.Exchanges[0].ReqBody), want) {
+			t.Errorf("TestRedact: got request body %s, want it to contain %s", run.Exchanges[0].ReqBody, want)
+		}
+	}
+}
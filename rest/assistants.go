@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/assistants"
+)
+
+// runPollInterval is the interval between polls of a run if the service does not send a
+// Retry-After header. Runs usually take seconds.
+const runPollInterval = time.Second
+
+// AssistantCreate creates an assistant.
+func (c *Client) AssistantCreate(ctx context.Context, req assistants.AssistantReq) (assistants.Assistant, error) {
+	if req.Model == "" {
+		return assistants.Assistant{}, fmt.Errorf("Model is required")
+	}
+	if err := req.Validate(); err != nil {
+		return assistants.Assistant{}, err
+	}
+	a, meta, err := resourceDo[assistants.Assistant](ctx, c, assistantsTmpl, http.MethodPost, []string{"assistants"}, nil, req)
+	a.Meta = meta
+	return a, err
+}
+
+// Assistant returns the assistant with id.
+func (c *Client) Assistant(ctx context.Context, id string) (assistants.Assistant, error) {
+	a, meta, err := resourceDo[assistants.Assistant](ctx, c, assistantsTmpl, http.MethodGet, []string{"assistants", id}, nil, nil)
+	a.Meta = meta
+	return a, err
+}
+
+// AssistantUpdate updates the assistant with id. Only the fields set in req are changed.
+func (c *Client) AssistantUpdate(ctx context.Context, id string, req assistants.AssistantReq) (assistants.Assistant, error) {
+	if err := req.Validate(); err != nil {
+		return assistants.Assistant{}, err
+	}
+	a, meta, err := resourceDo[assistants.Assistant](ctx, c, assistantsTmpl, http.MethodPost, []string{"assistants", id}, nil, req)
+	a.Meta = meta
+	return a, err
+}
+
+// AssistantDelete deletes the assistant with id.
+func (c *Client) AssistantDelete(ctx context.Context, id string) (assistants.Deleted, error) {
+	d, meta, err := resourceDo[assistants.Deleted](ctx, c, assistantsTmpl, http.MethodDelete, []string{"assistants", id}, nil, nil)
+	d.Meta = meta
+	return d, err
+}
+
+// Assistants lists the assistants.
+func (c *Client) Assistants(ctx context.Context, opts assistants.ListOptions) (assistants.List[assistants.Assistant], error) {
+	if err := opts.Validate(); err != nil {
+		return assistants.List[assistants.Assistant]{}, err
+	}
+	l, meta, err := resourceDo[assistants.List[assistants.Assistant]](ctx, c, assistantsTmpl, http.MethodGet, []string{"assistants"}, opts.Query(), nil)
+	l.Meta = meta
+	return l, err
+}
+
+// ThreadCreate creates a thread.
+func (c *Client) ThreadCreate(ctx context.Context, req assistants.ThreadReq) (assistants.Thread, error) {
+	if err := req.Validate(); err != nil {
+		return assistants.Thread{}, err
+	}
+	t, meta, err := resourceDo[assistants.Thread](ctx, c, assistantsTmpl, http.MethodPost, []string{"threads"}, nil, req)
+	t.Meta = meta
+	return t, err
+}
+
+// Thread returns the thread with id.
+func (c *Client) Thread(ctx context.Context, id string) (assistants.Thread, error) {
+	t, meta, err := resourceDo[assistants.Thread](ctx, c, assistantsTmpl, http.MethodGet, []string{"threads", id}, nil, nil)
+	t.Meta = meta
+	return t, err
+}
+
+// ThreadDelete deletes the thread with id.
+func (c *Client) ThreadDelete(ctx context.Context, id string) (assistants.Deleted, error) {
+	d, meta, err := resourceDo[assistants.Deleted](ctx, c, assistantsTmpl, http.MethodDelete, []string{"threads", id}, nil, nil)
+	d.Meta = meta
+	return d, err
+}
+
+// MessageCreate adds a message to the thread with threadID.
+func (c *Client) MessageCreate(ctx context.Context, threadID string, req assistants.MessageReq) (assistants.Message, error) {
+	if err := req.Validate(); err != nil {
+		return assistants.Message{}, err
+	}
+	m, meta, err := resourceDo[assistants.Message](ctx, c, assistantsTmpl, http.MethodPost, []string{"threads", threadID, "messages"}, nil, req)
+	m.Meta = meta
+	return m, err
+}
+
+// Messages lists the messages of the thread with threadID.
+func (c *Client) Messages(ctx context.Context, threadID string, opts assistants.ListOptions) (assistants.List[assistants.Message], error) {
+	if err := opts.Validate(); err != nil {
+		return assistants.List[assistants.Message]{}, err
+	}
+	l, meta, err := resourceDo[assistants.List[assistants.Message]](ctx, c, assistantsTmpl, http.MethodGet, []string{"threads", threadID, "messages"}, opts.Query(), nil)
+	l.Meta = meta
+	return l, err
+}
+
+// RunCreate starts a run on the thread with threadID and returns it without waiting for it to
+// finish. Use RunWait() to wait for it.
+func (c *Client) RunCreate(ctx context.Context, threadID string, req assistants.RunReq) (assistants.Run, error) {
+	if err := req.Validate(); err != nil {
+		return assistants.Run{}, err
+	}
+	r, meta, err := resourceDo[assistants.Run](ctx, c, assistantsTmpl, http.MethodPost, []string{"threads", threadID, "runs"}, nil, req)
+	r.Meta = meta
+	return r, err
+}
+
+// Run returns the current state of the run with runID.
+func (c *Client) Run(ctx context.Context, threadID, runID string) (assistants.Run, error) {
+	r, meta, err := resourceDo[assistants.Run](ctx, c, assistantsTmpl, http.MethodGet, []string{"threads", threadID, "runs", runID}, nil, nil)
+	r.Meta = meta
+	return r, err
+}
+
+// RunCancel cancels the run with runID. The run is Cancelling until the service stops it.
+func (c *Client) RunCancel(ctx context.Context, threadID, runID string) (assistants.Run, error) {
+	r, meta, err := resourceDo[assistants.Run](ctx, c, assistantsTmpl, http.MethodPost, []string{"threads", threadID, "runs", runID, "cancel"}, nil, nil)
+	r.Meta = meta
+	return r, err
+}
+
+// RunSubmitToolOutputs submits the outputs of the tool calls a run is waiting on and returns
+// the run without waiting for it to finish. All the tool calls must be answered in one call.
+func (c *Client) RunSubmitToolOutputs(ctx context.Context, threadID, runID string, req assistants.ToolOutputsReq) (assistants.Run, error) {
+	if err := req.Validate(); err != nil {
+		return assistants.Run{}, err
+	}
+	r, meta, err := resourceDo[assistants.Run](ctx, c, assistantsTmpl, http.MethodPost, []string{"threads", threadID, "runs", runID, "submit_tool_outputs"}, nil, req)
+	r.Meta = meta
+	return r, err
+}
+
+// RunSteps lists the steps of the run with runID.
+func (c *Client) RunSteps(ctx context.Context, threadID, runID string, opts assistants.ListOptions) (assistants.List[assistants.RunStep], error) {
+	if err := opts.Validate(); err != nil {
+		return assistants.List[assistants.RunStep]{}, err
+	}
+	l, meta, err := resourceDo[assistants.List[assistants.RunStep]](ctx, c, assistantsTmpl, http.MethodGet, []string{"threads", threadID, "runs", runID, "steps"}, opts.Query(), nil)
+	l.Meta = meta
+	return l, err
+}
+
+// RunWait polls run until it has ended or requires tool outputs, see assistants.RunStatus.Waiting(),
+// or ctx is cancelled. A run that ends without completing is returned without an error, check
+// its Status.
+func (c *Client) RunWait(ctx context.Context, run assistants.Run) (assistants.Run, error) {
+	return poll(
+		ctx,
+		runPollInterval,
+		run,
+		func(r assistants.Run) http.Header { return r.Meta.Header },
+		func(ctx context.Context, r assistants.Run) (assistants.Run, error) {
+			return c.Run(ctx, r.ThreadID, r.ID)
+		},
+		func(r assistants.Run) bool { return r.Status.Waiting() },
+	)
+}
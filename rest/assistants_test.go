@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/assistants"
+)
+
+func TestAssistantsURL(t *testing.T) {
+	c, err := New("test", auth.Authorizer{ApiKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc    string
+		elems   []string
+		opts    assistants.ListOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:  "create assistant",
+			elems: []string{"assistants"},
+			want:  "https://test.openai.azure.com/openai/assistants?api-version=" + AssistantsAPIVersion,
+		},
+		{
+			desc:  "IDs are escaped",
+			elems: []string{"threads", "a/b", "runs"},
+			want:  "https://test.openai.azure.com/openai/threads/a%2Fb/runs?api-version=" + AssistantsAPIVersion,
+		},
+		{
+			desc:  "list options",
+			elems: []string{"threads", "t", "messages"},
+			opts:  assistants.ListOptions{Limit: 5, Order: assistants.Asc, After: "msg_1"},
+			want:  "https://test.openai.azure.com/openai/threads/t/messages?after=msg_1&api-version=" + AssistantsAPIVersion + "&limit=5&order=asc",
+		},
+		{
+			desc:    "empty ID",
+			elems:   []string{"assistants", ""},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		u, err := c.resourceURL(assistantsTmpl, test.elems, test.opts.Query())
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestAssistantsURL(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestAssistantsURL(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if got := u.String(); got != test.want {
+			t.Errorf("TestAssistantsURL(%s): got %s, want %s", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestRunWait(t *testing.T) {
+	statuses := []assistants.RunStatus{assistants.InProgress, assistants.InProgress, assistants.RequiresAction}
+	polls := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Retry-After-Ms": []string{"1"}},
+			Request:    req,
+		}
+		switch {
+		case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/threads/thread_1/runs"):
+			var in assistants.RunReq
+			if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(strings.NewReader(fmt.Sprintf(`{"id": "run_1", "thread_id": "thread_1", "assistant_id": %q, "status": "queued"}`, in.AssistantID)))
+		case req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/threads/thread_1/runs/run_1"):
+			s := statuses[polls]
+			polls++
+			body := fmt.Sprintf(`{"id": "run_1", "thread_id": "thread_1", "status": %q}`, s)
+			if s == assistants.RequiresAction {
+				body = `{"id": "run_1", "thread_id": "thread_1", "status": "requires_action", "required_action": {"type": "submit_tool_outputs",
+					"submit_tool_outputs": {"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{}"}}]}}}`
+			}
+			resp.Body = io.NopCloser(strings.NewReader(body))
+		default:
+			resp.StatusCode = http.StatusNotFound
+			resp.Body = io.NopCloser(strings.NewReader(`{"error": {"code": "404"}}`))
+		}
+		return resp, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := c.RunCreate(context.Background(), "thread_1", assistants.RunReq{AssistantID: "asst_1"})
+	if err != nil {
+		t.Fatalf("TestRunWait(create): got err == %s, want err == nil", err)
+	}
+	if run.AssistantID != "asst_1" || run.Status != assistants.Queued {
+		t.Fatalf("TestRunWait(create): got %+v, want a queued run of asst_1", run)
+	}
+
+	run, err = c.RunWait(context.Background(), run)
+	if err != nil {
+		t.Fatalf("TestRunWait: got err == %s, want err == nil", err)
+	}
+	if polls != 3 {
+		t.Errorf("TestRunWait: got %d polls, want 3", polls)
+	}
+	if run.Status != assistants.RequiresAction {
+		t.Errorf("TestRunWait: got status %s, want %s", run.Status, assistants.RequiresAction)
+	}
+	if run.RequiredAction == nil || len(run.RequiredAction.SubmitToolOutputs.ToolCalls) != 1 {
+		t.Fatalf("TestRunWait: got RequiredAction %+v, want one tool call", run.RequiredAction)
+	}
+	if got := run.RequiredAction.SubmitToolOutputs.ToolCalls[0].Function.Name; got != "get_weather" {
+		t.Errorf("TestRunWait: got function %q, want get_weather", got)
+	}
+
+	if _, err := c.RunCreate(context.Background(), "thread_1", assistants.RunReq{}); err == nil {
+		t.Errorf("TestRunWait(no assistant): got err == nil, want err != nil")
+	}
+}
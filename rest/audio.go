@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/audio"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Transcribe sends a request to the Azure OpenAI service to transcribe audio into text in the
+// language of the audio. deploymentID must be a Whisper deployment.
+func (c *Client) Transcribe(ctx context.Context, deploymentID string, req audio.Req) (audio.Resp, error) {
+	return c.audio(ctx, transcribeTmpl, deploymentID, req)
+}
+
+// Translate sends a request to the Azure OpenAI service to translate audio into English text.
+// deploymentID must be a Whisper deployment. req.Language is not sent.
+func (c *Client) Translate(ctx context.Context, deploymentID string, req audio.Req) (audio.Resp, error) {
+	req.Language = ""
+	return c.audio(ctx, translateTmpl, deploymentID, req)
+}
+
+func (c *Client) audio(ctx context.Context, et endpointType, deploymentID string, req audio.Req) (audio.Resp, error) {
+	if err := req.Validate(); err != nil {
+		return audio.Resp{}, err
+	}
+
+	u, err := c.endpoints.url(et, deploymentID, c.vars)
+	if err != nil {
+		return audio.Resp{}, err
+	}
+
+	// The body is buffered so that it can be resent on a retry.
+	body, contentType, err := audioForm(req)
+	if err != nil {
+		return audio.Resp{}, err
+	}
+
+	// The limiter is passed no message, audio is not counted in tokens.
+	if err := c.limiter.wait(ctx, deploymentID, nil); err != nil {
+		return audio.Resp{}, err
+	}
+	start := time.Now()
+	resp, err := c.doHeader(ctx, http.MethodPost, u, body, http.Header{"Content-Type": []string{contentType}})
+	if err != nil {
+		c.stats.record(deploymentID, len(body), 0, time.Since(start), err)
+		return audio.Resp{}, err
+	}
+	defer resp.Body.Close()
+
+	meta := custom.NewResponseMeta(resp)
+	b, err := io.ReadAll(resp.Body)
+	c.stats.record(deploymentID, len(body), len(b), time.Since(start), err)
+	if err != nil {
+		return audio.Resp{}, fmt.Errorf("problem reading the response body: %w", err)
+	}
+	c.limiter.update(deploymentID, meta)
+
+	msg := audio.Resp{Meta: meta}
+	if !req.ResponseFormat.IsJSON() {
+		msg.Text = string(b)
+		return msg, nil
+	}
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return audio.Resp{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
+	}
+	return msg, nil
+}
+
+// audioForm encodes req as multipart/form-data and returns the body and its content type.
+func audioForm(req audio.Req) ([]byte, string, error) {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	f, err := w.CreateFormFile("file", req.FileName)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(f, req.File); err != nil {
+		return nil, "", fmt.Errorf("problem reading the audio: %w", err)
+	}
+
+	fields := []struct{ k, v string }{
+		{"language", req.Language},
+		{"prompt", req.Prompt},
+		{"response_format", string(req.ResponseFormat)},
+	}
+	if req.Temperature != 0 {
+		fields = append(fields, struct{ k, v string }{"temperature", strconv.FormatFloat(req.Temperature, 'f', -1, 64)})
+	}
+	for _, field := range fields {
+		if field.v == "" {
+			continue
+		}
+		if err := w.WriteField(field.k, field.v); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
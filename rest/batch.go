@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/batch"
+)
+
+// batchPollInterval is the interval between polls of a batch if the service does not send a
+// Retry-After header. Batches take minutes to hours.
+const batchPollInterval = 30 * time.Second
+
+// BatchCreate creates a batch from an input file that was uploaded with the batch purpose.
+func (c *Client) BatchCreate(ctx context.Context, req batch.CreateReq) (batch.Batch, error) {
+	if err := req.Validate(); err != nil {
+		return batch.Batch{}, err
+	}
+	if req.CompletionWindow == "" {
+		req.CompletionWindow = "24h"
+	}
+	b, meta, err := resourceDo[batch.Batch](ctx, c, batchesTmpl, http.MethodPost, []string{"batches"}, nil, req)
+	b.Meta = meta
+	return b, err
+}
+
+// Batch returns the batch with id.
+func (c *Client) Batch(ctx context.Context, id string) (batch.Batch, error) {
+	b, meta, err := resourceDo[batch.Batch](ctx, c, batchesTmpl, http.MethodGet, []string{"batches", id}, nil, nil)
+	b.Meta = meta
+	return b, err
+}
+
+// BatchCancel cancels the batch with id. The batch is Cancelling until the service stops it.
+func (c *Client) BatchCancel(ctx context.Context, id string) (batch.Batch, error) {
+	b, meta, err := resourceDo[batch.Batch](ctx, c, batchesTmpl, http.MethodPost, []string{"batches", id, "cancel"}, nil, nil)
+	b.Meta = meta
+	return b, err
+}
+
+// Batches lists the batches, newest first.
+func (c *Client) Batches(ctx context.Context, opts batch.ListOptions) (batch.List, error) {
+	if err := opts.Validate(); err != nil {
+		return batch.List{}, err
+	}
+	l, meta, err := resourceDo[batch.List](ctx, c, batchesTmpl, http.MethodGet, []string{"batches"}, opts.Query(), nil)
+	l.Meta = meta
+	return l, err
+}
+
+// BatchWait polls b until it has ended, see batch.Status.Done(), or ctx is cancelled. A batch
+// that ends without completing is returned without an error, check its Status.
+func (c *Client) BatchWait(ctx context.Context, b batch.Batch) (batch.Batch, error) {
+	return poll(
+		ctx,
+		batchPollInterval,
+		b,
+		func(b batch.Batch) http.Header { return b.Meta.Header },
+		func(ctx context.Context, b batch.Batch) (batch.Batch, error) {
+			return c.Batch(ctx, b.ID)
+		},
+		func(b batch.Batch) bool { return b.Status.Done() },
+	)
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"github.com/element-of-surprise/azopenai/cache"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// WithCache caches the responses of completions, chat and embeddings requests in c, keyed on
+// namespace and a hash of the URL and the request body. An identical request returns the cached
+// response without calling the service, and its ResponseMeta.Cached is set. Streams and images are
+// not cached. Remember that chat and completions with a Temperature above 0 vary between calls,
+// caching them always returns the first response. Use ContextWithoutCache() to bypass the cache for
+// a call. Errors from the cache are logged to the WithLogger() logger and otherwise ignored.
+//
+// The credentials are not part of the key. namespace must identify who may read the responses,
+// such as the tenant or the name of the credential, and a cache shared between processes must not
+// be used by clients with different credentials under the same namespace, or one caller is served
+// responses made for another.
+func WithCache(namespace string, c cache.Cache) Option {
+	return func(client *Client) error {
+		if namespace == "" {
+			return fmt.Errorf("WithCache: namespace cannot be empty")
+		}
+		if c == nil {
+			return fmt.Errorf("WithCache: cache cannot be nil")
+		}
+		client.cache = c
+		client.cacheNS = namespace
+		return nil
+	}
+}
+
+type noCacheKey struct{}
+
+// ContextWithoutCache returns a Context that bypasses the cache set with WithCache() for
+// requests made with it. The responses are not stored either.
+func ContextWithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// cacheKey returns the cache key for a request of msg to addr in namespace ns.
+func cacheKey(ns string, addr *url.URL, msg []byte) string {
+	h := sha256.New()
+	h.Write([]byte(ns))
+	h.Write([]byte{0})
+	h.Write([]byte(addr.String()))
+	h.Write([]byte{0})
+	h.Write(msg)
+	return "azopenai:" + ns + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// sendCached is the same as send, but uses the cache set with WithCache(), shares identical
+// requests in flight if WithDedup() was used and stores the response for ContextWithRawResponse().
+func (c *Client) sendCached(ctx context.Context, deploymentID string, addr *url.URL, msg []byte) ([]byte, custom.ResponseMeta, error) {
+	b, meta, err := c.cachedSend(ctx, deploymentID, addr, msg)
+	if err == nil {
+		storeRaw(ctx, b)
+	}
+	return b, meta, err
+}
+
+func (c *Client) cachedSend(ctx context.Context, deploymentID string, addr *url.URL, msg []byte) ([]byte, custom.ResponseMeta, error) {
+	if c.cache == nil && c.dedup == nil {
+		return c.send(ctx, deploymentID, addr, msg)
+	}
+	key := cacheKey(c.cacheNS, addr, msg)
+	if c.cache == nil || ctx.Value(noCacheKey{}) != nil {
+		return c.sendShared(ctx, deploymentID, addr, msg, key)
+	}
+
+	b, ok, err := c.cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		c.logCacheErr(ctx, "get", err)
+	case ok:
+		return b, custom.ResponseMeta{StatusCode: 200, RemainingRequests: -1, RemainingTokens: -1, Cached: true}, nil
+	}
+
+	b, meta, err := c.sendShared(ctx, deploymentID, addr, msg, key)
+	if err != nil {
+		return b, meta, err
+	}
+	if err := c.cache.Set(ctx, key, b); err != nil {
+		c.logCacheErr(ctx, "set", err)
+	}
+	return b, meta, nil
+}
+
+func (c *Client) logCacheErr(ctx context.Context, op string, err error) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "azopenai cache error", slog.String("op", op), slog.String("error", err.Error()))
+}
@@ -0,0 +1,121 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/cache"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+func TestCache(t *testing.T) {
+	calls := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"data": [{"object": "embedding", "embedding": [0.1], "index": 0}]}`)),
+			Request:    req,
+		}, nil
+	})
+
+	lru, err := cache.NewLRU(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}), WithCache("test", lru))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	req := embeddings.Req{Input: []string{"hello"}}
+	tests := []struct {
+		desc       string
+		ctx        context.Context
+		req        embeddings.Req
+		wantCalls  int
+		wantCached bool
+	}{
+		{desc: "first call", ctx: ctx, req: req, wantCalls: 1},
+		{desc: "identical call", ctx: ctx, req: req, wantCalls: 1, wantCached: true},
+		{desc: "different input", ctx: ctx, req: embeddings.Req{Input: []string{"world"}}, wantCalls: 2},
+		{desc: "bypass", ctx: ContextWithoutCache(ctx), req: req, wantCalls: 3},
+	}
+
+	for _, test := range tests {
+		resp, err := c.Embeddings(test.ctx, "deployment", test.req)
+		if err != nil {
+			t.Fatalf("TestCache(%s): got err == %s, want err == nil", test.desc, err)
+		}
+		if calls != test.wantCalls {
+			t.Errorf("TestCache(%s): got %d calls to the service, want %d", test.desc, calls, test.wantCalls)
+		}
+		if resp.Meta.Cached != test.wantCached {
+			t.Errorf("TestCache(%s): got Cached == %v, want %v", test.desc, resp.Meta.Cached, test.wantCached)
+		}
+		if len(resp.Data) != 1 {
+			t.Errorf("TestCache(%s): got %d embeddings, want 1", test.desc, len(resp.Data))
+		}
+	}
+}
+
+func TestCacheNamespace(t *testing.T) {
+	calls := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"data": [{"object": "embedding", "embedding": [0.1], "index": 0}]}`)),
+			Request:    req,
+		}, nil
+	})
+
+	if _, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}), WithCache("", &cache.LRU{})); err == nil {
+		t.Errorf("TestCacheNamespace(empty namespace): got err == nil, want err != nil")
+	}
+
+	// Clients share one cache, as processes sharing a Redis cache would.
+	lru, err := cache.NewLRU(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient := func(key, ns string) *Client {
+		c, err := New("test", auth.Authorizer{ApiKey: key}, WithClient(&http.Client{Transport: rt}), WithCache(ns, lru))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		desc       string
+		client     *Client
+		wantCalls  int
+		wantCached bool
+	}{
+		{desc: "tenant a", client: newClient("keyA", "a"), wantCalls: 1},
+		{desc: "tenant b with another key", client: newClient("keyB", "b"), wantCalls: 2},
+		{desc: "tenant a again", client: newClient("keyA", "a"), wantCalls: 2, wantCached: true},
+	}
+
+	req := embeddings.Req{Input: []string{"hello"}}
+	for _, test := range tests {
+		resp, err := test.client.Embeddings(context.Background(), "deployment", req)
+		if err != nil {
+			t.Fatalf("TestCacheNamespace(%s): got err == %s, want err == nil", test.desc, err)
+		}
+		if calls != test.wantCalls {
+			t.Errorf("TestCacheNamespace(%s): got %d calls to the service, want %d", test.desc, calls, test.wantCalls)
+		}
+		if resp.Meta.Cached != test.wantCached {
+			t.Errorf("TestCacheNamespace(%s): got Cached == %v, want %v", test.desc, resp.Meta.Cached, test.wantCached)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Decoder returns a reader of the decompressed body. See WithDecoder().
+type Decoder func(body io.Reader) (io.ReadCloser, error)
+
+// encoding is a content encoding the Client accepts.
+type encoding struct {
+	name    string
+	decoder Decoder
+}
+
+// compression is how the Client negotiates compressed responses. The zero value accepts gzip.
+type compression struct {
+	disabled  bool
+	encodings []encoding
+}
+
+// gzipEncoding is always accepted unless compression is disabled.
+var gzipEncoding = encoding{
+	name: "gzip",
+	decoder: func(body io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(body)
+	},
+}
+
+// WithDecoder accepts responses compressed with the encoding name, such as "zstd" from a gateway that
+// compresses responses, and decompresses them with d. gzip is always accepted. Encodings are
+// preferred in the order they are added, before gzip. Large chat and embeddings responses
+// compress well, so this saves bandwidth when the service is far away.
+func WithDecoder(name string, d Decoder) Option {
+	return func(client *Client) error {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "" || name == "identity":
+			return fmt.Errorf("WithDecoder: encoding %q is not valid", name)
+		case d == nil:
+			return fmt.Errorf("WithDecoder: Decoder cannot be nil")
+		}
+		client.compress.encodings = append(client.compress.encodings, encoding{name: name, decoder: d})
+		return nil
+	}
+}
+
+// WithoutCompression asks for responses that are not compressed, which is useful to read the
+// responses on the wire when debugging.
+func WithoutCompression() Option {
+	return func(client *Client) error {
+		client.compress.disabled = true
+		return nil
+	}
+}
+
+// acceptEncoding returns the Accept-Encoding header to send.
+func (c compression) acceptEncoding() string {
+	if c.disabled {
+		return "identity"
+	}
+	names := make([]string, 0, len(c.encodings)+1)
+	for _, e := range c.encodings {
+		names = append(names, e.name)
+	}
+	return strings.Join(append(names, gzipEncoding.name), ", ")
+}
+
+// decoder returns the Decoder for the Content-Encoding name.
+func (c compression) decoder(name string) (Decoder, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, e := range c.encodings {
+		if e.name == name {
+			return e.decoder, true
+		}
+	}
+	if name == gzipEncoding.name {
+		return gzipEncoding.decoder, true
+	}
+	return nil, false
+}
+
+// decode replaces the body of resp with its decompressed body if resp is compressed. Setting
+// Accept-Encoding stops the http.Transport from decompressing gzip itself, so it is done here.
+func (c compression) decode(resp *http.Response) error {
+	ce := resp.Header.Get("Content-Encoding")
+	if ce == "" || strings.EqualFold(ce, "identity") || resp.Uncompressed {
+		return nil
+	}
+	d, ok := c.decoder(ce)
+	if !ok {
+		return fmt.Errorf("response has Content-Encoding %q, which has no Decoder", ce)
+	}
+	r, err := d(resp.Body)
+	if err != nil {
+		return fmt.Errorf("problem decompressing the %s response: %w", ce, err)
+	}
+	resp.Body = &decodedBody{ReadCloser: r, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decodedBody is a decompressed response body. Closing it also closes the raw body.
+type decodedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if rerr := b.raw.Close(); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+)
+
+func TestCompression(t *testing.T) {
+	const body = `{"id": "asst_1"}`
+	gz := &bytes.Buffer{}
+	w := gzip.NewWriter(gz)
+	w.Write([]byte(body))
+	w.Close()
+
+	// b64 stands in for an encoding such as zstd.
+	b64 := func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+	}
+
+	tests := []struct {
+		desc       string
+		options    []Option
+		encoding   string
+		wantAccept string
+		wantErr    bool
+	}{
+		{desc: "gzip", encoding: "gzip", wantAccept: "gzip"},
+		{desc: "not compressed", wantAccept: "gzip"},
+		{
+			desc:       "custom decoder",
+			options:    []Option{WithDecoder("B64", b64)},
+			encoding:   "b64",
+			wantAccept: "b64, gzip",
+		},
+		{desc: "disabled", options: []Option{WithoutCompression()}, wantAccept: "identity"},
+		{desc: "unknown encoding", encoding: "br", wantAccept: "gzip", wantErr: true},
+	}
+
+	for _, test := range tests {
+		var gotAccept string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAccept = r.Header.Get("Accept-Encoding")
+			if test.encoding != "" {
+				w.Header().Set("Content-Encoding", test.encoding)
+			}
+			switch test.encoding {
+			case "gzip":
+				w.Write(gz.Bytes())
+			case "b64":
+				w.Write([]byte(base64.StdEncoding.EncodeToString([]byte(body))))
+			default:
+				w.Write([]byte(body))
+			}
+		}))
+
+		options := append([]Option{WithEndpoint(srv.URL)}, test.options...)
+		c, err := New("test", auth.Authorizer{ApiKey: "key"}, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := c.Assistant(context.Background(), "asst_1")
+		srv.Close()
+
+		if gotAccept != test.wantAccept {
+			t.Errorf("TestCompression(%s): got Accept-Encoding %q, want %q", test.desc, gotAccept, test.wantAccept)
+		}
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestCompression(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestCompression(%s): got err == %s, want err == nil", test.desc, err)
+		case err == nil && a.ID != "asst_1":
+			t.Errorf("TestCompression(%s): got ID %q, want asst_1", test.desc, a.ID)
+		}
+	}
+
+	if _, err := New("test", auth.Authorizer{ApiKey: "key"}, WithDecoder("identity", b64)); err == nil || !strings.Contains(err.Error(), "not valid") {
+		t.Errorf("TestCompression(identity decoder): got err == %v, want err != nil", err)
+	}
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/errors"
+)
+
+type ifMatchKey struct{}
+
+// ContextWithIfMatch returns a Context that sends an If-Match header with etag on the changes
+// to assistants, files and batches made with it. The service only makes the change if the
+// resource has not changed since etag, from custom.ResponseMeta.ETag, was read. Otherwise the
+// call fails with an errors.PreconditionFailed.
+func ContextWithIfMatch(ctx context.Context, etag string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, etag)
+}
+
+// ifMatch returns the etag set with ContextWithIfMatch().
+func ifMatch(ctx context.Context) string {
+	etag, _ := ctx.Value(ifMatchKey{}).(string)
+	return etag
+}
+
+// conflictDelay is the delay before the first retry of RetryConflict(). It doubles on each retry.
+const conflictDelay = 100 * time.Millisecond
+
+// RetryConflict calls fn until it does not fail with an errors.Conflict or errors.PreconditionFailed,
+// up to attempts times, waiting a little longer between each. fn should re-read the resource and
+// apply its change to the current state, so that it is merged with changes made by others:
+//
+//	err := rest.RetryConflict(ctx, 3, func(ctx context.Context) error {
+//		a, err := client.Assistant(ctx, id)
+//		if err != nil {
+//			return err
+//		}
+//		ctx = rest.ContextWithIfMatch(ctx, a.Meta.ETag)
+//		_, err = client.AssistantUpdate(ctx, id, assistants.AssistantReq{Tools: append(a.Tools, tool)})
+//		return err
+//	})
+//
+// Other errors are returned right away. If every attempt conflicts, the last error is returned.
+func RetryConflict(ctx context.Context, attempts int, fn func(ctx context.Context) error) error {
+	if attempts < 1 {
+		return fmt.Errorf("RetryConflict: attempts must be >= 1, was %d", attempts)
+	}
+
+	delay := conflictDelay
+	for i := 0; ; i++ {
+		err := fn(ctx)
+		if err == nil || !isConflict(err) || i == attempts-1 {
+			return err
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
+
+// isConflict returns true if err is an errors.Conflict or errors.PreconditionFailed.
+func isConflict(err error) bool {
+	var c errors.Conflict
+	var p errors.PreconditionFailed
+	return errors.As(err, &c) || errors.As(err, &p)
+}
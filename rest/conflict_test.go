@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest/messages/assistants"
+)
+
+func TestConflictErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		status   int
+		wantType string
+	}{
+		{desc: "conflict", status: http.StatusConflict, wantType: "conflict"},
+		{desc: "precondition failed", status: http.StatusPreconditionFailed, wantType: "precondition"},
+		{desc: "bad request", status: http.StatusBadRequest, wantType: "json"},
+	}
+
+	for _, test := range tests {
+		var gotIfMatch string
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			gotIfMatch = req.Header.Get("If-Match")
+			return &http.Response{
+				StatusCode: test.status,
+				Header:     http.Header{"Etag": []string{`"v2"`}},
+				Body:       io.NopCloser(strings.NewReader(`{"error": {"code": "conflict"}}`)),
+				Request:    req,
+			}, nil
+		})
+		c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := ContextWithIfMatch(context.Background(), `"v1"`)
+		_, err = c.AssistantUpdate(ctx, "asst_1", assistants.AssistantReq{Name: "bot"})
+		if gotIfMatch != `"v1"` {
+			t.Errorf("TestConflictErrors(%s): got If-Match %q, want %q", test.desc, gotIfMatch, `"v1"`)
+		}
+
+		var conflict errors.Conflict
+		var precond errors.PreconditionFailed
+		var jsonErr errors.JSON
+		if !errors.As(err, &jsonErr) || jsonErr.StatusCode != test.status {
+			t.Errorf("TestConflictErrors(%s): got err %v, want it to wrap an errors.JSON with status %d", test.desc, err, test.status)
+		}
+		switch test.wantType {
+		case "conflict":
+			if !errors.As(err, &conflict) || conflict.ETag != `"v2"` {
+				t.Errorf("TestConflictErrors(%s): got err %T, want errors.Conflict with ETag", test.desc, err)
+			}
+		case "precondition":
+			if !errors.As(err, &precond) || precond.ETag != `"v2"` {
+				t.Errorf("TestConflictErrors(%s): got err %T, want errors.PreconditionFailed with ETag", test.desc, err)
+			}
+		default:
+			if errors.As(err, &conflict) || errors.As(err, &precond) {
+				t.Errorf("TestConflictErrors(%s): got err %T, want neither conflict type", test.desc, err)
+			}
+		}
+	}
+}
+
+func TestRetryConflict(t *testing.T) {
+	conflict := errors.Conflict{Err: fmt.Errorf("busy")}
+	other := fmt.Errorf("other")
+
+	tests := []struct {
+		desc      string
+		errs      []error
+		attempts  int
+		wantCalls int
+		wantErr   error
+	}{
+		{desc: "succeeds after conflicts", errs: []error{conflict, errors.PreconditionFailed{Err: other}, nil}, attempts: 3, wantCalls: 3},
+		{desc: "runs out of attempts", errs: []error{conflict, conflict, conflict}, attempts: 2, wantCalls: 2, wantErr: conflict},
+		{desc: "other errors are not retried", errs: []error{other, nil}, attempts: 3, wantCalls: 1, wantErr: other},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		err := RetryConflict(context.Background(), test.attempts, func(ctx context.Context) error {
+			err := test.errs[calls]
+			calls++
+			return err
+		})
+		if calls != test.wantCalls {
+			t.Errorf("TestRetryConflict(%s): got %d calls, want %d", test.desc, calls, test.wantCalls)
+		}
+		if err != test.wantErr {
+			t.Errorf("TestRetryConflict(%s): got err == %v, want err == %v", test.desc, err, test.wantErr)
+		}
+	}
+
+	if err := RetryConflict(context.Background(), 0, func(ctx context.Context) error { return nil }); err == nil {
+		t.Errorf("TestRetryConflict(0 attempts): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"expvar"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// ConnStats are the connection statistics of a Client, gathered with net/http/httptrace.
+// Each new connection costs a DNS lookup, a TCP connect and a TLS handshake, so few reused
+// connections is a common hidden cause of latency. This usually means response bodies are not
+// read and closed, or the http.Transport's MaxIdleConnsPerHost is lower than the concurrency.
+type ConnStats struct {
+	// NewConns is the number of requests that opened a new connection.
+	NewConns int64
+	// ReusedConns is the number of requests that reused a connection.
+	ReusedConns int64
+	// IdleConns is the number of reused connections that were idle in the pool. The others were
+	// reused by HTTP/2 while in use by another request.
+	IdleConns int64
+	// DNSLookups is the number of DNS lookups.
+	DNSLookups int64
+	// DNSTime is the total time spent on DNS lookups.
+	DNSTime time.Duration
+	// ConnectTime is the total time spent on TCP connects.
+	ConnectTime time.Duration
+	// TLSHandshakes is the number of TLS handshakes.
+	TLSHandshakes int64
+	// TLSTime is the total time spent on TLS handshakes.
+	TLSTime time.Duration
+}
+
+// ReuseRatio returns the fraction of requests that reused a connection, or 0 if there were
+// no requests.
+func (s ConnStats) ReuseRatio() float64 {
+	total := s.NewConns + s.ReusedConns
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ReusedConns) / float64(total)
+}
+
+// Add returns the sum of s and o.
+func (s ConnStats) Add(o ConnStats) ConnStats {
+	return ConnStats{
+		NewConns:      s.NewConns + o.NewConns,
+		ReusedConns:   s.ReusedConns + o.ReusedConns,
+		IdleConns:     s.IdleConns + o.IdleConns,
+		DNSLookups:    s.DNSLookups + o.DNSLookups,
+		DNSTime:       s.DNSTime + o.DNSTime,
+		ConnectTime:   s.ConnectTime + o.ConnectTime,
+		TLSHandshakes: s.TLSHandshakes + o.TLSHandshakes,
+		TLSTime:       s.TLSTime + o.TLSTime,
+	}
+}
+
+// connStats gathers ConnStats. It is safe for concurrent use.
+type connStats struct {
+	newConns      atomic.Int64
+	reusedConns   atomic.Int64
+	idleConns     atomic.Int64
+	dnsLookups    atomic.Int64
+	dnsTime       atomic.Int64
+	connectTime   atomic.Int64
+	tlsHandshakes atomic.Int64
+	tlsTime       atomic.Int64
+}
+
+// trace returns ctx with an httptrace.ClientTrace that records to s. Traces already in ctx
+// are still called. It is safe to call on a nil *connStats.
+func (s *connStats) trace(ctx context.Context) context.Context {
+	if s == nil {
+		return ctx
+	}
+	var dnsStart, connectStart, tlsStart time.Time
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				s.newConns.Add(1)
+				return
+			}
+			s.reusedConns.Add(1)
+			if info.WasIdle {
+				s.idleConns.Add(1)
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			s.dnsLookups.Add(1)
+			s.dnsTime.Add(int64(time.Since(dnsStart)))
+		},
+		ConnectStart: func(string, string) {
+			connectStart = time.Now()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				s.connectTime.Add(int64(time.Since(connectStart)))
+			}
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			s.tlsHandshakes.Add(1)
+			s.tlsTime.Add(int64(time.Since(tlsStart)))
+		},
+	})
+}
+
+func (s *connStats) snapshot() ConnStats {
+	return ConnStats{
+		NewConns:      s.newConns.Load(),
+		ReusedConns:   s.reusedConns.Load(),
+		IdleConns:     s.idleConns.Load(),
+		DNSLookups:    s.dnsLookups.Load(),
+		DNSTime:       time.Duration(s.dnsTime.Load()),
+		ConnectTime:   time.Duration(s.connectTime.Load()),
+		TLSHandshakes: s.tlsHandshakes.Load(),
+		TLSTime:       time.Duration(s.tlsTime.Load()),
+	}
+}
+
+// expvar returns s as an expvar.Var, with times in milliseconds.
+func (s *connStats) expvar() expvar.Var {
+	return expvar.Func(func() any {
+		cs := s.snapshot()
+		return map[string]int64{
+			"new_conns":      cs.NewConns,
+			"reused_conns":   cs.ReusedConns,
+			"idle_conns":     cs.IdleConns,
+			"dns_lookups":    cs.DNSLookups,
+			"dns_ms":         cs.DNSTime.Milliseconds(),
+			"connect_ms":     cs.ConnectTime.Milliseconds(),
+			"tls_handshakes": cs.TLSHandshakes,
+			"tls_ms":         cs.TLSTime.Milliseconds(),
+		}
+	})
+}
+
+// ConnStats returns the connection statistics of the Client. A Client made with NewPool()
+// returns the sum of its members.
+func (c *Client) ConnStats() ConnStats {
+	if c.pool == nil {
+		return c.conns.snapshot()
+	}
+	var sum ConnStats
+	seen := map[*Client]bool{}
+	for _, m := range c.pool.members {
+		// Members can share a Client to use several of its deployments.
+		if seen[m.Client] {
+			continue
+		}
+		seen[m.Client] = true
+		sum = sum.Add(m.Client.ConnStats())
+	}
+	return sum
+}
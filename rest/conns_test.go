@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+)
+
+func TestConnStats(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "asst_1"}`))
+	}))
+	defer srv.Close()
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithEndpoint(srv.URL), WithClient(srv.Client()), WithExpvar("TestConnStats"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Assistant(context.Background(), "asst_1"); err != nil {
+			t.Fatalf("TestConnStats: got err == %s, want err == nil", err)
+		}
+	}
+
+	got := c.ConnStats()
+	if got.NewConns != 1 || got.ReusedConns != 2 || got.IdleConns != 2 {
+		t.Errorf("TestConnStats: got %d new, %d reused and %d idle conns, want 1, 2 and 2", got.NewConns, got.ReusedConns, got.IdleConns)
+	}
+	if got.TLSHandshakes != 1 || got.TLSTime <= 0 {
+		t.Errorf("TestConnStats: got %d TLS handshakes in %s, want 1", got.TLSHandshakes, got.TLSTime)
+	}
+	if r := got.ReuseRatio(); r < 0.66 || r > 0.67 {
+		t.Errorf("TestConnStats: got ReuseRatio() %v, want 2/3", r)
+	}
+
+	v := expvar.Get("TestConnStats").(*expvar.Map).Get("connections")
+	if v == nil {
+		t.Fatalf("TestConnStats: connections were not published to expvar")
+	}
+	m := map[string]int64{}
+	if err := json.Unmarshal([]byte(v.String()), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["new_conns"] != 1 || m["reused_conns"] != 2 {
+		t.Errorf("TestConnStats(expvar): got %v, want 1 new and 2 reused conns", m)
+	}
+}
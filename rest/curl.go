@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WithLogCurl adds a "curl" attribute to each request logged by WithLogger(), holding a curl
+// command that sends the same request. This makes it simple to reproduce a problem outside of Go,
+// such as when filing a support ticket. The values of the headers redacted by WithLogBodies() are
+// replaced with REDACTED, which must be replaced with a real credential to run the command.
+// Remember that the command holds the request body, which may hold sensitive data.
+// This does nothing without WithLogger().
+func WithLogCurl() Option {
+	return func(client *Client) error {
+		client.logCurl = true
+		return nil
+	}
+}
+
+// Curl returns a curl command that sends req with body. The values of DefaultRedactedHeaders
+// and redactHeaders are replaced with REDACTED. Multipart bodies, such as audio files, are not
+// included and must be added to the command by hand.
+func Curl(req *http.Request, body []byte, redactHeaders ...string) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "curl -X %s %s", req.Method, shellQuote(req.URL.String()))
+
+	h := redact(req.Header, redactHeaders)
+	// curl sets these itself.
+	h.Del("Content-Length")
+	h.Del("Accept-Encoding")
+
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(sb, " \\\n  -H %s", shellQuote(k+": "+v))
+		}
+	}
+
+	switch {
+	case len(body) == 0:
+	case strings.HasPrefix(h.Get("Content-Type"), "multipart/"):
+		fmt.Fprintf(sb, " \\\n  --data-binary %s", shellQuote(fmt.Sprintf("<multipart body of %d bytes>", len(body))))
+	default:
+		fmt.Fprintf(sb, " \\\n  --data-raw %s", shellQuote(string(body)))
+	}
+	return sb.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// WithDedup makes concurrent identical completions, chat and embeddings requests share a single
+// call to the service. Requests are identical if they have the same URL and body. Only requests
+// in flight at the same time are shared, use WithCache() to reuse responses of earlier requests.
+// This reduces spend when many goroutines ask the same thing at once, such as a server receiving
+// the same question from many users. Streams and images are not shared.
+func WithDedup() Option {
+	return func(client *Client) error {
+		client.dedup = &flightGroup{calls: map[string]*flight{}}
+		return nil
+	}
+}
+
+// flight is a call in flight that other callers can wait on.
+type flight struct {
+	done chan struct{}
+	b    []byte
+	meta custom.ResponseMeta
+	err  error
+}
+
+// flightGroup shares calls with the same key that are in flight at the same time.
+type flightGroup struct {
+	mu    sync.Mutex // Protects calls
+	calls map[string]*flight
+}
+
+// do calls f, unless a call with key is in flight, in which case it waits for and returns its result.
+// If the shared call fails because its caller's Context was cancelled, but ctx is not, f is called.
+func (g *flightGroup) do(ctx context.Context, key string, f func() ([]byte, custom.ResponseMeta, error)) ([]byte, custom.ResponseMeta, error) {
+	g.mu.Lock()
+	if fl, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, custom.ResponseMeta{}, ctx.Err()
+		case <-fl.done:
+		}
+		if isCtxErr(fl.err) && ctx.Err() == nil {
+			return f()
+		}
+		return fl.b, fl.meta, fl.err
+	}
+	fl := &flight{done: make(chan struct{})}
+	g.calls[key] = fl
+	g.mu.Unlock()
+
+	fl.b, fl.meta, fl.err = f()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(fl.done)
+
+	return fl.b, fl.meta, fl.err
+}
+
+func isCtxErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// sendShared is the same as send, but shares identical requests in flight if WithDedup() was used.
+func (c *Client) sendShared(ctx context.Context, deploymentID string, addr *url.URL, msg []byte, key string) ([]byte, custom.ResponseMeta, error) {
+	if c.dedup == nil {
+		return c.send(ctx, deploymentID, addr, msg)
+	}
+	return c.dedup.do(ctx, key, func() ([]byte, custom.ResponseMeta, error) {
+		return c.send(ctx, deploymentID, addr, msg)
+	})
+}
@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+func TestDedup(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		started <- struct{}{}
+		<-release
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"data": [{"object": "embedding", "embedding": [0.1], "index": 0}]}`)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}), WithDedup())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 5
+	req := embeddings.Req{Input: []string{"hello"}}
+	errs := make(chan error, callers)
+	wg := sync.WaitGroup{}
+	call := func() {
+		defer wg.Done()
+		_, err := c.Embeddings(context.Background(), "deployment", req)
+		errs <- err
+	}
+
+	wg.Add(1)
+	go call()
+	<-started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go call()
+	}
+	// Give the other callers time to join the call in flight.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("TestDedup: got err == %s, want err == nil", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("TestDedup: got %d calls to the service, want 1", got)
+	}
+
+	// A call after the first has finished is not shared.
+	if _, err := c.Embeddings(context.Background(), "deployment", req); err != nil {
+		t.Fatalf("TestDedup(later call): got err == %s, want err == nil", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("TestDedup(later call): got %d calls to the service, want 2", got)
+	}
+}
+
+func TestFlightGroupCancel(t *testing.T) {
+	g := &flightGroup{calls: map[string]*flight{}}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		g.do(leaderCtx, "key", func() ([]byte, custom.ResponseMeta, error) {
+			close(started)
+			<-leaderCtx.Done()
+			return nil, custom.ResponseMeta{}, leaderCtx.Err()
+		})
+	}()
+	<-started
+
+	followerDone := make(chan struct{})
+	var got []byte
+	go func() {
+		defer close(followerDone)
+		got, _, _ = g.do(context.Background(), "key", func() ([]byte, custom.ResponseMeta, error) {
+			return []byte("follower"), custom.ResponseMeta{}, nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-leaderDone
+	<-followerDone
+
+	// The follower's Context is live, so it makes its own call.
+	if string(got) != "follower" {
+		t.Errorf("TestFlightGroupCancel: got %q, want %q", got, "follower")
+	}
+}
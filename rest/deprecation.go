@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Deprecation describes a deprecated part of the SDK that was used. Deprecated parts keep working
+// for at least two minor versions after they are deprecated, see the azopenai package documentation.
+type Deprecation struct {
+	// API is the deprecated function, type, field or value, such as "chat.CallParams.MaxTokens".
+	API string
+	// Instead is what should be used instead.
+	Instead string
+}
+
+// String implements fmt.Stringer.
+func (d Deprecation) String() string {
+	return fmt.Sprintf("%s is deprecated and will be removed in a future version, use %s instead", d.API, d.Instead)
+}
+
+// WithDeprecationHandler calls h the first time each deprecated part of the SDK is used with
+// the Client, instead of logging it to the WithLogger() logger. This allows failing tests or
+// reporting to a metrics system when deprecated APIs are used. h must be safe for concurrent use.
+func WithDeprecationHandler(h func(ctx context.Context, d Deprecation)) Option {
+	return func(client *Client) error {
+		if h == nil {
+			return fmt.Errorf("WithDeprecationHandler: handler cannot be nil")
+		}
+		client.deprecationHandler = h
+		return nil
+	}
+}
+
+// Deprecated reports that a deprecated part of the SDK was used. Each Deprecation.API is only
+// reported once per Client. It is sent to the WithDeprecationHandler() handler if set, or else
+// logged at slog.LevelWarn to the WithLogger() logger. Without either, this does nothing.
+// This is used by the client packages and is not normally called by users.
+func (c *Client) Deprecated(ctx context.Context, d Deprecation) {
+	if c.deprecationHandler == nil && c.logger == nil {
+		return
+	}
+	if _, loaded := c.deprecations.LoadOrStore(d.API, struct{}{}); loaded {
+		return
+	}
+
+	if c.deprecationHandler != nil {
+		c.deprecationHandler(ctx, d)
+		return
+	}
+	c.logger.LogAttrs(
+		ctx,
+		slog.LevelWarn,
+		"azopenai deprecated API used",
+		slog.String("api", d.API),
+		slog.String("instead", d.Instead),
+	)
+}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+)
+
+func TestDeprecated(t *testing.T) {
+	ctx := context.Background()
+	d := Deprecation{API: "chat.Old", Instead: "chat.New"}
+
+	var got []Deprecation
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithDeprecationHandler(func(ctx context.Context, d Deprecation) {
+		got = append(got, d)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Deprecated(ctx, d)
+	c.Deprecated(ctx, d)
+	c.Deprecated(ctx, Deprecation{API: "chat.Other", Instead: "chat.New"})
+	if len(got) != 2 || got[0] != d {
+		t.Errorf("TestDeprecated(handler): got %v, want each API reported once", got)
+	}
+
+	buf := &bytes.Buffer{}
+	c, err = New("test", auth.Authorizer{ApiKey: "key"}, WithLogger(slog.New(slog.NewTextHandler(buf, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Deprecated(ctx, d)
+	c.Deprecated(ctx, d)
+	if n := strings.Count(buf.String(), "api=chat.Old"); n != 1 {
+		t.Errorf("TestDeprecated(logger): got %d warnings, want 1:\n%s", n, buf.String())
+	}
+
+	// Without a handler or logger this must not panic.
+	c, err = New("test", auth.Authorizer{ApiKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Deprecated(ctx, d)
+}
@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type extraFieldsKey struct{}
+
+// ContextWithExtraFields returns a Context that merges fields into the JSON body of completions,
+// embeddings and chat requests made with it. This allows using parameters the service supports
+// before this SDK does. Fields replace those set by the SDK with the same name. The values must
+// encode to JSON.
+func ContextWithExtraFields(ctx context.Context, fields map[string]any) context.Context {
+	return context.WithValue(ctx, extraFieldsKey{}, fields)
+}
+
+type rawResponseKey struct{}
+
+// ContextWithRawResponse returns a Context that stores the body of the response to completions,
+// embeddings and chat requests made with it in dst, so fields this SDK does not decode can be
+// read. Streams are not stored, see chat.WithRawEvents() for those. dst must not be shared by
+// concurrent requests.
+func ContextWithRawResponse(ctx context.Context, dst *[]byte) context.Context {
+	return context.WithValue(ctx, rawResponseKey{}, dst)
+}
+
+// marshalReq encodes req to JSON and merges any fields set with ContextWithExtraFields().
+func marshalReq(ctx context.Context, req any) ([]byte, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := ctx.Value(extraFieldsKey{}).(map[string]any)
+	if len(fields) == 0 {
+		return b, nil
+	}
+
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("problem decoding request to add extra fields: %w", err)
+	}
+	for k, v := range fields {
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("extra field %q cannot be encoded to JSON: %w", k, err)
+		}
+		m[k] = vb
+	}
+	return json.Marshal(m)
+}
+
+// storeRaw stores b in the destination set with ContextWithRawResponse(), if any.
+func storeRaw(ctx context.Context, b []byte) {
+	if dst, _ := ctx.Value(rawResponseKey{}).(*[]byte); dst != nil {
+		*dst = append([]byte(nil), b...)
+	}
+}
@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+	"github.com/element-of-surprise/azopenai/rest/messages/files"
+)
+
+// FileUpload uploads a file. req.File is streamed to the service as it is read, so large files
+// are not held in memory. Because of this the upload is not retried, retry the call if it fails
+// with a retryable error and req.File can be read again.
+func (c *Client) FileUpload(ctx context.Context, req files.UploadReq) (files.File, error) {
+	if err := req.Validate(); err != nil {
+		return files.File{}, err
+	}
+
+	u, err := c.resourceURL(filesTmpl, []string{"files"}, nil)
+	if err != nil {
+		return files.File{}, err
+	}
+
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(fileForm(w, req))
+	}()
+
+	start := time.Now()
+	resp, err := c.doStream(ctx, http.MethodPost, u, pr, http.Header{"Content-Type": []string{w.FormDataContentType()}})
+	// Closing the reader stops the writer if the request ended before the file was sent.
+	pr.Close()
+	c.stats.record(string(filesTmpl), 0, 0, time.Since(start), err)
+	if err != nil {
+		return files.File{}, err
+	}
+	defer resp.Body.Close()
+
+	f := files.File{Meta: custom.NewResponseMeta(resp)}
+	if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+		return files.File{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
+	}
+	return f, nil
+}
+
+// fileForm writes req to w as multipart/form-data.
+func fileForm(w *multipart.Writer, req files.UploadReq) error {
+	if err := w.WriteField("purpose", string(req.Purpose)); err != nil {
+		return err
+	}
+	f, err := w.CreateFormFile("file", req.FileName)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, req.File); err != nil {
+		return fmt.Errorf("problem reading the file: %w", err)
+	}
+	return w.Close()
+}
+
+// File returns the file with id.
+func (c *Client) File(ctx context.Context, id string) (files.File, error) {
+	f, meta, err := resourceDo[files.File](ctx, c, filesTmpl, http.MethodGet, []string{"files", id}, nil, nil)
+	f.Meta = meta
+	return f, err
+}
+
+// Files lists the files. If purpose is set, only files with that purpose are listed.
+func (c *Client) Files(ctx context.Context, purpose files.Purpose) (files.List, error) {
+	var q url.Values
+	if purpose != "" {
+		q = url.Values{"purpose": []string{string(purpose)}}
+	}
+	l, meta, err := resourceDo[files.List](ctx, c, filesTmpl, http.MethodGet, []string{"files"}, q, nil)
+	l.Meta = meta
+	return l, err
+}
+
+// FileDelete deletes the file with id.
+func (c *Client) FileDelete(ctx context.Context, id string) (files.DeleteResp, error) {
+	d, meta, err := resourceDo[files.DeleteResp](ctx, c, filesTmpl, http.MethodDelete, []string{"files", id}, nil, nil)
+	d.Meta = meta
+	return d, err
+}
+
+// FileContent returns the content of the file with id. The content is streamed from the
+// service as it is read. The caller must close it.
+func (c *Client) FileContent(ctx context.Context, id string) (io.ReadCloser, error) {
+	u, err := c.resourceURL(filesTmpl, []string{"files", id, "content"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := c.doHeader(ctx, http.MethodGet, u, nil, nil)
+	c.stats.record(string(filesTmpl), 0, 0, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// doStream sends body to addr once. Unlike doHeader() the request is not retried, as body can
+// only be read once, and the body is not logged.
+func (c *Client) doStream(ctx context.Context, method string, addr *url.URL, body io.Reader, header http.Header) (*http.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	resp, err := c.doOnce(ctx, method, addr, body, header)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// doOnce sends the request of doStream.
+func (c *Client) doOnce(ctx context.Context, method string, addr *url.URL, body io.Reader, header http.Header) (*http.Response, error) {
+	hreq, err := http.NewRequestWithContext(c.conns.trace(ctx), method, "", body)
+	if err != nil {
+		return nil, err
+	}
+	hreq.Host = addr.Host
+	hreq.URL = addr
+
+	if err := c.auth.Authorize(ctx, hreq); err != nil {
+		return nil, err
+	}
+	c.setHeaders(hreq)
+	for k, v := range header {
+		hreq.Header[k] = v
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(hreq)
+	c.logRequest(ctx, hreq, nil, 0, resp, err, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	c.recordQuota(addr, resp, time.Now())
+	if err := c.compress.decode(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, specErr(resp)
+	}
+	return resp, nil
+}
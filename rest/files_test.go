@@ -0,0 +1,149 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/files"
+)
+
+func TestFileUpload(t *testing.T) {
+	const content = `{"messages": [{"role": "user", "content": "hi"}]}` + "\n"
+
+	var gotPurpose, gotName, gotContent string
+	var gotLength int64
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		gotLength = req.ContentLength
+		_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			return nil, err
+		}
+		r := multipart.NewReader(req.Body, params["boundary"])
+		for {
+			p, err := r.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			b, err := io.ReadAll(p)
+			if err != nil {
+				return nil, err
+			}
+			switch p.FormName() {
+			case "purpose":
+				gotPurpose = string(b)
+			case "file":
+				gotName = p.FileName()
+				gotContent = string(b)
+			}
+		}
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"id": "file-1", "bytes": 50, "filename": "train.jsonl", "purpose": "fine-tune", "status": "pending"}`)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A reader without a length shows the file is streamed, not buffered.
+	f, err := c.FileUpload(context.Background(), files.UploadReq{
+		File:     io.MultiReader(strings.NewReader(content)),
+		FileName: "train.jsonl",
+		Purpose:  files.FineTune,
+	})
+	if err != nil {
+		t.Fatalf("TestFileUpload: got err == %s, want err == nil", err)
+	}
+	if gotLength > 0 {
+		t.Errorf("TestFileUpload: got ContentLength %d, want the body to be streamed", gotLength)
+	}
+	if gotPurpose != "fine-tune" || gotName != "train.jsonl" || gotContent != content {
+		t.Errorf("TestFileUpload: got purpose %q, name %q, content %q, want fine-tune, train.jsonl, %q", gotPurpose, gotName, gotContent, content)
+	}
+	if f.ID != "file-1" || f.Status != files.Pending {
+		t.Errorf("TestFileUpload: got %+v, want a pending file-1", f)
+	}
+
+	if _, err := c.FileUpload(context.Background(), files.UploadReq{File: strings.NewReader(content), FileName: "train.jsonl"}); err == nil {
+		t.Errorf("TestFileUpload(no purpose): got err == nil, want err != nil")
+	}
+}
+
+func TestFileUploadError(t *testing.T) {
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"error": {"code": "invalidPayload"}}`)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The service replies without reading the body, so the form writer must not block.
+	_, err = c.FileUpload(context.Background(), files.UploadReq{
+		File:     strings.NewReader(strings.Repeat("x", 1<<20)),
+		FileName: "train.jsonl",
+		Purpose:  files.FineTune,
+	})
+	if err == nil {
+		t.Fatalf("TestFileUploadError: got err == nil, want err != nil")
+	}
+}
+
+func TestFileContent(t *testing.T) {
+	const content = "line 1\nline 2\n"
+
+	var gotPath string
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		gotPath = req.URL.Path
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/octet-stream"}},
+			Body:       io.NopCloser(strings.NewReader(content)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := c.FileContent(context.Background(), "file-1")
+	if err != nil {
+		t.Fatalf("TestFileContent: got err == %s, want err == nil", err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("TestFileContent(read): got err == %s, want err == nil", err)
+	}
+	if string(b) != content {
+		t.Errorf("TestFileContent: got %q, want %q", b, content)
+	}
+	if want := "/openai/files/file-1/content"; gotPath != want {
+		t.Errorf("TestFileContent: got path %q, want %q", gotPath, want)
+	}
+
+	if _, err := c.FileContent(context.Background(), ""); err == nil {
+		t.Errorf("TestFileContent(no id): got err == nil, want err != nil")
+	}
+}
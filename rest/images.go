@@ -0,0 +1,125 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+	"github.com/element-of-surprise/azopenai/rest/messages/images"
+)
+
+// imagesPollInterval is the interval between polls of an image operation if the service
+// does not send a Retry-After header.
+const imagesPollInterval = 1 * time.Second
+
+// Images sends a request to the Azure OpenAI service to generate images from a prompt. Image
+// generation is a long running operation, this submits the request and polls the operation
+// until it has finished or the context is cancelled. If the operation does not succeed, the
+// returned error will be an *images.Error if the service provided one.
+func (c *Client) Images(ctx context.Context, req images.Req) (images.Resp, error) {
+	op, err := c.ImagesSubmit(ctx, req)
+	if err != nil {
+		return images.Resp{}, err
+	}
+
+	op, err = poll(
+		ctx,
+		imagesPollInterval,
+		op,
+		func(op images.Resp) http.Header { return op.Meta.Header },
+		func(ctx context.Context, op images.Resp) (images.Resp, error) {
+			return c.ImagesOperation(ctx, op.ID, op.Meta.Header.Get("operation-location"))
+		},
+		func(op images.Resp) bool { return op.Status.Done() },
+	)
+	if err != nil {
+		return images.Resp{}, err
+	}
+	if op.Status != images.Succeeded {
+		if op.Error != nil {
+			return op, op.Error
+		}
+		return op, fmt.Errorf("image operation %s finished with status %s", op.ID, op.Status)
+	}
+	return op, nil
+}
+
+// ImagesSubmit submits a request to generate images and returns the operation without waiting
+// for it to finish. Use ImagesOperation() to get the status of the operation. Most users should
+// use Images() instead.
+func (c *Client) ImagesSubmit(ctx context.Context, req images.Req) (images.Resp, error) {
+	if err := req.Validate(); err != nil {
+		return images.Resp{}, err
+	}
+
+	u, err := c.endpoints.url(imagesTmpl, "", c.vars)
+	if err != nil {
+		return images.Resp{}, err
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return images.Resp{}, err
+	}
+	// Image generation does not use a deployment, so stats are recorded under "images".
+	resp, meta, err := c.send(ctx, string(imagesTmpl), u, b)
+	if err != nil {
+		return images.Resp{}, err
+	}
+
+	var msg images.Resp
+	if err := json.Unmarshal(resp, &msg); err != nil {
+		return images.Resp{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
+	}
+	msg.Meta = meta
+	return msg, nil
+}
+
+// ImagesOperation returns the current state of an image generation operation. location is the
+// operation-location header returned with the operation, if it is empty the location is built
+// from the operation ID.
+func (c *Client) ImagesOperation(ctx context.Context, id, location string) (images.Resp, error) {
+	submit, err := c.endpoints.url(imagesTmpl, "", c.vars)
+	if err != nil {
+		return images.Resp{}, err
+	}
+
+	var u *url.URL
+	if location != "" {
+		u, err = url.Parse(location)
+		if err != nil {
+			return images.Resp{}, fmt.Errorf("bad operation-location header %q: %w", location, err)
+		}
+		// Our credentials are sent to this address, so it must be the service we submitted to.
+		if u.Host != submit.Host {
+			return images.Resp{}, fmt.Errorf("operation-location host %q does not match %q", u.Host, submit.Host)
+		}
+	} else {
+		if id == "" {
+			return images.Resp{}, fmt.Errorf("must provide the operation id or location")
+		}
+		u = &url.URL{
+			Scheme:   submit.Scheme,
+			Host:     submit.Host,
+			Path:     "/openai/operations/images/" + url.PathEscape(id),
+			RawQuery: submit.RawQuery,
+		}
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return images.Resp{}, err
+	}
+	defer resp.Body.Close()
+
+	var msg images.Resp
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return images.Resp{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
+	}
+	msg.Meta = custom.NewResponseMeta(resp)
+	return msg, nil
+}
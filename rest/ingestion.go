@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+	"github.com/element-of-surprise/azopenai/rest/messages/ingestion"
+)
+
+// ingestionPollInterval is the interval between polls of an ingestion job if the service
+// does not send a Retry-After header. Jobs take minutes, so there is no need to poll often.
+const ingestionPollInterval = 10 * time.Second
+
+// Ingest creates an ingestion job that builds a search index from documents in blob storage,
+// then polls the job until it has finished or the context is cancelled. jobID is chosen by the
+// caller and is also the name of the index that is created. If the job does not succeed, the
+// returned error will be an *ingestion.Error if the service provided one.
+func (c *Client) Ingest(ctx context.Context, jobID string, req ingestion.Req) (ingestion.Resp, error) {
+	job, err := c.IngestionJobCreate(ctx, jobID, req)
+	if err != nil {
+		return ingestion.Resp{}, err
+	}
+
+	job, err = poll(
+		ctx,
+		ingestionPollInterval,
+		job,
+		func(job ingestion.Resp) http.Header { return job.Meta.Header },
+		func(ctx context.Context, _ ingestion.Resp) (ingestion.Resp, error) {
+			return c.IngestionJob(ctx, jobID)
+		},
+		func(job ingestion.Resp) bool { return job.Status.Done() },
+	)
+	if err != nil {
+		return ingestion.Resp{}, err
+	}
+	if job.Status != ingestion.Succeeded {
+		if job.Error != nil {
+			return job, job.Error
+		}
+		return job, fmt.Errorf("ingestion job %s finished with status %s", jobID, job.Status)
+	}
+	return job, nil
+}
+
+// IngestionJobCreate creates an ingestion job and returns it without waiting for it to finish.
+// Use IngestionJob() to get the status of the job. Most users should use Ingest() instead.
+func (c *Client) IngestionJobCreate(ctx context.Context, jobID string, req ingestion.Req) (ingestion.Resp, error) {
+	if err := req.Validate(); err != nil {
+		return ingestion.Resp{}, err
+	}
+	u, err := c.ingestionURL(jobID)
+	if err != nil {
+		return ingestion.Resp{}, err
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return ingestion.Resp{}, err
+	}
+	return c.ingestionDo(ctx, http.MethodPut, u, b, req.Header())
+}
+
+// IngestionJob returns the current state of an ingestion job.
+func (c *Client) IngestionJob(ctx context.Context, jobID string) (ingestion.Resp, error) {
+	u, err := c.ingestionURL(jobID)
+	if err != nil {
+		return ingestion.Resp{}, err
+	}
+	return c.ingestionDo(ctx, http.MethodGet, u, nil, nil)
+}
+
+func (c *Client) ingestionDo(ctx context.Context, method string, u *url.URL, msg []byte, header http.Header) (ingestion.Resp, error) {
+	start := time.Now()
+	resp, err := c.doHeader(ctx, method, u, msg, header)
+	// Ingestion jobs do not use a deployment, so stats are recorded under "ingestion".
+	c.stats.record(string(ingestionTmpl), len(msg), 0, time.Since(start), err)
+	if err != nil {
+		return ingestion.Resp{}, err
+	}
+	defer resp.Body.Close()
+
+	var job ingestion.Resp
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return ingestion.Resp{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
+	}
+	job.Meta = custom.NewResponseMeta(resp)
+	return job, nil
+}
+
+// ingestionURL returns the URL of the job. These are not cached as each job has its own URL.
+func (c *Client) ingestionURL(jobID string) (*url.URL, error) {
+	if jobID == "" {
+		return nil, fmt.Errorf("jobID cannot be empty")
+	}
+	vars := c.vars
+	vars.DeploymentID = url.PathEscape(jobID)
+	return c.endpoints.set(ingestionTmpl, vars)
+}
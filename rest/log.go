@@ -0,0 +1,199 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// maxLogBody is the largest body we will log, larger bodies are truncated.
+const maxLogBody = 64 << 10
+
+// DefaultRedactedHeaders are the headers whose values are replaced with REDACTED in logs and
+// curl commands because they hold credentials. The headers added with WithHeader() and
+// WithRedactHeaders() are redacted too.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Api-Key",
+	"Ocp-Apim-Subscription-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// WithLogger logs every request made by the client to logger. Requests are logged at
+// slog.LevelDebug with the method, URL, attempt, status code and duration. Failed requests
+// are logged at slog.LevelWarn and retries at slog.LevelInfo. Bodies are not logged unless
+// WithLogBodies() is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(client *Client) error {
+		if logger == nil {
+			return fmt.Errorf("WithLogger: logger cannot be nil")
+		}
+		client.logger = logger
+		return nil
+	}
+}
+
+// WithLogBodies logs the headers and bodies of requests and responses at slog.LevelDebug,
+// in addition to what WithLogger() logs. The values of DefaultRedactedHeaders, the headers added
+// with WithHeader() and those passed to WithRedactHeaders() are redacted. Bodies over 64 KiB are
+// truncated and multipart bodies, such as audio files, are not logged. Remember that bodies
+// contain prompts and responses, which may hold sensitive data. This does nothing without
+// WithLogger().
+func WithLogBodies() Option {
+	return func(client *Client) error {
+		client.logBodies = true
+		return nil
+	}
+}
+
+// WithRedactHeaders redacts the values of headers in logs and curl commands, in addition to
+// DefaultRedactedHeaders and the headers added with WithHeader(). Use this for the headers
+// set by a custom auth.Provider.
+func WithRedactHeaders(headers ...string) Option {
+	return func(client *Client) error {
+		client.redactHeaders = append(client.redactHeaders, headers...)
+		return nil
+	}
+}
+
+// logRequest logs a request attempt and its result. It is safe to call if no logger is set.
+// If bodies are logged, resp.Body is wrapped so the response body is logged when it is closed.
+func (c *Client) logRequest(ctx context.Context, hreq *http.Request, msg []byte, attempt int, resp *http.Response, err error, d time.Duration) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", hreq.Method),
+		slog.String("url", hreq.URL.String()),
+		slog.Int("attempt", attempt),
+		slog.Duration("duration", d),
+	}
+	if c.logBodies {
+		attrs = append(attrs, slog.Any("request_headers", redact(hreq.Header, c.redactHeaders)), slog.String("request_body", logBody(hreq.Header, msg)))
+	}
+	if c.logCurl {
+		attrs = append(attrs, slog.String("curl", Curl(hreq, msg, c.redactHeaders...)))
+	}
+
+	switch {
+	case err != nil:
+		attrs = append(attrs, slog.String("error", err.Error()))
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "azopenai request failed", attrs...)
+		return
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		attrs = append(attrs, slog.Int("status", resp.StatusCode), slog.String("request_id", custom.NewResponseMeta(resp).RequestID))
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "azopenai request failed", attrs...)
+	default:
+		attrs = append(attrs, slog.Int("status", resp.StatusCode), slog.String("request_id", custom.NewResponseMeta(resp).RequestID))
+		c.logger.LogAttrs(ctx, slog.LevelDebug, "azopenai request", attrs...)
+	}
+
+	if c.logBodies {
+		resp.Body = &logBodyReader{
+			ReadCloser: resp.Body,
+			log: func(b string) {
+				c.logger.LogAttrs(
+					ctx,
+					slog.LevelDebug,
+					"azopenai response",
+					slog.String("url", hreq.URL.String()),
+					slog.Int("attempt", attempt),
+					slog.Any("response_headers", redact(resp.Header, c.redactHeaders)),
+					slog.String("response_body", b),
+				)
+			},
+		}
+	}
+}
+
+// logRetry logs that a request will be retried after wait.
+func (c *Client) logRetry(ctx context.Context, hreq *http.Request, attempt int, status int, wait time.Duration) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(
+		ctx,
+		slog.LevelInfo,
+		"azopenai retrying request",
+		slog.String("method", hreq.Method),
+		slog.String("url", hreq.URL.String()),
+		slog.Int("attempt", attempt),
+		slog.Int("status", status),
+		slog.Duration("wait", wait),
+	)
+}
+
+// redact returns a copy of h with the values of DefaultRedactedHeaders and extra replaced.
+func redact(h http.Header, extra []string) http.Header {
+	n := h.Clone()
+	for _, headers := range [][]string{DefaultRedactedHeaders, extra} {
+		for _, k := range headers {
+			k = http.CanonicalHeaderKey(k)
+			if _, ok := n[k]; ok {
+				n[k] = []string{"REDACTED"}
+			}
+		}
+	}
+	return n
+}
+
+// logBody returns body as a string for logging.
+func logBody(h http.Header, body []byte) string {
+	if strings.HasPrefix(h.Get("Content-Type"), "multipart/") {
+		return fmt.Sprintf("<multipart body of %d bytes>", len(body))
+	}
+	if len(body) > maxLogBody {
+		return string(body[:maxLogBody]) + "...<truncated>"
+	}
+	return string(body)
+}
+
+// logBodyReader records up to maxLogBody bytes that are read and logs them on Close.
+type logBodyReader struct {
+	io.ReadCloser
+	log func(body string)
+
+	buf       bytes.Buffer
+	truncated bool
+	once      sync.Once
+}
+
+// Read implements io.Reader.
+func (l *logBodyReader) Read(p []byte) (int, error) {
+	n, err := l.ReadCloser.Read(p)
+	if room := maxLogBody - l.buf.Len(); room > 0 {
+		if n > room {
+			l.buf.Write(p[:room])
+			l.truncated = true
+		} else {
+			l.buf.Write(p[:n])
+		}
+	} else if n > 0 {
+		l.truncated = true
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (l *logBodyReader) Close() error {
+	err := l.ReadCloser.Close()
+	l.once.Do(func() {
+		s := l.buf.String()
+		if l.truncated {
+			s += "...<truncated>"
+		}
+		l.log(s)
+	})
+	return err
+}
@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		desc         string
+		logBodies    bool
+		logCurl      bool
+		options      []Option
+		want         []string
+		wantNotFound []string
+	}{
+		{
+			desc: "without bodies",
+			want: []string{
+				`"msg":"azopenai retrying request"`,
+				`"msg":"azopenai request failed"`,
+				`"msg":"azopenai request"`,
+				`"status":429`,
+				`"status":200`,
+				`"url":"https://test.openai.azure.com/openai/deployments/deployment/embeddings`,
+			},
+			wantNotFound: []string{"secret-key", `"request_body"`, `"response_body"`, `"curl"`},
+		},
+		{
+			desc:      "with bodies",
+			logBodies: true,
+			want: []string{
+				`"request_body":"{\"input\":[\"hello\"]`,
+				`"response_body":"{\"data\"`,
+				`"Api-Key":["REDACTED"]`,
+			},
+			wantNotFound: []string{"secret-key"},
+		},
+		{
+			desc:    "with curl",
+			logCurl: true,
+			want: []string{
+				`"curl":"curl -X POST 'https://test.openai.azure.com/openai/deployments/deployment/embeddings`,
+				`-H 'Api-Key: REDACTED'`,
+				`--data-raw '{\"input\":[\"hello\"]`,
+			},
+			wantNotFound: []string{"secret-key", `"request_body"`},
+		},
+		{
+			desc:      "custom headers",
+			logBodies: true,
+			logCurl:   true,
+			options: []Option{
+				WithHeader("X-Gateway-Key", "gateway-secret"),
+				WithOrganization("my-org"),
+				WithRedactHeaders("x-provider-token"),
+			},
+			want: []string{
+				`"X-Gateway-Key":["REDACTED"]`,
+				`"X-Provider-Token":["REDACTED"]`,
+				`-H 'X-Gateway-Key: REDACTED'`,
+				`-H 'X-Provider-Token: REDACTED'`,
+				`"Openai-Organization":["my-org"]`,
+			},
+			wantNotFound: []string{"secret-key", "gateway-secret", "provider-secret"},
+		},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			code, body := http.StatusOK, `{"data": [{"object": "embedding", "embedding": [0.1], "index": 0}]}`
+			if calls == 1 {
+				code, body = http.StatusTooManyRequests, `{"error": {"code": "429"}}`
+			}
+			return &http.Response{
+				StatusCode: code,
+				Header:     http.Header{"Retry-After-Ms": []string{"1"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		})
+
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		opts := []Option{
+			WithClient(&http.Client{Transport: rt}),
+			WithRetryPolicy(RetryPolicy{MaxRetries: 1}),
+			WithLogger(logger),
+		}
+		if test.logBodies {
+			opts = append(opts, WithLogBodies())
+		}
+		if test.logCurl {
+			opts = append(opts, WithLogCurl())
+		}
+		opts = append(opts, test.options...)
+		// A custom auth.Provider, which sets a header that is only redacted with WithRedactHeaders().
+		provider := auth.ProviderFunc(func(ctx context.Context, req *http.Request) error {
+			req.Header.Set("Api-Key", "secret-key")
+			req.Header.Set("X-Provider-Token", "provider-secret")
+			return nil
+		})
+		c, err := New("test", provider, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := c.Embeddings(context.Background(), "deployment", embeddings.Req{Input: []string{"hello"}}); err != nil {
+			t.Fatalf("TestLogger(%s): got err == %s, want err == nil", test.desc, err)
+		}
+
+		got := buf.String()
+		for _, w := range test.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("TestLogger(%s): log does not contain %s:\n%s", test.desc, w, got)
+			}
+		}
+		for _, w := range test.wantNotFound {
+			if strings.Contains(got, w) {
+				t.Errorf("TestLogger(%s): log contains %s:\n%s", test.desc, w, got)
+			}
+		}
+	}
+}
+
+func TestCurl(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://test.openai.azure.com/openai/deployments/d/chat/completions?api-version=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Api-Key", "secret-key")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", "20")
+
+	got := Curl(req, []byte(`{"content":"it's"}`))
+	want := "curl -X POST 'https://test.openai.azure.com/openai/deployments/d/chat/completions?api-version=1' \\\n" +
+		"  -H 'Api-Key: REDACTED' \\\n" +
+		"  -H 'Content-Type: application/json' \\\n" +
+		`  --data-raw '{"content":"it'\''s"}'`
+	if got != want {
+		t.Errorf("TestCurl: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	req.Header.Set("X-Gateway-Key", "gateway-secret")
+	if got := Curl(req, nil, "x-gateway-key"); !strings.Contains(got, "-H 'X-Gateway-Key: REDACTED'") {
+		t.Errorf("TestCurl(redactHeaders): got:\n%s\nwant X-Gateway-Key redacted", got)
+	}
+}
@@ -0,0 +1,551 @@
+// Package assistants contains the request and response types for the Assistants API, which
+// covers assistants, threads, messages, runs and run steps.
+package assistants
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// ToolType is the type of a Tool.
+type ToolType string
+
+const (
+	// UnknownTool indicates the type was not set.
+	UnknownTool ToolType = ""
+	// CodeInterpreter lets the assistant write and run code.
+	CodeInterpreter ToolType = "code_interpreter"
+	// FileSearch lets the assistant search files attached to it or the thread.
+	FileSearch ToolType = "file_search"
+	// Function lets the assistant call a function you run, see Run.RequiredAction.
+	Function ToolType = "function"
+)
+
+// Tool is a tool an assistant can use.
+type Tool struct {
+	// Type is the type of the tool. This is required.
+	Type ToolType `json:"type"`
+	// Function describes the function. This is required if Type is Function.
+	Function *FunctionDef `json:"function,omitempty"`
+}
+
+// FunctionDef describes a function the assistant can call.
+type FunctionDef struct {
+	// Name is the name of the function. This is required.
+	Name string `json:"name"`
+	// Description describes what the function does, which the model uses to decide when to call it.
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the function's parameters.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// Validate validates the Tool.
+func (t Tool) Validate() error {
+	switch t.Type {
+	case CodeInterpreter, FileSearch:
+		return nil
+	case Function:
+		if t.Function == nil || t.Function.Name == "" {
+			return errors.New("a Function tool requires Function.Name")
+		}
+		return nil
+	}
+	return fmt.Errorf("Tool.Type %q is not supported", t.Type)
+}
+
+func validateTools(tools []Tool) error {
+	if len(tools) > 128 {
+		return errors.New("cannot have more than 128 tools")
+	}
+	for i, t := range tools {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("tool %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateMetadata(m map[string]string) error {
+	if len(m) > 16 {
+		return errors.New("Metadata cannot have more than 16 entries")
+	}
+	return nil
+}
+
+// AssistantReq creates or updates an assistant.
+type AssistantReq struct {
+	// Model is the deployment the assistant uses. This is required to create an assistant.
+	Model string `json:"model,omitempty"`
+	// Name is the name of the assistant, up to 256 characters.
+	Name string `json:"name,omitempty"`
+	// Description describes the assistant, up to 512 characters.
+	Description string `json:"description,omitempty"`
+	// Instructions are the system instructions of the assistant.
+	Instructions string `json:"instructions,omitempty"`
+	// Tools are the tools the assistant can use, up to 128.
+	Tools []Tool `json:"tools,omitempty"`
+	// Metadata is up to 16 key-value pairs attached to the assistant.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate validates the AssistantReq.
+func (r AssistantReq) Validate() error {
+	if len(r.Name) > 256 {
+		return errors.New("Name cannot be longer than 256 characters")
+	}
+	if len(r.Description) > 512 {
+		return errors.New("Description cannot be longer than 512 characters")
+	}
+	if err := validateTools(r.Tools); err != nil {
+		return err
+	}
+	return validateMetadata(r.Metadata)
+}
+
+// Assistant is an assistant.
+type Assistant struct {
+	// ID is the ID of the assistant.
+	ID string `json:"id"`
+	// CreatedAt is when the assistant was created.
+	CreatedAt custom.UnixTime `json:"created_at"`
+	// Model is the deployment the assistant uses.
+	Model string `json:"model"`
+	// Name is the name of the assistant.
+	Name string `json:"name,omitempty"`
+	// Description describes the assistant.
+	Description string `json:"description,omitempty"`
+	// Instructions are the system instructions of the assistant.
+	Instructions string `json:"instructions,omitempty"`
+	// Tools are the tools the assistant can use.
+	Tools []Tool `json:"tools,omitempty"`
+	// Metadata is the metadata attached to the assistant.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Role is the role of the author of a Message.
+type Role string
+
+const (
+	// UnknownRole indicates the role was not set.
+	UnknownRole Role = ""
+	// UserRole is a message from the user.
+	UserRole Role = "user"
+	// AssistantRole is a message from the assistant.
+	AssistantRole Role = "assistant"
+)
+
+// MessageReq adds a message to a thread.
+type MessageReq struct {
+	// Role is the role of the author. This is required.
+	Role Role `json:"role"`
+	// Content is the text of the message. This is required.
+	Content string `json:"content"`
+	// Metadata is up to 16 key-value pairs attached to the message.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate validates the MessageReq.
+func (r MessageReq) Validate() error {
+	switch r.Role {
+	case UserRole, AssistantRole:
+	default:
+		return fmt.Errorf("Role %q is not supported", r.Role)
+	}
+	if r.Content == "" {
+		return errors.New("Content is required")
+	}
+	return validateMetadata(r.Metadata)
+}
+
+// ContentType is the type of a part of a Message.
+type ContentType string
+
+const (
+	// TextContent is text.
+	TextContent ContentType = "text"
+	// ImageFileContent is an image file, such as a chart made by the code interpreter.
+	ImageFileContent ContentType = "image_file"
+)
+
+// Content is a part of a Message.
+type Content struct {
+	// Type is the type of the part.
+	Type ContentType `json:"type"`
+	// Text is the text. This is set if Type is TextContent.
+	Text *Text `json:"text,omitempty"`
+	// ImageFile is the image. This is set if Type is ImageFileContent.
+	ImageFile *ImageFile `json:"image_file,omitempty"`
+}
+
+// Text is the text of a Content.
+type Text struct {
+	// Value is the text.
+	Value string `json:"value"`
+	// Annotations are the citations and file paths in Value, in the service's format.
+	Annotations json.RawMessage `json:"annotations,omitempty"`
+}
+
+// ImageFile is an image of a Content.
+type ImageFile struct {
+	// FileID is the ID of the file holding the image.
+	FileID string `json:"file_id"`
+}
+
+// Message is a message in a thread.
+type Message struct {
+	// ID is the ID of the message.
+	ID string `json:"id"`
+	// CreatedAt is when the message was created.
+	CreatedAt custom.UnixTime `json:"created_at"`
+	// ThreadID is the ID of the thread the message is in.
+	ThreadID string `json:"thread_id"`
+	// Role is the role of the author.
+	Role Role `json:"role"`
+	// Content are the parts of the message.
+	Content []Content `json:"content"`
+	// AssistantID is the ID of the assistant that wrote the message, if it was written by a run.
+	AssistantID string `json:"assistant_id,omitempty"`
+	// RunID is the ID of the run that wrote the message, if any.
+	RunID string `json:"run_id,omitempty"`
+	// Metadata is the metadata attached to the message.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Text returns the text parts of the message joined with newlines.
+func (m Message) Text() string {
+	var parts []string
+	for _, c := range m.Content {
+		if c.Type == TextContent && c.Text != nil {
+			parts = append(parts, c.Text.Value)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ThreadReq creates a thread.
+type ThreadReq struct {
+	// Messages are messages to start the thread with.
+	Messages []MessageReq `json:"messages,omitempty"`
+	// Metadata is up to 16 key-value pairs attached to the thread.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate validates the ThreadReq.
+func (r ThreadReq) Validate() error {
+	for i, m := range r.Messages {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+	}
+	return validateMetadata(r.Metadata)
+}
+
+// Thread is a conversation between a user and assistants.
+type Thread struct {
+	// ID is the ID of the thread.
+	ID string `json:"id"`
+	// CreatedAt is when the thread was created.
+	CreatedAt custom.UnixTime `json:"created_at"`
+	// Metadata is the metadata attached to the thread.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// RunReq starts a run of an assistant on a thread.
+type RunReq struct {
+	// AssistantID is the ID of the assistant to run. This is required.
+	AssistantID string `json:"assistant_id"`
+	// Model overrides the deployment of the assistant.
+	Model string `json:"model,omitempty"`
+	// Instructions overrides the instructions of the assistant.
+	Instructions string `json:"instructions,omitempty"`
+	// AdditionalInstructions are added to the instructions of the assistant for this run.
+	AdditionalInstructions string `json:"additional_instructions,omitempty"`
+	// Tools overrides the tools of the assistant.
+	Tools []Tool `json:"tools,omitempty"`
+	// Metadata is up to 16 key-value pairs attached to the run.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate validates the RunReq.
+func (r RunReq) Validate() error {
+	if r.AssistantID == "" {
+		return errors.New("AssistantID is required")
+	}
+	if err := validateTools(r.Tools); err != nil {
+		return err
+	}
+	return validateMetadata(r.Metadata)
+}
+
+// RunStatus is the status of a Run.
+type RunStatus string
+
+const (
+	// Queued indicates the run has not started.
+	Queued RunStatus = "queued"
+	// InProgress indicates the run is running.
+	InProgress RunStatus = "in_progress"
+	// RequiresAction indicates the run is waiting for the outputs of the tool calls in
+	// Run.RequiredAction.
+	RequiresAction RunStatus = "requires_action"
+	// Cancelling indicates the run is being cancelled.
+	Cancelling RunStatus = "cancelling"
+	// Cancelled indicates the run was cancelled.
+	Cancelled RunStatus = "cancelled"
+	// Failed indicates the run failed, see Run.LastError.
+	Failed RunStatus = "failed"
+	// Completed indicates the run finished.
+	Completed RunStatus = "completed"
+	// Incomplete indicates the run ended early, such as when it reached a token limit.
+	Incomplete RunStatus = "incomplete"
+	// Expired indicates the run was not finished in time, such as when tool outputs were not
+	// submitted before Run.ExpiresAt.
+	Expired RunStatus = "expired"
+)
+
+// Done returns true if the run has ended and will not change.
+func (s RunStatus) Done() bool {
+	switch s {
+	case Cancelled, Failed, Completed, Incomplete, Expired:
+		return true
+	}
+	return false
+}
+
+// Waiting returns true if the run has ended or is waiting for tool outputs.
+func (s RunStatus) Waiting() bool {
+	return s == RequiresAction || s.Done()
+}
+
+// Run is a run of an assistant on a thread.
+type Run struct {
+	// ID is the ID of the run.
+	ID string `json:"id"`
+	// CreatedAt is when the run was created.
+	CreatedAt custom.UnixTime `json:"created_at"`
+	// ThreadID is the ID of the thread the run is on.
+	ThreadID string `json:"thread_id"`
+	// AssistantID is the ID of the assistant that is run.
+	AssistantID string `json:"assistant_id"`
+	// Status is the status of the run.
+	Status RunStatus `json:"status"`
+	// RequiredAction holds the tool calls to run when Status is RequiresAction.
+	RequiredAction *RequiredAction `json:"required_action,omitempty"`
+	// LastError is the error of the run when Status is Failed.
+	LastError *RunError `json:"last_error,omitempty"`
+	// ExpiresAt is when the run expires if it has not ended.
+	ExpiresAt custom.UnixTime `json:"expires_at"`
+	// Model is the deployment used by the run.
+	Model string `json:"model"`
+	// Instructions are the instructions used by the run.
+	Instructions string `json:"instructions,omitempty"`
+	// Tools are the tools used by the run.
+	Tools []Tool `json:"tools,omitempty"`
+	// Usage is the number of tokens used. This is only set after the run has ended.
+	Usage *Usage `json:"usage,omitempty"`
+	// Metadata is the metadata attached to the run.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// RequiredAction is the action a run needs to continue.
+type RequiredAction struct {
+	// Type is always "submit_tool_outputs".
+	Type string `json:"type"`
+	// SubmitToolOutputs holds the tool calls whose outputs must be submitted.
+	SubmitToolOutputs SubmitToolOutputs `json:"submit_tool_outputs"`
+}
+
+// SubmitToolOutputs holds the tool calls a run is waiting on.
+type SubmitToolOutputs struct {
+	// ToolCalls are the function calls to run.
+	ToolCalls []ToolCall `json:"tool_calls"`
+}
+
+// ToolCall is a call to a function.
+type ToolCall struct {
+	// ID is the ID of the call, which is set in ToolOutput.ToolCallID.
+	ID string `json:"id"`
+	// Type is always "function".
+	Type string `json:"type"`
+	// Function is the function to call.
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall is the function and arguments of a ToolCall.
+type FunctionCall struct {
+	// Name is the name of the function.
+	Name string `json:"name"`
+	// Arguments are the arguments as a JSON object. The model can generate invalid JSON or
+	// arguments that are not in the schema, so validate them.
+	Arguments string `json:"arguments"`
+}
+
+// ToolOutput is the output of a ToolCall.
+type ToolOutput struct {
+	// ToolCallID is the ToolCall.ID of the call.
+	ToolCallID string `json:"tool_call_id"`
+	// Output is the output of the call.
+	Output string `json:"output"`
+}
+
+// ToolOutputsReq submits the outputs of tool calls to a run.
+type ToolOutputsReq struct {
+	// ToolOutputs are the outputs of each ToolCall.
+	ToolOutputs []ToolOutput `json:"tool_outputs"`
+}
+
+// Validate validates the ToolOutputsReq.
+func (r ToolOutputsReq) Validate() error {
+	if len(r.ToolOutputs) == 0 {
+		return errors.New("ToolOutputs are required")
+	}
+	for i, o := range r.ToolOutputs {
+		if o.ToolCallID == "" {
+			return fmt.Errorf("tool output %d: ToolCallID is required", i)
+		}
+	}
+	return nil
+}
+
+// RunError is the error of a failed run.
+type RunError struct {
+	// Code is the error code, such as "rate_limit_exceeded".
+	Code string `json:"code"`
+	// Message is the error message.
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *RunError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Usage is the number of tokens used by a run or run step.
+type Usage struct {
+	// PromptTokens is the number of tokens in the prompts.
+	PromptTokens int `json:"prompt_tokens"`
+	// CompletionTokens is the number of tokens generated.
+	CompletionTokens int `json:"completion_tokens"`
+	// TotalTokens is the total number of tokens used.
+	TotalTokens int `json:"total_tokens"`
+}
+
+// RunStep is a step of a run, such as creating a message or calling tools.
+type RunStep struct {
+	// ID is the ID of the step.
+	ID string `json:"id"`
+	// CreatedAt is when the step was created.
+	CreatedAt custom.UnixTime `json:"created_at"`
+	// RunID is the ID of the run.
+	RunID string `json:"run_id"`
+	// Type is the type of the step, "message_creation" or "tool_calls".
+	Type string `json:"type"`
+	// Status is the status of the step. The values are the same as RunStatus, except that steps
+	// can't be Queued or RequiresAction.
+	Status RunStatus `json:"status"`
+	// StepDetails are the details of the step, in the service's format.
+	StepDetails json.RawMessage `json:"step_details,omitempty"`
+	// LastError is the error of the step when Status is Failed.
+	LastError *RunError `json:"last_error,omitempty"`
+	// Usage is the number of tokens used by the step.
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Order is the order of a list.
+type Order string
+
+const (
+	// UnknownOrder indicates the order was not set. The service will use Desc.
+	UnknownOrder Order = ""
+	// Asc lists the oldest items first.
+	Asc Order = "asc"
+	// Desc lists the newest items first.
+	Desc Order = "desc"
+)
+
+// ListOptions are options to list items. All fields are optional.
+type ListOptions struct {
+	// Limit is the number of items to return, between 1 and 100. The service default is 20.
+	Limit int
+	// Order is the order of the items by creation time.
+	Order Order
+	// After lists the items after the item with this ID, for paging.
+	After string
+	// Before lists the items before the item with this ID, for paging.
+	Before string
+}
+
+// Validate validates the ListOptions.
+func (o ListOptions) Validate() error {
+	if o.Limit < 0 || o.Limit > 100 {
+		return fmt.Errorf("Limit must be between 1 and 100, was %d", o.Limit)
+	}
+	switch o.Order {
+	case UnknownOrder, Asc, Desc:
+	default:
+		return fmt.Errorf("Order %q is not supported", o.Order)
+	}
+	return nil
+}
+
+// Query returns the options as query parameters.
+func (o ListOptions) Query() url.Values {
+	v := url.Values{}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Order != UnknownOrder {
+		v.Set("order", string(o.Order))
+	}
+	if o.After != "" {
+		v.Set("after", o.After)
+	}
+	if o.Before != "" {
+		v.Set("before", o.Before)
+	}
+	return v
+}
+
+// List is a page of items.
+type List[T any] struct {
+	// Data are the items.
+	Data []T `json:"data"`
+	// FirstID is the ID of the first item.
+	FirstID string `json:"first_id"`
+	// LastID is the ID of the last item. Use it with ListOptions.After to get the next page.
+	LastID string `json:"last_id"`
+	// HasMore indicates there are more items after this page.
+	HasMore bool `json:"has_more"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Deleted is the response to deleting an item.
+type Deleted struct {
+	// ID is the ID of the item.
+	ID string `json:"id"`
+	// Deleted indicates the item was deleted.
+	Deleted bool `json:"deleted"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
@@ -0,0 +1,114 @@
+// Package audio contains the request and response types for the Whisper audio transcription
+// and translation APIs.
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Format is the format the text is returned in.
+type Format string
+
+const (
+	// UnknownFormat indicates the format was not set. The service will use JSON.
+	UnknownFormat Format = ""
+	// JSON returns the text in a JSON object.
+	JSON Format = "json"
+	// Text returns the text as plain text.
+	Text Format = "text"
+	// SRT returns the text as SubRip subtitles.
+	SRT Format = "srt"
+	// VerboseJSON returns the text in a JSON object with the language, duration and segments
+	// with timestamps.
+	VerboseJSON Format = "verbose_json"
+	// VTT returns the text as WebVTT subtitles.
+	VTT Format = "vtt"
+)
+
+// IsJSON returns true if the response for the format is JSON.
+func (f Format) IsJSON() bool {
+	return f == UnknownFormat || f == JSON || f == VerboseJSON
+}
+
+// Req represents a request to the transcription or translation API. It is sent as
+// multipart/form-data, not JSON.
+type Req struct {
+	// File is the audio to transcribe, in one of flac, mp3, mp4, mpeg, mpga, m4a, ogg, wav or webm
+	// format. This is required.
+	File io.Reader
+	// FileName is the name of the file. The service uses the extension to detect the format.
+	// This is required.
+	FileName string
+	// Language is the ISO-639-1 language of the audio, such as "en". Setting this improves accuracy
+	// and latency. This is only used for transcriptions, translations are always to English.
+	Language string
+	// Prompt is optional text to guide the style of the text or continue a previous segment.
+	// It should be in the same language as the audio.
+	Prompt string
+	// ResponseFormat is the format of the text. Defaults to JSON.
+	ResponseFormat Format
+	// Temperature is the sampling temperature between 0 and 1. 0 lets the service raise the
+	// temperature until certain thresholds are hit.
+	Temperature float64
+}
+
+// Validate validates the Req.
+func (r Req) Validate() error {
+	switch {
+	case r.File == nil:
+		return errors.New("File is required")
+	case r.FileName == "":
+		return errors.New("FileName is required")
+	case r.Temperature < 0 || r.Temperature > 1:
+		return errors.New("Temperature must be between 0 and 1")
+	}
+	switch r.ResponseFormat {
+	case UnknownFormat, JSON, Text, SRT, VerboseJSON, VTT:
+	default:
+		return fmt.Errorf("ResponseFormat %q is not supported", r.ResponseFormat)
+	}
+	return nil
+}
+
+// Resp is the response from the transcription or translation API.
+type Resp struct {
+	// Text is the text of the audio. For the Text, SRT and VTT formats this is the entire response.
+	Text string `json:"text"`
+	// Language is the language of the audio. Only set for VerboseJSON.
+	Language string `json:"language,omitempty"`
+	// Duration is the duration of the audio in seconds. Only set for VerboseJSON.
+	Duration float64 `json:"duration,omitempty"`
+	// Segments are the segments of the text with timestamps. Only set for VerboseJSON.
+	Segments []Segment `json:"segments,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Segment is a segment of the text with timestamps.
+type Segment struct {
+	// ID is the index of the segment.
+	ID int `json:"id"`
+	// Seek is the seek offset of the segment.
+	Seek int `json:"seek"`
+	// Start is the start of the segment in seconds.
+	Start float64 `json:"start"`
+	// End is the end of the segment in seconds.
+	End float64 `json:"end"`
+	// Text is the text of the segment.
+	Text string `json:"text"`
+	// Tokens are the token IDs of the text.
+	Tokens []int `json:"tokens,omitempty"`
+	// Temperature is the temperature used to generate the segment.
+	Temperature float64 `json:"temperature"`
+	// AvgLogprob is the average log probability of the segment. Below -1 the segment may be wrong.
+	AvgLogprob float64 `json:"avg_logprob"`
+	// CompressionRatio is the compression ratio of the segment. Above 2.4 the segment may be repetitive.
+	CompressionRatio float64 `json:"compression_ratio"`
+	// NoSpeechProb is the probability there is no speech in the segment.
+	NoSpeechProb float64 `json:"no_speech_prob"`
+}
@@ -0,0 +1,244 @@
+// Package batch contains the request and response types for the Batch API, which runs a JSONL
+// file of requests asynchronously at a lower cost.
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Endpoint is the API the requests of a batch are sent to. All the requests of a batch must
+// use the same Endpoint.
+type Endpoint string
+
+const (
+	// ChatEndpoint sends the requests to the chat API.
+	ChatEndpoint Endpoint = "/chat/completions"
+	// EmbeddingsEndpoint sends the requests to the embeddings API.
+	EmbeddingsEndpoint Endpoint = "/embeddings"
+)
+
+// Status is the status of a Batch.
+type Status string
+
+const (
+	// Validating indicates the input file is being validated.
+	Validating Status = "validating"
+	// Failed indicates the input file failed validation, see Batch.Errors.
+	Failed Status = "failed"
+	// InProgress indicates the requests are running.
+	InProgress Status = "in_progress"
+	// Finalizing indicates the requests are done and the output files are being written.
+	Finalizing Status = "finalizing"
+	// Completed indicates the batch finished and its output files are ready.
+	Completed Status = "completed"
+	// Expired indicates the batch was not finished within its completion window. The output
+	// files hold the requests that were finished.
+	Expired Status = "expired"
+	// Cancelling indicates the batch is being cancelled.
+	Cancelling Status = "cancelling"
+	// Cancelled indicates the batch was cancelled.
+	Cancelled Status = "cancelled"
+)
+
+// Done returns true if the batch has ended.
+func (s Status) Done() bool {
+	switch s {
+	case Failed, Completed, Expired, Cancelled:
+		return true
+	}
+	return false
+}
+
+// CreateReq creates a batch.
+type CreateReq struct {
+	// InputFileID is the ID of the JSONL file of requests, uploaded with the batch purpose.
+	// This is required.
+	InputFileID string `json:"input_file_id"`
+	// Endpoint is the API the requests are sent to. This is required.
+	Endpoint Endpoint `json:"endpoint"`
+	// CompletionWindow is the time the batch must finish in. Defaults to "24h", which is the
+	// only value the service supports.
+	CompletionWindow string `json:"completion_window"`
+	// Metadata is up to 16 key/value pairs stored with the batch.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate validates the CreateReq.
+func (r CreateReq) Validate() error {
+	switch {
+	case r.InputFileID == "":
+		return errors.New("InputFileID is required")
+	case r.Endpoint == "":
+		return errors.New("Endpoint is required")
+	case len(r.Metadata) > 16:
+		return errors.New("Metadata cannot have more than 16 keys")
+	}
+	return nil
+}
+
+// Batch is a batch job.
+type Batch struct {
+	// ID is the ID of the batch.
+	ID string `json:"id"`
+	// Endpoint is the API the requests are sent to.
+	Endpoint Endpoint `json:"endpoint"`
+	// Status is the status of the batch.
+	Status Status `json:"status"`
+	// Errors are the errors found when validating the input file.
+	Errors *Errors `json:"errors,omitempty"`
+	// InputFileID is the ID of the input file.
+	InputFileID string `json:"input_file_id"`
+	// CompletionWindow is the time the batch must finish in.
+	CompletionWindow string `json:"completion_window"`
+	// OutputFileID is the ID of the file of successful responses.
+	OutputFileID string `json:"output_file_id,omitempty"`
+	// ErrorFileID is the ID of the file of failed requests.
+	ErrorFileID string `json:"error_file_id,omitempty"`
+	// RequestCounts are the number of requests by outcome.
+	RequestCounts RequestCounts `json:"request_counts"`
+	// Metadata is the metadata stored with the batch.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// CreatedAt is when the batch was created.
+	CreatedAt custom.UnixTime `json:"created_at"`
+	// InProgressAt is when the batch started running.
+	InProgressAt custom.UnixTime `json:"in_progress_at"`
+	// ExpiresAt is when the batch expires.
+	ExpiresAt custom.UnixTime `json:"expires_at"`
+	// CompletedAt is when the batch completed.
+	CompletedAt custom.UnixTime `json:"completed_at"`
+	// FailedAt is when the batch failed.
+	FailedAt custom.UnixTime `json:"failed_at"`
+	// CancelledAt is when the batch was cancelled.
+	CancelledAt custom.UnixTime `json:"cancelled_at"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// RequestCounts are the number of requests of a batch by outcome.
+type RequestCounts struct {
+	// Total is the number of requests.
+	Total int `json:"total"`
+	// Completed is the number of requests that succeeded.
+	Completed int `json:"completed"`
+	// Failed is the number of requests that failed.
+	Failed int `json:"failed"`
+}
+
+// Errors are the errors found when validating an input file.
+type Errors struct {
+	// Data are the errors.
+	Data []Error `json:"data"`
+}
+
+// Error is an error in an input file.
+type Error struct {
+	// Code is the error code.
+	Code string `json:"code"`
+	// Message is a description of the error.
+	Message string `json:"message"`
+	// Param is the parameter that caused the error, if any.
+	Param string `json:"param,omitempty"`
+	// Line is the line of the input file that caused the error, if any.
+	Line *int `json:"line,omitempty"`
+}
+
+// Error implements error.
+func (e Error) Error() string {
+	if e.Line != nil {
+		return fmt.Sprintf("line %d: %s: %s", *e.Line, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// ListOptions are options to list batches.
+type ListOptions struct {
+	// Limit is the number of batches to return, between 1 and 100. The service default is 20.
+	Limit int
+	// After lists the batches after the batch with this ID, for paging.
+	After string
+}
+
+// Validate validates the ListOptions.
+func (o ListOptions) Validate() error {
+	if o.Limit < 0 || o.Limit > 100 {
+		return errors.New("Limit must be between 1 and 100")
+	}
+	return nil
+}
+
+// Query returns the ListOptions as query parameters.
+func (o ListOptions) Query() url.Values {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.After != "" {
+		q.Set("after", o.After)
+	}
+	return q
+}
+
+// List is a page of batches.
+type List struct {
+	// Data are the batches.
+	Data []Batch `json:"data"`
+	// FirstID is the ID of the first batch.
+	FirstID string `json:"first_id"`
+	// LastID is the ID of the last batch. Use it with ListOptions.After to get the next page.
+	LastID string `json:"last_id"`
+	// HasMore indicates there are more batches after this page.
+	HasMore bool `json:"has_more"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// InputLine is a line of an input file.
+type InputLine struct {
+	// CustomID identifies the request in the output files. It must be unique in the file.
+	CustomID string `json:"custom_id"`
+	// Method is the HTTP method, always POST.
+	Method string `json:"method"`
+	// URL is the Endpoint of the request.
+	URL Endpoint `json:"url"`
+	// Body is the request body. Its model field must be the deployment to use.
+	Body json.RawMessage `json:"body"`
+}
+
+// OutputLine is a line of an output or error file.
+type OutputLine struct {
+	// ID is the ID of the line.
+	ID string `json:"id"`
+	// CustomID is the CustomID of the InputLine.
+	CustomID string `json:"custom_id"`
+	// Response is the response to the request, if one was received.
+	Response *LineResp `json:"response"`
+	// Error is the error if the request could not be sent.
+	Error *LineError `json:"error"`
+}
+
+// LineResp is the response to a request of a batch.
+type LineResp struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"status_code"`
+	// RequestID is the ID of the request.
+	RequestID string `json:"request_id"`
+	// Body is the response body, which is the same as the response of the Endpoint.
+	Body json.RawMessage `json:"body"`
+}
+
+// LineError is an error for a request of a batch that could not be sent.
+type LineError struct {
+	// Code is the error code.
+	Code string `json:"code"`
+	// Message is a description of the error.
+	Message string `json:"message"`
+}
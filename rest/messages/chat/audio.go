@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Modality is a type of output the model can generate.
+type Modality string
+
+const (
+	// TextModality is text output. This is the default.
+	TextModality Modality = "text"
+	// AudioModality is audio output. This requires TextModality as well.
+	AudioModality Modality = "audio"
+)
+
+// AudioFormat is the encoding of audio.
+type AudioFormat string
+
+const (
+	// WAV is audio in the wav format. This can be used for input and output.
+	WAV AudioFormat = "wav"
+	// MP3 is audio in the mp3 format. This can be used for input and output.
+	MP3 AudioFormat = "mp3"
+	// FLAC is audio in the flac format. This is only used for output.
+	FLAC AudioFormat = "flac"
+	// Opus is audio in the opus format. This is only used for output.
+	Opus AudioFormat = "opus"
+	// PCM16 is raw 16-bit PCM audio at 24kHz. This is only used for output and is the
+	// only format supported when streaming.
+	PCM16 AudioFormat = "pcm16"
+)
+
+// Voice is the voice the model uses for audio output, such as "alloy".
+type Voice string
+
+// AudioParams are the parameters for audio output. See Req.Audio.
+type AudioParams struct {
+	// Voice is the voice to use.
+	Voice Voice `json:"voice"`
+	// Format is the format of the audio.
+	Format AudioFormat `json:"format"`
+}
+
+// InputAudio is audio in a ContentPart.
+type InputAudio struct {
+	// Data is the base64 encoded audio.
+	Data string `json:"data"`
+	// Format is the format of the audio, WAV or MP3.
+	Format AudioFormat `json:"format"`
+}
+
+// AudioOutput is audio generated by the model.
+type AudioOutput struct {
+	// ID is the ID of the audio, which can be used to refer to it in later messages.
+	ID string `json:"id,omitempty"`
+	// Data is the base64 encoded audio. When streaming, each delta holds separately encoded data.
+	Data string `json:"data,omitempty"`
+	// ExpiresAt is when the audio can no longer be referred to by ID.
+	ExpiresAt *custom.UnixTime `json:"expires_at,omitempty"`
+	// Transcript is the transcript of the audio.
+	Transcript string `json:"transcript,omitempty"`
+}
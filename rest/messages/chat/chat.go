@@ -2,6 +2,9 @@
 package chat
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/element-of-surprise/azopenai/rest/messages/custom"
 )
 
@@ -34,6 +37,15 @@ type Req struct {
 	// Most models have a context length of 2048 tokens (except for the newest models, which support 4096). Has minimum of 0.
 	MaxTokens int `json:"max_tokens,omitempty"`
 
+	// MaxCompletionTokens is the maximum number of tokens to generate, including reasoning tokens.
+	// Reasoning models, such as the o-series, require this in place of MaxTokens.
+	// Only one of MaxTokens and MaxCompletionTokens can be set.
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+
+	// ReasoningEffort limits the reasoning of reasoning models, such as o1 and o3-mini. Lower effort
+	// gives faster responses that use fewer reasoning tokens. Other models reject this.
+	ReasoningEffort ReasoningEffort `json:"reasoning_effort,omitempty"`
+
 	// Temperature is the sampling temperature to use. Higher values means the model will take more risks.
 	// Try 0.9 for more creative applications, and 0 (argmax sampling) for ones with a well-defined answer.
 	// It is generally recommend altering this or TopP but not both.
@@ -53,9 +65,32 @@ type Req struct {
 	// existing frequency in the text so far, decreasing the model's likelihood to repeat the same line verbatim.
 	FrequencyPenalty float64 `json:"frequency_penalty,omitempty"`
 
+	// Store indicates the completion should be stored by the service for use in evals and distillation.
+	Store bool `json:"store,omitempty"`
+
+	// Metadata are developer defined tags for a stored completion, used to filter stored completions.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
 	// Stream indicates whether to stream back partial progress. If set, tokens will be sent as data-only server-sent
 	// events as they become available, with the stream terminated by a data: [DONE] message.
 	Stream bool `json:"stream,omitempty"`
+
+	// Modalities are the types of output the model generates. If not set, only text is generated.
+	Modalities []Modality `json:"modalities,omitempty"`
+
+	// Audio are the parameters for audio output. This is required if Modalities has AudioModality.
+	Audio *AudioParams `json:"audio,omitempty"`
+
+	// Prediction is content the response is expected to mostly match, such as a document being edited.
+	// This reduces latency when much of the response is known ahead of time.
+	Prediction *Prediction `json:"prediction,omitempty"`
+
+	// ResponseFormat sets the format of the output, such as JSON mode. If not set, the output is text.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	// DataSources are data sources used to ground responses with Azure OpenAI on your data.
+	// If set, the request is sent to the extensions endpoint.
+	DataSources []DataSource `json:"dataSources,omitempty"`
 }
 
 // Defaults sets the default values for the request. You must do this before settings
@@ -68,6 +103,78 @@ func (c Req) Defaults() Req {
 	return c
 }
 
+// Validate returns an error if the Req has values the service would reject.
+func (r Req) Validate() error {
+	if len(r.Messages) == 0 {
+		return errors.New("Messages cannot be empty")
+	}
+	for i, m := range r.Messages {
+		if m.Role == UnknownRole {
+			return fmt.Errorf("message %d: Role is required", i)
+		}
+	}
+	if r.N < 0 || r.N > 128 {
+		return fmt.Errorf("N must be between 1 and 128, was %d", r.N)
+	}
+	if r.MaxTokens < 0 {
+		return errors.New("MaxTokens cannot be < 0")
+	}
+	if r.MaxCompletionTokens < 0 {
+		return errors.New("MaxCompletionTokens cannot be < 0")
+	}
+	if r.MaxTokens > 0 && r.MaxCompletionTokens > 0 {
+		return errors.New("cannot set both MaxTokens and MaxCompletionTokens")
+	}
+	switch r.ReasoningEffort {
+	case UnknownEffort, LowEffort, MediumEffort, HighEffort:
+	default:
+		return fmt.Errorf("ReasoningEffort %q is not valid", r.ReasoningEffort)
+	}
+	if r.Temperature < 0 || r.Temperature > 2 {
+		return fmt.Errorf("Temperature must be between 0 and 2, was %v", r.Temperature)
+	}
+	if r.TopP < 0 || r.TopP > 1 {
+		return fmt.Errorf("TopP must be between 0 and 1, was %v", r.TopP)
+	}
+	if r.PresencePenalty < -2 || r.PresencePenalty > 2 {
+		return fmt.Errorf("PresencePenalty must be between -2 and 2, was %v", r.PresencePenalty)
+	}
+	if r.FrequencyPenalty < -2 || r.FrequencyPenalty > 2 {
+		return fmt.Errorf("FrequencyPenalty must be between -2 and 2, was %v", r.FrequencyPenalty)
+	}
+	if len(r.Stop) > 4 {
+		return errors.New("Stop cannot have more than 4 entries")
+	}
+	for k, v := range r.LogitBias {
+		if v < -100 || v > 100 {
+			return fmt.Errorf("LogitBias[%s] must be between -100 and 100, was %v", k, v)
+		}
+	}
+	if len(r.Metadata) > 16 {
+		return errors.New("Metadata cannot have more than 16 entries")
+	}
+	if r.ResponseFormat != nil {
+		if err := r.ResponseFormat.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReasoningEffort is how much a reasoning model reasons before responding.
+type ReasoningEffort string
+
+const (
+	// UnknownEffort is the default value for ReasoningEffort, the service default is used.
+	UnknownEffort ReasoningEffort = ""
+	// LowEffort favors speed and fewer reasoning tokens.
+	LowEffort ReasoningEffort = "low"
+	// MediumEffort balances speed and reasoning. This is the service default.
+	MediumEffort ReasoningEffort = "medium"
+	// HighEffort favors more complete reasoning.
+	HighEffort ReasoningEffort = "high"
+)
+
 // Role is a the type of role of the author of a message.
 type Role string
 
@@ -78,8 +185,13 @@ const (
 	User Role = "user"
 	// System is a system message.
 	System Role = "system"
+	// Developer is a message with instructions for the model. Newer models, such as the o-series,
+	// use this in place of System.
+	Developer Role = "developer"
 	// Assistant is an assistant message.
 	Assistant Role = "assistant"
+	// Tool is a message from a tool, such as the citations from a DataSource.
+	Tool Role = "tool"
 )
 
 // SendMsg is a message to send to the chat API.
@@ -89,6 +201,9 @@ type SendMsg struct {
 
 	// Contents of the message.
 	Content string `json:"content"`
+	// Parts are the contents of a message with multiple parts, such as text and images.
+	// If set, Content is ignored.
+	Parts []ContentPart `json:"-"`
 
 	// Name of the user in chat.
 	Name string `json:"name,omitempty"`
@@ -108,6 +223,11 @@ type Resp struct {
 	Choices []Choice `json:"choices"`
 	// Usage is usage information for the chat request.
 	Usage Usage `json:"usage"`
+	// PromptFilterResults are the Azure content filter results for the prompt.
+	PromptFilterResults []custom.PromptFilterResults `json:"prompt_filter_results,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
 }
 
 // Choice is a chat completion.
@@ -116,16 +236,27 @@ type Choice struct {
 	Index int `json:"index"`
 	// Message is the message received from the chat API.
 	Message RecvMsg `json:"message"`
+	// Delta is the partial message received when streaming. When streaming, this is set
+	// instead of Message. The first delta for a choice usually only has the Role set, the
+	// following deltas have only Content set.
+	Delta RecvMsg `json:"delta"`
+	// Messages are the messages received from the extensions endpoint, which is used when the
+	// request has DataSources. This is set instead of Message and Delta.
+	Messages []ExtMsg `json:"messages,omitempty"`
 	// FinishReason is the reason the chat session ended.
-	FinishReason string `json:"finish_reason"`
+	FinishReason custom.FinishReason `json:"finish_reason"`
+	// ContentFilterResults are the Azure content filter results for the choice.
+	ContentFilterResults *custom.ContentFilterResults `json:"content_filter_results,omitempty"`
 }
 
 // RecvMsg is a message received from the chat API.
 type RecvMsg struct {
 	// Role is the role of the author of this message.
-	Role Role `json:"role"`
+	Role Role `json:"role,omitempty"`
 	// Content is the content of the message.
-	Content string `json:"content"`
+	Content string `json:"content,omitempty"`
+	// Audio is the audio generated by the model if the request had AudioModality.
+	Audio *AudioOutput `json:"audio,omitempty"`
 }
 
 // Usage is the usage information for a chat request.
@@ -136,4 +267,31 @@ type Usage struct {
 	CompletionTokens int `json:"completion_tokens"`
 	// Tokens is the total number of tokens used.
 	TotalTokens int `json:"total_tokens"`
+	// CompletionTokensDetails breaks down the CompletionTokens. This is only sent by newer API versions.
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// CompletionTokensDetails breaks down the completion tokens of a chat request.
+type CompletionTokensDetails struct {
+	// ReasoningTokens are tokens the model used for reasoning that are not part of the response.
+	ReasoningTokens int `json:"reasoning_tokens"`
+	// AcceptedPredictionTokens are tokens of the Prediction that appeared in the response.
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens"`
+	// RejectedPredictionTokens are tokens of the Prediction that did not appear in the response.
+	// These are billed as completion tokens.
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens"`
+}
+
+// PredictionType is the type of a Prediction.
+type PredictionType string
+
+// ContentPrediction is a Prediction of the content of the response.
+const ContentPrediction PredictionType = "content"
+
+// Prediction is predicted output for a request. See Req.Prediction.
+type Prediction struct {
+	// Type is the type of prediction. This is always ContentPrediction.
+	Type PredictionType `json:"type"`
+	// Content is the expected content of the response.
+	Content string `json:"content"`
 }
@@ -0,0 +1,37 @@
+package chat
+
+import "testing"
+
+func TestReqValidate(t *testing.T) {
+	msgs := []SendMsg{{Role: User, Content: "hi"}}
+
+	tests := []struct {
+		desc    string
+		req     Req
+		wantErr bool
+	}{
+		{desc: "valid", req: Req{Messages: msgs, N: 1, Temperature: 1, TopP: 1}},
+		{desc: "zero values are valid", req: Req{Messages: msgs}},
+		{desc: "no messages", req: Req{}, wantErr: true},
+		{desc: "message without role", req: Req{Messages: []SendMsg{{Content: "hi"}}}, wantErr: true},
+		{desc: "temperature too high", req: Req{Messages: msgs, Temperature: 2.1}, wantErr: true},
+		{desc: "negative temperature", req: Req{Messages: msgs, Temperature: -0.1}, wantErr: true},
+		{desc: "TopP too high", req: Req{Messages: msgs, TopP: 1.5}, wantErr: true},
+		{desc: "N too high", req: Req{Messages: msgs, N: 129}, wantErr: true},
+		{desc: "too many stops", req: Req{Messages: msgs, Stop: []string{"a", "b", "c", "d", "e"}}, wantErr: true},
+		{desc: "penalty out of range", req: Req{Messages: msgs, PresencePenalty: 3}, wantErr: true},
+		{desc: "logit bias out of range", req: Req{Messages: msgs, LogitBias: map[string]float64{"1": 101}}, wantErr: true},
+		{desc: "both max tokens", req: Req{Messages: msgs, MaxTokens: 1, MaxCompletionTokens: 1}, wantErr: true},
+		{desc: "bad reasoning effort", req: Req{Messages: msgs, ReasoningEffort: "extreme"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := test.req.Validate()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestReqValidate(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestReqValidate(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PartType is the type of a ContentPart.
+type PartType string
+
+const (
+	// TextPart is a ContentPart holding text.
+	TextPart PartType = "text"
+	// ImagePart is a ContentPart holding an image.
+	ImagePart PartType = "image_url"
+	// InputAudioPart is a ContentPart holding audio.
+	InputAudioPart PartType = "input_audio"
+)
+
+// ImageDetail is the level of detail the model uses to view an image.
+type ImageDetail string
+
+const (
+	// AutoDetail lets the model choose the level of detail. This is the default.
+	AutoDetail ImageDetail = "auto"
+	// LowDetail views a low resolution version of the image, which uses fewer tokens.
+	LowDetail ImageDetail = "low"
+	// HighDetail views the image at high resolution.
+	HighDetail ImageDetail = "high"
+)
+
+// ImageURL is an image in a ContentPart.
+type ImageURL struct {
+	// URL is the URL of the image or a base64 encoded data URL, such as "data:image/png;base64,...".
+	URL string `json:"url"`
+	// Detail is the level of detail used to view the image. This is optional.
+	Detail ImageDetail `json:"detail,omitempty"`
+}
+
+// ContentPart is a part of a message with multiple parts, such as text and images.
+// Images require a model with vision support and audio a model with audio support.
+type ContentPart struct {
+	// Type is the type of the part.
+	Type PartType `json:"type"`
+	// Text is the text of a TextPart.
+	Text string `json:"text,omitempty"`
+	// ImageURL is the image of an ImagePart.
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+	// InputAudio is the audio of an InputAudioPart.
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+}
+
+// Validate validates the ContentPart.
+func (p ContentPart) Validate() error {
+	switch p.Type {
+	case TextPart:
+		if p.ImageURL != nil || p.InputAudio != nil {
+			return fmt.Errorf("text part cannot have an ImageURL or InputAudio")
+		}
+	case ImagePart:
+		if p.ImageURL == nil || strings.TrimSpace(p.ImageURL.URL) == "" {
+			return fmt.Errorf("image part must have an ImageURL.URL")
+		}
+		switch p.ImageURL.Detail {
+		case "", AutoDetail, LowDetail, HighDetail:
+		default:
+			return fmt.Errorf("image part has unknown detail %q", p.ImageURL.Detail)
+		}
+	case InputAudioPart:
+		if p.InputAudio == nil || p.InputAudio.Data == "" {
+			return fmt.Errorf("input audio part must have InputAudio.Data")
+		}
+		switch p.InputAudio.Format {
+		case WAV, MP3:
+		default:
+			return fmt.Errorf("input audio part has unsupported format %q", p.InputAudio.Format)
+		}
+	default:
+		return fmt.Errorf("unknown part type %q", p.Type)
+	}
+	return nil
+}
+
+// sendMsg is SendMsg as sent on the wire, where content is either a string or a list of parts.
+type sendMsg struct {
+	Role    Role            `json:"role"`
+	Content json.RawMessage `json:"content"`
+	Name    string          `json:"name,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Content is sent as a string unless Parts are set.
+func (s SendMsg) MarshalJSON() ([]byte, error) {
+	var (
+		content []byte
+		err     error
+	)
+	if len(s.Parts) > 0 {
+		content, err = json.Marshal(s.Parts)
+	} else {
+		content, err = json.Marshal(s.Content)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sendMsg{Role: s.Role, Content: content, Name: s.Name})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *SendMsg) UnmarshalJSON(b []byte) error {
+	var m sendMsg
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	*s = SendMsg{Role: m.Role, Name: m.Name}
+	content := strings.TrimSpace(string(m.Content))
+	switch {
+	case content == "" || content == "null":
+	case strings.HasPrefix(content, "["):
+		if err := json.Unmarshal(m.Content, &s.Parts); err != nil {
+			return fmt.Errorf("problem decoding message content parts: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(m.Content, &s.Content); err != nil {
+			return fmt.Errorf("problem decoding message content: %w", err)
+		}
+	}
+	return nil
+}
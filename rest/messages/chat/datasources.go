@@ -0,0 +1,174 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DataSourceType is the type of a data source used with Azure OpenAI on your data.
+type DataSourceType string
+
+const (
+	// UnknownDataSource indicates the DataSourceType was not set.
+	UnknownDataSource DataSourceType = ""
+	// AzureCognitiveSearch is an Azure Cognitive Search index. Parameters must be an AzureSearchParams.
+	AzureCognitiveSearch DataSourceType = "AzureCognitiveSearch"
+	// AzureCosmosDB is an Azure Cosmos DB for MongoDB vCore index. Parameters must be a CosmosDBParams.
+	AzureCosmosDB DataSourceType = "AzureCosmosDB"
+)
+
+// DataSource is a source of data the model uses to ground its responses, which allows you to
+// use Azure OpenAI on your data. When a request has DataSources, it is sent to the extensions
+// endpoint and responses have Choice.Messages set instead of Choice.Message.
+type DataSource struct {
+	// Type is the type of the data source.
+	Type DataSourceType `json:"type"`
+	// Parameters are the parameters for the data source. This should be an AzureSearchParams or
+	// CosmosDBParams, but can be any type that marshals to the parameters the service expects.
+	Parameters any `json:"parameters"`
+}
+
+// Validate validates the DataSource.
+func (d DataSource) Validate() error {
+	if d.Type == UnknownDataSource {
+		return fmt.Errorf("DataSource.Type must be set")
+	}
+	if d.Parameters == nil {
+		return fmt.Errorf("DataSource.Parameters must be set")
+	}
+	return nil
+}
+
+// FieldsMapping maps fields in an index to the roles they play in the response.
+type FieldsMapping struct {
+	// ContentFields are the fields that hold the content of a document.
+	ContentFields []string `json:"contentFields,omitempty"`
+	// TitleField is the field that holds the title of a document.
+	TitleField string `json:"titleField,omitempty"`
+	// URLField is the field that holds the URL of a document.
+	URLField string `json:"urlField,omitempty"`
+	// FilepathField is the field that holds the file path of a document.
+	FilepathField string `json:"filepathField,omitempty"`
+	// VectorFields are the fields that hold vector data.
+	VectorFields []string `json:"vectorFields,omitempty"`
+}
+
+// AzureSearchParams are the parameters for an AzureCognitiveSearch data source.
+type AzureSearchParams struct {
+	// Endpoint is the endpoint of the search service, such as "https://mysearch.search.windows.net".
+	Endpoint string `json:"endpoint"`
+	// Key is the admin key of the search service.
+	Key string `json:"key"`
+	// IndexName is the name of the index to search.
+	IndexName string `json:"indexName"`
+	// FieldsMapping maps the index fields. This is optional.
+	FieldsMapping *FieldsMapping `json:"fieldsMapping,omitempty"`
+	// InScope limits responses to the data in the index when true. This is optional.
+	InScope *bool `json:"inScope,omitempty"`
+	// TopNDocuments is the number of documents to use for grounding. This is optional.
+	TopNDocuments int `json:"topNDocuments,omitempty"`
+	// QueryType is the type of query, such as "simple", "semantic", "vector", "vectorSimpleHybrid"
+	// or "vectorSemanticHybrid". This is optional.
+	QueryType string `json:"queryType,omitempty"`
+	// SemanticConfiguration is the semantic configuration to use with semantic queries.
+	SemanticConfiguration string `json:"semanticConfiguration,omitempty"`
+	// RoleInformation gives the model instructions about how it should behave. This is optional.
+	RoleInformation string `json:"roleInformation,omitempty"`
+	// Filter is a search filter to apply. This is optional.
+	Filter string `json:"filter,omitempty"`
+	// Strictness is how strictly the model filters search documents for relevance, 1 to 5. This is optional.
+	Strictness int `json:"strictness,omitempty"`
+	// EmbeddingDeploymentName is the deployment of the embeddings model used for vector queries.
+	EmbeddingDeploymentName string `json:"embeddingDeploymentName,omitempty"`
+}
+
+// CosmosDBAuth is the authentication for a CosmosDB data source.
+type CosmosDBAuth struct {
+	// Type is the authentication type, currently only "ConnectionString".
+	Type string `json:"type"`
+	// ConnectionString is the connection string to the database.
+	ConnectionString string `json:"connectionString"`
+}
+
+// EmbeddingDependency is the embeddings deployment used to vectorize queries.
+type EmbeddingDependency struct {
+	// Type is the dependency type, such as "DeploymentName".
+	Type string `json:"type"`
+	// DeploymentName is the name of the embeddings deployment.
+	DeploymentName string `json:"deploymentName"`
+}
+
+// CosmosDBParams are the parameters for an AzureCosmosDB data source.
+type CosmosDBParams struct {
+	// Authentication is how to authenticate to the database.
+	Authentication CosmosDBAuth `json:"authentication"`
+	// DatabaseName is the name of the database.
+	DatabaseName string `json:"databaseName"`
+	// ContainerName is the name of the container.
+	ContainerName string `json:"containerName"`
+	// IndexName is the name of the vector index.
+	IndexName string `json:"indexName"`
+	// FieldsMapping maps the index fields.
+	FieldsMapping FieldsMapping `json:"fieldsMapping"`
+	// EmbeddingDependency is the embeddings deployment used to vectorize queries.
+	EmbeddingDependency EmbeddingDependency `json:"embeddingDependency"`
+	// InScope limits responses to the data in the index when true. This is optional.
+	InScope *bool `json:"inScope,omitempty"`
+	// TopNDocuments is the number of documents to use for grounding. This is optional.
+	TopNDocuments int `json:"topNDocuments,omitempty"`
+	// Strictness is how strictly the model filters documents for relevance, 1 to 5. This is optional.
+	Strictness int `json:"strictness,omitempty"`
+	// RoleInformation gives the model instructions about how it should behave. This is optional.
+	RoleInformation string `json:"roleInformation,omitempty"`
+}
+
+// ExtMsg is a message received from the extensions endpoint, used when a request has DataSources.
+type ExtMsg struct {
+	// Index is the index of the message in the choice.
+	Index int `json:"index"`
+	// Role is the role of the author of this message. This is Tool for the message holding
+	// the citations and Assistant for the answer.
+	Role Role `json:"role,omitempty"`
+	// Content is the content of the message. For a Tool message, this is JSON that can be
+	// decoded with ToolContent().
+	Content string `json:"content,omitempty"`
+	// EndTurn indicates this is the last message of the turn.
+	EndTurn bool `json:"end_turn"`
+	// Delta is the partial message received when streaming.
+	Delta RecvMsg `json:"delta"`
+}
+
+// Citation is a document the model used to ground its response.
+type Citation struct {
+	// Content is the content of the document chunk.
+	Content string `json:"content"`
+	// Title is the title of the document.
+	Title string `json:"title"`
+	// URL is the URL of the document.
+	URL string `json:"url"`
+	// Filepath is the file path of the document.
+	Filepath string `json:"filepath"`
+	// ChunkID is the ID of the chunk within the document.
+	ChunkID string `json:"chunk_id"`
+}
+
+// ToolContent is the content of a Tool message from the extensions endpoint.
+type ToolContent struct {
+	// Citations are the documents used to ground the response.
+	Citations []Citation `json:"citations"`
+	// Intent is the intent the service detected in the conversation, which is useful to send back
+	// in later requests.
+	Intent string `json:"intent"`
+}
+
+// ToolContent decodes the Content of a Tool message.
+func (m ExtMsg) ToolContent() (ToolContent, error) {
+	if m.Role != Tool {
+		return ToolContent{}, fmt.Errorf("message has role %q, not %q", m.Role, Tool)
+	}
+	var tc ToolContent
+	if err := json.Unmarshal([]byte(m.Content), &tc); err != nil {
+		return ToolContent{}, fmt.Errorf("problem decoding tool message content: %w", err)
+	}
+	return tc, nil
+}
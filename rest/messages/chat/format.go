@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ResponseFormatType is the type of output the model must produce.
+type ResponseFormatType string
+
+const (
+	// TextFormat is plain text output, which is the default.
+	TextFormat ResponseFormatType = "text"
+	// JSONObjectFormat enables JSON mode, which constrains the output to valid JSON. The messages
+	// must also instruct the model to produce JSON.
+	JSONObjectFormat ResponseFormatType = "json_object"
+	// JSONSchemaFormat constrains the output to JSON that matches ResponseFormat.JSONSchema.
+	JSONSchemaFormat ResponseFormatType = "json_schema"
+)
+
+// ResponseFormat sets the format of the model output.
+type ResponseFormat struct {
+	// Type is the type of output. This is required.
+	Type ResponseFormatType `json:"type"`
+	// JSONSchema is the schema the output must match. This is required if Type is JSONSchemaFormat.
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// Validate validates the ResponseFormat.
+func (r ResponseFormat) Validate() error {
+	switch r.Type {
+	case TextFormat, JSONObjectFormat:
+		if r.JSONSchema != nil {
+			return fmt.Errorf("JSONSchema can only be set when Type is %q", JSONSchemaFormat)
+		}
+	case JSONSchemaFormat:
+		if r.JSONSchema == nil {
+			return fmt.Errorf("JSONSchema is required when Type is %q", JSONSchemaFormat)
+		}
+		return r.JSONSchema.Validate()
+	default:
+		return fmt.Errorf("ResponseFormat.Type %q is not supported", r.Type)
+	}
+	return nil
+}
+
+// JSONSchema is a JSON schema for the model output.
+type JSONSchema struct {
+	// Name is the name of the schema. This is required.
+	Name string `json:"name"`
+	// Description describes what the output is for, which the model uses to produce it.
+	Description string `json:"description,omitempty"`
+	// Schema is the JSON schema document. This is required.
+	Schema json.RawMessage `json:"schema"`
+	// Strict requires the output to follow the schema exactly. This only supports a subset of
+	// JSON schema, see the service documentation.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// Validate validates the JSONSchema.
+func (j JSONSchema) Validate() error {
+	switch {
+	case j.Name == "":
+		return errors.New("JSONSchema.Name is required")
+	case len(j.Schema) == 0:
+		return errors.New("JSONSchema.Schema is required")
+	case !json.Valid(j.Schema):
+		return errors.New("JSONSchema.Schema is not valid JSON")
+	}
+	return nil
+}
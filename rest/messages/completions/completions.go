@@ -69,6 +69,10 @@ type Req struct {
 
 	// Stop  provides up to 4 sequences where the API will stop generating further tokens. The returned text will not contain the stop sequence.
 	Stop []string `json:"stop,omitempty"`
+
+	// BestOf generates BestOf completions server side and returns the N with the highest log probability per token.
+	// It must be >= N and cannot be more than 1 when streaming. It uses BestOf times the tokens of one completion.
+	BestOf int `json:"best_of,omitempty"`
 }
 
 // Defaults sets all the default values for fields if the field is set to the zero value of the type. This will overwrite fields that have valid zero values
@@ -94,13 +98,20 @@ func (r Req) Defaults() Req {
 	return r
 }
 
-func (r Req) validate() error {
+// Validate returns an error if the Req has values the service would reject.
+func (r Req) Validate() error {
 	if len(r.Prompt) > 2048 {
 		return fmt.Errorf("cannot have a prompt list with more than 2048 entries")
 	}
 	if r.MaxTokens < 0 || r.MaxTokens > 4096 {
 		return fmt.Errorf("cannot set MaxTokens < 0 or > 4096")
 	}
+	if r.Temperature < 0 || r.Temperature > 2 {
+		return fmt.Errorf("Temperature must be between 0 and 2, was %v", r.Temperature)
+	}
+	if r.TopP < 0 || r.TopP > 1 {
+		return fmt.Errorf("TopP must be between 0 and 1, was %v", r.TopP)
+	}
 	if r.N < 1 || r.N > 128 {
 		return fmt.Errorf("cannot set N < 1 or > 128")
 	}
@@ -110,6 +121,17 @@ func (r Req) validate() error {
 	if len(r.Stop) > 4 {
 		return fmt.Errorf("Stop cannot have more than 4 entries")
 	}
+	if r.BestOf != 0 && (r.BestOf < r.N || r.BestOf > 128) {
+		return fmt.Errorf("BestOf must be between N (%d) and 128, was %d", r.N, r.BestOf)
+	}
+	if r.BestOf > 1 && r.Stream {
+		return fmt.Errorf("BestOf cannot be > 1 when streaming")
+	}
+	for k, v := range r.LogitBias {
+		if v < -100 || v > 100 {
+			return fmt.Errorf("LogitBias[%s] must be between -100 and 100, was %v", k, v)
+		}
+	}
 	return nil
 }
 
@@ -119,13 +141,33 @@ type Resp struct {
 	Object  string          `json:"object"`
 	Model   string          `json:"model"`
 	Choices []Choices       `json:"choices"`
+	Usage   Usage           `json:"usage"`
+
+	// PromptFilterResults are the Azure content filter results for the prompts.
+	PromptFilterResults []custom.PromptFilterResults `json:"prompt_filter_results,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Usage is the usage information for a completions request.
+type Usage struct {
+	// PromptTokens is the number of tokens used for the prompt.
+	PromptTokens int `json:"prompt_tokens"`
+	// CompletionTokens is the number of tokens used for the completion.
+	CompletionTokens int `json:"completion_tokens"`
+	// TotalTokens is the total number of tokens used.
+	TotalTokens int `json:"total_tokens"`
 }
 
 type Choices struct {
-	Text         string   `json:"text"`
-	FinishReason string   `json:"finish_reason"`
-	Logprobs     LogProbs `json:"logprobs"`
-	Index        int      `json:"index"`
+	Text         string              `json:"text"`
+	FinishReason custom.FinishReason `json:"finish_reason"`
+	Logprobs     LogProbs            `json:"logprobs"`
+	Index        int                 `json:"index"`
+
+	// ContentFilterResults are the Azure content filter results for the choice.
+	ContentFilterResults *custom.ContentFilterResults `json:"content_filter_results,omitempty"`
 }
 
 type LogProbs struct {
@@ -0,0 +1,32 @@
+package completions
+
+import "testing"
+
+func TestReqValidate(t *testing.T) {
+	tests := []struct {
+		desc    string
+		req     Req
+		wantErr bool
+	}{
+		{desc: "defaults are valid", req: Req{Prompt: []string{"hi"}}.Defaults()},
+		{desc: "N of 0", req: Req{Prompt: []string{"hi"}}, wantErr: true},
+		{desc: "temperature too high", req: Req{Prompt: []string{"hi"}, N: 1, Temperature: 2.5}, wantErr: true},
+		{desc: "TopP too high", req: Req{Prompt: []string{"hi"}, N: 1, TopP: 1.1}, wantErr: true},
+		{desc: "too many stops", req: Req{Prompt: []string{"hi"}, N: 1, Stop: []string{"a", "b", "c", "d", "e"}}, wantErr: true},
+		{desc: "logprobs too high", req: Req{Prompt: []string{"hi"}, N: 1, Logprobs: 6}, wantErr: true},
+		{desc: "best of", req: Req{Prompt: []string{"hi"}, N: 2, BestOf: 3}},
+		{desc: "best of < N", req: Req{Prompt: []string{"hi"}, N: 2, BestOf: 1}, wantErr: true},
+		{desc: "best of when streaming", req: Req{Prompt: []string{"hi"}, N: 1, BestOf: 2, Stream: true}, wantErr: true},
+		{desc: "logit bias out of range", req: Req{Prompt: []string{"hi"}, N: 1, LogitBias: map[string]float64{"1": -101}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := test.req.Validate()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestReqValidate(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestReqValidate(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package custom
+
+// Severity is the severity of content found by a content filter.
+type Severity string
+
+const (
+	// Safe indicates no harmful content was found.
+	Safe Severity = "safe"
+	// Low indicates content of low severity was found.
+	Low Severity = "low"
+	// Medium indicates content of medium severity was found.
+	Medium Severity = "medium"
+	// High indicates content of high severity was found.
+	High Severity = "high"
+)
+
+// SeverityResult is the result of a content filter that rates the severity of content.
+type SeverityResult struct {
+	// Filtered indicates the content was filtered.
+	Filtered bool `json:"filtered"`
+	// Severity is the severity of the content.
+	Severity Severity `json:"severity"`
+}
+
+// DetectedResult is the result of a content filter that detects content, such as a jailbreak attempt.
+type DetectedResult struct {
+	// Filtered indicates the content was filtered.
+	Filtered bool `json:"filtered"`
+	// Detected indicates the content was detected.
+	Detected bool `json:"detected"`
+}
+
+// ContentFilterResults are the results of the Azure content filters for a prompt or a choice.
+// A category is nil if the filter did not run.
+type ContentFilterResults struct {
+	// Hate is the result of the hate filter.
+	Hate *SeverityResult `json:"hate,omitempty"`
+	// SelfHarm is the result of the self-harm filter.
+	SelfHarm *SeverityResult `json:"self_harm,omitempty"`
+	// Sexual is the result of the sexual filter.
+	Sexual *SeverityResult `json:"sexual,omitempty"`
+	// Violence is the result of the violence filter.
+	Violence *SeverityResult `json:"violence,omitempty"`
+	// Jailbreak is the result of the jailbreak filter, which only runs on prompts.
+	Jailbreak *DetectedResult `json:"jailbreak,omitempty"`
+	// Profanity is the result of the profanity filter.
+	Profanity *DetectedResult `json:"profanity,omitempty"`
+}
+
+// Filtered returns true if any of the filters filtered the content.
+func (c ContentFilterResults) Filtered() bool {
+	for _, r := range []*SeverityResult{c.Hate, c.SelfHarm, c.Sexual, c.Violence} {
+		if r != nil && r.Filtered {
+			return true
+		}
+	}
+	for _, r := range []*DetectedResult{c.Jailbreak, c.Profanity} {
+		if r != nil && r.Filtered {
+			return true
+		}
+	}
+	return false
+}
+
+// FilteredCategories returns the names of the filters that filtered the content, such as
+// "hate" or "jailbreak", as they are named in the JSON.
+func (c ContentFilterResults) FilteredCategories() []string {
+	var cats []string
+	for _, r := range []struct {
+		name string
+		res  *SeverityResult
+	}{{"hate", c.Hate}, {"self_harm", c.SelfHarm}, {"sexual", c.Sexual}, {"violence", c.Violence}} {
+		if r.res != nil && r.res.Filtered {
+			cats = append(cats, r.name)
+		}
+	}
+	for _, r := range []struct {
+		name string
+		res  *DetectedResult
+	}{{"jailbreak", c.Jailbreak}, {"profanity", c.Profanity}} {
+		if r.res != nil && r.res.Filtered {
+			cats = append(cats, r.name)
+		}
+	}
+	return cats
+}
+
+// PromptFilterResults are the content filter results for a prompt.
+type PromptFilterResults struct {
+	// PromptIndex is the index of the prompt.
+	PromptIndex int `json:"prompt_index"`
+	// ContentFilterResults are the results of the content filters.
+	ContentFilterResults ContentFilterResults `json:"content_filter_results"`
+}
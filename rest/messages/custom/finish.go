@@ -0,0 +1,40 @@
+package custom
+
+// FinishReason is the reason the model stopped generating tokens for a choice. Values the
+// SDK does not know are kept as is, use Known() to check for them.
+type FinishReason string
+
+const (
+	// Unfinished indicates the choice has not finished. This is the value on streamed
+	// responses before the last chunk of a choice.
+	Unfinished FinishReason = ""
+	// Stop indicates the model hit a natural stop point or a stop sequence.
+	Stop FinishReason = "stop"
+	// Length indicates the model hit max_tokens or the context window.
+	Length FinishReason = "length"
+	// ToolCalls indicates the model called a tool.
+	ToolCalls FinishReason = "tool_calls"
+	// FunctionCall indicates the model called a function.
+	FunctionCall FinishReason = "function_call"
+	// ContentFilter indicates content was omitted by the content filters.
+	ContentFilter FinishReason = "content_filter"
+)
+
+// Known returns true if f is one of the FinishReason constants.
+func (f FinishReason) Known() bool {
+	switch f {
+	case Unfinished, Stop, Length, ToolCalls, FunctionCall, ContentFilter:
+		return true
+	}
+	return false
+}
+
+// IsTruncated returns true if the choice was cut off by max_tokens or the context window.
+func (f FinishReason) IsTruncated() bool {
+	return f == Length
+}
+
+// WasFiltered returns true if content was omitted by the content filters.
+func (f FinishReason) WasFiltered() bool {
+	return f == ContentFilter
+}
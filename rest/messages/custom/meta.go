@@ -0,0 +1,69 @@
+package custom
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseMeta is metadata about a response that is taken from the HTTP response headers.
+// This is useful for debugging and for making throttling decisions.
+type ResponseMeta struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// RequestID is the value of the x-ms-request-id header, or apim-request-id if that is not
+	// set. Provide this when opening a support ticket.
+	RequestID string
+	// Region is the Azure region that served the request, from the x-ms-region header.
+	Region string
+	// RemainingRequests is the number of requests remaining in the current rate limit window,
+	// from the x-ratelimit-remaining-requests header. This is -1 if the header was not sent.
+	RemainingRequests int
+	// RemainingTokens is the number of tokens remaining in the current rate limit window,
+	// from the x-ratelimit-remaining-tokens header. This is -1 if the header was not sent.
+	RemainingTokens int
+	// ProcessingTime is the time the service spent processing the request, from the
+	// openai-processing-ms header. This is 0 if the header was not sent.
+	ProcessingTime time.Duration
+	// ETag is the version of the resource, from the ETag header. Pass it to
+	// rest.ContextWithIfMatch() to only change the resource if it has not changed since.
+	ETag string
+	// Header holds all the response headers.
+	Header http.Header
+	// Cached indicates the response came from the cache set with rest.WithCache(), so it has no
+	// headers or rate limit information.
+	Cached bool
+}
+
+// NewResponseMeta creates a ResponseMeta from the headers of an HTTP response.
+func NewResponseMeta(resp *http.Response) ResponseMeta {
+	h := resp.Header
+	if h == nil {
+		h = http.Header{}
+	}
+
+	m := ResponseMeta{
+		StatusCode:        resp.StatusCode,
+		RequestID:         h.Get("x-ms-request-id"),
+		Region:            h.Get("x-ms-region"),
+		RemainingRequests: headerInt(h, "x-ratelimit-remaining-requests"),
+		RemainingTokens:   headerInt(h, "x-ratelimit-remaining-tokens"),
+		ETag:              h.Get("ETag"),
+		Header:            h,
+	}
+	if m.RequestID == "" {
+		m.RequestID = h.Get("apim-request-id")
+	}
+	if ms, err := strconv.ParseFloat(h.Get("openai-processing-ms"), 64); err == nil {
+		m.ProcessingTime = time.Duration(ms * float64(time.Millisecond))
+	}
+	return m
+}
+
+func headerInt(h http.Header, key string) int {
+	i, err := strconv.Atoi(h.Get(key))
+	if err != nil {
+		return -1
+	}
+	return i
+}
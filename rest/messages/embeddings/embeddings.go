@@ -1,7 +1,27 @@
 // Package embeddings contains the request and response types for the embeddings API.
 package embeddings
 
-import "errors"
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// EncodingFormat is the format the service returns the embeddings in.
+type EncodingFormat string
+
+const (
+	// Float returns the embeddings as JSON arrays of numbers. This is the service default.
+	Float EncodingFormat = "float"
+	// Base64 returns the embeddings as base64 encoded little-endian float32s, which is about
+	// a quarter of the size. They are decoded into Data.Embedding.
+	Base64 EncodingFormat = "base64"
+)
 
 // Req represents a request to the embeddings API.
 type Req struct {
@@ -16,6 +36,11 @@ type Req struct {
 	// User represents your end-user, which can help monitoring and detecting abuse.
 	// This is optional.
 	User string `json:"user,omitempty"`
+	// Dimensions is the number of dimensions of the embeddings. Only text-embedding-3 and later
+	// models support this. This is optional, the default is the full size of the model.
+	Dimensions int `json:"dimensions,omitempty"`
+	// EncodingFormat is the format the embeddings are returned in. This is optional.
+	EncodingFormat EncodingFormat `json:"encoding_format,omitempty"`
 }
 
 // Validate validates the EmbeddingsInput.
@@ -26,6 +51,14 @@ func (e Req) Validate() error {
 	if len(e.Input) > 2048 {
 		return errors.New("input cannot have more than 2048 entries")
 	}
+	if e.Dimensions < 0 {
+		return errors.New("dimensions cannot be < 0")
+	}
+	switch e.EncodingFormat {
+	case "", Float, Base64:
+	default:
+		return fmt.Errorf("encoding format %q is not supported", e.EncodingFormat)
+	}
 	return nil
 }
 
@@ -39,10 +72,71 @@ type Data struct {
 	Index int `json:"index"`
 }
 
+// UnmarshalJSON implements json.Unmarshaler. An Embedding sent with the Base64 EncodingFormat is
+// decoded.
+func (d *Data) UnmarshalJSON(b []byte) error {
+	type data Data
+	var raw struct {
+		data
+		Embedding json.RawMessage `json:"embedding"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*d = Data(raw.data)
+
+	switch {
+	case len(raw.Embedding) == 0:
+		return nil
+	case raw.Embedding[0] != '"':
+		return json.Unmarshal(raw.Embedding, &d.Embedding)
+	}
+
+	var s string
+	if err := json.Unmarshal(raw.Embedding, &s); err != nil {
+		return err
+	}
+	v, err := decodeBase64(s)
+	if err != nil {
+		return fmt.Errorf("embedding %d: %w", d.Index, err)
+	}
+	d.Embedding = v
+	return nil
+}
+
+// decodeBase64 decodes base64 encoded little-endian float32s.
+func decodeBase64(s string) ([]float64, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("problem decoding base64: %w", err)
+	}
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("base64 embedding has %d bytes, which is not a multiple of 4", len(b))
+	}
+	v := make([]float64, len(b)/4)
+	for i := range v {
+		v[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:])))
+	}
+	return v, nil
+}
+
 // Resp represents a response from the embeddings API.
 type Resp struct {
 	// Model is the model used.
 	Model string `json:"model"`
 	// Data is the embedding data. We guarantee sorted order of the data by index.
 	Data []Data `json:"data"`
+	// Usage is the usage information for the request.
+	Usage Usage `json:"usage"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Usage is the usage information for an embeddings request.
+type Usage struct {
+	// PromptTokens is the number of tokens in the input.
+	PromptTokens int `json:"prompt_tokens"`
+	// TotalTokens is the total number of tokens used.
+	TotalTokens int `json:"total_tokens"`
 }
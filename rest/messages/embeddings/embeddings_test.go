@@ -0,0 +1,79 @@
+package embeddings
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestReqValidate(t *testing.T) {
+	tests := []struct {
+		desc    string
+		req     Req
+		wantErr bool
+	}{
+		{desc: "valid", req: Req{Input: []string{"hi"}, Dimensions: 256, EncodingFormat: Base64}},
+		{desc: "no input", req: Req{}, wantErr: true},
+		{desc: "negative dimensions", req: Req{Input: []string{"hi"}, Dimensions: -1}, wantErr: true},
+		{desc: "unknown encoding format", req: Req{Input: []string{"hi"}, EncodingFormat: "int8"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := test.req.Validate()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestReqValidate(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestReqValidate(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+}
+
+func TestDataUnmarshal(t *testing.T) {
+	tests := []struct {
+		desc    string
+		json    string
+		want    Data
+		wantErr bool
+	}{
+		{
+			desc: "float",
+			json: `{"object":"embedding","embedding":[0.5,-2],"index":1}`,
+			want: Data{Object: "embedding", Embedding: []float64{0.5, -2}, Index: 1},
+		},
+		{
+			// 0.5 and -2 as little-endian float32s.
+			desc: "base64",
+			json: `{"object":"embedding","embedding":"AAAAPwAAAMA=","index":1}`,
+			want: Data{Object: "embedding", Embedding: []float64{0.5, -2}, Index: 1},
+		},
+		{
+			desc:    "bad base64",
+			json:    `{"embedding":"!!"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "partial float32",
+			json:    `{"embedding":"AAAA"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		var got Data
+		err := json.Unmarshal([]byte(test.json), &got)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestDataUnmarshal(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestDataUnmarshal(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TestDataUnmarshal(%s): got %+v, want %+v", test.desc, got, test.want)
+		}
+	}
+}
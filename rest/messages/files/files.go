@@ -0,0 +1,118 @@
+// Package files contains the request and response types for the Files API, which stores files
+// on the resource for fine-tuning, batch jobs and assistants.
+package files
+
+import (
+	"errors"
+	"io"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Purpose is what a file is used for.
+type Purpose string
+
+const (
+	// FineTune is a JSONL file of training or validation data for fine-tuning.
+	FineTune Purpose = "fine-tune"
+	// FineTuneResults is a file of results written by a fine-tuning job. These are not uploaded.
+	FineTuneResults Purpose = "fine-tune-results"
+	// Assistants is a file used by assistants, such as for file search or the code interpreter.
+	Assistants Purpose = "assistants"
+	// AssistantsOutput is a file written by an assistant. These are not uploaded.
+	AssistantsOutput Purpose = "assistants_output"
+	// Batch is a JSONL file of requests for a batch job.
+	Batch Purpose = "batch"
+	// BatchOutput is a file of results written by a batch job. These are not uploaded.
+	BatchOutput Purpose = "batch_output"
+)
+
+// Status is the processing status of a file.
+type Status string
+
+const (
+	// Uploaded indicates the file was uploaded and has not been processed.
+	Uploaded Status = "uploaded"
+	// Pending indicates the file is waiting to be processed.
+	Pending Status = "pending"
+	// Running indicates the file is being processed.
+	Running Status = "running"
+	// Processed indicates the file was processed and is ready to use.
+	Processed Status = "processed"
+	// Error indicates the file could not be processed, see File.StatusDetails.
+	Error Status = "error"
+	// Deleting indicates the file is being deleted.
+	Deleting Status = "deleting"
+	// Deleted indicates the file was deleted.
+	Deleted Status = "deleted"
+)
+
+// UploadReq uploads a file. It is sent as multipart/form-data, not JSON.
+type UploadReq struct {
+	// File is the content of the file. It is streamed to the service, not read into memory.
+	// This is required.
+	File io.Reader
+	// FileName is the name of the file, such as "training.jsonl". This is required.
+	FileName string
+	// Purpose is what the file is used for. This is required.
+	Purpose Purpose
+}
+
+// Validate validates the UploadReq.
+func (r UploadReq) Validate() error {
+	switch {
+	case r.File == nil:
+		return errors.New("File is required")
+	case r.FileName == "":
+		return errors.New("FileName is required")
+	}
+	switch r.Purpose {
+	case FineTune, Assistants, Batch:
+	case "":
+		return errors.New("Purpose is required")
+	default:
+		return errors.New("Purpose must be one of fine-tune, assistants or batch")
+	}
+	return nil
+}
+
+// File is a file stored on the resource.
+type File struct {
+	// ID is the ID of the file.
+	ID string `json:"id"`
+	// Bytes is the size of the file.
+	Bytes int64 `json:"bytes"`
+	// CreatedAt is when the file was created.
+	CreatedAt custom.UnixTime `json:"created_at"`
+	// Filename is the name of the file.
+	Filename string `json:"filename"`
+	// Purpose is what the file is used for.
+	Purpose Purpose `json:"purpose"`
+	// Status is the processing status of the file.
+	Status Status `json:"status"`
+	// StatusDetails is the reason a file has the Error status.
+	StatusDetails string `json:"status_details,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// List is a list of files.
+type List struct {
+	// Data are the files.
+	Data []File `json:"data"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// DeleteResp is the response to deleting a file.
+type DeleteResp struct {
+	// ID is the ID of the file.
+	ID string `json:"id"`
+	// Deleted indicates the file was deleted.
+	Deleted bool `json:"deleted"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
@@ -0,0 +1,148 @@
+// Package images contains the request and response types for the image generation API.
+package images
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// Size is the size of the generated images.
+type Size string
+
+const (
+	// UnknownSize indicates the size was not set. The service will use Size1024x1024.
+	UnknownSize Size = ""
+	// Size256x256 is a 256x256 image.
+	Size256x256 Size = "256x256"
+	// Size512x512 is a 512x512 image.
+	Size512x512 Size = "512x512"
+	// Size1024x1024 is a 1024x1024 image.
+	Size1024x1024 Size = "1024x1024"
+)
+
+// Format is the format the generated images are returned in.
+type Format string
+
+const (
+	// UnknownFormat indicates the format was not set. The service will use URL.
+	UnknownFormat Format = ""
+	// URL returns a URL to the image. The image will be deleted after 24 hours.
+	URL Format = "url"
+	// B64JSON returns the image as base64 encoded data in the JSON response.
+	B64JSON Format = "b64_json"
+)
+
+// Req represents a request to the image generation API.
+type Req struct {
+	// Prompt is a text description of the desired images. This is required.
+	Prompt string `json:"prompt"`
+	// N is the number of images to generate. Must be between 1 and 5. Defaults to 1.
+	N int `json:"n,omitempty"`
+	// Size is the size of the generated images. Defaults to Size1024x1024.
+	Size Size `json:"size,omitempty"`
+	// ResponseFormat is the format the images are returned in. Defaults to URL.
+	ResponseFormat Format `json:"response_format,omitempty"`
+	// User is a unique identifier representing your end-user, which can help monitoring and detecting abuse.
+	User string `json:"user,omitempty"`
+}
+
+// Validate validates the Req.
+func (r Req) Validate() error {
+	if r.Prompt == "" {
+		return errors.New("Prompt is required")
+	}
+	if r.N < 0 || r.N > 5 {
+		return errors.New("N must be between 1 and 5")
+	}
+	switch r.Size {
+	case UnknownSize, Size256x256, Size512x512, Size1024x1024:
+	default:
+		return fmt.Errorf("Size %q is not supported", r.Size)
+	}
+	switch r.ResponseFormat {
+	case UnknownFormat, URL, B64JSON:
+	default:
+		return fmt.Errorf("ResponseFormat %q is not supported", r.ResponseFormat)
+	}
+	return nil
+}
+
+// Status is the status of an image generation operation.
+type Status string
+
+const (
+	// NotRunning indicates the operation has not started.
+	NotRunning Status = "notRunning"
+	// Running indicates the operation is running.
+	Running Status = "running"
+	// Succeeded indicates the operation finished and the images are available.
+	Succeeded Status = "succeeded"
+	// Failed indicates the operation failed.
+	Failed Status = "failed"
+	// Canceled indicates the operation was canceled.
+	Canceled Status = "canceled"
+	// Deleted indicates the operation was deleted.
+	Deleted Status = "deleted"
+)
+
+// Done returns true if the operation has finished, successfully or not.
+func (s Status) Done() bool {
+	switch s {
+	case Succeeded, Failed, Canceled, Deleted:
+		return true
+	}
+	return false
+}
+
+// Resp is the response from the image generation API. Image generation is a long running
+// operation, so this is the state of the operation.
+type Resp struct {
+	// ID is the ID of the operation.
+	ID string `json:"id"`
+	// Status is the status of the operation.
+	Status Status `json:"status"`
+	// Created is the time the operation was created.
+	Created custom.UnixTime `json:"created"`
+	// Expires is the time the operation and the images it created will be deleted.
+	Expires custom.UnixTime `json:"expires"`
+	// Result holds the images if Status is Succeeded.
+	Result Result `json:"result"`
+	// Error holds the error if Status is Failed.
+	Error *Error `json:"error,omitempty"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Result holds the generated images.
+type Result struct {
+	// Created is the time the images were created.
+	Created custom.UnixTime `json:"created"`
+	// Data holds the images.
+	Data []Data `json:"data"`
+}
+
+// Data is a single generated image.
+type Data struct {
+	// URL is the URL of the image. Set if the ResponseFormat was URL.
+	URL string `json:"url,omitempty"`
+	// B64JSON is the base64 encoded image. Set if the ResponseFormat was B64JSON.
+	B64JSON string `json:"b64_json,omitempty"`
+	// RevisedPrompt is the prompt that was used to generate the image, if the service revised it.
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// Error is an error returned for a failed operation.
+type Error struct {
+	// Code is the error code.
+	Code string `json:"code"`
+	// Message is the error message.
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
@@ -0,0 +1,157 @@
+// Package ingestion contains the request and response types for the ingestion jobs API, which
+// creates Azure Cognitive Search indexes from documents in blob storage for use as chat data sources.
+package ingestion
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// CompletionAction is what to do with the intermediate assets when a job finishes.
+type CompletionAction string
+
+const (
+	// UnknownCompletionAction indicates the action was not set. The service will use CleanUpAssets.
+	UnknownCompletionAction CompletionAction = ""
+	// CleanUpAssets removes the intermediate assets, such as the indexer and skillset.
+	CleanUpAssets CompletionAction = "cleanUpAssets"
+	// KeepAllAssets keeps the intermediate assets.
+	KeepAllAssets CompletionAction = "keepAllAssets"
+)
+
+// Req represents a request to create an ingestion job. Most of the fields are sent as headers.
+type Req struct {
+	// SearchServiceEndpoint is the endpoint of the search service to create the index in. This is required.
+	SearchServiceEndpoint string `json:"-"`
+	// SearchServiceAdminKey is the admin key of the search service. This is optional if the
+	// Azure OpenAI resource has a managed identity with access to the search service.
+	SearchServiceAdminKey string `json:"-"`
+	// StorageConnectionString is the connection string of the storage account holding the documents. This is required.
+	StorageConnectionString string `json:"-"`
+	// StorageContainer is the container holding the documents. This is required.
+	StorageContainer string `json:"-"`
+	// ChunkSize is the number of tokens in each chunk of a document. This is optional.
+	ChunkSize int `json:"-"`
+	// EmbeddingEndpoint is the endpoint of an embeddings deployment used to vectorize the chunks. This is optional.
+	EmbeddingEndpoint string `json:"-"`
+	// EmbeddingKey is the key for the EmbeddingEndpoint.
+	EmbeddingKey string `json:"-"`
+
+	// CompletionAction is what to do with the intermediate assets when the job finishes.
+	CompletionAction CompletionAction `json:"completionAction,omitempty"`
+	// DataRefreshIntervalInMinutes sets how often the index is refreshed from storage. 0 disables refreshing.
+	DataRefreshIntervalInMinutes int `json:"dataRefreshIntervalInMinutes,omitempty"`
+}
+
+// Validate validates the Req.
+func (r Req) Validate() error {
+	switch {
+	case r.SearchServiceEndpoint == "":
+		return errors.New("SearchServiceEndpoint is required")
+	case r.StorageConnectionString == "":
+		return errors.New("StorageConnectionString is required")
+	case r.StorageContainer == "":
+		return errors.New("StorageContainer is required")
+	case r.ChunkSize < 0:
+		return errors.New("ChunkSize cannot be < 0")
+	case r.DataRefreshIntervalInMinutes < 0:
+		return errors.New("DataRefreshIntervalInMinutes cannot be < 0")
+	case r.EmbeddingKey != "" && r.EmbeddingEndpoint == "":
+		return errors.New("EmbeddingKey requires EmbeddingEndpoint")
+	}
+	switch r.CompletionAction {
+	case UnknownCompletionAction, CleanUpAssets, KeepAllAssets:
+	default:
+		return fmt.Errorf("CompletionAction %q is not supported", r.CompletionAction)
+	}
+	return nil
+}
+
+// Header returns the headers the request fields are sent in.
+func (r Req) Header() http.Header {
+	h := http.Header{}
+	set := func(k, v string) {
+		if v != "" {
+			h.Set(k, v)
+		}
+	}
+	set("searchServiceEndpoint", r.SearchServiceEndpoint)
+	set("searchServiceAdminKey", r.SearchServiceAdminKey)
+	set("storageConnectionString", r.StorageConnectionString)
+	set("storageContainer", r.StorageContainer)
+	set("embeddingEndpoint", r.EmbeddingEndpoint)
+	set("embeddingKey", r.EmbeddingKey)
+	if r.ChunkSize > 0 {
+		h.Set("chunkSize", strconv.Itoa(r.ChunkSize))
+	}
+	return h
+}
+
+// Status is the status of an ingestion job.
+type Status string
+
+const (
+	// NotRunning indicates the job has not started.
+	NotRunning Status = "notRunning"
+	// Running indicates the job is running.
+	Running Status = "running"
+	// Succeeded indicates the job finished and the index is ready.
+	Succeeded Status = "succeeded"
+	// Failed indicates the job failed.
+	Failed Status = "failed"
+)
+
+// Done returns true if the job has finished, successfully or not.
+func (s Status) Done() bool {
+	return s == Succeeded || s == Failed
+}
+
+// Resp is the state of an ingestion job.
+type Resp struct {
+	// ID is the ID of the job.
+	ID string `json:"id"`
+	// Status is the status of the job.
+	Status Status `json:"status"`
+	// Error holds the error if Status is Failed.
+	Error *Error `json:"error,omitempty"`
+	// Warnings are problems that did not stop the job, such as documents that could not be read.
+	Warnings []string `json:"warnings,omitempty"`
+	// Progress is the progress of each stage of the job.
+	Progress Progress `json:"progress"`
+
+	// Meta is metadata from the response headers. This is not part of the JSON response.
+	Meta custom.ResponseMeta `json:"-"`
+}
+
+// Progress is the progress of a job.
+type Progress struct {
+	// StageProgress is the progress of each stage of the job.
+	StageProgress []StageProgress `json:"stageProgress"`
+}
+
+// StageProgress is the progress of a stage of a job.
+type StageProgress struct {
+	// Name is the name of the stage, such as "Preprocessing" or "Indexing".
+	Name string `json:"name"`
+	// TotalItems is the number of items the stage will process.
+	TotalItems int `json:"totalItems"`
+	// ProcessedItems is the number of items the stage has processed.
+	ProcessedItems int `json:"processedItems"`
+}
+
+// Error is an error returned for a failed job.
+type Error struct {
+	// Code is the error code.
+	Code string `json:"code"`
+	// Message is the error message.
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
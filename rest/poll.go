@@ -0,0 +1,35 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// poll calls get with the last state until done returns true or ctx is done. Between calls it waits
+// for the Retry-After of the last response, or interval if the service did not send one. This is
+// used for long running operations.
+func poll[T any](ctx context.Context, interval time.Duration, state T, header func(T) http.Header, get func(context.Context, T) (T, error), done func(T) bool) (T, error) {
+	for !done(state) {
+		wait := interval
+		if d, ok := retryAfter(header(state), time.Now()); ok {
+			wait = d
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			var zero T
+			return zero, ctx.Err()
+		case <-t.C:
+		}
+
+		var err error
+		state, err = get(ctx, state)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,243 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+// Strategy is how a pool chooses the deployment for a request.
+type Strategy int
+
+const (
+	// RoundRobin sends each request to the next deployment in turn.
+	RoundRobin Strategy = iota
+	// LeastLoaded sends each request to the deployment with the fewest requests in flight.
+	LeastLoaded
+	// MostRemaining sends each request to the deployment with the most tokens, then requests,
+	// remaining in its rate limit window, as reported by its last response. Deployments whose
+	// quota is not known yet are tried first. This pools the quota of several resources or keys.
+	MostRemaining
+)
+
+// Member is a deployment in a pool.
+type Member struct {
+	// Client is the Client for the resource the deployment is in.
+	Client *Client
+	// DeploymentID is the ID of the deployment.
+	DeploymentID string
+}
+
+type member struct {
+	Member
+	inflight atomic.Int64
+}
+
+type pool struct {
+	strategy Strategy
+	members  []*member
+	next     atomic.Uint64
+}
+
+// NewPool returns a Client that spreads chat and embeddings requests across members, such as
+// deployments in several regions, chosen by strategy. A request that fails with a 429 or 5XX
+// status code, or a network error, is sent to the next member until all members have been tried.
+// Streams are only sent to the next member if they fail before the first event.
+// The deploymentID passed to the methods of the Client is ignored. The Client only supports
+// Chat(), ChatStream(), OpenChatStream() and Embeddings(), other methods return an error.
+func NewPool(strategy Strategy, members ...Member) (*Client, error) {
+	switch strategy {
+	case RoundRobin, LeastLoaded, MostRemaining:
+	default:
+		return nil, fmt.Errorf("NewPool: unknown strategy %d", strategy)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("NewPool: must have at least one member")
+	}
+
+	p := &pool{strategy: strategy}
+	for i, m := range members {
+		if m.Client == nil {
+			return nil, fmt.Errorf("NewPool: member %d has a nil Client", i)
+		}
+		if m.Client.pool != nil {
+			return nil, fmt.Errorf("NewPool: member %d is a pool", i)
+		}
+		if m.DeploymentID == "" {
+			return nil, fmt.Errorf("NewPool: member %d has an empty DeploymentID", i)
+		}
+		p.members = append(p.members, &member{Member: m})
+	}
+	return &Client{pool: p}, nil
+}
+
+// order returns the members in the order they should be tried. Members that are throttled
+// are tried last, whatever the strategy.
+func (p *pool) order() []*member {
+	n := len(p.members)
+	start := int(p.next.Add(1)-1) % n
+
+	l := make([]*member, 0, n)
+	for i := 0; i < n; i++ {
+		l = append(l, p.members[(start+i)%n])
+	}
+
+	now := time.Now()
+	quotas := make(map[*member]Quota, n)
+	for _, m := range l {
+		quotas[m] = m.Client.Quota(m.DeploymentID)
+	}
+	// The rotation breaks ties, so equal members share the requests.
+	sort.SliceStable(l, func(i, j int) bool {
+		qi, qj := quotas[l[i]], quotas[l[j]]
+		if ti, tj := qi.Throttled(now), qj.Throttled(now); ti != tj {
+			return tj
+		}
+		switch p.strategy {
+		case LeastLoaded:
+			return l[i].inflight.Load() < l[j].inflight.Load()
+		case MostRemaining:
+			if ri, rj := remaining(qi.RemainingTokens), remaining(qj.RemainingTokens); ri != rj {
+				return ri > rj
+			}
+			return remaining(qi.RemainingRequests) > remaining(qj.RemainingRequests)
+		}
+		return false
+	})
+	return l
+}
+
+// remaining returns v, or the most possible if v is not known.
+func remaining(v int) int {
+	if v < 0 {
+		return math.MaxInt
+	}
+	return v
+}
+
+// MemberStatus is the status of a member of a pool.
+type MemberStatus struct {
+	Member
+	// Inflight is the number of requests in flight to the member.
+	Inflight int
+	// Quota is the rate limit state of the member.
+	Quota Quota
+}
+
+// PoolStatus returns the status of each member of a Client made with NewPool(), in the order
+// they were passed. Other Clients return nil.
+func (c *Client) PoolStatus() []MemberStatus {
+	if c.pool == nil {
+		return nil
+	}
+	l := make([]MemberStatus, 0, len(c.pool.members))
+	for _, m := range c.pool.members {
+		l = append(l, MemberStatus{Member: m.Member, Inflight: int(m.inflight.Load()), Quota: m.Client.Quota(m.DeploymentID)})
+	}
+	return l
+}
+
+// failover returns true if a request that failed with err should be sent to another member.
+func failover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var (
+		jErr errors.JSON
+		sErr errors.StatusCode
+		nErr net.Error
+	)
+	switch {
+	case errors.As(err, &jErr):
+		return jErr.StatusCode == http.StatusTooManyRequests || jErr.StatusCode >= 500
+	case errors.As(err, &sErr):
+		return sErr.StatusCode == http.StatusTooManyRequests || sErr.StatusCode >= 500
+	case errors.As(err, &nErr):
+		return true
+	}
+	return false
+}
+
+// do calls f with each member in order until it succeeds or should not fail over.
+func do[T any](ctx context.Context, p *pool, f func(m *member) (T, error)) (T, error) {
+	var (
+		resp T
+		err  error
+	)
+	for _, m := range p.order() {
+		m.inflight.Add(1)
+		resp, err = f(m)
+		m.inflight.Add(-1)
+		if err == nil || !failover(ctx, err) {
+			return resp, err
+		}
+	}
+	return resp, err
+}
+
+func (p *pool) chat(ctx context.Context, req chat.Req) (chat.Resp, error) {
+	return do(ctx, p, func(m *member) (chat.Resp, error) {
+		return m.Client.Chat(ctx, m.DeploymentID, req)
+	})
+}
+
+func (p *pool) embeddings(ctx context.Context, req embeddings.Req) (embeddings.Resp, error) {
+	return do(ctx, p, func(m *member) (embeddings.Resp, error) {
+		return m.Client.Embeddings(ctx, m.DeploymentID, req)
+	})
+}
+
+func (p *pool) chatStream(ctx context.Context, req chat.Req) chan StreamRecv[chat.Resp] {
+	out := make(chan StreamRecv[chat.Resp], 1)
+	go func() {
+		defer close(out)
+
+		members := p.order()
+		for i, m := range members {
+			if !streamMember(ctx, m, req, out, i < len(members)-1) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// streamMember sends the chat stream of m to out. It returns true if the stream failed before
+// sending anything and the caller should fail over, which is only done if canFailover is set.
+// m is counted as in flight until its stream is drained, however streamMember returns.
+func streamMember(ctx context.Context, m *member, req chat.Req, out chan StreamRecv[chat.Resp], canFailover bool) bool {
+	m.inflight.Add(1)
+	defer m.inflight.Add(-1)
+
+	in := m.Client.ChatStream(ctx, m.DeploymentID, req)
+	defer func() {
+		for range in {
+		}
+	}()
+
+	first, ok := <-in
+	if !ok {
+		return false
+	}
+	if first.Err != nil && canFailover && failover(ctx, first.Err) {
+		return true
+	}
+	if !sendRecv(ctx, out, first) {
+		return false
+	}
+	for r := range in {
+		if !sendRecv(ctx, out, r) {
+			return false
+		}
+	}
+	return false
+}
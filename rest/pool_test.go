@@ -0,0 +1,280 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/completions"
+)
+
+// poolMember returns a Client whose requests are counted in calls under name and fail with
+// status if it is not 200.
+func poolMember(t *testing.T, name string, status int, mu *sync.Mutex, calls map[string]int) *Client {
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls[name]++
+		mu.Unlock()
+
+		header := http.Header{"Content-Type": []string{"application/json"}}
+		body := `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "` + name + `"}}]}`
+		b, _ := io.ReadAll(req.Body)
+		switch {
+		case status != http.StatusOK:
+			body = `{"error": {"code": "throttled"}}`
+		case strings.Contains(string(b), `"stream":true`):
+			header.Set("Content-Type", "text/event-stream")
+			body = `data: {"choices": [{"index": 0, "delta": {"content": "` + name + `"}}]}` + "\n\ndata: [DONE]\n\n"
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	c, err := New(name, auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPool(t *testing.T) {
+	mu := &sync.Mutex{}
+	req := chat.Req{Messages: []chat.SendMsg{{Role: chat.User, Content: "hi"}}}
+
+	// Round robin spreads requests evenly.
+	calls := map[string]int{}
+	p, err := NewPool(
+		RoundRobin,
+		Member{Client: poolMember(t, "east", http.StatusOK, mu, calls), DeploymentID: "gpt4"},
+		Member{Client: poolMember(t, "west", http.StatusOK, mu, calls), DeploymentID: "gpt4"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := p.Chat(context.Background(), "ignored", req); err != nil {
+			t.Fatalf("TestPool(round robin): got err == %s, want err == nil", err)
+		}
+	}
+	if calls["east"] != 2 || calls["west"] != 2 {
+		t.Errorf("TestPool(round robin): got calls %v, want 2 to each member", calls)
+	}
+
+	// A throttled member fails over to the next one.
+	calls = map[string]int{}
+	p, err = NewPool(
+		RoundRobin,
+		Member{Client: poolMember(t, "east", http.StatusTooManyRequests, mu, calls), DeploymentID: "gpt4"},
+		Member{Client: poolMember(t, "west", http.StatusOK, mu, calls), DeploymentID: "gpt4"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := p.Chat(context.Background(), "ignored", req)
+	if err != nil {
+		t.Fatalf("TestPool(failover): got err == %s, want err == nil", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "west" {
+		t.Errorf("TestPool(failover): got response from %q, want %q", got, "west")
+	}
+	if calls["east"] != 1 || calls["west"] != 1 {
+		t.Errorf("TestPool(failover): got calls %v, want 1 to each member", calls)
+	}
+
+	// Streams fail over before the first event.
+	calls = map[string]int{}
+	p, err = NewPool(
+		RoundRobin,
+		Member{Client: poolMember(t, "east", http.StatusServiceUnavailable, mu, calls), DeploymentID: "gpt4"},
+		Member{Client: poolMember(t, "west", http.StatusOK, mu, calls), DeploymentID: "gpt4"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	for r := range p.ChatStream(context.Background(), "ignored", req) {
+		if r.Err != nil {
+			t.Fatalf("TestPool(stream failover): got err == %s, want err == nil", r.Err)
+		}
+		for _, c := range r.Data.Choices {
+			text += c.Delta.Content
+		}
+	}
+	if text != "west" || calls["east"] != 1 {
+		t.Errorf("TestPool(stream failover): got text %q after calls %v, want %q after 1 call to east", text, calls, "west")
+	}
+
+	if _, err := p.Completions(context.Background(), "ignored", completions.Req{Prompt: []string{"hi"}}); err == nil {
+		t.Errorf("TestPool(completions): got err == nil, want err != nil")
+	}
+}
+
+func TestPoolStreamCancel(t *testing.T) {
+	// The member streams until its body is closed.
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		r, w := io.Pipe()
+		go func() {
+			for {
+				if _, err := w.Write([]byte(`data: {"choices": [{"index": 0, "delta": {"content": "x"}}]}` + "\n\n")); err != nil {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       r,
+			Request:    req,
+		}, nil
+	})
+	c, err := New("east", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPool(LeastLoaded, Member{Client: c, DeploymentID: "gpt4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := p.ChatStream(ctx, "ignored", chat.Req{Messages: []chat.SendMsg{{Role: chat.User, Content: "hi"}}})
+	if r := <-ch; r.Err != nil {
+		t.Fatalf("TestPoolStreamCancel: got err == %s, want err == nil", r.Err)
+	}
+	if got := p.PoolStatus()[0].Inflight; got != 1 {
+		t.Errorf("TestPoolStreamCancel: got Inflight == %d while streaming, want 1", got)
+	}
+	cancel()
+	// Stop reading, as a consumer that cancels usually does. The stream must not block on a
+	// send, so after this it can only have its buffered value left.
+	time.Sleep(100 * time.Millisecond)
+
+	if got := p.PoolStatus()[0].Inflight; got != 0 {
+		t.Errorf("TestPoolStreamCancel: got Inflight == %d after cancel, want 0", got)
+	}
+	closed := false
+	for i := 0; !closed; i++ {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				closed = true
+				break
+			}
+			if i > 0 {
+				t.Fatalf("TestPoolStreamCancel: got more than the buffered value after cancel, the stream kept sending")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("TestPoolStreamCancel: channel was not closed after cancel")
+		}
+	}
+}
+
+// quotaMember returns a Client whose responses report tokens remaining, or a 429 asking to retry
+// in 60 seconds if tokens is 0. Requests are counted in calls under name.
+func quotaMember(t *testing.T, name string, tokens int, mu *sync.Mutex, calls map[string]int) *Client {
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls[name]++
+		mu.Unlock()
+
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header: http.Header{
+				"Content-Type":                   []string{"application/json"},
+				"X-Ratelimit-Remaining-Tokens":   []string{strconv.Itoa(tokens)},
+				"X-Ratelimit-Remaining-Requests": []string{"10"},
+			},
+			Body:    io.NopCloser(strings.NewReader(`{"choices": [{"index": 0, "message": {"role": "assistant", "content": "` + name + `"}}]}`)),
+			Request: req,
+		}
+		if tokens == 0 {
+			resp.StatusCode = http.StatusTooManyRequests
+			resp.Header.Set("Retry-After", "60")
+			resp.Body = io.NopCloser(strings.NewReader(`{"error": {"code": "429"}}`))
+		}
+		return resp, nil
+	})
+	c, err := New(name, auth.Authorizer{ApiKey: name}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPoolQuota(t *testing.T) {
+	mu := &sync.Mutex{}
+	req := chat.Req{Messages: []chat.SendMsg{{Role: chat.User, Content: "hi"}}}
+
+	// Once quotas are known, MostRemaining sends everything to the member with the most tokens.
+	calls := map[string]int{}
+	p, err := NewPool(
+		MostRemaining,
+		Member{Client: quotaMember(t, "key1", 100, mu, calls), DeploymentID: "gpt4"},
+		Member{Client: quotaMember(t, "key2", 5000, mu, calls), DeploymentID: "gpt4"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 6; i++ {
+		if _, err := p.Chat(context.Background(), "ignored", req); err != nil {
+			t.Fatalf("TestPoolQuota(most remaining): got err == %s, want err == nil", err)
+		}
+	}
+	// The first two calls learn the quotas, one to each member.
+	if calls["key1"] != 1 || calls["key2"] != 5 {
+		t.Errorf("TestPoolQuota(most remaining): got calls %v, want 1 to key1 and 5 to key2", calls)
+	}
+	status := p.PoolStatus()
+	if len(status) != 2 || status[1].Quota.RemainingTokens != 5000 || status[1].Quota.RemainingRequests != 10 {
+		t.Errorf("TestPoolQuota(status): got %+v, want key2 with 5000 tokens and 10 requests", status)
+	}
+
+	// A throttled member is tried last until its Retry-After passes.
+	calls = map[string]int{}
+	p, err = NewPool(
+		RoundRobin,
+		Member{Client: quotaMember(t, "key1", 0, mu, calls), DeploymentID: "gpt4"},
+		Member{Client: quotaMember(t, "key2", 5000, mu, calls), DeploymentID: "gpt4"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := p.Chat(context.Background(), "ignored", req); err != nil {
+			t.Fatalf("TestPoolQuota(throttled): got err == %s, want err == nil", err)
+		}
+	}
+	if calls["key1"] != 1 || calls["key2"] != 4 {
+		t.Errorf("TestPoolQuota(throttled): got calls %v, want 1 to key1 and 4 to key2", calls)
+	}
+	if q := p.PoolStatus()[0].Quota; !q.Throttled(time.Now()) {
+		t.Errorf("TestPoolQuota(throttled): got Throttled() == false, want true for %+v", q)
+	}
+}
+
+func TestDeploymentFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/openai/deployments/gpt4/chat/completions", "gpt4"},
+		{"/gateway/openai/deployments/ada/embeddings", "ada"},
+		{"/openai/images/generations:submit", ""},
+	}
+	for _, test := range tests {
+		if got := deploymentFromPath(test.path); got != test.want {
+			t.Errorf("TestDeploymentFromPath(%s): got %q, want %q", test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// throttleWait is how long a Client is considered throttled after a 429 response without a
+// Retry-After header.
+const throttleWait = 5 * time.Second
+
+// Quota is the rate limit state of a Client, as reported by the service in its last response.
+type Quota struct {
+	// RemainingRequests is the number of requests remaining in the current rate limit window.
+	// This is -1 if it is not known.
+	RemainingRequests int
+	// RemainingTokens is the number of tokens remaining in the current rate limit window.
+	// This is -1 if it is not known.
+	RemainingTokens int
+	// ThrottledUntil is when the service said to retry after the last 429 (Too Many Requests)
+	// response. This is the zero value if the Client has not been throttled.
+	ThrottledUntil time.Time
+	// Updated is when the last response was received. This is the zero value if there
+	// has not been a response.
+	Updated time.Time
+}
+
+// Throttled returns true if the Client is throttled at now.
+func (q Quota) Throttled(now time.Time) bool {
+	return now.Before(q.ThrottledUntil)
+}
+
+// Quota returns the rate limit state of deploymentID from the last response of the service.
+// This differs for each deployment, resource and key, so it can be used to choose between Clients.
+// A Client made with NewPool() returns the zero value, use PoolStatus() instead.
+func (c *Client) Quota(deploymentID string) Quota {
+	if q, ok := c.quotas.Load(deploymentID); ok {
+		return q.(Quota)
+	}
+	return Quota{RemainingRequests: -1, RemainingTokens: -1}
+}
+
+// recordQuota records the rate limit state reported by resp for the deployment in addr.
+// Responses for requests not made to a deployment are ignored.
+func (c *Client) recordQuota(addr *url.URL, resp *http.Response, now time.Time) {
+	id := deploymentFromPath(addr.Path)
+	if id == "" {
+		return
+	}
+
+	meta := custom.NewResponseMeta(resp)
+	q := Quota{
+		RemainingRequests: meta.RemainingRequests,
+		RemainingTokens:   meta.RemainingTokens,
+		Updated:           now,
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok := retryAfter(resp.Header, now)
+		if !ok {
+			wait = throttleWait
+		}
+		q.ThrottledUntil = now.Add(wait)
+	}
+	c.quotas.Store(id, q)
+}
+
+// deploymentFromPath returns the deployment ID in a URL path such as
+// "/openai/deployments/gpt4/chat/completions", or "" if there is none.
+func deploymentFromPath(p string) string {
+	_, after, ok := strings.Cut(p, "/deployments/")
+	if !ok {
+		return ""
+	}
+	id, _, _ := strings.Cut(after, "/")
+	return id
+}
@@ -0,0 +1,244 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// ErrRateLimited is returned when RateLimit.Reject is set and a request would exceed the limit.
+var ErrRateLimited = errors.New("client side rate limit exceeded")
+
+// RateLimit is a client side limit on the requests and tokens sent to each deployment, which should
+// match the quota of the deployment. Limiting on the client avoids 429 responses and the retries
+// they cause. The limit is a token bucket per deployment that refills continuously.
+type RateLimit struct {
+	// RequestsPerMinute is the number of requests allowed per minute. 0 means no limit.
+	RequestsPerMinute int
+	// TokensPerMinute is the number of tokens allowed per minute. 0 means no limit. The tokens for a
+	// request are estimated before it is sent as the request size / 4 plus its max_tokens, which is
+	// how the service counts tokens against the quota.
+	TokensPerMinute int
+	// Reject causes requests that would exceed the limit to fail with ErrRateLimited instead of
+	// waiting until they can be sent.
+	Reject bool
+}
+
+func (r RateLimit) validate() error {
+	if r.RequestsPerMinute < 0 || r.TokensPerMinute < 0 {
+		return fmt.Errorf("RateLimit values cannot be < 0")
+	}
+	if r.RequestsPerMinute == 0 && r.TokensPerMinute == 0 {
+		return fmt.Errorf("RateLimit must set RequestsPerMinute or TokensPerMinute")
+	}
+	return nil
+}
+
+// WithRateLimit sets a client side rate limit for each deployment. The remaining budget is also
+// lowered to the x-ratelimit-remaining-requests and x-ratelimit-remaining-tokens headers the
+// service returns, so the limit adapts if other clients share the quota.
+func WithRateLimit(limit RateLimit) Option {
+	return func(client *Client) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		client.limiter = newLimiter(limit)
+		return nil
+	}
+}
+
+// limiter implements RateLimit.
+type limiter struct {
+	limit RateLimit
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*limitBuckets
+}
+
+type limitBuckets struct {
+	requests, tokens *bucket
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	return &limiter{limit: limit, now: time.Now, buckets: map[string]*limitBuckets{}}
+}
+
+// wait blocks until msg can be sent to deploymentID. This is safe to call on a nil *limiter.
+func (l *limiter) wait(ctx context.Context, deploymentID string, msg []byte) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	b := l.get(deploymentID)
+	now := l.now()
+	var wait time.Duration
+	if b.requests != nil {
+		wait = b.requests.take(now, 1)
+	}
+	if b.tokens != nil {
+		if w := b.tokens.take(now, estimateTokens(msg)); w > wait {
+			wait = w
+		}
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	undo := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if b.requests != nil {
+			b.requests.give(1)
+		}
+		if b.tokens != nil {
+			b.tokens.give(estimateTokens(msg))
+		}
+	}
+
+	if l.limit.Reject {
+		undo()
+		return ErrRateLimited
+	}
+	if deadline, ok := ctx.Deadline(); ok && l.now().Add(wait).After(deadline) {
+		undo()
+		return fmt.Errorf("%w: would need to wait %v, which is past the context deadline", ErrRateLimited, wait)
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		undo()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// update lowers the budget of deploymentID to what the service reports as remaining. This is
+// safe to call on a nil *limiter.
+func (l *limiter) update(deploymentID string, meta custom.ResponseMeta) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.get(deploymentID)
+	now := l.now()
+	if b.requests != nil && meta.RemainingRequests >= 0 {
+		b.requests.lower(now, float64(meta.RemainingRequests))
+	}
+	if b.tokens != nil && meta.RemainingTokens >= 0 {
+		b.tokens.lower(now, float64(meta.RemainingTokens))
+	}
+}
+
+// get returns the buckets for deploymentID. l.mu must be held.
+func (l *limiter) get(deploymentID string) *limitBuckets {
+	b, ok := l.buckets[deploymentID]
+	if ok {
+		return b
+	}
+	b = &limitBuckets{}
+	now := l.now()
+	if l.limit.RequestsPerMinute > 0 {
+		b.requests = newBucket(now, l.limit.RequestsPerMinute)
+	}
+	if l.limit.TokensPerMinute > 0 {
+		b.tokens = newBucket(now, l.limit.TokensPerMinute)
+	}
+	l.buckets[deploymentID] = b
+	return b
+}
+
+// estimateTokens estimates the tokens the service counts against the quota for msg.
+// Reasoning models are sent max_completion_tokens instead of max_tokens, so that is used
+// if it is set.
+func estimateTokens(msg []byte) float64 {
+	var req struct {
+		MaxTokens           int `json:"max_tokens"`
+		MaxCompletionTokens int `json:"max_completion_tokens"`
+		N                   int `json:"n"`
+	}
+	// Errors are ignored, not every request has these fields.
+	json.Unmarshal(msg, &req)
+	n := req.N
+	if n < 1 {
+		n = 1
+	}
+	limit := req.MaxTokens
+	if req.MaxCompletionTokens > 0 {
+		limit = req.MaxCompletionTokens
+	}
+	return float64(len(msg))/4 + float64(limit*n)
+}
+
+// bucket is a token bucket that refills continuously. The level can go negative, which
+// reserves capacity for requests that are waiting.
+type bucket struct {
+	capacity float64
+	perSec   float64
+	level    float64
+	last     time.Time
+}
+
+func newBucket(now time.Time, perMinute int) *bucket {
+	return &bucket{
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		level:    float64(perMinute),
+		last:     now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.level += now.Sub(b.last).Seconds() * b.perSec
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+	b.last = now
+}
+
+// take removes n from the bucket and returns how long to wait until the bucket is no longer
+// negative. A request larger than the capacity is treated as the capacity, otherwise it could never be sent.
+func (b *bucket) take(now time.Time, n float64) time.Duration {
+	b.refill(now)
+	if n > b.capacity {
+		n = b.capacity
+	}
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.perSec * float64(time.Second))
+}
+
+// give returns n to the bucket.
+func (b *bucket) give(n float64) {
+	if n > b.capacity {
+		n = b.capacity
+	}
+	b.level += n
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+}
+
+// lower sets the level to remaining if that is lower.
+func (b *bucket) lower(now time.Time, remaining float64) {
+	b.refill(now)
+	if remaining < b.level {
+		b.level = remaining
+	}
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := newLimiter(RateLimit{RequestsPerMinute: 2, TokensPerMinute: 1000, Reject: true})
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	msg := []byte(`{"max_tokens": 100}`)
+	for i := 0; i < 2; i++ {
+		if err := l.wait(ctx, "d", msg); err != nil {
+			t.Fatalf("TestLimiter: request %d got err == %s, want err == nil", i, err)
+		}
+	}
+	if err := l.wait(ctx, "d", msg); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("TestLimiter: third request got err == %v, want ErrRateLimited", err)
+	}
+	if err := l.wait(ctx, "other", msg); err != nil {
+		t.Errorf("TestLimiter: other deployment got err == %s, want err == nil", err)
+	}
+
+	// After 30 seconds one request has refilled.
+	now = now.Add(30 * time.Second)
+	if err := l.wait(ctx, "d", msg); err != nil {
+		t.Errorf("TestLimiter: request after refill got err == %s, want err == nil", err)
+	}
+
+	// The service says there are no tokens left, even though our budget has room.
+	now = now.Add(time.Minute)
+	l.update("d", custom.ResponseMeta{RemainingRequests: -1, RemainingTokens: 0})
+	if err := l.wait(ctx, "d", msg); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("TestLimiter: request after update got err == %v, want ErrRateLimited", err)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		desc string
+		msg  string
+		want float64
+	}{
+		{desc: "No max", msg: `{"input":"hello"}`, want: 4.25},
+		{desc: "max_tokens", msg: `{"max_tokens":100}`, want: 104.5},
+		{desc: "max_tokens with n", msg: `{"max_tokens":100,"n":2}`, want: 206},
+		{desc: "max_completion_tokens", msg: `{"max_completion_tokens":1000}`, want: 1007.5},
+		{desc: "max_completion_tokens with n", msg: `{"max_completion_tokens":1000,"n":3}`, want: 3009},
+		{desc: "max_completion_tokens over max_tokens", msg: `{"max_tokens":10,"max_completion_tokens":1000}`, want: 1011.5},
+	}
+
+	for _, test := range tests {
+		if got := estimateTokens([]byte(test.msg)); got != test.want {
+			t.Errorf("TestEstimateTokens(%s): got %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := newLimiter(RateLimit{RequestsPerMinute: 600})
+	ctx := context.Background()
+
+	// The bucket starts full, so drain it.
+	l.mu.Lock()
+	l.get("d").requests.level = 0
+	l.mu.Unlock()
+
+	start := time.Now()
+	if err := l.wait(ctx, "d", nil); err != nil {
+		t.Fatalf("TestLimiterWait: got err == %s, want err == nil", err)
+	}
+	// 600 per minute is one every 100ms.
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("TestLimiterWait: waited %v, want about 100ms", d)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, "d", nil); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("TestLimiterWait: got err == %v with a short deadline, want ErrRateLimited", err)
+	}
+}
@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// resourceDo sends body, if not nil, to the path made of elems using the et template and
+// decodes the response into a T. This is used by APIs that act on resources of the service,
+// such as assistants and files, instead of a deployment.
+func resourceDo[T any](ctx context.Context, c *Client, et endpointType, method string, elems []string, query url.Values, body any) (T, custom.ResponseMeta, error) {
+	var zero T
+
+	u, err := c.resourceURL(et, elems, query)
+	if err != nil {
+		return zero, custom.ResponseMeta{}, err
+	}
+
+	var msg []byte
+	var header http.Header
+	if body != nil {
+		msg, err = json.Marshal(body)
+		if err != nil {
+			return zero, custom.ResponseMeta{}, err
+		}
+		header = http.Header{"Content-Type": []string{"application/json"}}
+	}
+	if etag := ifMatch(ctx); etag != "" && method != http.MethodGet {
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Set("If-Match", etag)
+	}
+
+	start := time.Now()
+	resp, err := c.doHeader(ctx, method, u, msg, header)
+	// These APIs do not use a deployment, so stats are recorded under the template name.
+	c.stats.record(string(et), len(msg), 0, time.Since(start), err)
+	if err != nil {
+		return zero, custom.ResponseMeta{}, err
+	}
+	defer resp.Body.Close()
+
+	meta := custom.NewResponseMeta(resp)
+	var v T
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return zero, meta, fmt.Errorf("problem unmarshaling the response body: %w", err)
+	}
+	return v, meta, nil
+}
+
+// resourceURL returns the URL of the path made of elems using the et template. These are not
+// cached as most paths hold IDs.
+func (c *Client) resourceURL(et endpointType, elems []string, query url.Values) (*url.URL, error) {
+	escaped := make([]string, 0, len(elems))
+	for _, e := range elems {
+		if e == "" {
+			return nil, fmt.Errorf("IDs cannot be empty")
+		}
+		escaped = append(escaped, url.PathEscape(e))
+	}
+	vars := c.vars
+	vars.DeploymentID = strings.Join(escaped, "/")
+	u, err := c.endpoints.set(et, vars)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		q := u.Query()
+		for k, v := range query {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u, nil
+}
@@ -1,36 +1,75 @@
 // Package rest provides access to the Azure OpenAI service via the REST API. This is
 // a low-level package that provides access to the REST API directly. Most normal use
 // cases will use the higher-level azopenai.Client and its sub-clients.
+//
+// This package imports expvar for WithExpvar(), and importing expvar registers the /debug/vars
+// handler on http.DefaultServeMux. This happens whether or not WithExpvar() is used, so a program
+// that serves http.DefaultServeMux on a public address exposes the process's command line and
+// memory stats there. Serve your own http.ServeMux instead.
 package rest
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/cache"
 	"github.com/element-of-surprise/azopenai/errors"
 	"github.com/element-of-surprise/azopenai/rest/messages/chat"
 	"github.com/element-of-surprise/azopenai/rest/messages/completions"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
 	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
 )
 
-// APIVersion represents the version of the Azure OpenAI service this client is using.
+// APIVersion represents the default version of the Azure OpenAI service this client is using.
+// This can be changed with WithAPIVersion() or ContextWithAPIVersion().
 const APIVersion = "2023-03-15-preview"
 
+// ExtensionsAPIVersion represents the version of the Azure OpenAI service used for chat requests
+// with data sources, which are not available in APIVersion.
+const ExtensionsAPIVersion = "2023-08-01-preview"
+
+// IngestionAPIVersion represents the version of the Azure OpenAI service used for ingestion
+// jobs, which are not available in APIVersion.
+const IngestionAPIVersion = "2023-10-01-preview"
+
+// ImagesAPIVersion represents the version of the Azure OpenAI service used for image generation,
+// which is not available in APIVersion.
+const ImagesAPIVersion = "2023-06-01-preview"
+
+// AudioAPIVersion represents the version of the Azure OpenAI service used for audio transcription
+// and translation, which are not available in APIVersion.
+const AudioAPIVersion = "2023-09-01-preview"
+
+// AssistantsAPIVersion represents the version of the Azure OpenAI service used for the
+// Assistants API, which is not available in APIVersion.
+const AssistantsAPIVersion = "2024-05-01-preview"
+
+// FilesAPIVersion represents the version of the Azure OpenAI service used for the Files and
+// Batch APIs, which are not available in APIVersion.
+const FilesAPIVersion = "2024-10-21"
+
 type templVars struct {
-	ResourceName string
-	DeploymentID string
-	APIVersion   string
+	ResourceName         string
+	BaseURL              string
+	DeploymentID         string
+	APIVersion           string
+	ImagesAPIVersion     string
+	IngestionAPIVersion  string
+	ExtensionsAPIVersion string
+	AudioAPIVersion      string
+	AssistantsAPIVersion string
+	FilesAPIVersion      string
 }
 
 type deployments map[string]*url.URL
@@ -48,19 +87,43 @@ const (
 	completionsTmpl endpointType = "completions"
 	embeddingsTmpl  endpointType = "embeddings"
 	chatTmpl        endpointType = "chat"
+	imagesTmpl      endpointType = "images"
+	extChatTmpl     endpointType = "extensionsChat"
+	ingestionTmpl   endpointType = "ingestion"
+	transcribeTmpl  endpointType = "transcriptions"
+	translateTmpl   endpointType = "translations"
+	assistantsTmpl  endpointType = "assistants"
+	filesTmpl       endpointType = "files"
+	batchesTmpl     endpointType = "batches"
 )
 
 func newEndpoints() *endpoints {
 	const (
-		completions = "https://{{.ResourceName}}.openai.azure.com/openai/deployments/{{.DeploymentID}}/completions?api-version={{.APIVersion}}"
-		embeddings  = "https://{{.ResourceName}}.openai.azure.com/openai/deployments/{{.DeploymentID}}/embeddings?api-version={{.APIVersion}}"
-		chat        = "https://{{.ResourceName}}.openai.azure.com/openai/deployments/{{.DeploymentID}}/chat/completions?api-version={{.APIVersion}}"
+		completions = "{{.BaseURL}}/openai/deployments/{{.DeploymentID}}/completions?api-version={{.APIVersion}}"
+		embeddings  = "{{.BaseURL}}/openai/deployments/{{.DeploymentID}}/embeddings?api-version={{.APIVersion}}"
+		chat        = "{{.BaseURL}}/openai/deployments/{{.DeploymentID}}/chat/completions?api-version={{.APIVersion}}"
+		extChat     = "{{.BaseURL}}/openai/deployments/{{.DeploymentID}}/extensions/chat/completions?api-version={{.ExtensionsAPIVersion}}"
+		ingestion   = "{{.BaseURL}}/openai/extensions/on-your-data/ingestion-jobs/{{.DeploymentID}}?api-version={{.IngestionAPIVersion}}"
+		images      = "{{.BaseURL}}/openai/images/generations:submit?api-version={{.ImagesAPIVersion}}"
+		transcribe  = "{{.BaseURL}}/openai/deployments/{{.DeploymentID}}/audio/transcriptions?api-version={{.AudioAPIVersion}}"
+		translate   = "{{.BaseURL}}/openai/deployments/{{.DeploymentID}}/audio/translations?api-version={{.AudioAPIVersion}}"
+		assistants  = "{{.BaseURL}}/openai/{{.DeploymentID}}?api-version={{.AssistantsAPIVersion}}"
+		files       = "{{.BaseURL}}/openai/{{.DeploymentID}}?api-version={{.FilesAPIVersion}}"
 	)
 
 	temps := &template.Template{}
 	temps = template.Must(temps.New(string(completionsTmpl)).Parse(completions))
 	temps = template.Must(temps.New(string(embeddingsTmpl)).Parse(embeddings))
 	temps = template.Must(temps.New(string(chatTmpl)).Parse(chat))
+	temps = template.Must(temps.New(string(imagesTmpl)).Parse(images))
+	temps = template.Must(temps.New(string(extChatTmpl)).Parse(extChat))
+	temps = template.Must(temps.New(string(ingestionTmpl)).Parse(ingestion))
+	temps = template.Must(temps.New(string(transcribeTmpl)).Parse(transcribe))
+	temps = template.Must(temps.New(string(translateTmpl)).Parse(translate))
+	temps = template.Must(temps.New(string(assistantsTmpl)).Parse(assistants))
+	// Batches share the files template, they are a separate type so their stats are separate.
+	temps = template.Must(temps.New(string(filesTmpl)).Parse(files))
+	temps = template.Must(temps.New(string(batchesTmpl)).Parse(files))
 
 	return &endpoints{
 		temps: temps,
@@ -69,6 +132,10 @@ func newEndpoints() *endpoints {
 }
 
 func (e *endpoints) url(eType endpointType, deploymentID string, vars templVars) (*url.URL, error) {
+	if e == nil {
+		// Clients made with NewPool() don't have endpoints.
+		return nil, fmt.Errorf("a pool Client only supports Chat(), ChatStream() and Embeddings()")
+	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -78,14 +145,16 @@ func (e *endpoints) url(eType endpointType, deploymentID string, vars templVars)
 		e.m[eType] = deploy
 	}
 
-	u := deploy[deploymentID]
+	// The API version can be changed per call, so it is part of the key.
+	key := deploymentID + "@" + vars.APIVersion
+	u := deploy[key]
 	if u == nil {
 		vars.DeploymentID = deploymentID
 		u, err := e.set(eType, vars)
 		if err != nil {
 			return nil, err
 		}
-		e.m[eType][deploymentID] = u
+		e.m[eType][key] = u
 		return u, nil
 	}
 	return u, nil
@@ -102,7 +171,7 @@ func (e *endpoints) set(et endpointType, vars templVars) (*url.URL, error) {
 
 // Client provides access to the Azure OpenAI service via the REST API.
 type Client struct {
-	auth   auth.Authorizer
+	auth   auth.Provider
 	client *http.Client
 
 	vars templVars
@@ -112,12 +181,60 @@ type Client struct {
 	chatURL        *url.URL
 
 	endpoints *endpoints
+
+	// headers are added to every request.
+	headers http.Header
+	// stats are published if WithExpvar() or PublishExpvar() was used. This is nil for a Client
+	// made with NewPool().
+	stats *stats
+	// expvar is the name passed to WithExpvar().
+	expvar string
+	// conns are the connection statistics. This is nil for a Client made with NewPool().
+	conns *connStats
+	// retry is the policy for retrying failed requests.
+	retry RetryPolicy
+	// limiter is set if WithRateLimit() was used.
+	limiter *limiter
+	// cloud is the domain used to build the base URL if WithEndpoint() was not used.
+	cloud Cloud
+	// logger is set if WithLogger() was used.
+	logger *slog.Logger
+	// logBodies is set if WithLogBodies() was used.
+	logBodies bool
+	// logCurl is set if WithLogCurl() was used.
+	logCurl bool
+	// redactHeaders are redacted in logs in addition to DefaultRedactedHeaders. These are the
+	// headers added with WithHeader() and those passed to WithRedactHeaders().
+	redactHeaders []string
+	// pool is set if the Client was made with NewPool().
+	pool *pool
+	// cache is set if WithCache() was used.
+	cache cache.Cache
+	// cacheNS is the namespace passed to WithCache().
+	cacheNS string
+	// dedup is set if WithDedup() was used.
+	dedup *flightGroup
+	// deprecationHandler is set if WithDeprecationHandler() was used.
+	deprecationHandler func(context.Context, Deprecation)
+	// quotas holds the Quota of each deployment from its last response, see Quota().
+	quotas sync.Map
+	// deprecations holds the Deprecation.API values already reported by Deprecated().
+	deprecations sync.Map
+	// compress is set by WithDecoder() and WithoutCompression().
+	compress compression
+	// timeout is set by WithTimeout().
+	timeout time.Duration
+	// idleTimeout is set by WithStreamIdleTimeout().
+	idleTimeout time.Duration
+	// transport is set by WithProxy(), WithTLSConfig(), WithDialer() and WithConnPool().
+	transport transport
 }
 
 // Option provides optional arguments to the New constructor.
 type Option func(*Client) error
 
-// WithClient sets the HTTP client to use for requests.
+// WithClient sets the HTTP client to use for requests. Without it, the Client makes one with
+// a transport tuned by WithProxy(), WithTLSConfig(), WithDialer() and WithConnPool().
 func WithClient(c *http.Client) Option {
 	return func(client *Client) error {
 		client.client = c
@@ -125,30 +242,221 @@ func WithClient(c *http.Client) Option {
 	}
 }
 
-// New creates a new instance of the Client type.
-func New(resourceName string, auth auth.Authorizer, options ...Option) (*Client, error) {
-	var err error
-	auth, err = auth.Validate()
+// WithHeader adds a header that is sent with every request. This is useful for gateways
+// that require extra headers. Authorization headers are set by the auth.Provider and
+// should not be set here. As these headers often hold credentials, their values are
+// redacted in logs and curl commands.
+func WithHeader(key, value string) Option {
+	return withHeader(key, value, true)
+}
+
+// withHeader implements WithHeader(). If redact is set, the value of the header is redacted
+// in logs and curl commands.
+func withHeader(key, value string, redact bool) Option {
+	return func(client *Client) error {
+		if key == "" {
+			return fmt.Errorf("header key cannot be empty")
+		}
+		if client.headers == nil {
+			client.headers = http.Header{}
+		}
+		client.headers.Add(key, value)
+		if redact {
+			client.redactHeaders = append(client.redactHeaders, key)
+		}
+		return nil
+	}
+}
+
+// WithOrganization sets the OpenAI-Organization header on every request. This is only used
+// by OpenAI compatible gateways that multiplex organizations, the Azure OpenAI service ignores it.
+func WithOrganization(org string) Option {
+	return withHeader("OpenAI-Organization", org, false)
+}
+
+// WithProject sets the OpenAI-Project header on every request. This is only used
+// by OpenAI compatible gateways that multiplex projects, the Azure OpenAI service ignores it.
+func WithProject(project string) Option {
+	return withHeader("OpenAI-Project", project, false)
+}
+
+// Cloud is the domain of the Azure OpenAI service in an Azure cloud.
+type Cloud string
+
+const (
+	// AzurePublic is the Azure public cloud. This is the default.
+	AzurePublic Cloud = "openai.azure.com"
+	// AzureGovernment is the Azure US Government cloud.
+	AzureGovernment Cloud = "openai.azure.us"
+	// AzureChina is the Azure China cloud operated by 21Vianet.
+	AzureChina Cloud = "openai.azure.cn"
+)
+
+// WithCloud sets the Azure cloud the resource is in. Endpoints will be
+// https://[resourceName].[cloud]. When using an auth.AzIdentity, remember to also request
+// a token scope for that cloud. WithEndpoint() takes precedence over this.
+func WithCloud(cloud Cloud) Option {
+	return func(client *Client) error {
+		if cloud == "" {
+			return fmt.Errorf("cloud cannot be empty")
+		}
+		client.cloud = cloud
+		return nil
+	}
+}
+
+// WithEndpoint sets the base URL of the service, such as "https://myresource.privatelink.example.com".
+// This is used for custom domains, private links and gateways. The resource name passed to New()
+// is ignored. The URL may have a path, which requests are made under.
+func WithEndpoint(endpoint string) Option {
+	return func(client *Client) error {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("endpoint %q is not a valid URL: %w", endpoint, err)
+		}
+		if u.Scheme != "https" && u.Scheme != "http" {
+			return fmt.Errorf("endpoint %q must use http or https", endpoint)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("endpoint %q must have a host", endpoint)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("endpoint %q cannot have a query or fragment", endpoint)
+		}
+		client.vars.BaseURL = strings.TrimSuffix(u.String(), "/")
+		return nil
+	}
+}
+
+// WithAPIVersion sets the API version used for completions, embeddings and chat requests,
+// such as "2024-02-01". This allows using newer service versions than APIVersion. Note that
+// newer versions may return fields these types do not decode.
+func WithAPIVersion(version string) Option {
+	return func(client *Client) error {
+		if err := validAPIVersion(version); err != nil {
+			return err
+		}
+		client.vars.APIVersion = version
+		return nil
+	}
+}
+
+// APIVersions are the API versions a Client uses for each group of APIs.
+type APIVersions struct {
+	// Default is used for completions, embeddings and chat, see WithAPIVersion().
+	Default string
+	// Extensions is used for chat requests with data sources.
+	Extensions string
+	// Ingestion is used for ingestion jobs.
+	Ingestion string
+	// Images is used for image generation.
+	Images string
+	// Audio is used for audio transcription and translation.
+	Audio string
+	// Assistants is used for the Assistants API.
+	Assistants string
+	// Files is used for the Files and Batch APIs.
+	Files string
+}
+
+// APIVersions returns the API versions the Client uses when the Context does not override them.
+// A Client made with NewPool() returns the zero value, as its members can differ.
+func (c *Client) APIVersions() APIVersions {
+	return APIVersions{
+		Default:    c.vars.APIVersion,
+		Extensions: c.vars.ExtensionsAPIVersion,
+		Ingestion:  c.vars.IngestionAPIVersion,
+		Images:     c.vars.ImagesAPIVersion,
+		Audio:      c.vars.AudioAPIVersion,
+		Assistants: c.vars.AssistantsAPIVersion,
+		Files:      c.vars.FilesAPIVersion,
+	}
+}
+
+type apiVersionKey struct{}
+
+// ContextWithAPIVersion returns a Context that overrides the API version for completions,
+// embeddings and chat requests made with it. This takes precedence over WithAPIVersion().
+func ContextWithAPIVersion(ctx context.Context, version string) (context.Context, error) {
+	if err := validAPIVersion(version); err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, apiVersionKey{}, version), nil
+}
+
+// APIVersionFromContext returns the API version set with ContextWithAPIVersion().
+func APIVersionFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(apiVersionKey{}).(string)
+	return v, ok
+}
+
+func validAPIVersion(version string) error {
+	if version == "" {
+		return fmt.Errorf("API version cannot be empty")
+	}
+	if url.QueryEscape(version) != version {
+		return fmt.Errorf("API version %q contains invalid characters", version)
+	}
+	return nil
+}
+
+// varsFor returns the template variables for a request made with ctx.
+func (c *Client) varsFor(ctx context.Context) templVars {
+	vars := c.vars
+	if v, ok := APIVersionFromContext(ctx); ok {
+		vars.APIVersion = v
+	}
+	return vars
+}
+
+// New creates a new instance of the Client type. provider is usually an auth.Authorizer, but can be
+// any auth.Provider, such as one for an API gateway.
+func New(resourceName string, provider auth.Provider, options ...Option) (*Client, error) {
+	provider, err := auth.Validate(provider)
 	if err != nil {
 		return nil, err
 	}
 
 	c := &Client{
 		vars: templVars{
-			ResourceName: resourceName,
-			APIVersion:   APIVersion,
+			ResourceName:     resourceName,
+			APIVersion:       APIVersion,
+			ImagesAPIVersion: ImagesAPIVersion,
+
+			ExtensionsAPIVersion: ExtensionsAPIVersion,
+			IngestionAPIVersion:  IngestionAPIVersion,
+			AudioAPIVersion:      AudioAPIVersion,
+			AssistantsAPIVersion: AssistantsAPIVersion,
+			FilesAPIVersion:      FilesAPIVersion,
 		},
 		endpoints: newEndpoints(),
-		auth:      auth,
+		auth:      provider,
+		cloud:     AzurePublic,
+		conns:     &connStats{},
 	}
 	for _, o := range options {
 		if err := o(c); err != nil {
 			return nil, err
 		}
 	}
+	if c.vars.BaseURL == "" {
+		if resourceName == "" {
+			return nil, fmt.Errorf("resourceName cannot be empty unless WithEndpoint() is used")
+		}
+		c.vars.BaseURL = "https://" + resourceName + "." + string(c.cloud)
+	}
 
-	if c.client == nil {
-		c.client = &http.Client{}
+	switch {
+	case c.client == nil:
+		c.client = c.transport.client()
+	case c.transport.set:
+		return nil, fmt.Errorf("WithProxy(), WithTLSConfig(), WithDialer() and WithConnPool() cannot be used with WithClient(), set them on its Transport")
+	}
+	c.stats = newStats(c.conns)
+	if c.expvar != "" {
+		if err := c.stats.publish(c.expvar); err != nil {
+			return nil, fmt.Errorf("WithExpvar: %w", err)
+		}
 	}
 
 	return c, nil
@@ -159,16 +467,16 @@ var requestsBuff = newBufferPool()
 
 // Complete sends a request to the Azure OpenAI service to complete the given prompt.
 func (c *Client) Completions(ctx context.Context, deploymentID string, req completions.Req) (completions.Resp, error) {
-	u, err := c.endpoints.url(completionsTmpl, deploymentID, c.vars)
+	u, err := c.endpoints.url(completionsTmpl, deploymentID, c.varsFor(ctx))
 	if err != nil {
 		return completions.Resp{}, err
 	}
 
-	b, err := json.Marshal(req)
+	b, err := marshalReq(ctx, req)
 	if err != nil {
 		return completions.Resp{}, err
 	}
-	resp, err := c.send(ctx, u, b)
+	resp, meta, err := c.sendCached(ctx, deploymentID, u, b)
 	if err != nil {
 		return completions.Resp{}, err
 	}
@@ -177,6 +485,7 @@ func (c *Client) Completions(ctx context.Context, deploymentID string, req compl
 	if err := json.Unmarshal(resp, &msg); err != nil {
 		return completions.Resp{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
 	}
+	msg.Meta = meta
 	return msg, nil
 }
 
@@ -184,55 +493,46 @@ func (c *Client) Completions(ctx context.Context, deploymentID string, req compl
 // to the request, it will stream the results back to the client. The client can stop the stream by cancelling
 // the context.
 func (c *Client) CompletionsStream(ctx context.Context, deploymentID string, req completions.Req) chan StreamRecv[completions.Resp] {
-	ch := make(chan StreamRecv[completions.Resp], 1)
-
-	u, err := c.endpoints.url(completionsTmpl, deploymentID, c.vars)
+	u, err := c.endpoints.url(completionsTmpl, deploymentID, c.varsFor(ctx))
 	if err != nil {
-		ch <- StreamRecv[completions.Resp]{Err: err}
-		return ch
+		return streamErr[completions.Resp](err)
 	}
 
 	req.Stream = true
-	b, err := json.Marshal(req)
+	b, err := marshalReq(ctx, req)
 	if err != nil {
-		ch <- StreamRecv[completions.Resp]{Err: err}
-		return ch
+		return streamErr[completions.Resp](err)
 	}
 
-	go func() {
-		defer close(ch)
-
-		responses, err := c.stream(ctx, u, b)
-		if err != nil {
-			ch <- StreamRecv[completions.Resp]{Err: err}
-			return
-		}
-
-		for response := range responses {
-			var msg completions.Resp
-			if err := json.Unmarshal(response.Data, &msg); err != nil {
-				ch <- StreamRecv[completions.Resp]{Err: fmt.Errorf("problem unmarshaling the response body: %w", err)}
-				return
-			}
-			ch <- StreamRecv[completions.Resp]{Data: msg}
-		}
-	}()
+	return decodeStream(ctx, c, deploymentID, u, b, func(r completions.Resp, m custom.ResponseMeta) completions.Resp {
+		r.Meta = m
+		return r
+	})
+}
 
-	return ch
+// OpenCompletionsStream is the same as CompletionsStream, except it returns a Stream that can be
+// closed to stop receiving and release the connection. The Stream must be closed.
+func (c *Client) OpenCompletionsStream(ctx context.Context, deploymentID string, req completions.Req) *Stream[completions.Resp] {
+	return newStream(ctx, func(ctx context.Context) chan StreamRecv[completions.Resp] {
+		return c.CompletionsStream(ctx, deploymentID, req)
+	})
 }
 
 // Embeddings sends a request to the Azure OpenAI service to get the embeddings for the given set of data.
 func (c *Client) Embeddings(ctx context.Context, deploymentID string, req embeddings.Req) (embeddings.Resp, error) {
-	u, err := c.endpoints.url(embeddingsTmpl, deploymentID, c.vars)
+	if c.pool != nil {
+		return c.pool.embeddings(ctx, req)
+	}
+	u, err := c.endpoints.url(embeddingsTmpl, deploymentID, c.varsFor(ctx))
 	if err != nil {
 		return embeddings.Resp{}, err
 	}
 
-	b, err := json.Marshal(req)
+	b, err := marshalReq(ctx, req)
 	if err != nil {
 		return embeddings.Resp{}, err
 	}
-	resp, err := c.send(ctx, u, b)
+	resp, meta, err := c.sendCached(ctx, deploymentID, u, b)
 	if err != nil {
 		return embeddings.Resp{}, err
 	}
@@ -241,6 +541,7 @@ func (c *Client) Embeddings(ctx context.Context, deploymentID string, req embedd
 	if err := json.Unmarshal(resp, &msg); err != nil {
 		return embeddings.Resp{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
 	}
+	msg.Meta = meta
 
 	sort.Slice(msg.Data, func(i, j int) bool {
 		return msg.Data[i].Index < msg.Data[j].Index
@@ -251,16 +552,19 @@ func (c *Client) Embeddings(ctx context.Context, deploymentID string, req embedd
 
 // Chat sends a request to the Azure OpenAI service to get responses to chat messages for the given set of data.
 func (c *Client) Chat(ctx context.Context, deploymentID string, req chat.Req) (chat.Resp, error) {
-	u, err := c.endpoints.url(chatTmpl, deploymentID, c.vars)
+	if c.pool != nil {
+		return c.pool.chat(ctx, req)
+	}
+	u, err := c.chatEndpoint(ctx, deploymentID, req)
 	if err != nil {
 		return chat.Resp{}, err
 	}
 
-	b, err := json.Marshal(req)
+	b, err := marshalReq(ctx, req)
 	if err != nil {
 		return chat.Resp{}, err
 	}
-	resp, err := c.send(ctx, u, b)
+	resp, meta, err := c.sendCached(ctx, deploymentID, u, b)
 	if err != nil {
 		return chat.Resp{}, err
 	}
@@ -269,6 +573,7 @@ func (c *Client) Chat(ctx context.Context, deploymentID string, req chat.Req) (c
 	if err := json.Unmarshal(resp, &msg); err != nil {
 		return chat.Resp{}, fmt.Errorf("problem unmarshaling the response body: %w", err)
 	}
+	msg.Meta = meta
 
 	sort.Slice(msg.Choices, func(i, j int) bool {
 		return msg.Choices[i].Index < msg.Choices[j].Index
@@ -277,114 +582,142 @@ func (c *Client) Chat(ctx context.Context, deploymentID string, req chat.Req) (c
 	return msg, nil
 }
 
-func (c *Client) send(ctx context.Context, addr *url.URL, msg []byte) ([]byte, error) {
-	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, "", nil)
-	if err != nil {
-		return nil, err
-	}
-	hreq.Host = addr.Host
-	hreq.URL = addr
-
-	if err := c.auth.Authorize(ctx, hreq); err != nil {
-		return nil, err
+// ChatStream is the same as Chat, except that as the service accumulates tokens to respond
+// to the request, it will stream the results back to the client. Each response will contain
+// Choices with the Delta field set instead of Message. The client can stop the stream by cancelling
+// the context.
+func (c *Client) ChatStream(ctx context.Context, deploymentID string, req chat.Req) chan StreamRecv[chat.Resp] {
+	if c.pool != nil {
+		return c.pool.chatStream(ctx, req)
 	}
-
-	buff := requestsBuff.Get()
-	defer requestsBuff.Put(buff)
-
-	buff.Reset(msg)
-	hreq.Body = buff
-
-	resp, err := c.client.Do(hreq)
+	u, err := c.chatEndpoint(ctx, deploymentID, req)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, specErr(resp)
+		return streamErr[chat.Resp](err)
 	}
 
-	b, err := io.ReadAll(resp.Body)
+	req.Stream = true
+	b, err := marshalReq(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("problem reading the response body: %w", err)
+		return streamErr[chat.Resp](err)
 	}
 
-	return b, nil
+	return decodeStream(ctx, c, deploymentID, u, b, func(r chat.Resp, m custom.ResponseMeta) chat.Resp {
+		r.Meta = m
+		return r
+	})
 }
 
-var bufIOs = sync.Pool{
-	New: func() any {
-		return bufio.NewReader(nil)
-	},
+// OpenChatStream is the same as ChatStream, except it returns a Stream that can be
+// closed to stop receiving and release the connection. The Stream must be closed.
+func (c *Client) OpenChatStream(ctx context.Context, deploymentID string, req chat.Req) *Stream[chat.Resp] {
+	return newStream(ctx, func(ctx context.Context) chan StreamRecv[chat.Resp] {
+		return c.ChatStream(ctx, deploymentID, req)
+	})
 }
 
-var streamDone = []byte("[DONE]")
-var streamHeader = []byte("data: ")
-
-func (c *Client) stream(ctx context.Context, addr *url.URL, msg []byte) (chan StreamRecv[[]byte], error) {
-	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, "", nil)
-	if err != nil {
-		return nil, err
+// chatEndpoint returns the URL for a chat request. Requests with data sources use the extensions endpoint.
+func (c *Client) chatEndpoint(ctx context.Context, deploymentID string, req chat.Req) (*url.URL, error) {
+	if len(req.DataSources) == 0 {
+		return c.endpoints.url(chatTmpl, deploymentID, c.varsFor(ctx))
 	}
-	hreq.URL = addr
-
-	if err := c.auth.Authorize(ctx, hreq); err != nil {
-		return nil, err
+	for _, ds := range req.DataSources {
+		if err := ds.Validate(); err != nil {
+			return nil, err
+		}
 	}
+	return c.endpoints.url(extChatTmpl, deploymentID, c.varsFor(ctx))
+}
 
-	buff := requestsBuff.Get()
-	defer requestsBuff.Put(buff)
-
-	buff.Reset(msg)
-	hreq.Body = buff
+func (c *Client) send(ctx context.Context, deploymentID string, addr *url.URL, msg []byte) ([]byte, custom.ResponseMeta, error) {
+	if err := c.limiter.wait(ctx, deploymentID, msg); err != nil {
+		return nil, custom.ResponseMeta{}, err
+	}
+	start := time.Now()
+	b, meta, err := c.post(ctx, addr, msg)
+	c.stats.record(deploymentID, len(msg), len(b), time.Since(start), err)
+	if err == nil {
+		c.limiter.update(deploymentID, meta)
+	}
+	return b, meta, err
+}
 
-	resp, err := c.client.Do(hreq)
+func (c *Client) post(ctx context.Context, addr *url.URL, msg []byte) ([]byte, custom.ResponseMeta, error) {
+	resp, err := c.do(ctx, http.MethodPost, addr, msg)
 	if err != nil {
-		return nil, err
+		return nil, custom.ResponseMeta{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, specErr(resp)
+	meta := custom.NewResponseMeta(resp)
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, meta, fmt.Errorf("problem reading the response body: %w", err)
 	}
 
-	ch := make(chan StreamRecv[[]byte], 1)
-	go func() {
-		defer close(ch)
-
-		bio := bufIOs.Get().(*bufio.Reader)
-		bio.Reset(resp.Body)
-		defer bufIOs.Put(bio)
-
-		for {
-			line, err := bio.ReadBytes('\n')
-			if err != nil {
-				ch <- StreamRecv[[]byte]{Err: err}
-				return
-			}
-			line = bytes.TrimSpace(line)
-
-			if !bytes.HasPrefix(line, streamHeader) {
-				// This indicates an empty message. We may want to put a limit on the number of empty messages.
-				// For now, we just ignore them.
-				continue
-			}
-			line = bytes.TrimPrefix(line, streamHeader)
-
-			// This indicates the end of the stream.
-			if bytes.Equal(line, streamDone) {
-				return
-			}
+	return b, meta, nil
+}
 
-			ch <- StreamRecv[[]byte]{Data: line}
+// setHeaders adds the Accept-Encoding header and the headers set with WithHeader() to the
+// request. An Accept-Encoding set with WithHeader() replaces ours.
+func (c *Client) setHeaders(hreq *http.Request) {
+	if c.headers.Get("Accept-Encoding") == "" {
+		hreq.Header.Set("Accept-Encoding", c.compress.acceptEncoding())
+	}
+	for k, v := range c.headers {
+		for _, val := range v {
+			hreq.Header.Add(k, val)
 		}
-	}()
-
-	return ch, nil
+	}
 }
 
+// specErr returns the error for a response that is not 2xx. 409 and 412 responses are wrapped
+// in an errors.Conflict and errors.PreconditionFailed, so they can be told apart from other errors.
 func specErr(resp *http.Response) error {
+	err := bodyErr(resp)
+	switch resp.StatusCode {
+	case http.StatusConflict:
+		return errors.Conflict{ETag: resp.Header.Get("ETag"), Err: err}
+	case http.StatusPreconditionFailed:
+		return errors.PreconditionFailed{ETag: resp.Header.Get("ETag"), Err: err}
+	case http.StatusBadRequest:
+		return filterErr(err)
+	}
+	return err
+}
+
+// filterErr returns an errors.PromptFiltered wrapping err if err is the error the service
+// returns when the content filters block a prompt. Otherwise it returns err.
+func filterErr(err error) error {
+	var jErr errors.JSON
+	if !errors.As(err, &jErr) {
+		return err
+	}
+	var body struct {
+		Error struct {
+			Message    string `json:"message"`
+			Code       string `json:"code"`
+			InnerError struct {
+				Code                string                       `json:"code"`
+				ContentFilterResult *custom.ContentFilterResults `json:"content_filter_result"`
+			} `json:"innererror"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(jErr.Message), &body) != nil {
+		return err
+	}
+	e := body.Error
+	if e.Code != "content_filter" && e.InnerError.ContentFilterResult == nil {
+		return err
+	}
+	pf := errors.PromptFiltered{Code: e.InnerError.Code, Message: e.Message, Err: err}
+	if e.InnerError.ContentFilterResult != nil {
+		pf.Results = *e.InnerError.ContentFilterResult
+	}
+	return pf
+}
+
+// bodyErr returns an errors.JSON, or errors.StatusCode if the body is not JSON, for resp.
+func bodyErr(resp *http.Response) error {
 	msg, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return errors.StatusCode{
@@ -406,11 +739,3 @@ func specErr(resp *http.Response) error {
 		StatusCode: resp.StatusCode,
 	}
 }
-
-// StreamRecv is used to receive data from a stream.
-type StreamRecv[T any] struct {
-	// Data is data sent by the stream.
-	Data T
-	// Err is an error related to the stream. The stream is terminated after this.
-	Err error
-}
@@ -1,7 +1,24 @@
 package rest
 
 import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest/messages/audio"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+	"github.com/element-of-surprise/azopenai/rest/messages/images"
+	"github.com/element-of-surprise/azopenai/rest/messages/ingestion"
 )
 
 func TestEndpoints(t *testing.T) {
@@ -45,6 +62,7 @@ func TestEndpoints(t *testing.T) {
 	e := newEndpoints()
 	vars := templVars{
 		ResourceName: "test",
+		BaseURL:      "https://test.openai.azure.com",
 		APIVersion:   APIVersion,
 	}
 	for _, test := range tests {
@@ -58,3 +76,520 @@ func TestEndpoints(t *testing.T) {
 		}
 	}
 }
+
+func TestAPIVersion(t *testing.T) {
+	var got []string
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = append(got, req.URL.Query().Get("api-version"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}), WithAPIVersion("2024-02-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := ContextWithAPIVersion(context.Background(), "2024-06-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ctx := range []context.Context{context.Background(), ctx, context.Background()} {
+		if _, err := c.Chat(ctx, "deployment", chat.Req{}); err != nil {
+			t.Fatalf("TestAPIVersion: got err == %s, want err == nil", err)
+		}
+	}
+
+	want := []string{"2024-02-01", "2024-06-01", "2024-02-01"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("TestAPIVersion: request %d got api-version %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if v := c.APIVersions(); v.Default != "2024-02-01" || v.Files != FilesAPIVersion {
+		t.Errorf("TestAPIVersion: got APIVersions() %+v, want Default 2024-02-01 and Files %s", v, FilesAPIVersion)
+	}
+
+	if _, err := ContextWithAPIVersion(context.Background(), "2024-02-01&x=y"); err == nil {
+		t.Errorf("TestAPIVersion: got err == nil for an invalid version, want err != nil")
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		desc    string
+		options []Option
+		want    string
+		err     bool
+	}{
+		{
+			desc: "default",
+			want: "https://test.openai.azure.com/openai/deployments/d/chat/completions",
+		},
+		{
+			desc:    "government cloud",
+			options: []Option{WithCloud(AzureGovernment)},
+			want:    "https://test.openai.azure.us/openai/deployments/d/chat/completions",
+		},
+		{
+			desc:    "custom endpoint with path",
+			options: []Option{WithCloud(AzureChina), WithEndpoint("https://gateway.example.com/azure/")},
+			want:    "https://gateway.example.com/azure/openai/deployments/d/chat/completions",
+		},
+		{
+			desc:    "endpoint without a host",
+			options: []Option{WithEndpoint("https:///path")},
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		c, err := New("test", auth.Authorizer{ApiKey: "key"}, test.options...)
+		switch {
+		case err == nil && test.err:
+			t.Errorf("TestBaseURL(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.err:
+			t.Errorf("TestBaseURL(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		u, err := c.chatEndpoint(context.Background(), "d", chat.Req{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := *u
+		got.RawQuery = ""
+		if got.String() != test.want {
+			t.Errorf("TestBaseURL(%s): got %s, want %s", test.desc, got.String(), test.want)
+		}
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func sseResponse(events ...string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		b := &strings.Builder{}
+		for _, e := range events {
+			b.WriteString("data: " + e + "\n\n")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+			Body:       io.NopCloser(strings.NewReader(b.String())),
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	rt := sseResponse(
+		`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant"}}]}`,
+		`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+		`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":" world"},"finish_reason":"stop"}]}`,
+		`[DONE]`,
+	)
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		text   string
+		role   chat.Role
+		finish custom.FinishReason
+	)
+	for recv := range c.ChatStream(context.Background(), "deployment", chat.Req{}) {
+		if recv.Err != nil {
+			t.Fatalf("TestChatStream: got err == %s, want err == nil", recv.Err)
+		}
+		for _, choice := range recv.Data.Choices {
+			if choice.Delta.Role != "" {
+				role = choice.Delta.Role
+			}
+			text += choice.Delta.Content
+			if choice.FinishReason != "" {
+				finish = choice.FinishReason
+			}
+		}
+	}
+
+	if role != chat.Assistant {
+		t.Errorf("TestChatStream: got role %q, want %q", role, chat.Assistant)
+	}
+	if text != "Hello world" {
+		t.Errorf("TestChatStream: got text %q, want %q", text, "Hello world")
+	}
+	if finish != "stop" {
+		t.Errorf("TestChatStream: got finish reason %q, want %q", finish, "stop")
+	}
+}
+
+func TestChatDataSources(t *testing.T) {
+	const body = `{"id":"1","choices":[{"index":0,"messages":[` +
+		`{"index":0,"role":"tool","content":"{\"citations\":[{\"title\":\"doc\",\"url\":\"https://doc\"}],\"intent\":\"[\\\"q\\\"]\"}","end_turn":false},` +
+		`{"index":1,"role":"assistant","content":"answer","end_turn":true}]}]}`
+
+	var path string
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		path = req.URL.Path
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := chat.Req{
+		DataSources: []chat.DataSource{
+			{Type: chat.AzureCognitiveSearch, Parameters: chat.AzureSearchParams{IndexName: "docs"}},
+		},
+	}
+	resp, err := c.Chat(context.Background(), "deployment", req)
+	if err != nil {
+		t.Fatalf("TestChatDataSources: got err == %s, want err == nil", err)
+	}
+	if want := "/openai/deployments/deployment/extensions/chat/completions"; path != want {
+		t.Errorf("TestChatDataSources: got path %q, want %q", path, want)
+	}
+
+	msgs := resp.Choices[0].Messages
+	if len(msgs) != 2 {
+		t.Fatalf("TestChatDataSources: got %d messages, want 2", len(msgs))
+	}
+	tc, err := msgs[0].ToolContent()
+	if err != nil {
+		t.Fatalf("TestChatDataSources: got err == %s, want err == nil", err)
+	}
+	if len(tc.Citations) != 1 || tc.Citations[0].URL != "https://doc" {
+		t.Errorf("TestChatDataSources: got citations %+v, want one with URL https://doc", tc.Citations)
+	}
+	if tc.Intent != `["q"]` {
+		t.Errorf("TestChatDataSources: got intent %q, want %q", tc.Intent, `["q"]`)
+	}
+	if msgs[1].Content != "answer" || !msgs[1].EndTurn {
+		t.Errorf("TestChatDataSources: got assistant message %+v, want content answer with end_turn", msgs[1])
+	}
+
+	_, err = c.Chat(context.Background(), "deployment", chat.Req{DataSources: []chat.DataSource{{}}})
+	if err == nil {
+		t.Errorf("TestChatDataSources: got err == nil for an invalid DataSource, want err != nil")
+	}
+}
+
+// closeBody records when it is closed.
+type closeBody struct {
+	io.Reader
+	closed chan struct{}
+}
+
+func (b *closeBody) Close() error {
+	close(b.closed)
+	return nil
+}
+
+func TestStreamClose(t *testing.T) {
+	event := "data: " + `{"id":"1","choices":[{"index":0,"delta":{"content":"x"}}]}` + "\n\n"
+	body := &closeBody{
+		// Far more events than the channels buffer, so the reader blocks if nobody receives.
+		Reader: strings.NewReader(strings.Repeat(event, 100)),
+		closed: make(chan struct{}),
+	}
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body, Request: req}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := c.OpenChatStream(context.Background(), "deployment", chat.Req{})
+	recv, err := stream.Recv(context.Background())
+	if err != nil {
+		t.Fatalf("TestStreamClose: got err == %s, want err == nil", err)
+	}
+	if recv.Data.Choices[0].Delta.Content != "x" {
+		t.Errorf("TestStreamClose: got content %q, want %q", recv.Data.Choices[0].Delta.Content, "x")
+	}
+
+	// Stop reading with most of the events unread.
+	stream.Close()
+	select {
+	case <-body.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("TestStreamClose: body was not closed after Close()")
+	}
+	if _, err := stream.Recv(context.Background()); err != io.EOF {
+		t.Errorf("TestStreamClose: got err == %v after Close(), want io.EOF", err)
+	}
+}
+
+func TestImages(t *testing.T) {
+	const opLoc = "https://test.openai.azure.com/openai/operations/images/op1?api-version=" + ImagesAPIVersion
+
+	polls := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Request:    req,
+		}
+		switch {
+		case req.Method == http.MethodPost:
+			resp.StatusCode = http.StatusAccepted
+			resp.Header.Set("operation-location", opLoc)
+			resp.Body = io.NopCloser(strings.NewReader(`{"id": "op1", "status": "notRunning"}`))
+		case req.URL.String() == opLoc:
+			polls++
+			body := `{"id": "op1", "status": "running"}`
+			if polls > 1 {
+				body = `{"id": "op1", "status": "succeeded", "result": {"data": [{"url": "https://image"}]}}`
+			}
+			resp.Body = io.NopCloser(strings.NewReader(body))
+		default:
+			t.Fatalf("TestImages: unexpected request %s %s", req.Method, req.URL)
+		}
+		return resp, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Images(context.Background(), images.Req{Prompt: "a gopher"})
+	if err != nil {
+		t.Fatalf("TestImages: got err == %s, want err == nil", err)
+	}
+	if polls != 2 {
+		t.Errorf("TestImages: got %d polls, want 2", polls)
+	}
+	if len(resp.Result.Data) != 1 || resp.Result.Data[0].URL != "https://image" {
+		t.Errorf("TestImages: got result %+v, want a single image", resp.Result)
+	}
+}
+
+func TestIngest(t *testing.T) {
+	const jobURL = "https://test.openai.azure.com/openai/extensions/on-your-data/ingestion-jobs/docs?api-version=" + IngestionAPIVersion
+
+	polls := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() != jobURL {
+			t.Fatalf("TestIngest: unexpected request %s %s", req.Method, req.URL)
+		}
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Request:    req,
+		}
+		switch req.Method {
+		case http.MethodPut:
+			if got := req.Header.Get("storageContainer"); got != "container" {
+				t.Errorf("TestIngest: got storageContainer header %q, want %q", got, "container")
+			}
+			resp.Body = io.NopCloser(strings.NewReader(`{"id": "docs", "status": "notRunning"}`))
+		case http.MethodGet:
+			polls++
+			body := `{"id": "docs", "status": "running"}`
+			if polls > 1 {
+				body = `{"id": "docs", "status": "failed", "error": {"code": "BadDocs", "message": "no documents"}}`
+			}
+			resp.Body = io.NopCloser(strings.NewReader(body))
+		}
+		return resp, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := ingestion.Req{
+		SearchServiceEndpoint:   "https://search",
+		StorageConnectionString: "conn",
+		StorageContainer:        "container",
+	}
+	_, err = c.Ingest(context.Background(), "docs", req)
+	var jobErr *ingestion.Error
+	if !errors.As(err, &jobErr) || jobErr.Code != "BadDocs" {
+		t.Fatalf("TestIngest: got err == %v, want *ingestion.Error with code BadDocs", err)
+	}
+	if polls != 2 {
+		t.Errorf("TestIngest: got %d polls, want 2", polls)
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	const wantURL = "https://test.openai.azure.com/openai/deployments/whisper/audio/transcriptions?api-version=" + AudioAPIVersion
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() != wantURL {
+			t.Fatalf("TestTranscribe: got URL %s, want %s", req.URL, wantURL)
+		}
+		_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("TestTranscribe: bad Content-Type: %s", err)
+		}
+		form, err := multipart.NewReader(req.Body, params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			t.Fatalf("TestTranscribe: problem reading form: %s", err)
+		}
+		if got := form.Value["response_format"]; len(got) != 1 || got[0] != "verbose_json" {
+			t.Errorf("TestTranscribe: got response_format %v, want verbose_json", got)
+		}
+		if got := form.Value["language"]; len(got) != 1 || got[0] != "en" {
+			t.Errorf("TestTranscribe: got language %v, want en", got)
+		}
+		if got := form.File["file"]; len(got) != 1 || got[0].Filename != "speech.mp3" || got[0].Size != 5 {
+			t.Errorf("TestTranscribe: file part was not sent correctly: %+v", got)
+		}
+
+		body := `{"text": "hello world", "language": "english", "duration": 1.5, "segments": [{"id": 0, "start": 0, "end": 1.5, "text": "hello world"}]}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Transcribe(
+		context.Background(),
+		"whisper",
+		audio.Req{
+			File:           strings.NewReader("audio"),
+			FileName:       "speech.mp3",
+			Language:       "en",
+			ResponseFormat: audio.VerboseJSON,
+		},
+	)
+	if err != nil {
+		t.Fatalf("TestTranscribe: got err == %s, want err == nil", err)
+	}
+	if resp.Text != "hello world" || len(resp.Segments) != 1 || resp.Segments[0].End != 1.5 {
+		t.Errorf("TestTranscribe: got %+v, want the text and one segment", resp)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	var got http.Header
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"data": [{"object": "embedding", "embedding": [0.1], "index": 0}]}`)),
+			Request:    req,
+		}, nil
+	})
+
+	apim := auth.ProviderFunc(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("Ocp-Apim-Subscription-Key", "subscription")
+		return nil
+	})
+	c, err := New("test", apim, WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Embeddings(context.Background(), "deployment", embeddings.Req{Input: []string{"hello"}}); err != nil {
+		t.Fatalf("TestProvider: got err == %s, want err == nil", err)
+	}
+	if got.Get("Ocp-Apim-Subscription-Key") != "subscription" || got.Get("Api-Key") != "" {
+		t.Errorf("TestProvider: got headers %v, want only the subscription key", got)
+	}
+
+	if _, err := New("test", nil); err == nil {
+		t.Errorf("TestProvider(nil): got err == nil, want err != nil")
+	}
+	if _, err := New("test", auth.Authorizer{}); err == nil {
+		t.Errorf("TestProvider(empty Authorizer): got err == nil, want err != nil")
+	}
+}
+
+func TestPromptFiltered(t *testing.T) {
+	tests := []struct {
+		desc     string
+		body     string
+		wantCats []string
+		wantCode string
+		wantErr  bool
+	}{
+		{
+			desc: "content filter",
+			body: `{"error":{"message":"The response was filtered","code":"content_filter","status":400,` +
+				`"innererror":{"code":"ResponsibleAIPolicyViolation","content_filter_result":{` +
+				`"hate":{"filtered":false,"severity":"safe"},"violence":{"filtered":true,"severity":"medium"},` +
+				`"jailbreak":{"filtered":true,"detected":true}}}}}`,
+			wantCats: []string{"violence", "jailbreak"},
+			wantCode: "ResponsibleAIPolicyViolation",
+		},
+		{
+			desc:    "other bad request",
+			body:    `{"error":{"message":"bad","code":"invalid_value"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(test.body)),
+				Request:    req,
+			}, nil
+		})
+		c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithClient(&http.Client{Transport: rt}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = c.Chat(context.Background(), "deployment", chat.Req{Messages: []chat.SendMsg{{Role: chat.User, Content: "hi"}}})
+		var pf errors.PromptFiltered
+		if !errors.As(err, &pf) {
+			if !test.wantErr {
+				t.Errorf("TestPromptFiltered(%s): got err %v, want errors.PromptFiltered", test.desc, err)
+			}
+			continue
+		}
+		if test.wantErr {
+			t.Errorf("TestPromptFiltered(%s): got errors.PromptFiltered, want another error", test.desc)
+			continue
+		}
+		if !reflect.DeepEqual(pf.Results.FilteredCategories(), test.wantCats) {
+			t.Errorf("TestPromptFiltered(%s): got categories %v, want %v", test.desc, pf.Results.FilteredCategories(), test.wantCats)
+		}
+		if pf.Code != test.wantCode || pf.Results.Violence.Severity != custom.Medium {
+			t.Errorf("TestPromptFiltered(%s): got %+v", test.desc, pf)
+		}
+		var jErr errors.JSON
+		if !errors.As(err, &jErr) || jErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("TestPromptFiltered(%s): got err %v, want it to wrap an errors.JSON", test.desc, err)
+		}
+	}
+}
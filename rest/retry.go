@@ -0,0 +1,247 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy details how requests that fail with a retryable status code are retried.
+// The zero value does not retry.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times a request is retried. 0 disables retries.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry. Each following retry doubles the
+	// delay, with jitter added. Defaults to 500ms.
+	BaseDelay time.Duration
+	// MaxDelay is the maximum delay between retries, excluding delays the service asks
+	// for with a Retry-After header. Defaults to 30 seconds.
+	MaxDelay time.Duration
+	// StatusCodes are the HTTP status codes that are retried. Defaults to
+	// 408, 429, 500, 502, 503 and 504.
+	StatusCodes []int
+}
+
+// DefaultRetryPolicy is a RetryPolicy that works well for most uses.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+var defaultRetryCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func (r RetryPolicy) defaults() RetryPolicy {
+	if r.BaseDelay == 0 {
+		r.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if r.MaxDelay == 0 {
+		r.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if r.StatusCodes == nil {
+		r.StatusCodes = defaultRetryCodes
+	}
+	return r
+}
+
+func (r RetryPolicy) validate() error {
+	if r.MaxRetries < 0 {
+		return fmt.Errorf("RetryPolicy.MaxRetries cannot be < 0")
+	}
+	if r.BaseDelay < 0 || r.MaxDelay < 0 {
+		return fmt.Errorf("RetryPolicy delays cannot be < 0")
+	}
+	if r.MaxDelay < r.BaseDelay {
+		return fmt.Errorf("RetryPolicy.MaxDelay cannot be < BaseDelay")
+	}
+	return nil
+}
+
+func (r RetryPolicy) retryable(statusCode int) bool {
+	for _, c := range r.StatusCodes {
+		if c == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before retry number attempt (starting at 0).
+func (r RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if d, ok := retryAfter(resp.Header, time.Now()); ok {
+		return d
+	}
+
+	d := r.BaseDelay
+	for i := 0; i < attempt && d < r.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	// Add jitter so that clients that were throttled together don't retry together.
+	half := int64(d / 2)
+	if half > 0 {
+		d = time.Duration(half + rand.Int63n(half))
+	}
+	return d
+}
+
+// retryAfter returns the delay the service asked for. Azure uses retry-after-ms and
+// x-ms-retry-after-ms in addition to the standard Retry-After header, which can either
+// be in seconds or an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	for _, k := range []string{"retry-after-ms", "x-ms-retry-after-ms"} {
+		if v := h.Get(k); v != "" {
+			if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+				return time.Duration(ms * float64(time.Millisecond)), true
+			}
+		}
+	}
+
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// WithRetryPolicy sets the RetryPolicy used to retry requests that fail with retryable
+// status codes, such as 429 (Too Many Requests). By default requests are not retried.
+// Retries will honor Retry-After headers and will not wait past the deadline of the context.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(client *Client) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		client.retry = policy.defaults()
+		return nil
+	}
+}
+
+// do sends a request with msg as the body to addr, retrying according to the RetryPolicy. If msg
+// is nil, no body is sent. If the final response does not have a 2XX status code an error is
+// returned. On success the caller must close the response body.
+func (c *Client) do(ctx context.Context, method string, addr *url.URL, msg []byte) (*http.Response, error) {
+	return c.doHeader(ctx, method, addr, msg, nil)
+}
+
+// doHeader is the same as do, but also sends header with the request.
+func (c *Client) doHeader(ctx context.Context, method string, addr *url.URL, msg []byte, header http.Header) (*http.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	resp, err := c.doRetry(ctx, method, addr, msg, header)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// doRetry sends the request of doHeader, retrying according to the RetryPolicy.
+func (c *Client) doRetry(ctx context.Context, method string, addr *url.URL, msg []byte, header http.Header) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		hreq, err := http.NewRequestWithContext(c.conns.trace(ctx), method, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		hreq.Host = addr.Host
+		hreq.URL = addr
+
+		if err := c.auth.Authorize(ctx, hreq); err != nil {
+			return nil, err
+		}
+		c.setHeaders(hreq)
+		for k, v := range header {
+			hreq.Header[k] = v
+		}
+
+		buff := requestsBuff.Get()
+		if msg != nil {
+			buff.Reset(msg)
+			hreq.Body = buff
+			hreq.ContentLength = int64(len(msg))
+		}
+
+		start := time.Now()
+		resp, err := c.client.Do(hreq)
+		c.logRequest(ctx, hreq, msg, attempt, resp, err, time.Since(start))
+		if err != nil {
+			requestsBuff.Put(buff)
+			return nil, err
+		}
+		c.recordQuota(addr, resp, time.Now())
+		// The request buffer is not returned to the pool until the response body is
+		// closed, as the transport may still be reading it until then.
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: func() { requestsBuff.Put(buff) }}
+		if err := c.compress.decode(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		if attempt >= c.retry.MaxRetries || !c.retry.retryable(resp.StatusCode) {
+			defer resp.Body.Close()
+			return nil, specErr(resp)
+		}
+
+		wait := c.retry.delay(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			// We can't retry before the deadline, so return the error we have.
+			defer resp.Body.Close()
+			return nil, specErr(resp)
+		}
+		c.logRetry(ctx, hreq, attempt, resp.StatusCode, wait)
+		// Drain the body so that the connection can be reused.
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// releaseBody calls release once when the body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close implements io.Closer.
+func (r *releaseBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
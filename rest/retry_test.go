@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/errors"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc   string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			desc:   "no header",
+			header: http.Header{},
+		},
+		{
+			desc:   "seconds",
+			header: http.Header{"Retry-After": []string{"2"}},
+			want:   2 * time.Second,
+			wantOK: true,
+		},
+		{
+			desc:   "milliseconds take precedence",
+			header: http.Header{"Retry-After": []string{"2"}, "Retry-After-Ms": []string{"150"}},
+			want:   150 * time.Millisecond,
+			wantOK: true,
+		},
+		{
+			desc:   "http date",
+			header: http.Header{"Retry-After": []string{now.Add(3 * time.Second).Format(http.TimeFormat)}},
+			want:   3 * time.Second,
+			wantOK: true,
+		},
+		{
+			desc:   "garbage",
+			header: http.Header{"Retry-After": []string{"soon"}},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := retryAfter(test.header, now)
+		if ok != test.wantOK {
+			t.Errorf("TestRetryAfter(%s): got ok == %v, want ok == %v", test.desc, ok, test.wantOK)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("TestRetryAfter(%s): got %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		desc      string
+		policy    RetryPolicy
+		codes     []int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			desc:      "no retry policy",
+			codes:     []int{429, 200},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			desc:      "retry on 429 then succeed",
+			policy:    RetryPolicy{MaxRetries: 3},
+			codes:     []int{429, 503, 200},
+			wantCalls: 3,
+		},
+		{
+			desc:      "not retryable",
+			policy:    RetryPolicy{MaxRetries: 3},
+			codes:     []int{400, 200},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			desc:      "retries exhausted",
+			policy:    RetryPolicy{MaxRetries: 1},
+			codes:     []int{429, 429, 200},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			code := test.codes[calls]
+			calls++
+			body := `{"data": [{"object": "embedding", "embedding": [0.1], "index": 0}]}`
+			if code != http.StatusOK {
+				body = `{"error": {"code": "429"}}`
+			}
+			return &http.Response{
+				StatusCode: code,
+				Header:     http.Header{"Retry-After-Ms": []string{"1"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		})
+
+		c, err := New(
+			"test",
+			auth.Authorizer{ApiKey: "key"},
+			WithClient(&http.Client{Transport: rt}),
+			WithRetryPolicy(test.policy),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = c.Embeddings(context.Background(), "deployment", embeddings.Req{Input: []string{"hello"}})
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRetry(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRetry(%s): got err == %s, want err == nil", test.desc, err)
+		case err != nil:
+			var jErr errors.JSON
+			if !errors.As(err, &jErr) {
+				t.Errorf("TestRetry(%s): got err type %T, want errors.JSON", test.desc, err)
+			}
+		}
+		if calls != test.wantCalls {
+			t.Errorf("TestRetry(%s): got %d calls, want %d", test.desc, calls, test.wantCalls)
+		}
+	}
+}
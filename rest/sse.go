@@ -0,0 +1,331 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/rest/messages/custom"
+)
+
+// StreamRecv is used to receive data from a stream.
+type StreamRecv[T any] struct {
+	// Data is data sent by the stream. This is the zero value if Event.Name is not empty or "message",
+	// as the SDK only knows how to decode message events.
+	Data T
+	// Event is the raw server-sent event that Data was decoded from. This allows handling
+	// event types the SDK does not decode yet.
+	Event Event
+	// Err is an error related to the stream. The stream is terminated after this.
+	Err error
+}
+
+// Event is a raw server-sent event.
+type Event struct {
+	// Name is the event type from the "event:" field. This is empty for the default
+	// "message" events that Azure OpenAI sends.
+	Name string
+	// ID is the last event ID, from the "id:" field of this event or an earlier one.
+	ID string
+	// Data is the contents of the "data:" fields. The values of multiple "data:" fields are
+	// joined with "\n".
+	Data []byte
+}
+
+// IsMessage returns true if this is a message event, which is the type the SDK decodes.
+func (e Event) IsMessage() bool {
+	return e.Name == "" || e.Name == "message"
+}
+
+var bufIOs = sync.Pool{
+	New: func() any {
+		return bufio.NewReader(nil)
+	},
+}
+
+var streamDone = []byte("[DONE]")
+
+// eventReader reads server-sent events as the HTML standard describes, so streams that pass
+// through proxies that reformat them, such as API Management, still decode. Lines can end with
+// "\r\n", "\n" or "\r" and can be longer than the bufio.Reader's buffer. Comment lines and
+// unknown fields, such as "retry:", are ignored.
+type eventReader struct {
+	r *bufio.Reader
+	// onLine is called for each line read, including comments that proxies send as keepalives.
+	onLine func()
+	// lastID is the last event ID, which carries over to events without an "id:" field.
+	lastID string
+	// skipLF is set when the last line ended with "\r", as a "\n" after it is part of the same ending.
+	skipLF bool
+}
+
+// readLine returns the next line without its line ending. A line that is not ended before the
+// stream ends is not returned, as the standard discards it.
+func (e *eventReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		b, err := e.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if e.skipLF {
+			e.skipLF = false
+			if b[0] == '\n' {
+				e.r.Discard(1)
+				continue
+			}
+		}
+
+		chunk, _ := e.r.Peek(e.r.Buffered())
+		i := bytes.IndexAny(chunk, "\r\n")
+		if i < 0 {
+			line = append(line, chunk...)
+			e.r.Discard(len(chunk))
+			continue
+		}
+		line = append(line, chunk[:i]...)
+		e.skipLF = chunk[i] == '\r'
+		e.r.Discard(i + 1)
+		if line == nil {
+			line = []byte{}
+		}
+		return line, nil
+	}
+}
+
+// next returns the next event that has data. Events without a "data:" field are not dispatched.
+func (e *eventReader) next() (Event, error) {
+	var (
+		ev      Event
+		hasData bool
+	)
+	for {
+		line, err := e.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+		if e.onLine != nil {
+			e.onLine()
+		}
+
+		// A blank line dispatches the event.
+		if len(line) == 0 {
+			if !hasData {
+				ev = Event{}
+				continue
+			}
+			ev.ID = e.lastID
+			return ev, nil
+		}
+		if line[0] == ':' {
+			// A comment.
+			continue
+		}
+
+		// A line without a colon is a field name with an empty value.
+		field, value := line, []byte{}
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], bytes.TrimPrefix(line[i+1:], []byte(" "))
+		}
+		switch string(field) {
+		case "data":
+			if hasData {
+				ev.Data = append(ev.Data, '\n')
+			}
+			ev.Data = append(ev.Data, value...)
+			if ev.Data == nil {
+				ev.Data = []byte{}
+			}
+			hasData = true
+		case "event":
+			ev.Name = string(value)
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				e.lastID = string(value)
+			}
+		}
+	}
+}
+
+func (c *Client) stream(ctx context.Context, deploymentID string, addr *url.URL, msg []byte) (chan StreamRecv[Event], custom.ResponseMeta, error) {
+	if err := c.limiter.wait(ctx, deploymentID, msg); err != nil {
+		return nil, custom.ResponseMeta{}, err
+	}
+	start := time.Now()
+	ch, meta, err := c.postStream(ctx, addr, msg)
+	c.stats.record(deploymentID, len(msg), 0, time.Since(start), err)
+	if err == nil {
+		c.limiter.update(deploymentID, meta)
+	}
+	return ch, meta, err
+}
+
+func (c *Client) postStream(ctx context.Context, addr *url.URL, msg []byte) (chan StreamRecv[Event], custom.ResponseMeta, error) {
+	parent := ctx
+	ctx, wd := c.watch(ctx)
+	resp, err := c.do(ctx, http.MethodPost, addr, msg)
+	if err != nil {
+		wd.pause()
+		return nil, custom.ResponseMeta{}, idleErr(ctx, err)
+	}
+	meta := custom.NewResponseMeta(resp)
+	wd.reset()
+
+	ch := make(chan StreamRecv[Event], 1)
+	go func() {
+		defer close(ch)
+		defer wd.pause()
+		// The body must stay open until we are done reading the stream.
+		defer resp.Body.Close()
+
+		bio := bufIOs.Get().(*bufio.Reader)
+		bio.Reset(resp.Body)
+		defer bufIOs.Put(bio)
+
+		er := &eventReader{r: bio, onLine: wd.reset}
+		for {
+			ev, err := er.next()
+			if err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				if err = idleErr(ctx, err); err == ErrStreamIdle {
+					// The watchdog cancelled ctx, so sendRecv() would drop err.
+					sendRecv(parent, ch, StreamRecv[Event]{Err: err})
+					return
+				}
+				sendRecv(ctx, ch, StreamRecv[Event]{Err: err})
+				return
+			}
+			// This indicates the end of the stream.
+			if ev.IsMessage() && bytes.Equal(bytes.TrimSpace(ev.Data), streamDone) {
+				return
+			}
+			// The consumer being slow is not the stream being idle.
+			wd.pause()
+			if !sendRecv(ctx, ch, StreamRecv[Event]{Data: ev, Event: ev}) {
+				return
+			}
+			wd.reset()
+		}
+	}()
+
+	return ch, meta, nil
+}
+
+// sendRecv sends r on ch unless ctx is done first. It returns false if ctx is done, which means
+// the consumer may have stopped reading and the sender should exit. In that case the context
+// error is sent instead if there is room in the channel, so a consumer that is still reading
+// learns why the stream ended.
+func sendRecv[T any](ctx context.Context, ch chan StreamRecv[T], r StreamRecv[T]) bool {
+	select {
+	case ch <- r:
+		return true
+	case <-ctx.Done():
+		select {
+		case ch <- StreamRecv[T]{Err: ctx.Err()}:
+		default:
+		}
+		return false
+	}
+}
+
+// streamErr returns a closed channel that will return err.
+func streamErr[T any](err error) chan StreamRecv[T] {
+	ch := make(chan StreamRecv[T], 1)
+	ch <- StreamRecv[T]{Err: err}
+	close(ch)
+	return ch
+}
+
+// decodeStream sends msg to addr and decodes the message events of the stream into T. Events
+// that are not message events are passed through with only the Event set. setMeta is used to
+// set the ResponseMeta on each T.
+func decodeStream[T any](ctx context.Context, c *Client, deploymentID string, addr *url.URL, msg []byte, setMeta func(T, custom.ResponseMeta) T) chan StreamRecv[T] {
+	ch := make(chan StreamRecv[T], 1)
+
+	go func() {
+		defer close(ch)
+
+		// Cancelling on return stops the reader goroutine and closes the body if we exit early.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		responses, meta, err := c.stream(ctx, deploymentID, addr, msg)
+		if err != nil {
+			sendRecv(ctx, ch, StreamRecv[T]{Err: err})
+			return
+		}
+
+		for response := range responses {
+			if response.Err != nil {
+				sendRecv(ctx, ch, StreamRecv[T]{Err: response.Err})
+				return
+			}
+			if !response.Event.IsMessage() {
+				if !sendRecv(ctx, ch, StreamRecv[T]{Event: response.Event}) {
+					return
+				}
+				continue
+			}
+
+			var data T
+			if err := json.Unmarshal(response.Event.Data, &data); err != nil {
+				sendRecv(ctx, ch, StreamRecv[T]{Event: response.Event, Err: fmt.Errorf("problem unmarshaling the response body: %w", err)})
+				return
+			}
+			if !sendRecv(ctx, ch, StreamRecv[T]{Data: setMeta(data, meta), Event: response.Event}) {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Stream is a stream of responses from the service. Unlike the channels returned by
+// CompletionsStream() and ChatStream(), a Stream can be closed before it is finished, which
+// stops the goroutines reading it and closes the response body. Close must always be called.
+type Stream[T any] struct {
+	ch     chan StreamRecv[T]
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func newStream[T any](ctx context.Context, open func(ctx context.Context) chan StreamRecv[T]) *Stream[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Stream[T]{ch: open(ctx), cancel: cancel}
+}
+
+// Recv returns the next response. It returns io.EOF when the stream is finished. If the stream
+// returns an error, both the StreamRecv with Err set and the error are returned. If ctx is done
+// before a response is received, ctx.Err() is returned and the stream remains open.
+func (s *Stream[T]) Recv(ctx context.Context) (StreamRecv[T], error) {
+	select {
+	case <-ctx.Done():
+		return StreamRecv[T]{}, ctx.Err()
+	case r, ok := <-s.ch:
+		if !ok {
+			return StreamRecv[T]{}, io.EOF
+		}
+		return r, r.Err
+	}
+}
+
+// Close stops the stream and waits for the response body to be closed. It is safe to call
+// Close more than once and after the stream is finished.
+func (s *Stream[T]) Close() error {
+	s.once.Do(func() {
+		s.cancel()
+		for range s.ch {
+		}
+	})
+	return nil
+}
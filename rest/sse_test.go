@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEventReader(t *testing.T) {
+	long := strings.Repeat("x", 100000)
+
+	tests := []struct {
+		desc string
+		in   string
+		want []Event
+	}{
+		{
+			desc: "LF",
+			in:   "data: {\"a\":1}\n\ndata: {\"a\":2}\n\n",
+			want: []Event{{Data: []byte(`{"a":1}`)}, {Data: []byte(`{"a":2}`)}},
+		},
+		{
+			desc: "CRLF",
+			in:   "data: {\"a\":1}\r\n\r\ndata: {\"a\":2}\r\n\r\n",
+			want: []Event{{Data: []byte(`{"a":1}`)}, {Data: []byte(`{"a":2}`)}},
+		},
+		{
+			desc: "CR",
+			in:   "data: 1\r\rdata: 2\r\r",
+			want: []Event{{Data: []byte("1")}, {Data: []byte("2")}},
+		},
+		{
+			desc: "multi-line data",
+			in:   "data: {\"a\":\ndata:1}\n\n",
+			want: []Event{{Data: []byte("{\"a\":\n1}")}},
+		},
+		{
+			desc: "comments and unknown fields",
+			in:   ": keepalive\n\nretry: 1000\nfoo: bar\ndata: 1\n: ping\n\n",
+			want: []Event{{Data: []byte("1")}},
+		},
+		{
+			desc: "only one leading space is removed",
+			in:   "data:  1 \n\n",
+			want: []Event{{Data: []byte(" 1 ")}},
+		},
+		{
+			desc: "field without a colon",
+			in:   "data\n\n",
+			want: []Event{{Data: []byte{}}},
+		},
+		{
+			desc: "event names and ids",
+			in:   "event: thread.run\nid: 1\ndata: a\n\ndata: b\n\nid\ndata: c\n\n",
+			want: []Event{
+				{Name: "thread.run", ID: "1", Data: []byte("a")},
+				{ID: "1", Data: []byte("b")},
+				{Data: []byte("c")},
+			},
+		},
+		{
+			desc: "event without data is not dispatched",
+			in:   "event: ping\n\ndata: 1\n\n",
+			want: []Event{{Data: []byte("1")}},
+		},
+		{
+			desc: "line longer than the buffer",
+			in:   "data: " + long + "\n\n",
+			want: []Event{{Data: []byte(long)}},
+		},
+		{
+			desc: "unfinished event is discarded",
+			in:   "data: 1\n\ndata: 2",
+			want: []Event{{Data: []byte("1")}},
+		},
+	}
+
+	for _, test := range tests {
+		lines := 0
+		// A small reader makes lines and line endings span reads.
+		er := &eventReader{r: bufio.NewReaderSize(&oneByteReader{strings.NewReader(test.in)}, 16), onLine: func() { lines++ }}
+
+		var got []Event
+		for {
+			ev, err := er.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("TestEventReader(%s): got err == %s, want err == nil", test.desc, err)
+			}
+			got = append(got, ev)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TestEventReader(%s): got %q, want %q", test.desc, got, test.want)
+		}
+		if lines == 0 {
+			t.Errorf("TestEventReader(%s): onLine was not called", test.desc)
+		}
+	}
+}
+
+// oneByteReader returns one byte per Read.
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
@@ -0,0 +1,183 @@
+package rest
+
+import (
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram buckets.
+var latencyBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	60 * time.Second,
+}
+
+// histogram is a latency histogram that implements expvar.Var.
+type histogram struct {
+	// counts has one more entry than latencyBuckets, for values above the last bucket.
+	counts []atomic.Int64
+	sum    atomic.Int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]atomic.Int64, len(latencyBuckets)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	h.sum.Add(int64(d / time.Millisecond))
+	for i, b := range latencyBuckets {
+		if d <= b {
+			h.counts[i].Add(1)
+			return
+		}
+	}
+	h.counts[len(latencyBuckets)].Add(1)
+}
+
+// String implements expvar.Var. It outputs the buckets as "le_<ms>" keys with cumulative
+// counts, the same as Prometheus histograms.
+func (h *histogram) String() string {
+	b := &strings.Builder{}
+	b.WriteString("{")
+	var total int64
+	for i, bucket := range latencyBuckets {
+		total += h.counts[i].Load()
+		fmt.Fprintf(b, `"le_%d": %d, `, bucket/time.Millisecond, total)
+	}
+	total += h.counts[len(latencyBuckets)].Load()
+	fmt.Fprintf(b, `"le_inf": %d, "count": %d, "sum_ms": %d}`, total, total, h.sum.Load())
+	return b.String()
+}
+
+// deploymentStats are the stats for a single deployment.
+type deploymentStats struct {
+	requests      *expvar.Int
+	errors        *expvar.Int
+	requestBytes  *expvar.Int
+	responseBytes *expvar.Int
+	latency       *histogram
+}
+
+// stats publishes per deployment stats via expvar. Nothing is recorded until it is published.
+type stats struct {
+	root      *expvar.Map
+	published atomic.Bool
+
+	mu          sync.Mutex // Protects deployments
+	deployments map[string]*deploymentStats
+}
+
+// publishMu makes checking and publishing an expvar name atomic, as expvar.Publish() panics
+// if the name is taken.
+var publishMu sync.Mutex
+
+func newStats(conns *connStats) *stats {
+	s := &stats{
+		root:        new(expvar.Map).Init(),
+		deployments: map[string]*deploymentStats{},
+	}
+	if conns != nil {
+		s.root.Set("connections", conns.expvar())
+	}
+	return s
+}
+
+// publish publishes the stats under name.
+func (s *stats) publish(name string) error {
+	if name == "" {
+		return fmt.Errorf("expvar name cannot be empty")
+	}
+
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	if s.published.Load() {
+		return fmt.Errorf("the stats are already published")
+	}
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+	expvar.Publish(name, s.root)
+	s.published.Store(true)
+	return nil
+}
+
+func (s *stats) deployment(deploymentID string) *deploymentStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.deployments[deploymentID]; ok {
+		return d
+	}
+
+	d := &deploymentStats{
+		requests:      new(expvar.Int),
+		errors:        new(expvar.Int),
+		requestBytes:  new(expvar.Int),
+		responseBytes: new(expvar.Int),
+		latency:       newHistogram(),
+	}
+	m := new(expvar.Map).Init()
+	m.Set("requests", d.requests)
+	m.Set("errors", d.errors)
+	m.Set("request_bytes", d.requestBytes)
+	m.Set("response_bytes", d.responseBytes)
+	m.Set("latency_ms", d.latency)
+	s.root.Set(deploymentID, m)
+
+	s.deployments[deploymentID] = d
+	return d
+}
+
+// record records a single request if the stats are published. It is safe to call on a nil *stats.
+func (s *stats) record(deploymentID string, reqSize, respSize int, latency time.Duration, err error) {
+	if s == nil || !s.published.Load() {
+		return
+	}
+	d := s.deployment(deploymentID)
+	d.requests.Add(1)
+	if err != nil {
+		d.errors.Add(1)
+	}
+	d.requestBytes.Add(int64(reqSize))
+	d.responseBytes.Add(int64(respSize))
+	d.latency.observe(latency)
+}
+
+// WithExpvar publishes per deployment request counts, error counts, request and response sizes
+// and latency histograms via the expvar package under name. The stats are published when New()
+// succeeds, as expvar cannot unpublish a name, so a failed New() leaves name free. name must be
+// unique for the process. For streaming calls the latency is the time until the stream starts
+// and response sizes are not recorded. The Client's ConnStats() are published under
+// "connections", with times in milliseconds. See the package documentation about the
+// /debug/vars handler.
+func WithExpvar(name string) Option {
+	return func(client *Client) error {
+		if name == "" {
+			return fmt.Errorf("WithExpvar: name cannot be empty")
+		}
+		client.expvar = name
+		return nil
+	}
+}
+
+// PublishExpvar publishes the Client's stats under name, the same as WithExpvar(). This is used to
+// publish the stats of several Clients only once all of them were made. Requests made before it is
+// called are not counted. It returns an error if name is already published or the Client's stats
+// already are.
+func (c *Client) PublishExpvar(name string) error {
+	if c.stats == nil {
+		return fmt.Errorf("the Client has no stats to publish")
+	}
+	return c.stats.publish(name)
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"expvar"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/embeddings"
+)
+
+func TestExpvar(t *testing.T) {
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"data": [{"object": "embedding", "embedding": [0.1], "index": 0}]}`)),
+			Request:    req,
+		}, nil
+	})
+	client := WithClient(&http.Client{Transport: rt})
+
+	// A New() that fails does not publish, so the name can be used again.
+	if _, err := New("", auth.Authorizer{ApiKey: "key"}, client, WithExpvar("TestExpvar")); err == nil {
+		t.Fatalf("TestExpvar(failed New): got err == nil, want err != nil")
+	}
+	if expvar.Get("TestExpvar") != nil {
+		t.Errorf("TestExpvar(failed New): the stats were published")
+	}
+
+	c, err := New("test", auth.Authorizer{ApiKey: "key"}, client, WithExpvar("TestExpvar"))
+	if err != nil {
+		t.Fatalf("TestExpvar: got err == %s, want err == nil", err)
+	}
+	if _, err := c.Embeddings(context.Background(), "deployment", embeddings.Req{Input: []string{"hi"}}); err != nil {
+		t.Fatalf("TestExpvar: got err == %s, want err == nil", err)
+	}
+	m, ok := expvar.Get("TestExpvar").(*expvar.Map)
+	if !ok {
+		t.Fatalf("TestExpvar: the stats were not published")
+	}
+	d, ok := m.Get("deployment").(*expvar.Map)
+	if !ok || d.Get("requests").String() != "1" {
+		t.Errorf("TestExpvar: got deployment stats %v, want 1 request", m.Get("deployment"))
+	}
+
+	tests := []struct {
+		desc string
+		err  func() error
+	}{
+		{
+			desc: "WithExpvar with a published name",
+			err: func() error {
+				_, err := New("test", auth.Authorizer{ApiKey: "key"}, client, WithExpvar("TestExpvar"))
+				return err
+			},
+		},
+		{
+			desc: "WithExpvar with an empty name",
+			err: func() error {
+				_, err := New("test", auth.Authorizer{ApiKey: "key"}, client, WithExpvar(""))
+				return err
+			},
+		},
+		{desc: "PublishExpvar with a published name", err: func() error { return c.PublishExpvar("TestExpvar") }},
+		{desc: "PublishExpvar of published stats", err: func() error { return c.PublishExpvar("TestExpvar2") }},
+	}
+	for _, test := range tests {
+		if err := test.err(); err == nil {
+			t.Errorf("TestExpvar(%s): got err == nil, want err != nil", test.desc)
+		}
+	}
+
+	// Stats are only recorded once published.
+	later, err := New("test", auth.Authorizer{ApiKey: "key"}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later.Embeddings(context.Background(), "deployment", embeddings.Req{Input: []string{"hi"}})
+	if err := later.PublishExpvar("TestExpvarLater"); err != nil {
+		t.Fatalf("TestExpvar(PublishExpvar): got err == %s, want err == nil", err)
+	}
+	if m := expvar.Get("TestExpvarLater").(*expvar.Map); m.Get("deployment") != nil || m.Get("connections") == nil {
+		t.Errorf("TestExpvar(PublishExpvar): got %v, want only connections before any request", m)
+	}
+}
@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrStreamIdle is returned by a stream when no data was received within the stream idle
+// timeout, see WithStreamIdleTimeout().
+var ErrStreamIdle = errors.New("no data was received on the stream within the idle timeout")
+
+// WithTimeout sets a deadline of d on each request, layered on top of the deadline of the
+// context. It covers retries and reading the response. It does not apply to streams, which
+// can run for minutes, use WithStreamIdleTimeout() for those.
+func WithTimeout(d time.Duration) Option {
+	return func(client *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("WithTimeout: timeout must be > 0")
+		}
+		client.timeout = d
+		return nil
+	}
+}
+
+// WithStreamIdleTimeout fails a stream with ErrStreamIdle if the response headers or the next
+// line of the stream are not received within d, so a hung stream fails fast instead of
+// waiting for the deadline of the context.
+func WithStreamIdleTimeout(d time.Duration) Option {
+	return func(client *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("WithStreamIdleTimeout: timeout must be > 0")
+		}
+		client.idleTimeout = d
+		return nil
+	}
+}
+
+type idleTimeoutKey struct{}
+
+// ContextWithStreamIdleTimeout returns a context that sets the stream idle timeout of the
+// streams made with it, overriding WithStreamIdleTimeout().
+func ContextWithStreamIdleTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, idleTimeoutKey{}, d)
+}
+
+// streamKey marks the context of a stream request, which WithTimeout() does not apply to.
+type streamKey struct{}
+
+// withTimeout returns ctx with the timeout of WithTimeout() unless ctx is for a stream.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 || ctx.Value(streamKey{}) != nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// cancelBody cancels the context of the request when the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// watchdog cancels the context of a stream when it has been idle for longer than its timeout.
+// A nil *watchdog does nothing.
+type watchdog struct {
+	d     time.Duration
+	timer *time.Timer
+}
+
+// watch returns ctx for a stream request and the watchdog for it, which is nil if there is no
+// idle timeout. The watchdog is running.
+func (c *Client) watch(ctx context.Context) (context.Context, *watchdog) {
+	ctx = context.WithValue(ctx, streamKey{}, true)
+	d := c.idleTimeout
+	if v, ok := ctx.Value(idleTimeoutKey{}).(time.Duration); ok {
+		d = v
+	}
+	if d <= 0 {
+		return ctx, nil
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, &watchdog{d: d, timer: time.AfterFunc(d, func() { cancel(ErrStreamIdle) })}
+}
+
+// reset restarts the idle timeout after the stream received data.
+func (w *watchdog) reset() {
+	if w != nil {
+		w.timer.Reset(w.d)
+	}
+}
+
+// pause stops the idle timeout while the stream waits on its consumer.
+func (w *watchdog) pause() {
+	if w != nil {
+		w.timer.Stop()
+	}
+}
+
+// idleErr returns ErrStreamIdle if the watchdog cancelled ctx, otherwise err.
+func idleErr(ctx context.Context, err error) error {
+	if context.Cause(ctx) == ErrStreamIdle {
+		return ErrStreamIdle
+	}
+	return err
+}
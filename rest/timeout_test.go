@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/rest/messages/chat"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		desc    string
+		delay   time.Duration
+		wantErr bool
+	}{
+		{desc: "in time"},
+		{desc: "too slow", delay: time.Second, wantErr: true},
+	}
+
+	for _, test := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(test.delay):
+			case <-r.Context().Done():
+				return
+			}
+			w.Write([]byte(`{"id": "asst_1"}`))
+		}))
+
+		c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithEndpoint(srv.URL), WithTimeout(100*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Assistant(context.Background(), "asst_1")
+		srv.Close()
+
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestTimeout(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestTimeout(%s): got err == %s, want err == nil", test.desc, err)
+		case err != nil && !errors.Is(err, context.DeadlineExceeded):
+			t.Errorf("TestTimeout(%s): got err == %s, want context.DeadlineExceeded", test.desc, err)
+		}
+	}
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	const event = "data: " + `{"id":"1","choices":[{"index":0,"delta":{"content":"x"}}]}` + "\n\n"
+
+	tests := []struct {
+		desc string
+		// headers is if the server sends the headers and an event before hanging.
+		headers bool
+		// callIdle overrides the idle timeout of the Client when set.
+		callIdle time.Duration
+		wantText bool
+		wantIdle bool
+	}{
+		{desc: "hangs before headers", wantIdle: true},
+		{desc: "hangs after an event", headers: true, wantText: true, wantIdle: true},
+		{desc: "per call override", headers: true, callIdle: time.Minute, wantText: true},
+	}
+
+	for _, test := range tests {
+		// release stops the handler hanging, it may not see the client go away.
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if test.headers {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte(event))
+				w.(http.Flusher).Flush()
+			}
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+
+		c, err := New("test", auth.Authorizer{ApiKey: "key"}, WithEndpoint(srv.URL), WithStreamIdleTimeout(100*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The deadline stops the per call override test, which never goes idle.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if test.callIdle > 0 {
+			ctx = ContextWithStreamIdleTimeout(ctx, test.callIdle)
+		}
+
+		var gotText, gotIdle bool
+		for recv := range c.ChatStream(ctx, "deployment", chat.Req{}) {
+			switch {
+			case recv.Err != nil:
+				gotIdle = errors.Is(recv.Err, ErrStreamIdle)
+			case len(recv.Data.Choices) > 0:
+				gotText = true
+			}
+		}
+		cancel()
+		close(release)
+		srv.Close()
+
+		if gotText != test.wantText {
+			t.Errorf("TestStreamIdleTimeout(%s): got text == %v, want %v", test.desc, gotText, test.wantText)
+		}
+		if gotIdle != test.wantIdle {
+			t.Errorf("TestStreamIdleTimeout(%s): got ErrStreamIdle == %v, want %v", test.desc, gotIdle, test.wantIdle)
+		}
+	}
+}
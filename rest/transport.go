@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ConnPool tunes the connection pool of the http.Transport the Client makes when WithClient()
+// is not used. The http.DefaultTransport keeps 2 idle connections per host, so concurrent
+// requests, such as batches of embeddings, keep opening and closing connections.
+type ConnPool struct {
+	// MaxIdleConns is the maximum number of idle connections across all hosts. Defaults to 100.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of idle connections to a host. Set it to about
+	// the number of concurrent requests. Defaults to 100.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections to a host, including those in use. 0 means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept. Defaults to 90 seconds.
+	IdleConnTimeout time.Duration
+}
+
+// DefaultConnPool is the ConnPool used when WithConnPool() is not.
+var DefaultConnPool = ConnPool{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 100,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+func (p ConnPool) defaults() ConnPool {
+	if p.MaxIdleConns == 0 {
+		p.MaxIdleConns = DefaultConnPool.MaxIdleConns
+	}
+	if p.MaxIdleConnsPerHost == 0 {
+		p.MaxIdleConnsPerHost = DefaultConnPool.MaxIdleConnsPerHost
+	}
+	if p.IdleConnTimeout == 0 {
+		p.IdleConnTimeout = DefaultConnPool.IdleConnTimeout
+	}
+	return p
+}
+
+func (p ConnPool) validate() error {
+	switch {
+	case p.MaxIdleConns < 0:
+		return fmt.Errorf("MaxIdleConns cannot be < 0")
+	case p.MaxIdleConnsPerHost < 0:
+		return fmt.Errorf("MaxIdleConnsPerHost cannot be < 0")
+	case p.MaxConnsPerHost < 0:
+		return fmt.Errorf("MaxConnsPerHost cannot be < 0")
+	case p.IdleConnTimeout < 0:
+		return fmt.Errorf("IdleConnTimeout cannot be < 0")
+	}
+	return nil
+}
+
+// DialFunc dials a connection, see http.Transport.DialContext.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// transport holds the options for the http.Transport the Client makes when WithClient() is
+// not used.
+type transport struct {
+	// set is true if any of the options was used.
+	set   bool
+	proxy *url.URL
+	tls   *tls.Config
+	dial  DialFunc
+	pool  ConnPool
+}
+
+// WithProxy sends requests through the proxy at proxyURL, such as "http://proxy:8080". Without
+// it, the proxy comes from the HTTPS_PROXY and NO_PROXY environment variables. This cannot be
+// used with WithClient().
+func WithProxy(proxyURL string) Option {
+	return func(client *Client) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("WithProxy: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("WithProxy: %q must have a scheme and host", proxyURL)
+		}
+		client.transport.proxy = u
+		client.transport.set = true
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration, such as root CAs for a proxy that inspects TLS or
+// client certificates. cfg is cloned. This cannot be used with WithClient().
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(client *Client) error {
+		if cfg == nil {
+			return fmt.Errorf("WithTLSConfig: cfg cannot be nil")
+		}
+		client.transport.tls = cfg.Clone()
+		client.transport.set = true
+		return nil
+	}
+}
+
+// WithDialer sets the function used to dial connections, such as the DialContext method of a
+// net.Dialer with a KeepAlive. This cannot be used with WithClient().
+func WithDialer(dial DialFunc) Option {
+	return func(client *Client) error {
+		if dial == nil {
+			return fmt.Errorf("WithDialer: dial cannot be nil")
+		}
+		client.transport.dial = dial
+		client.transport.set = true
+		return nil
+	}
+}
+
+// WithConnPool tunes the connection pool. Zero fields use the values of DefaultConnPool, which
+// is used when this is not set. This cannot be used with WithClient().
+func WithConnPool(p ConnPool) Option {
+	return func(client *Client) error {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("WithConnPool: %w", err)
+		}
+		client.transport.pool = p
+		client.transport.set = true
+		return nil
+	}
+}
+
+// client returns the http.Client for the options.
+func (t transport) client() *http.Client {
+	ht := http.DefaultTransport.(*http.Transport).Clone()
+
+	pool := t.pool.defaults()
+	ht.MaxIdleConns = pool.MaxIdleConns
+	ht.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	ht.MaxConnsPerHost = pool.MaxConnsPerHost
+	ht.IdleConnTimeout = pool.IdleConnTimeout
+
+	if t.proxy != nil {
+		ht.Proxy = http.ProxyURL(t.proxy)
+	}
+	if t.tls != nil {
+		ht.TLSClientConfig = t.tls
+	}
+	if t.dial != nil {
+		ht.DialContext = t.dial
+	}
+	return &http.Client{Transport: ht}
+}
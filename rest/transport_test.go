@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/auth"
+)
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		desc    string
+		options []Option
+		want    ConnPool
+		wantErr bool
+	}{
+		{desc: "defaults", want: DefaultConnPool},
+		{
+			desc:    "conn pool",
+			options: []Option{WithConnPool(ConnPool{MaxIdleConnsPerHost: 8, MaxConnsPerHost: 16})},
+			want:    ConnPool{MaxIdleConns: 100, MaxIdleConnsPerHost: 8, MaxConnsPerHost: 16, IdleConnTimeout: 90 * time.Second},
+		},
+		{desc: "negative conn pool", options: []Option{WithConnPool(ConnPool{MaxConnsPerHost: -1})}, wantErr: true},
+		{desc: "proxy without a scheme", options: []Option{WithProxy("proxy:8080")}, wantErr: true},
+		{desc: "nil tls config", options: []Option{WithTLSConfig(nil)}, wantErr: true},
+		{
+			desc:    "with client",
+			options: []Option{WithClient(&http.Client{}), WithProxy("http://proxy:8080")},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		c, err := New("test", auth.Authorizer{ApiKey: "key"}, test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestTransport(%s): got err == nil, want err != nil", test.desc)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestTransport(%s): got err == %s, want err == nil", test.desc, err)
+			continue
+		case err != nil:
+			continue
+		}
+
+		ht := c.client.Transport.(*http.Transport)
+		got := ConnPool{
+			MaxIdleConns:        ht.MaxIdleConns,
+			MaxIdleConnsPerHost: ht.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     ht.MaxConnsPerHost,
+			IdleConnTimeout:     ht.IdleConnTimeout,
+		}
+		if got != test.want {
+			t.Errorf("TestTransport(%s): got %+v, want %+v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestProxyAndDialer(t *testing.T) {
+	var gotHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.URL.Host
+		w.Write([]byte(`{"id": "asst_1"}`))
+	}))
+	defer proxy.Close()
+
+	var dials atomic.Int64
+	d := &net.Dialer{}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return d.DialContext(ctx, network, addr)
+	}
+
+	c, err := New(
+		"test",
+		auth.Authorizer{ApiKey: "key"},
+		WithEndpoint("http://resource.invalid"),
+		WithProxy(proxy.URL),
+		WithDialer(dial),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Assistant(context.Background(), "asst_1"); err != nil {
+		t.Fatalf("TestProxyAndDialer: got err == %s, want err == nil", err)
+	}
+
+	if gotHost != "resource.invalid" {
+		t.Errorf("TestProxyAndDialer: got proxied host %q, want %q", gotHost, "resource.invalid")
+	}
+	if dials.Load() == 0 {
+		t.Errorf("TestProxyAndDialer: got 0 dials, want the dialer to be used")
+	}
+}
@@ -98,6 +98,25 @@ func ChatWithParamsPerCall(apiKey, resourceName, deploymentID string) error {
 	return nil
 }
 
+func ChatStream(apiKey, resourceName, deploymentID string) error {
+	client, err := azopenai.New(resourceName, auth.Authorizer{ApiKey: apiKey})
+	if err != nil {
+		return err
+	}
+
+	chatClient := client.Chat(deploymentID)
+	messages := []chat.SendMsg{{Role: chat.User, Content: "Tell me a short story"}}
+	for data := range chatClient.Stream(context.Background(), messages) {
+		if data.Err != nil {
+			return data.Err
+		}
+		fmt.Print(data.Data.Text[0])
+	}
+	fmt.Println()
+
+	return nil
+}
+
 func Completions(apiKey, resourceName, deploymentID string) error {
 	client, err := azopenai.New(resourceName, auth.Authorizer{ApiKey: apiKey})
 	if err != nil {
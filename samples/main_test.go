@@ -32,6 +32,15 @@ func TestChatWithParamsPerCall(t *testing.T) {
 	}
 }
 
+func TestChatStream(t *testing.T) {
+	apiKey := os.Getenv("API_KEY")
+	resourceName := os.Getenv("RESOURCE_NAME")
+	deploymentID := "gpt-35-turbo"
+	if err := ChatStream(apiKey, resourceName, deploymentID); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCompletions(t *testing.T) {
 	apiKey := os.Getenv("API_KEY")
 	resourceName := os.Getenv("RESOURCE_NAME")
@@ -0,0 +1,407 @@
+/*
+Package variants picks between system prompt variants and learns which works best from the
+outcomes you report, such as a thumbs up from a user. This lets prompt changes be tested on real
+traffic while most calls move to the better variant as evidence is gathered.
+
+Each call to Select() picks a variant with the Set's Policy and returns a Choice with a call ID.
+When the outcome of the call is known, report it with ReportOutcome(callID, score), where score
+is between 0 (bad) and 1 (good).
+
+Using a Set with the chat client:
+
+	set, err := variants.New(
+		[]variants.Variant{
+			{Name: "short", Prompt: "You are a helpful assistant. Answer in one sentence."},
+			{Name: "friendly", Prompt: "You are a friendly assistant who explains your answers.", Weight: 0.5},
+		},
+		variants.WithPolicy(variants.Thompson),
+	)
+	if err != nil {
+		return err
+	}
+
+	var choice variants.Choice
+	resp, err := chatClient.Call(ctx, messages, chat.WithHook(set.ChatHook(&choice)))
+	if err != nil {
+		return err
+	}
+	...
+	// Later, when the user rates the answer.
+	if err := set.ReportOutcome(choice.CallID, 1); err != nil {
+		return err
+	}
+
+You can also use a Set directly:
+
+	choice, err := set.Select()
+	if err != nil {
+		return err
+	}
+	messages = append([]chat.SendMsg{{Role: chat.System, Content: choice.Prompt}}, messages...)
+*/
+package variants
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/element-of-surprise/azopenai/clients/chat"
+)
+
+// Policy is how a Set picks a variant.
+type Policy int
+
+const (
+	// EpsilonGreedy picks the variant with the best mean score, except for a fraction of calls,
+	// set with WithEpsilon(), where it picks a variant at random by Weight.
+	EpsilonGreedy Policy = 0
+	// Thompson picks each variant with the probability that it is the best, using a Beta
+	// distribution of its scores. It explores less as evidence is gathered.
+	Thompson Policy = 1
+)
+
+// String implements fmt.Stringer.
+func (p Policy) String() string {
+	switch p {
+	case EpsilonGreedy:
+		return "EpsilonGreedy"
+	case Thompson:
+		return "Thompson"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// Variant is a system prompt variant.
+type Variant struct {
+	// Name is the name of the variant. This is required and must be unique in a Set.
+	Name string
+	// Prompt is the system prompt.
+	Prompt string
+	// Weight is how often the variant is picked relative to others while no outcomes have been
+	// reported, and when EpsilonGreedy explores. Defaults to 1.
+	Weight float64
+}
+
+// Choice is a variant picked for a call.
+type Choice struct {
+	// CallID identifies the call for ReportOutcome().
+	CallID string
+	// Name is the name of the variant.
+	Name string
+	// Prompt is the system prompt of the variant.
+	Prompt string
+}
+
+// Stat is the statistics of a variant.
+type Stat struct {
+	// Name is the name of the variant.
+	Name string
+	// Selected is the number of times the variant was picked.
+	Selected int
+	// Reported is the number of outcomes reported for the variant.
+	Reported int
+	// Mean is the mean reported score, 0 if none were reported.
+	Mean float64
+}
+
+// arm holds the state of a variant.
+type arm struct {
+	Variant
+	selected int
+	reported int
+	sum      float64
+}
+
+func (a *arm) mean() float64 {
+	if a.reported == 0 {
+		return 0
+	}
+	return a.sum / float64(a.reported)
+}
+
+// Set is a set of variants. It is safe for concurrent use.
+type Set struct {
+	policy     Policy
+	epsilon    float64
+	maxPending int
+	seed       int64
+
+	mu      sync.Mutex
+	arms    []*arm
+	rand    *mrand.Rand
+	pending map[string]int
+	order   []string
+}
+
+// Option is an optional argument for New().
+type Option func(s *Set) error
+
+// WithPolicy sets the Policy. Defaults to EpsilonGreedy.
+func WithPolicy(p Policy) Option {
+	return func(s *Set) error {
+		switch p {
+		case EpsilonGreedy, Thompson:
+		default:
+			return fmt.Errorf("WithPolicy(%v): unknown Policy", p)
+		}
+		s.policy = p
+		return nil
+	}
+}
+
+// WithEpsilon sets the fraction of calls where EpsilonGreedy picks a variant at random, between
+// 0 and 1. Defaults to 0.1.
+func WithEpsilon(e float64) Option {
+	return func(s *Set) error {
+		if e < 0 || e > 1 {
+			return fmt.Errorf("WithEpsilon(%v): must be between 0 and 1", e)
+		}
+		s.epsilon = e
+		return nil
+	}
+}
+
+// WithMaxPending sets the number of calls that can wait for an outcome. When there are more,
+// the oldest call is forgotten and ReportOutcome() returns an error for it. Defaults to 10,000.
+func WithMaxPending(n int) Option {
+	return func(s *Set) error {
+		if n < 1 {
+			return fmt.Errorf("WithMaxPending(%d): must be >= 1", n)
+		}
+		s.maxPending = n
+		return nil
+	}
+}
+
+// WithSeed seeds the random number generator used to pick variants, so that the picks are
+// repeatable. This is useful in tests.
+func WithSeed(seed int64) Option {
+	return func(s *Set) error {
+		s.seed = seed
+		return nil
+	}
+}
+
+// New creates a new Set of variants.
+func New(variants []Variant, options ...Option) (*Set, error) {
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("must have at least one Variant")
+	}
+
+	s := &Set{
+		epsilon:    0.1,
+		maxPending: 10000,
+		seed:       time.Now().UnixNano(),
+		pending:    map[string]int{},
+	}
+	seen := map[string]bool{}
+	for _, v := range variants {
+		switch {
+		case v.Name == "":
+			return nil, fmt.Errorf("Variant.Name is required")
+		case seen[v.Name]:
+			return nil, fmt.Errorf("Variant %q is defined more than once", v.Name)
+		case v.Weight < 0:
+			return nil, fmt.Errorf("Variant %q: Weight must be >= 0", v.Name)
+		}
+		seen[v.Name] = true
+		if v.Weight == 0 {
+			v.Weight = 1
+		}
+		s.arms = append(s.arms, &arm{Variant: v})
+	}
+
+	for _, o := range options {
+		if err := o(s); err != nil {
+			return nil, err
+		}
+	}
+	s.rand = mrand.New(mrand.NewSource(s.seed))
+	return s, nil
+}
+
+// Select picks a variant for a call. Report the outcome of the call with ReportOutcome().
+func (s *Set) Select() (Choice, error) {
+	id, err := newID()
+	if err != nil {
+		return Choice{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.pick()
+	a := s.arms[i]
+	a.selected++
+
+	s.pending[id] = i
+	s.order = append(s.order, id)
+	for len(s.pending) > s.maxPending {
+		delete(s.pending, s.order[0])
+		s.order = s.order[1:]
+	}
+	// Calls that were reported leave their IDs in order, so compact it when it grows.
+	if len(s.order) > 2*s.maxPending {
+		order := make([]string, 0, len(s.pending))
+		for _, id := range s.order {
+			if _, ok := s.pending[id]; ok {
+				order = append(order, id)
+			}
+		}
+		s.order = order
+	}
+
+	return Choice{CallID: id, Name: a.Name, Prompt: a.Prompt}, nil
+}
+
+// ChatHook returns a chat.Hook that uses a system prompt picked with Select(), instead of one
+// from chat.Client.SetPrompts(), and sets choice to it. Report the outcome of the call with
+// ReportOutcome(choice.CallID, score). The messages cannot start with a system prompt.
+func (s *Set) ChatHook(choice *Choice) chat.Hook {
+	return func(ctx context.Context, call *chat.HookCall) (func(ctx context.Context, result chat.Chats) error, error) {
+		if choice == nil {
+			return nil, fmt.Errorf("ChatHook: choice cannot be nil")
+		}
+		if len(call.Messages) > 0 && (call.Messages[0].Role == chat.System || call.Messages[0].Role == chat.Developer) {
+			return nil, fmt.Errorf("ChatHook() cannot be used with messages that start with a system prompt")
+		}
+		c, err := s.Select()
+		if err != nil {
+			return nil, err
+		}
+		*choice = c
+		call.Messages = append([]chat.SendMsg{{Role: chat.System, Content: c.Prompt}}, call.Messages...)
+		return nil, nil
+	}
+}
+
+// ReportOutcome reports the score of the call with callID, between 0 (bad) and 1 (good). An
+// outcome can only be reported once per call.
+func (s *Set) ReportOutcome(callID string, score float64) error {
+	if score < 0 || score > 1 || math.IsNaN(score) {
+		return fmt.Errorf("score(%v) must be between 0 and 1", score)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.pending[callID]
+	if !ok {
+		return fmt.Errorf("call ID %q is unknown, was already reported or has expired", callID)
+	}
+	delete(s.pending, callID)
+
+	a := s.arms[i]
+	a.reported++
+	a.sum += score
+	return nil
+}
+
+// Stats returns the statistics of each variant, in the order they were passed to New().
+func (s *Set) Stats() []Stat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]Stat, 0, len(s.arms))
+	for _, a := range s.arms {
+		stats = append(stats, Stat{Name: a.Name, Selected: a.selected, Reported: a.reported, Mean: a.mean()})
+	}
+	return stats
+}
+
+// pick returns the index of the arm to use. s.mu must be held.
+func (s *Set) pick() int {
+	reported := false
+	for _, a := range s.arms {
+		if a.reported > 0 {
+			reported = true
+			break
+		}
+	}
+	if !reported {
+		return s.weighted()
+	}
+
+	switch s.policy {
+	case Thompson:
+		best, bestSample := 0, -1.0
+		for i, a := range s.arms {
+			sample := s.beta(1+a.sum, 1+float64(a.reported)-a.sum)
+			if sample > bestSample {
+				best, bestSample = i, sample
+			}
+		}
+		return best
+	default:
+		if s.rand.Float64() < s.epsilon {
+			return s.weighted()
+		}
+		// Variants with no outcomes are tried before the best is exploited.
+		best, bestMean := -1, -1.0
+		for i, a := range s.arms {
+			if a.reported == 0 {
+				return i
+			}
+			if m := a.mean(); m > bestMean {
+				best, bestMean = i, m
+			}
+		}
+		return best
+	}
+}
+
+// weighted returns the index of an arm picked at random by Weight. s.mu must be held.
+func (s *Set) weighted() int {
+	total := 0.0
+	for _, a := range s.arms {
+		total += a.Weight
+	}
+	r := s.rand.Float64() * total
+	for i, a := range s.arms {
+		r -= a.Weight
+		if r < 0 {
+			return i
+		}
+	}
+	return len(s.arms) - 1
+}
+
+// beta returns a sample of a Beta(alpha, beta) distribution, where alpha and beta are >= 1.
+func (s *Set) beta(alpha, beta float64) float64 {
+	x := s.gamma(alpha)
+	y := s.gamma(beta)
+	return x / (x + y)
+}
+
+// gamma returns a sample of a Gamma(shape, 1) distribution, where shape is >= 1, using the
+// Marsaglia and Tsang method.
+func (s *Set) gamma(shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := s.rand.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := s.rand.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("problem creating call ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,177 @@
+package variants
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/element-of-surprise/azopenai/auth"
+	"github.com/element-of-surprise/azopenai/clients/chat"
+	"github.com/element-of-surprise/azopenai/rest"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc     string
+		variants []Variant
+		options  []Option
+		wantErr  bool
+	}{
+		{desc: "no variants", wantErr: true},
+		{desc: "no name", variants: []Variant{{Prompt: "a"}}, wantErr: true},
+		{desc: "duplicate name", variants: []Variant{{Name: "a"}, {Name: "a"}}, wantErr: true},
+		{desc: "negative weight", variants: []Variant{{Name: "a", Weight: -1}}, wantErr: true},
+		{desc: "bad epsilon", variants: []Variant{{Name: "a"}}, options: []Option{WithEpsilon(2)}, wantErr: true},
+		{desc: "bad policy", variants: []Variant{{Name: "a"}}, options: []Option{WithPolicy(Policy(9))}, wantErr: true},
+		{desc: "success", variants: []Variant{{Name: "a"}, {Name: "b", Weight: 2}}, options: []Option{WithPolicy(Thompson)}},
+	}
+
+	for _, test := range tests {
+		_, err := New(test.variants, test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.desc)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestNew(%s): got err == %s, want err == nil", test.desc, err)
+		}
+	}
+}
+
+func TestLearns(t *testing.T) {
+	// "good" always scores 1 and "bad" always scores 0. "bad" has a higher weight, so it is
+	// picked more until outcomes are reported.
+	scores := map[string]float64{"good": 1, "bad": 0}
+
+	for _, policy := range []Policy{EpsilonGreedy, Thompson} {
+		s, err := New([]Variant{{Name: "good"}, {Name: "bad", Weight: 3}}, WithPolicy(policy), WithSeed(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 500; i++ {
+			c, err := s.Select()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.ReportOutcome(c.CallID, scores[c.Name]); err != nil {
+				t.Fatalf("TestLearns(%s): got err == %s, want err == nil", policy, err)
+			}
+		}
+
+		stats := s.Stats()
+		if stats[0].Selected < 400 {
+			t.Errorf("TestLearns(%s): got good selected %d times, want >= 400", policy, stats[0].Selected)
+		}
+		if stats[0].Mean != 1 || stats[1].Mean != 0 {
+			t.Errorf("TestLearns(%s): got means %v and %v, want 1 and 0", policy, stats[0].Mean, stats[1].Mean)
+		}
+		if stats[0].Reported+stats[1].Reported != 500 {
+			t.Errorf("TestLearns(%s): got %d reported, want 500", policy, stats[0].Reported+stats[1].Reported)
+		}
+	}
+}
+
+func TestReportOutcome(t *testing.T) {
+	s, err := New([]Variant{{Name: "a", Prompt: "prompt a"}}, WithMaxPending(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.Select()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "a" || c.Prompt != "prompt a" || c.CallID == "" {
+		t.Fatalf("TestReportOutcome: got %+v, want variant a with a call ID", c)
+	}
+
+	if err := s.ReportOutcome(c.CallID, 1.5); err == nil {
+		t.Errorf("TestReportOutcome(score > 1): got err == nil, want err != nil")
+	}
+	if err := s.ReportOutcome("unknown", 1); err == nil {
+		t.Errorf("TestReportOutcome(unknown call): got err == nil, want err != nil")
+	}
+	if err := s.ReportOutcome(c.CallID, 0.5); err != nil {
+		t.Errorf("TestReportOutcome: got err == %s, want err == nil", err)
+	}
+	if err := s.ReportOutcome(c.CallID, 0.5); err == nil {
+		t.Errorf("TestReportOutcome(reported twice): got err == nil, want err != nil")
+	}
+
+	// Only the last 2 calls are kept, so the first expires.
+	var ids []string
+	for i := 0; i < 3; i++ {
+		c, err := s.Select()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, c.CallID)
+	}
+	if err := s.ReportOutcome(ids[0], 1); err == nil {
+		t.Errorf("TestReportOutcome(expired): got err == nil, want err != nil")
+	}
+	for _, id := range ids[1:] {
+		if err := s.ReportOutcome(id, 1); err != nil {
+			t.Errorf("TestReportOutcome(pending): got err == %s, want err == nil", err)
+		}
+	}
+}
+
+func TestChatHook(t *testing.T) {
+	var got struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`)),
+			Request:    req,
+		}, nil
+	})
+	rc, err := rest.New("test", auth.Authorizer{ApiKey: "key"}, rest.WithClient(&http.Client{Transport: rt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := chat.New("deployment", rc)
+
+	set, err := New([]Variant{{Name: "short", Prompt: "Be brief."}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var choice Choice
+	_, err = c.Call(context.Background(), []chat.SendMsg{{Role: chat.User, Content: "hello"}}, chat.WithHook(set.ChatHook(&choice)))
+	if err != nil {
+		t.Fatalf("TestChatHook: got err == %s, want err == nil", err)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[0].Content != "Be brief." {
+		t.Errorf("TestChatHook: got messages %+v, want the variant prompt then the user message", got.Messages)
+	}
+	if choice.Name != "short" || choice.CallID == "" {
+		t.Errorf("TestChatHook: got Name %q, CallID %q, want short and a call ID", choice.Name, choice.CallID)
+	}
+	if err := set.ReportOutcome(choice.CallID, 1); err != nil {
+		t.Errorf("TestChatHook(ReportOutcome): got err == %s, want err == nil", err)
+	}
+
+	messages := []chat.SendMsg{{Role: chat.System, Content: "mine"}, {Role: chat.User, Content: "hello"}}
+	if _, err := c.Call(context.Background(), messages, chat.WithHook(set.ChatHook(&choice))); err == nil {
+		t.Errorf("TestChatHook(system prompt): got err == nil, want err != nil")
+	}
+}